@@ -1,9 +1,9 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
@@ -24,15 +24,31 @@ var (
 	argocdIncludeKinder   bool
 	argocdWaitTimeout     time.Duration
 	argocdSkipApp         bool
+	argocdAppLabels       []string
+	argocdAppAnnotations  []string
+	argocdProject         string
+	argocdCreateProject   bool
+	argocdAutoSync        bool
+	argocdPrune           bool
+	argocdSelfHeal        bool
+	argocdWaitForApps     bool
 
 	// Git credential flags
 	argocdGitUsername   string
 	argocdGitPassword   string
 	argocdGitSSHKeyPath string
 
+	// Registry credential flags
+	argocdRegistryUsername string
+	argocdRegistryPassword string
+
 	// Kubectl flags
 	argocdKubeconfig string
 	argocdContext    string
+	argocdUseKubectl bool
+
+	// Uninstall flags
+	argocdKeepNamespace bool
 )
 
 var argocdCmd = &cobra.Command{
@@ -86,7 +102,7 @@ Examples:
   kinder argocd bootstrap \
     --manifest-url https://raw.githubusercontent.com/org/gitops/main/app-of-apps.yaml`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Resolve version: CLI flag > config file > default
 		version := argocdVersion
@@ -100,28 +116,35 @@ Examples:
 			manifestURL = config.GetString(config.KeyArgocdManifestURL)
 		}
 
-		// Determine credential type
-		credType := kubernetes.GitCredentialNone
-		if argocdGitUsername != "" && argocdGitPassword != "" {
-			credType = kubernetes.GitCredentialHTTP
-		} else if argocdGitSSHKeyPath != "" {
-			credType = kubernetes.GitCredentialSSH
+		if argocdUseKubectl {
+			if err := checkTool("kubectl"); err != nil {
+				return err
+			}
 		}
 
-		// Validate credential combinations
-		if argocdGitUsername != "" && argocdGitPassword == "" {
-			return fmt.Errorf("--git-password is required when --git-username is provided")
+		credType, err := gitCredentialType("--git-", argocdGitUsername, argocdGitPassword, argocdGitSSHKeyPath)
+		if err != nil {
+			return err
+		}
+		if (argocdRegistryUsername == "") != (argocdRegistryPassword == "") {
+			return fmt.Errorf("--registry-username and --registry-password must be provided together")
+		}
+
+		appLabels, err := parseKeyValues("--app-label", argocdAppLabels)
+		if err != nil {
+			return err
 		}
-		if argocdGitPassword != "" && argocdGitUsername == "" {
-			return fmt.Errorf("--git-username is required when --git-password is provided")
+		appAnnotations, err := parseKeyValues("--app-annotation", argocdAppAnnotations)
+		if err != nil {
+			return err
 		}
 
 		// Load CA cert for registry TLS trust
-		dataDir, err := config.GetDataDir()
+		caCertPath, err := config.CACertPath()
 		if err != nil {
-			return fmt.Errorf("failed to get data dir: %w", err)
+			return err
 		}
-		caCertPEM, _ := os.ReadFile(dataDir + "/ca.crt")
+		caCertPEM, _ := os.ReadFile(caCertPath)
 
 		cfg := kubernetes.ArgoCDConfig{
 			Version:           version,
@@ -136,14 +159,26 @@ Examples:
 			HTTPUsername:      argocdGitUsername,
 			HTTPPassword:      argocdGitPassword,
 			SSHPrivateKeyPath: argocdGitSSHKeyPath,
+			RegistryUsername:  argocdRegistryUsername,
+			RegistryPassword:  argocdRegistryPassword,
 			IncludeKinderApps: argocdIncludeKinder,
+			AppLabels:         appLabels,
+			AppAnnotations:    appAnnotations,
+			Project:           argocdProject,
+			CreateProject:     argocdCreateProject,
+			AutoSync:          argocdAutoSync,
+			Prune:             argocdPrune,
+			SelfHeal:          argocdSelfHeal,
 			SkipInitialApp:    argocdSkipApp || argocdRepoURL == "",
 			WaitTimeout:       argocdWaitTimeout,
+			WaitForApps:       argocdWaitForApps,
 			KubeconfigPath:    argocdKubeconfig,
 			KubeContext:       argocdContext,
+			UseKubectl:        argocdUseKubectl,
 			Domain:            config.GetString(config.KeyDomain),
 			Port:              config.GetString(config.KeyTraefikPort),
 			CACertPEM:         string(caCertPEM),
+			Expose:            config.GetBool(config.KeyArgocdExpose),
 		}
 
 		Header("Installing ArgoCD...")
@@ -162,8 +197,12 @@ Examples:
 
 		// Show access instructions
 		Header("Connect to ArgoCD UI:")
-		Output("  kubectl port-forward svc/argocd-server -n %s 8080:80\n", cfg.Namespace)
-		Output("  Open: http://localhost:8080\n")
+		if cfg.Expose {
+			Output("  Open: https://argocd.%s:%s\n", cfg.Domain, cfg.Port)
+		} else {
+			Output("  kubectl port-forward svc/argocd-server -n %s 8080:80\n", cfg.Namespace)
+			Output("  Open: http://localhost:8080\n")
+		}
 		BlankLine()
 		Output("  No login required (anonymous admin access enabled)\n")
 
@@ -171,6 +210,58 @@ Examples:
 	},
 }
 
+var argocdUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove ArgoCD from the cluster",
+	Long: `Remove ArgoCD from the cluster without deleting the cluster.
+
+This command:
+  1. Deletes all Applications, waiting for their finalizers so that resources
+     they deployed are cleaned up by ArgoCD
+  2. Removes the ArgoCD install manifests
+  3. Deletes the argocd namespace (unless --keep-namespace)`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		// Resolve version: CLI flag > config file > default
+		version := argocdVersion
+		if version == "" {
+			version = config.GetString(config.KeyArgocdVersion)
+		}
+
+		if argocdUseKubectl {
+			if err := checkTool("kubectl"); err != nil {
+				return err
+			}
+		}
+
+		cfg := kubernetes.ArgoCDConfig{
+			Version:        version,
+			Namespace:      argocdNamespace,
+			WaitTimeout:    argocdWaitTimeout,
+			KubeconfigPath: argocdKubeconfig,
+			KubeContext:    argocdContext,
+			KeepNamespace:  argocdKeepNamespace,
+			UseKubectl:     argocdUseKubectl,
+		}
+
+		Header("Uninstalling ArgoCD...")
+		BlankLine()
+
+		if err := kubernetes.Uninstall(ctx, cfg, func(msg string) {
+			ProgressStart("", msg)
+			ProgressDone(true, "")
+		}); err != nil {
+			return fmt.Errorf("failed to uninstall ArgoCD: %w", err)
+		}
+
+		BlankLine()
+		Success("ArgoCD uninstalled")
+
+		return nil
+	},
+}
+
 var argocdShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show the ArgoCD installation configuration",
@@ -188,38 +279,103 @@ var argocdShowCmd = &cobra.Command{
 			manifestURL = config.GetString(config.KeyArgocdManifestURL)
 		}
 
-		Output("# ArgoCD Installation\n\n")
-		Output("Version: %s\n", version)
-		Output("Namespace: %s\n", argocdNamespace)
-		Output("Install URL: %s/%s/manifests/install.yaml\n\n", kubernetes.ArgoCDInstallURL, version)
+		Result("# ArgoCD Installation\n\n")
+		Result("Version: %s\n", version)
+		Result("Namespace: %s\n", argocdNamespace)
+		Result("Install URL: %s/%s/manifests/install.yaml\n\n", kubernetes.ArgoCDInstallURL, version)
 
-		Output("# Configuration\n\n")
-		Output("Authentication: Anonymous admin access (disabled)\n")
-		Output("Server mode: Insecure (no TLS)\n\n")
+		Result("# Configuration\n\n")
+		Result("Authentication: Anonymous admin access (disabled)\n")
+		Result("Server mode: Insecure (no TLS)\n\n")
 
 		if argocdRepoURL != "" {
-			Output("# Initial Application\n\n")
-			Output("Repository: %s\n", argocdRepoURL)
-			Output("Path: %s\n", argocdRepoPath)
-			Output("Branch: %s\n", argocdRepoBranch)
-			Output("App Name: %s\n", argocdAppName)
-			Output("Target Namespace: %s\n\n", argocdTargetNamespace)
+			Result("# Initial Application\n\n")
+			Result("Repository: %s\n", argocdRepoURL)
+			Result("Path: %s\n", argocdRepoPath)
+			Result("Branch: %s\n", argocdRepoBranch)
+			Result("App Name: %s\n", argocdAppName)
+			Result("Target Namespace: %s\n\n", argocdTargetNamespace)
+		}
+
+		Result("# Sync Policy\n\n")
+		if argocdAutoSync {
+			Result("Sync: automated (prune: %t, self-heal: %t)\n\n", argocdPrune, argocdSelfHeal)
+		} else {
+			Result("Sync: manual\n\n")
 		}
 
+		Result("# Project\n\n")
+		Result("Project: %s\n", argocdProject)
+		if argocdCreateProject {
+			Result("Create AppProject: yes\n")
+		}
+		Result("\n")
+
 		if argocdIncludeKinder {
-			Output("# Kinder Apps\n\n")
-			Output("Includes: trust-bundle, cert-issuer (OCI from local registry)\n\n")
+			Result("# Kinder Apps\n\n")
+			Result("Includes: trust-bundle, cert-issuer (OCI from local registry)\n")
+			if argocdRegistryUsername != "" {
+				Result("Registry credentials: %s\n", argocdRegistryUsername)
+			}
+			Result("\n")
+		}
+
+		if len(argocdAppLabels) > 0 || len(argocdAppAnnotations) > 0 {
+			Result("# Application Metadata\n\n")
+			for _, label := range argocdAppLabels {
+				Result("Label: %s\n", label)
+			}
+			for _, annotation := range argocdAppAnnotations {
+				Result("Annotation: %s\n", annotation)
+			}
+			Result("\n")
 		}
 
 		if manifestURL != "" {
-			Output("# App-of-Apps\n\n")
-			Output("Manifest URL: %s\n", manifestURL)
+			Result("# App-of-Apps\n\n")
+			Result("Manifest URL: %s\n", manifestURL)
 		}
 
 		return nil
 	},
 }
 
+// gitCredentialType returns the kind of Git credentials given, checking that
+// a username and password come together. prefix is the flags' prefix, such as
+// "--git-", for error messages.
+func gitCredentialType(prefix, username, password, sshKeyPath string) (kubernetes.GitCredentialType, error) {
+	if username != "" && password == "" {
+		return "", fmt.Errorf("%spassword is required when %susername is provided", prefix, prefix)
+	}
+	if password != "" && username == "" {
+		return "", fmt.Errorf("%susername is required when %spassword is provided", prefix, prefix)
+	}
+
+	switch {
+	case username != "":
+		return kubernetes.GitCredentialHTTP, nil
+	case sshKeyPath != "":
+		return kubernetes.GitCredentialSSH, nil
+	}
+	return kubernetes.GitCredentialNone, nil
+}
+
+// parseKeyValues turns repeated key=value flag values into a map
+func parseKeyValues(flag string, values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	result := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s must be key=value, got %q", flag, v)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
 func init() {
 	// Common flags for bootstrap and show
 	commonFlags := func(cmd *cobra.Command) {
@@ -235,19 +391,40 @@ func init() {
 		cmd.Flags().StringVar(&argocdGitPassword, "git-password", "", "Git password or token for HTTP auth")
 		cmd.Flags().StringVar(&argocdGitSSHKeyPath, "git-ssh-key", "", "Path to SSH private key file")
 		cmd.Flags().BoolVar(&argocdIncludeKinder, "include-kinder-apps", false, "Include Applications for trust-bundle and cert-issuer")
+		cmd.Flags().StringVar(&argocdRegistryUsername, "registry-username", "", "Username for pulling the kinder apps from the registry")
+		cmd.Flags().StringVar(&argocdRegistryPassword, "registry-password", "", "Password for pulling the kinder apps from the registry")
 		cmd.Flags().BoolVar(&argocdSkipApp, "skip-app", false, "Skip creating the initial application")
+		cmd.Flags().StringArrayVar(&argocdAppLabels, "app-label", nil, "Label for generated Applications as key=value (repeatable)")
+		cmd.Flags().StringVar(&argocdProject, "project", "default", "ArgoCD project for generated Applications")
+		cmd.Flags().BoolVar(&argocdCreateProject, "create-project", false, "Create an AppProject allowing the repository and kinder registry")
+		cmd.Flags().BoolVar(&argocdAutoSync, "auto-sync", true, "Enable automated sync for generated Applications")
+		cmd.Flags().BoolVar(&argocdPrune, "prune", true, "Prune resources removed from the source during automated sync")
+		cmd.Flags().BoolVar(&argocdSelfHeal, "self-heal", true, "Revert changes made in the cluster during automated sync")
+		cmd.Flags().StringArrayVar(&argocdAppAnnotations, "app-annotation", nil, "Annotation for generated Applications as key=value (repeatable)")
 	}
 
 	// Setup flags for bootstrap command
 	commonFlags(argocdBootstrapCmd)
 	argocdBootstrapCmd.Flags().DurationVar(&argocdWaitTimeout, "wait-timeout", 5*time.Minute, "Timeout for rollout")
+	argocdBootstrapCmd.Flags().BoolVar(&argocdWaitForApps, "wait", false, "Wait for created Applications to become Healthy and Synced")
 	argocdBootstrapCmd.Flags().StringVar(&argocdKubeconfig, "kubeconfig", "", "Path to kubeconfig file")
 	argocdBootstrapCmd.Flags().StringVar(&argocdContext, "context", "", "Kubernetes context to use")
+	argocdBootstrapCmd.Flags().BoolVar(&argocdUseKubectl, "use-kubectl", false, "Shell out to kubectl instead of using the Kubernetes API directly")
 
 	// Setup flags for show command
 	commonFlags(argocdShowCmd)
 
+	// Setup flags for uninstall command
+	argocdUninstallCmd.Flags().StringVar(&argocdVersion, "version", "", fmt.Sprintf("ArgoCD version that was installed (default %s)", config.DefaultArgocdVersion))
+	argocdUninstallCmd.Flags().StringVar(&argocdNamespace, "namespace", kubernetes.ArgoCDNamespace, "ArgoCD namespace")
+	argocdUninstallCmd.Flags().DurationVar(&argocdWaitTimeout, "wait-timeout", 5*time.Minute, "Timeout for each deletion")
+	argocdUninstallCmd.Flags().StringVar(&argocdKubeconfig, "kubeconfig", "", "Path to kubeconfig file")
+	argocdUninstallCmd.Flags().StringVar(&argocdContext, "context", "", "Kubernetes context to use")
+	argocdUninstallCmd.Flags().BoolVar(&argocdUseKubectl, "use-kubectl", false, "Shell out to kubectl instead of using the Kubernetes API directly")
+	argocdUninstallCmd.Flags().BoolVar(&argocdKeepNamespace, "keep-namespace", false, "Keep the ArgoCD namespace")
+
 	// Add subcommands
 	argocdCmd.AddCommand(argocdBootstrapCmd)
 	argocdCmd.AddCommand(argocdShowCmd)
+	argocdCmd.AddCommand(argocdUninstallCmd)
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"codeberg.org/hipkoi/kinder/kubernetes"
+)
+
+func TestGitCredentialType(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		sshKey   string
+		expected kubernetes.GitCredentialType
+		wantErr  string
+	}{
+		{name: "none", expected: kubernetes.GitCredentialNone},
+		{name: "http", username: "me", password: "token", expected: kubernetes.GitCredentialHTTP},
+		{name: "ssh", sshKey: "/home/me/.ssh/id_ed25519", expected: kubernetes.GitCredentialSSH},
+		{name: "username without password", username: "me", wantErr: "--argocd-git-password is required"},
+		{name: "password without username", password: "token", wantErr: "--argocd-git-username is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gitCredentialType("--argocd-git-", tt.username, tt.password, tt.sshKey)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// backupManifestName is the first entry of a backup archive
+	backupManifestName = "kinder-backup.json"
+	// backupConfigName is the archive entry holding the config file
+	backupConfigName = "config.yaml"
+	// backupDataPrefix is the archive directory holding the data directory
+	backupDataPrefix = "data/"
+)
+
+// version is the kinder version, set at build time with
+// -ldflags "-X main.version=<version>"
+var version = ""
+
+// kinderVersion returns the build version, falling back to the module
+// version recorded by go install
+func kinderVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// backupManifest describes a backup archive, for validation on restore
+type backupManifest struct {
+	Version   string    `json:"version"`
+	AppName   string    `json:"appName"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Registry is true when the Zot storage was included
+	Registry bool `json:"registry"`
+	// Config is true when the archive contains the config file
+	Config bool `json:"config"`
+}
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up the CA, config and data to an archive",
+	Long: `Write the data directory (CA, generated configs and manifests) and the config
+file to a gzipped tar archive, for sharing a setup or moving it to another
+machine. Use --skip-registry to leave out the Zot storage, which holds the
+pull-through cache and can be large.
+
+The archive contains the CA private key; keep it safe.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, err := getDataDir()
+		if err != nil {
+			return fmt.Errorf("failed to get data directory: %w", err)
+		}
+		if _, err := os.Stat(dataDir); err != nil {
+			return fmt.Errorf("no kinder data found at %s", dataDir)
+		}
+
+		configFile := config.ConfigFile()
+		manifest := backupManifest{
+			Version:   kinderVersion(),
+			AppName:   currentAppName(),
+			CreatedAt: time.Now().UTC(),
+			Registry:  !backupSkipRegistry,
+			Config:    configFile != "",
+		}
+
+		var exclude []string
+		if backupSkipRegistry {
+			exclude = cleanTargets["registry"]
+		}
+
+		out, err := os.OpenFile(backupOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to create backup file: %w", err)
+		}
+		if err := writeBackup(out, manifest, dataDir, configFile, exclude); err != nil {
+			out.Close()
+			os.Remove(backupOut)
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return fmt.Errorf("failed to write backup file: %w", err)
+		}
+
+		Success("Backup written to " + backupOut)
+		Verbose("Data directory: %s\n", dataDir)
+		if configFile != "" {
+			Verbose("Config file: %s\n", configFile)
+		}
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a backup archive",
+	Long: `Unpack an archive written by 'kinder backup' into the configured data
+directory and config file location. The backup must be for the same app name
+(or profile). Existing data and config are kept with a timestamped .bak
+suffix, and put back if the restore fails.
+
+Services must be stopped first.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		cfg, err := buildConfigFromFlags()
+		if err != nil {
+			return fmt.Errorf("failed to build config: %w", err)
+		}
+		if running := existingContainers(ctx, cfg.AllContainerNames()); len(running) > 0 {
+			return fmt.Errorf("cannot restore while containers are running (%s); run 'kinder stop' first", strings.Join(running, ", "))
+		}
+
+		dataDir, err := getDataDir()
+		if err != nil {
+			return fmt.Errorf("failed to get data directory: %w", err)
+		}
+		configFile, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		in, err := os.Open(backupIn)
+		if err != nil {
+			return fmt.Errorf("failed to open backup: %w", err)
+		}
+		defer in.Close()
+
+		manifest, err := restoreBackup(in, currentAppName(), dataDir, configFile)
+		if err != nil {
+			return err
+		}
+
+		if manifest.Version != kinderVersion() {
+			Warn("Backup was made with kinder %s, this is %s\n", manifest.Version, kinderVersion())
+		}
+		if !manifest.Registry {
+			Output("The backup has no registry storage; images are pulled again on demand\n")
+		}
+		Success("Restored backup from " + manifest.CreatedAt.Local().Format(time.RFC1123))
+		return nil
+	},
+}
+
+// currentAppName returns the configured app name, or the default
+func currentAppName() string {
+	if name := config.GetString(config.KeyAppName); name != "" {
+		return name
+	}
+	return config.DefaultAppName
+}
+
+// writeBackup writes a gzipped tar of the manifest, the config file (if
+// any) and the data directory, skipping the excluded data subdirectories
+func writeBackup(w io.Writer, manifest backupManifest, dataDir, configFile string, exclude []string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+	if err := writeTarFile(tw, backupManifestName, 0644, manifestJSON); err != nil {
+		return err
+	}
+
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := writeTarFile(tw, backupConfigName, 0644, data); err != nil {
+			return err
+		}
+	}
+
+	err = filepath.WalkDir(dataDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dataDir, p)
+		if err != nil {
+			return err
+		}
+		for _, ex := range exclude {
+			if rel == ex {
+				return filepath.SkipDir
+			}
+		}
+		if rel == "." {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = backupDataPrefix + filepath.ToSlash(rel)
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive data directory: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// writeTarFile adds a regular file to a tar archive
+func writeTarFile(tw *tar.Writer, name string, mode int64, data []byte) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    mode,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// restoreBackup validates a backup archive against appName and unpacks it
+// into dataDir and configFile, moving existing ones aside with a timestamped
+// .bak suffix. If unpacking fails the partial restore is removed and the
+// previous data and config are moved back.
+func restoreBackup(r io.Reader, appName, dataDir, configFile string) (backupManifest, error) {
+	var manifest backupManifest
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read backup: %w", err)
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != backupManifestName {
+		return manifest, fmt.Errorf("not a kinder backup: %s must be the first entry", backupManifestName)
+	}
+	if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+	if manifest.AppName != appName {
+		return manifest, fmt.Errorf("backup is for app %q, but the current app is %q; use --profile %s to restore it", manifest.AppName, appName, manifest.AppName)
+	}
+
+	now := time.Now()
+	dataBackup, err := moveAside(dataDir, now)
+	if err != nil {
+		return manifest, err
+	}
+	// A failed restore is undone so it leaves things as they were
+	var configMoved bool
+	var configBackup string
+	rollback := func(err error) (backupManifest, error) {
+		errs := []error{err, undoRestore(dataDir, dataBackup)}
+		if configMoved {
+			errs = append(errs, undoRestore(configFile, configBackup))
+		}
+		return manifest, errors.Join(errs...)
+	}
+
+	if manifest.Config {
+		if configBackup, err = moveAside(configFile, now); err != nil {
+			return rollback(err)
+		}
+		configMoved = true
+	}
+
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return rollback(fmt.Errorf("failed to create data directory: %w", err))
+	}
+	if err := extractBackup(tr, dataDir, configFile); err != nil {
+		return rollback(err)
+	}
+	return manifest, nil
+}
+
+// moveAside renames path to path.bak-<timestamp>, returning the new name, or
+// "" if path doesn't exist. A number is added if that name is taken.
+func moveAside(path string, now time.Time) (string, error) {
+	if _, err := os.Lstat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	base := path + ".bak-" + now.Format("20060102-150405")
+	backup := base
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s-%d", base, i)
+	}
+	if err := os.Rename(path, backup); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return backup, nil
+}
+
+// undoRestore removes a partially restored path and moves its backup, if
+// any, back in place
+func undoRestore(path, backup string) error {
+	if err := os.RemoveAll(path); err != nil {
+		return fmt.Errorf("failed to remove partial restore of %s: %w", path, err)
+	}
+	if backup == "" {
+		return nil
+	}
+	if err := os.Rename(backup, path); err != nil {
+		return fmt.Errorf("failed to move %s back to %s: %w", backup, path, err)
+	}
+	return nil
+}
+
+// extractBackup unpacks the data and config entries that follow the manifest
+func extractBackup(tr *tar.Reader, dataDir, configFile string) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		var target string
+		switch {
+		case hdr.Name == backupConfigName:
+			target = configFile
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("failed to create config directory: %w", err)
+			}
+		case strings.HasPrefix(hdr.Name, backupDataPrefix):
+			target, err = backupTarget(dataDir, strings.TrimPrefix(hdr.Name, backupDataPrefix))
+			if err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+
+		if err := extractTarEntry(tr, hdr, target); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+// backupTarget resolves an archive path under dir, rejecting paths that
+// would escape it
+func backupTarget(dir, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid path in backup: %s", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// extractTarEntry writes one tar entry to target
+func extractTarEntry(tr *tar.Reader, hdr *tar.Header, target string) error {
+	mode := fs.FileMode(hdr.Mode).Perm()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, mode)
+	case tar.TypeSymlink:
+		// Links must stay inside the restored tree
+		if !filepath.IsLocal(hdr.Linkname) {
+			return fmt.Errorf("symlink to %s leaves the data directory", hdr.Linkname)
+		}
+		return os.Symlink(hdr.Linkname, target)
+	case tar.TypeReg:
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	return nil
+}
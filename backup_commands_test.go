@@ -0,0 +1,122 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	files := map[string]string{
+		CACertFilename:                       "cert",
+		CAKeyFilename:                        "key",
+		filepath.Join("zot", "config.json"):  "{}",
+		filepath.Join("zot", "data", "blob"): "layer",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configFile, []byte("appName: kinder\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var buf bytes.Buffer
+	manifest := backupManifest{Version: "v1.0.0", AppName: "kinder", Config: true}
+	if err := writeBackup(&buf, manifest, src, configFile, cleanTargets["registry"]); err != nil {
+		t.Fatalf("writeBackup failed: %v", err)
+	}
+	archive := buf.Bytes()
+
+	if _, err := restoreBackup(bytes.NewReader(archive), "other", t.TempDir(), configFile); err == nil {
+		t.Error("expected error restoring a backup for another app")
+	}
+
+	dst := filepath.Join(t.TempDir(), "data")
+	restoredConfig := filepath.Join(t.TempDir(), "kinder", "config.yaml")
+	got, err := restoreBackup(bytes.NewReader(archive), "kinder", dst, restoredConfig)
+	if err != nil {
+		t.Fatalf("restoreBackup failed: %v", err)
+	}
+	if got.Version != "v1.0.0" {
+		t.Errorf("expected manifest version v1.0.0, got %s", got.Version)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dst, CAKeyFilename)); err != nil || string(data) != "key" {
+		t.Errorf("expected CA key to be restored, got %q (%v)", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(dst, CAKeyFilename)); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected CA key mode 0600, got %v (%v)", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "zot", "config.json")); err != nil {
+		t.Errorf("expected zot config to be restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "zot", "data")); !os.IsNotExist(err) {
+		t.Errorf("expected excluded registry storage to be left out, got %v", err)
+	}
+	if data, err := os.ReadFile(restoredConfig); err != nil || !strings.Contains(string(data), "appName") {
+		t.Errorf("expected config file to be restored, got %q (%v)", data, err)
+	}
+
+	// Restoring again keeps both earlier copies
+	for range 2 {
+		if _, err := restoreBackup(bytes.NewReader(archive), "kinder", dst, restoredConfig); err != nil {
+			t.Fatalf("repeated restoreBackup failed: %v", err)
+		}
+	}
+	if backups, _ := filepath.Glob(dst + ".bak-*"); len(backups) != 2 {
+		t.Errorf("expected 2 data backups, got %v", backups)
+	}
+	if backups, _ := filepath.Glob(restoredConfig + ".bak-*"); len(backups) != 2 {
+		t.Errorf("expected 2 config backups, got %v", backups)
+	}
+}
+
+func TestRestoreBackupRejectsEscapingPaths(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := writeTarFile(tw, backupManifestName, 0644, []byte(`{"appName":"kinder"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTarFile(tw, backupDataPrefix+"../escape", 0644, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	dir := t.TempDir()
+	dataDir := filepath.Join(dir, "data")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatalf("failed to create data dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dataDir, CACertFilename), []byte("existing"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := restoreBackup(&buf, "kinder", dataDir, filepath.Join(dir, "config.yaml")); err == nil {
+		t.Error("expected error for a path outside the data directory")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape")); !os.IsNotExist(err) {
+		t.Error("expected escaping file not to be written")
+	}
+
+	// The failed restore puts the existing data back
+	if data, err := os.ReadFile(filepath.Join(dataDir, CACertFilename)); err != nil || string(data) != "existing" {
+		t.Errorf("expected existing data to be put back, got %q (%v)", data, err)
+	}
+	if backups, _ := filepath.Glob(dataDir + ".bak-*"); len(backups) != 0 {
+		t.Errorf("expected no backup left behind, got %v", backups)
+	}
+}
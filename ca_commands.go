@@ -6,14 +6,34 @@ import (
 	"encoding/pem"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"codeberg.org/hipkoi/kinder/cacert"
+	"codeberg.org/hipkoi/kinder/config"
 	"codeberg.org/hipkoi/kinder/docker"
 	"github.com/spf13/cobra"
 )
 
+var (
+	caImportURL         string
+	caImportFingerprint string
+	caImportForce       bool
+	caTrustYes          bool
+	caCRLRevoke         []string
+	caCRLValidity       time.Duration
+)
+
+const (
+	// caCRLFilename is the DER CRL written by "ca crl"
+	caCRLFilename = "ca.crl"
+	// caRevokedFilename is the store of revoked serials the CRL is built from
+	caRevokedFilename = "revoked.json"
+)
+
 var caCmd = &cobra.Command{
 	Use:   "ca",
 	Short: "Manage CA certificates",
@@ -33,19 +53,19 @@ The certificate uses the machine's hostname as the CN and "kinder" as the Organi
 
 		// Get default paths if not provided
 		if certPath == "" {
-			dataDir, err := getDataDir()
+			path, err := config.CACertPath()
 			if err != nil {
-				return fmt.Errorf("failed to get data directory: %w", err)
+				return err
 			}
-			certPath = filepath.Join(dataDir, CACertFilename)
+			certPath = path
 		}
 
 		if keyPath == "" {
-			dataDir, err := getDataDir()
+			path, err := config.CAKeyPath()
 			if err != nil {
-				return fmt.Errorf("failed to get data directory: %w", err)
+				return err
 			}
-			keyPath = filepath.Join(dataDir, CAKeyFilename)
+			keyPath = path
 		}
 
 		// Ensure the directory exists
@@ -60,13 +80,13 @@ The certificate uses the machine's hostname as the CN and "kinder" as the Organi
 		}
 
 		// Generate the CA certificate with domain constraints
-		if err := cacert.GenerateCAWithDomain(certPath, keyPath, traefikDomain); err != nil {
+		if err := cacert.GenerateCAWithOptions(certPath, keyPath, traefikDomain, caOptions()); err != nil {
 			return fmt.Errorf("failed to generate CA certificate: %w", err)
 		}
 
-		fmt.Printf("CA certificate generated successfully:\n")
-		fmt.Printf("  Certificate: %s\n", certPath)
-		fmt.Printf("  Private Key: %s\n", keyPath)
+		Output("CA certificate generated successfully:\n")
+		Output("  Certificate: %s\n", certPath)
+		Output("  Private Key: %s\n", keyPath)
 
 		return nil
 	},
@@ -79,35 +99,25 @@ var printCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get default paths if not provided
 		if certPath == "" {
-			dataDir, err := getDataDir()
+			path, err := config.CACertPath()
 			if err != nil {
-				return fmt.Errorf("failed to get data directory: %w", err)
+				return err
 			}
-			certPath = filepath.Join(dataDir, CACertFilename)
+			certPath = path
 		}
 
 		if keyPath == "" {
-			dataDir, err := getDataDir()
+			path, err := config.CAKeyPath()
 			if err != nil {
-				return fmt.Errorf("failed to get data directory: %w", err)
+				return err
 			}
-			keyPath = filepath.Join(dataDir, CAKeyFilename)
+			keyPath = path
 		}
 
 		// Read and parse certificate
-		certPEM, err := os.ReadFile(certPath)
-		if err != nil {
-			return fmt.Errorf("failed to read certificate: %w", err)
-		}
-
-		block, _ := pem.Decode(certPEM)
-		if block == nil {
-			return fmt.Errorf("failed to decode PEM block from certificate")
-		}
-
-		cert, err := x509.ParseCertificate(block.Bytes)
+		cert, err := cacert.LoadCertificate(certPath)
 		if err != nil {
-			return fmt.Errorf("failed to parse certificate: %w", err)
+			return err
 		}
 
 		// Read and parse private key
@@ -220,3 +230,236 @@ var printCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var caImportStepCmd = &cobra.Command{
+	Use:   "import-step",
+	Short: "Adopt the root of an existing Step CA",
+	Long: `Fetch the root certificate of an external Step CA and use it as the kinder CA.
+
+The root is verified against the given SHA-256 fingerprint, as with
+"step ca bootstrap". It is then distributed like a generated kinder CA: in the
+trust bundle, mounted into Kind nodes and trusted by Traefik.
+
+The CA URL is saved as externalCA.url in the config file. While it is set,
+"kinder start" skips the local Step CA container and Traefik and the
+cert-manager issuer request certificates from the external CA's ACME
+provisioner, which must be named "acme".
+
+Example:
+  kinder ca import-step --ca-url https://ca.example.com --fingerprint 3f2a...`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if certPath == "" {
+			path, err := config.CACertPath()
+			if err != nil {
+				return err
+			}
+			certPath = path
+		}
+
+		if _, err := os.Stat(certPath); err == nil && !caImportForce {
+			return fmt.Errorf("CA certificate already exists at %s. Use --force to replace it", certPath)
+		}
+
+		rootPEM, err := cacert.FetchStepCARoot(ctx, caImportURL, caImportFingerprint)
+		if err != nil {
+			return fmt.Errorf("failed to import Step CA root: %w", err)
+		}
+
+		// Keep the previous CA, since its key cannot be recovered once replaced
+		if err := backupFile(certPath); err != nil {
+			return err
+		}
+		if keyPath == "" {
+			keyPath = filepath.Join(filepath.Dir(certPath), CAKeyFilename)
+		}
+		if err := backupFile(keyPath); err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(certPath), 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(certPath), err)
+		}
+		if err := os.WriteFile(certPath, rootPEM, 0644); err != nil {
+			return fmt.Errorf("failed to write CA certificate: %w", err)
+		}
+
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		caURL := strings.TrimSuffix(caImportURL, "/")
+		if err := config.SetFileValue(path, config.KeyExternalCAURL, caURL); err != nil {
+			return fmt.Errorf("failed to save external CA URL: %w", err)
+		}
+		config.Set(config.KeyExternalCAURL, caURL)
+
+		Output("Step CA root imported successfully:\n")
+		Output("  Certificate: %s\n", certPath)
+		Output("  External CA: %s (saved to %s)\n", caURL, path)
+
+		return nil
+	},
+}
+
+var caTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Install the CA certificate into the OS trust store",
+	Long: `Install the kinder CA certificate into the operating system's trust store,
+so browsers and tools trust the kinder services without warnings.
+
+On macOS the certificate is added to the System keychain with
+"security add-trusted-cert". On Linux it is copied to
+/usr/local/share/ca-certificates and "update-ca-certificates" is run (or to
+/etc/pki/ca-trust/source/anchors with "update-ca-trust"). On Windows the
+certutil command to run is printed instead.
+
+These changes need root, so kinder runs them with sudo unless it is already
+root. Without --yes the commands are only printed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateTrustStore(false)
+	},
+}
+
+var caUntrustCmd = &cobra.Command{
+	Use:   "untrust",
+	Short: "Remove the CA certificate from the OS trust store",
+	Long: `Remove the kinder CA certificate installed by "kinder ca trust" from the
+operating system's trust store. Like "ca trust", the commands need root and
+only run with --yes.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return updateTrustStore(true)
+	},
+}
+
+var caCRLCmd = &cobra.Command{
+	Use:   "crl",
+	Short: "Revoke certificates and regenerate the CA's CRL",
+	Long: `Regenerate the certificate revocation list (CRL) of the kinder CA.
+
+Serials passed with --revoke are added to the revoked serials kept in the data
+directory (revoked.json) before the CRL is signed with the CA key and written
+as DER to ca.crl in the data directory. Serials are hex, as printed by
+"kinder ca print" or openssl, with or without colons.
+
+kinder only writes the CRL file. To have clients check it, serve ca.crl at a
+URL of your choice and start Step CA with --stepca-crl-url so the URL is
+embedded in its intermediate certificate.`,
+	Example: `  kinder ca crl --revoke 3f:a2:91:0c
+  kinder ca crl --validity 720h`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dataDir, err := config.GetDataDir()
+		if err != nil {
+			return err
+		}
+		caCertPath, err := config.CACertPath()
+		if err != nil {
+			return err
+		}
+		caKeyPath, err := config.CAKeyPath()
+		if err != nil {
+			return err
+		}
+
+		revokedPath := filepath.Join(dataDir, caRevokedFilename)
+		list, err := cacert.LoadRevocations(revokedPath)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, serial := range caCRLRevoke {
+			added, err := list.Revoke(serial, now)
+			if err != nil {
+				return err
+			}
+			if !added {
+				Warn("Serial %s is already revoked\n", serial)
+				continue
+			}
+			Output("Revoked serial %s\n", serial)
+		}
+
+		crl, err := cacert.GenerateCRL(caCertPath, caKeyPath, list, now, caCRLValidity)
+		if err != nil {
+			return err
+		}
+
+		crlPath := filepath.Join(dataDir, caCRLFilename)
+		if err := os.WriteFile(crlPath, crl, 0644); err != nil {
+			return fmt.Errorf("failed to write CRL: %w", err)
+		}
+		if err := list.Save(revokedPath); err != nil {
+			return err
+		}
+
+		Output("CRL #%d with %d revoked certificate(s) written to %s\n", list.Number, len(list.Revoked), crlPath)
+		Output("  Next update: %s\n", now.Add(caCRLValidity).Format(time.RFC3339))
+		return nil
+	},
+}
+
+// updateTrustStore adds the kinder CA to the OS trust store, or removes it
+func updateTrustStore(remove bool) error {
+	if certPath == "" {
+		path, err := config.CACertPath()
+		if err != nil {
+			return err
+		}
+		certPath = path
+	}
+
+	cert, err := cacert.LoadCertificate(certPath)
+	if err != nil {
+		return err
+	}
+
+	appName := config.GetString(config.KeyAppName)
+	if appName == "" {
+		appName = config.DefaultAppName
+	}
+
+	plan, err := planTrust(runtime.GOOS, cert, certPath, appName+"-ca", remove, exec.LookPath)
+	if err != nil {
+		return err
+	}
+	changed, err := runTrustPlan(plan, caTrustYes)
+	if err != nil || !changed {
+		return err
+	}
+
+	if remove {
+		Success(fmt.Sprintf("Removed CA %q from the system trust store", cert.Subject.CommonName))
+	} else {
+		Success(fmt.Sprintf("Added CA %q from %s to the system trust store", cert.Subject.CommonName, certPath))
+		Output("Restart your browser for it to pick up the change.\n")
+	}
+	return nil
+}
+
+// backupFile renames an existing file to <path>.bak. Missing files are ignored.
+func backupFile(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.Rename(path, path+".bak"); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	return nil
+}
+
+// caSubjectFlags registers the flags that set the subject of a generated CA
+func caSubjectFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&caOrganization, "ca-org", "", "Organization of a generated CA certificate (default: kinder)")
+	cmd.Flags().StringVar(&caCommonName, "ca-cn", "", "Common name of a generated CA certificate; {hostname} is replaced (default: \"kinder Root CA ({hostname})\")")
+}
+
+// caOptions returns the subject of a generated CA from --ca-org, --ca-cn and
+// the ca section of the config
+func caOptions() cacert.CAOptions {
+	return cacert.CAOptions{
+		Organization: config.GetString(config.KeyCAOrganization),
+		CommonName:   config.GetString(config.KeyCACommonName),
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// macOSSystemKeychain is where "ca trust" installs the CA on macOS
+const macOSSystemKeychain = "/Library/Keychains/System.keychain"
+
+// linuxTrustStores are the system trust store layouts "ca trust" supports:
+// the directory for extra CA certificates and the command that rebuilds the
+// store from it
+var linuxTrustStores = []struct {
+	dir    string
+	update string
+}{
+	{"/usr/local/share/ca-certificates", "update-ca-certificates"}, // Debian, Ubuntu, Alpine
+	{"/etc/pki/ca-trust/source/anchors", "update-ca-trust"},        // Fedora, RHEL
+}
+
+// trustPlan describes how to add the CA to, or remove it from, the OS trust
+// store: commands that need root, or manual instructions where kinder cannot
+// do it itself
+type trustPlan struct {
+	Commands     [][]string
+	Instructions string
+}
+
+// planTrust returns the plan for trusting (or untrusting, if remove is set)
+// the CA certificate at certPath on goos. name identifies the installed file.
+// lookPath finds the platform tools, as exec.LookPath does.
+func planTrust(goos string, cert *x509.Certificate, certPath, name string, remove bool, lookPath func(string) (string, error)) (trustPlan, error) {
+	switch goos {
+	case "darwin":
+		if remove {
+			sum := sha1.Sum(cert.Raw)
+			hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+			return trustPlan{Commands: [][]string{
+				{"security", "delete-certificate", "-Z", hash, macOSSystemKeychain},
+			}}, nil
+		}
+		return trustPlan{Commands: [][]string{
+			{"security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", macOSSystemKeychain, certPath},
+		}}, nil
+
+	case "linux":
+		for _, store := range linuxTrustStores {
+			if _, err := lookPath(store.update); err != nil {
+				continue
+			}
+			installed := filepath.Join(store.dir, name+".crt")
+			if remove {
+				return trustPlan{Commands: [][]string{
+					{"rm", "-f", installed},
+					{store.update},
+				}}, nil
+			}
+			return trustPlan{Commands: [][]string{
+				{"install", "-m", "0644", certPath, installed},
+				{store.update},
+			}}, nil
+		}
+		return trustPlan{}, fmt.Errorf("no supported trust store found (need update-ca-certificates or update-ca-trust)")
+
+	case "windows":
+		if remove {
+			return trustPlan{Instructions: fmt.Sprintf(
+				"Run in an elevated prompt:\n  certutil -delstore Root %s", cert.SerialNumber.Text(16))}, nil
+		}
+		return trustPlan{Instructions: fmt.Sprintf(
+			"Run in an elevated prompt:\n  certutil -addstore -f Root %s", certPath)}, nil
+
+	default:
+		return trustPlan{}, fmt.Errorf("trusting the CA is not supported on %s", goos)
+	}
+}
+
+// withPrivileges prefixes commands with sudo unless kinder already runs as
+// root. It fails if sudo is needed but not installed.
+func withPrivileges(commands [][]string, euid int, lookPath func(string) (string, error)) ([][]string, error) {
+	if euid == 0 {
+		return commands, nil
+	}
+	if _, err := lookPath("sudo"); err != nil {
+		return nil, fmt.Errorf("updating the trust store needs root and sudo was not found; rerun as root")
+	}
+	wrapped := make([][]string, len(commands))
+	for i, args := range commands {
+		wrapped[i] = append([]string{"sudo"}, args...)
+	}
+	return wrapped, nil
+}
+
+// runTrustPlan prints and, when confirmed, runs a trust plan's commands. It
+// reports whether the trust store was changed.
+func runTrustPlan(plan trustPlan, confirmed bool) (bool, error) {
+	if plan.Instructions != "" {
+		Output("kinder cannot update this trust store itself.\n%s\n", plan.Instructions)
+		return false, nil
+	}
+
+	commands, err := withPrivileges(plan.Commands, os.Geteuid(), exec.LookPath)
+	if err != nil {
+		return false, err
+	}
+
+	if !confirmed {
+		Output("This will run:\n")
+		for _, args := range commands {
+			Output("  %s\n", strings.Join(args, " "))
+		}
+		Output("Rerun with --yes to update the system trust store.\n")
+		return false, nil
+	}
+
+	for _, args := range commands {
+		Verbose("Running: %s\n", strings.Join(args, " "))
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdin = os.Stdin // sudo may prompt for a password
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return false, fmt.Errorf("failed to run %s: %w", strings.Join(args, " "), err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPlanTrust(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("cert"), SerialNumber: big.NewInt(255)}
+
+	// tools returns a lookPath that finds only the named tools
+	tools := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, name := range names {
+				if name == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	tests := []struct {
+		name     string
+		goos     string
+		remove   bool
+		lookPath func(string) (string, error)
+		want     [][]string
+		manual   string
+		wantErr  bool
+	}{
+		{
+			name:     "debian trust",
+			goos:     "linux",
+			lookPath: tools("update-ca-certificates"),
+			want: [][]string{
+				{"install", "-m", "0644", "/data/ca.crt", "/usr/local/share/ca-certificates/kinder-ca.crt"},
+				{"update-ca-certificates"},
+			},
+		},
+		{
+			name:     "fedora untrust",
+			goos:     "linux",
+			remove:   true,
+			lookPath: tools("update-ca-trust"),
+			want: [][]string{
+				{"rm", "-f", "/etc/pki/ca-trust/source/anchors/kinder-ca.crt"},
+				{"update-ca-trust"},
+			},
+		},
+		{
+			name:     "linux without tools",
+			goos:     "linux",
+			lookPath: tools(),
+			wantErr:  true,
+		},
+		{
+			name:     "macos trust",
+			goos:     "darwin",
+			lookPath: tools(),
+			want: [][]string{
+				{"security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", macOSSystemKeychain, "/data/ca.crt"},
+			},
+		},
+		{
+			name:     "macos untrust by hash",
+			goos:     "darwin",
+			remove:   true,
+			lookPath: tools(),
+			want: [][]string{
+				// SHA-1 of "cert"
+				{"security", "delete-certificate", "-Z", "CD1B5069963D9878F92F1CFBE77F8912CDD5B2ED", macOSSystemKeychain},
+			},
+		},
+		{
+			name:     "windows instructions",
+			goos:     "windows",
+			remove:   true,
+			lookPath: tools(),
+			manual:   "certutil -delstore Root ff",
+		},
+		{
+			name:     "unsupported",
+			goos:     "plan9",
+			lookPath: tools(),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planTrust(tt.goos, cert, "/data/ca.crt", "kinder-ca", tt.remove, tt.lookPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("planTrust failed: %v", err)
+			}
+			if tt.manual != "" {
+				if len(plan.Commands) != 0 || !strings.Contains(plan.Instructions, tt.manual) {
+					t.Errorf("expected instructions containing %q, got %+v", tt.manual, plan)
+				}
+				return
+			}
+			if !reflect.DeepEqual(plan.Commands, tt.want) {
+				t.Errorf("expected commands %v, got %v", tt.want, plan.Commands)
+			}
+		})
+	}
+}
+
+func TestWithPrivileges(t *testing.T) {
+	commands := [][]string{{"update-ca-certificates"}}
+	found := func(string) (string, error) { return "/usr/bin/sudo", nil }
+	missing := func(string) (string, error) { return "", errors.New("not found") }
+
+	if got, _ := withPrivileges(commands, 0, missing); !reflect.DeepEqual(got, commands) {
+		t.Errorf("expected root to run commands directly, got %v", got)
+	}
+	if got, _ := withPrivileges(commands, 1000, found); !reflect.DeepEqual(got, [][]string{{"sudo", "update-ca-certificates"}}) {
+		t.Errorf("expected sudo prefix, got %v", got)
+	}
+	if _, err := withPrivileges(commands, 1000, missing); err == nil {
+		t.Error("expected error without sudo")
+	}
+}
@@ -11,9 +11,48 @@ import (
 	"math/big"
 	"net"
 	"os"
+	"strings"
 	"time"
 )
 
+const (
+	// DefaultOrganization is the Organization of generated CA certificates
+	DefaultOrganization = "kinder"
+	// DefaultCommonName is the CN template of generated CA certificates
+	DefaultCommonName = "kinder Root CA ({hostname})"
+)
+
+// CAOptions customize the subject of a generated CA certificate. Empty
+// fields use the defaults.
+type CAOptions struct {
+	// Organization is the subject Organization (default: kinder)
+	Organization string
+	// CommonName is the subject CN. "{hostname}" is replaced with the
+	// machine's hostname (default: "kinder Root CA ({hostname})").
+	CommonName string
+}
+
+// subject returns the certificate subject for the options
+func (o CAOptions) subject() (pkix.Name, error) {
+	org := o.Organization
+	if org == "" {
+		org = DefaultOrganization
+	}
+	cn := o.CommonName
+	if cn == "" {
+		cn = DefaultCommonName
+	}
+
+	if strings.Contains(cn, "{hostname}") {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return pkix.Name{}, fmt.Errorf("failed to get hostname: %w", err)
+		}
+		cn = strings.ReplaceAll(cn, "{hostname}", hostname)
+	}
+	return pkix.Name{CommonName: cn, Organization: []string{org}}, nil
+}
+
 // GenerateCA generates a new CA certificate and private key using ECDSA.
 // The certificate uses the machine's hostname as the CN and "kinder" as the Organization.
 func GenerateCA(certPath, keyPath string) error {
@@ -22,10 +61,15 @@ func GenerateCA(certPath, keyPath string) error {
 
 // GenerateCAWithDomain generates a CA certificate with name constraints for the specified domain
 func GenerateCAWithDomain(certPath, keyPath, domain string) error {
-	// Get the hostname for the CN
-	hostname, err := os.Hostname()
+	return GenerateCAWithOptions(certPath, keyPath, domain, CAOptions{})
+}
+
+// GenerateCAWithOptions generates a CA certificate with name constraints for
+// the specified domain and the subject given by opts
+func GenerateCAWithOptions(certPath, keyPath, domain string, opts CAOptions) error {
+	subject, err := opts.subject()
 	if err != nil {
-		return fmt.Errorf("failed to get hostname: %w", err)
+		return err
 	}
 
 	// Generate ECDSA private key using P-256 curve
@@ -54,12 +98,9 @@ func GenerateCAWithDomain(certPath, keyPath, domain string) error {
 
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
-		Subject: pkix.Name{
-			CommonName:   fmt.Sprintf("kinder Root CA (%s)", hostname),
-			Organization: []string{"kinder"},
-		},
-		NotBefore: notBefore,
-		NotAfter:  notAfter,
+		Subject:      subject,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
 		// Root CA only needs CertSign and CRLSign - no key encipherment or digital signature
 		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		BasicConstraintsValid: true,
@@ -107,43 +148,119 @@ func GenerateCAWithDomain(certPath, keyPath, domain string) error {
 	return nil
 }
 
-// GenerateIntermediate generates an intermediate CA certificate signed by the root CA
-func GenerateIntermediate(rootCertPath, rootKeyPath, intermediateCertPath, intermediateKeyPath string) error {
+// LoadCertificate reads and parses a PEM-encoded certificate from disk
+func LoadCertificate(certPath string) (*x509.Certificate, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM block from certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return cert, nil
+}
+
+// ValidateDomain checks that the CA's name constraints permit the given domain
+// and its subdomains. A CA without DNS name constraints permits any domain.
+func ValidateDomain(certPath, domain string) error {
+	cert, err := LoadCertificate(certPath)
+	if err != nil {
+		return err
+	}
+
+	if DomainPermitted(cert, domain) {
+		return nil
+	}
+
+	return fmt.Errorf("domain %q is not permitted by the CA name constraints (permitted: %s); regenerate the CA with 'ca generate --domain %s'",
+		domain, strings.Join(cert.PermittedDNSDomains, ", "), domain)
+}
+
+// DomainPermitted reports whether the certificate's permitted DNS domains cover
+// the given domain. Following RFC 5280, a constraint of "example.com" covers
+// the domain itself and any subdomain, while ".example.com" covers subdomains only.
+func DomainPermitted(cert *x509.Certificate, domain string) bool {
+	if len(cert.PermittedDNSDomains) == 0 {
+		return true
+	}
+
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, constraint := range cert.PermittedDNSDomains {
+		constraint = strings.ToLower(constraint)
+		if strings.HasPrefix(constraint, ".") {
+			if strings.HasSuffix(domain, constraint) {
+				return true
+			}
+			continue
+		}
+		if domain == constraint || strings.HasSuffix(domain, "."+constraint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loadRoot reads the root CA certificate and its ECDSA private key
+func loadRoot(rootCertPath, rootKeyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
 	// Read root CA certificate
 	rootCertPEM, err := os.ReadFile(rootCertPath)
 	if err != nil {
-		return fmt.Errorf("failed to read root certificate: %w", err)
+		return nil, nil, fmt.Errorf("failed to read root certificate: %w", err)
 	}
 
 	rootCertBlock, _ := pem.Decode(rootCertPEM)
 	if rootCertBlock == nil {
-		return fmt.Errorf("failed to decode root certificate PEM")
+		return nil, nil, fmt.Errorf("failed to decode root certificate PEM")
 	}
 
 	rootCert, err := x509.ParseCertificate(rootCertBlock.Bytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse root certificate: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse root certificate: %w", err)
 	}
 
 	// Read root CA private key
 	rootKeyPEM, err := os.ReadFile(rootKeyPath)
 	if err != nil {
-		return fmt.Errorf("failed to read root private key: %w", err)
+		return nil, nil, fmt.Errorf("failed to read root private key: %w", err)
 	}
 
 	rootKeyBlock, _ := pem.Decode(rootKeyPEM)
 	if rootKeyBlock == nil {
-		return fmt.Errorf("failed to decode root private key PEM")
+		return nil, nil, fmt.Errorf("failed to decode root private key PEM")
 	}
 
 	rootKey, err := x509.ParsePKCS8PrivateKey(rootKeyBlock.Bytes)
 	if err != nil {
-		return fmt.Errorf("failed to parse root private key: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse root private key: %w", err)
 	}
 
 	rootPrivateKey, ok := rootKey.(*ecdsa.PrivateKey)
 	if !ok {
-		return fmt.Errorf("root private key is not ECDSA")
+		return nil, nil, fmt.Errorf("root private key is not ECDSA")
+	}
+	return rootCert, rootPrivateKey, nil
+}
+
+// GenerateIntermediate generates an intermediate CA certificate signed by the root CA
+func GenerateIntermediate(rootCertPath, rootKeyPath, intermediateCertPath, intermediateKeyPath string) error {
+	return GenerateIntermediateWithCRL(rootCertPath, rootKeyPath, intermediateCertPath, intermediateKeyPath, "")
+}
+
+// GenerateIntermediateWithCRL generates an intermediate CA certificate signed
+// by the root CA, with crlURL as its CRL distribution point ("" for none)
+func GenerateIntermediateWithCRL(rootCertPath, rootKeyPath, intermediateCertPath, intermediateKeyPath, crlURL string) error {
+	rootCert, rootPrivateKey, err := loadRoot(rootCertPath, rootKeyPath)
+	if err != nil {
+		return err
 	}
 
 	// Generate new private key for intermediate
@@ -175,6 +292,9 @@ func GenerateIntermediate(rootCertPath, rootKeyPath, intermediateCertPath, inter
 		MaxPathLen:            0,
 		MaxPathLenZero:        true,
 	}
+	if crlURL != "" {
+		template.CRLDistributionPoints = []string{crlURL}
+	}
 
 	// Sign intermediate certificate with root CA
 	certDER, err := x509.CreateCertificate(rand.Reader, &template, rootCert, &intermediateKey.PublicKey, rootPrivateKey)
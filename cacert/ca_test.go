@@ -7,6 +7,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -39,69 +40,91 @@ func TestGenerateCA(t *testing.T) {
 }
 
 func TestGeneratedCertificateProperties(t *testing.T) {
-	// Create temporary directory for test files
-	tmpDir, err := os.MkdirTemp("", "ca-test-*")
-	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	certPath := filepath.Join(tmpDir, "ca.crt")
-	keyPath := filepath.Join(tmpDir, "ca.key")
+	hostname, _ := os.Hostname()
 
-	// Generate certificate
-	err = GenerateCA(certPath, keyPath)
-	if err != nil {
-		t.Fatalf("GenerateCA failed: %v", err)
-	}
+	tests := []struct {
+		name        string
+		opts        CAOptions
+		expectedOrg string
+		expectedCN  string
+	}{
+		{
+			name:        "defaults",
+			expectedOrg: "kinder",
+			expectedCN:  fmt.Sprintf("kinder Root CA (%s)", hostname),
+		},
+		{
+			name:        "custom subject",
+			opts:        CAOptions{Organization: "Example Corp", CommonName: "Example Dev CA"},
+			expectedOrg: "Example Corp",
+			expectedCN:  "Example Dev CA",
+		},
+		{
+			name:        "hostname template",
+			opts:        CAOptions{CommonName: "Example Dev CA on {hostname}"},
+			expectedOrg: "kinder",
+			expectedCN:  "Example Dev CA on " + hostname,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			certPath := filepath.Join(tmpDir, "ca.crt")
+			keyPath := filepath.Join(tmpDir, "ca.key")
+
+			// Generate certificate
+			if err := GenerateCAWithOptions(certPath, keyPath, "c0000201.sslip.io", tt.opts); err != nil {
+				t.Fatalf("GenerateCAWithOptions failed: %v", err)
+			}
 
-	// Read and parse certificate
-	certPEM, err := os.ReadFile(certPath)
-	if err != nil {
-		t.Fatalf("failed to read certificate: %v", err)
-	}
+			// Read and parse certificate
+			certPEM, err := os.ReadFile(certPath)
+			if err != nil {
+				t.Fatalf("failed to read certificate: %v", err)
+			}
 
-	block, _ := pem.Decode(certPEM)
-	if block == nil {
-		t.Fatal("failed to decode PEM block")
-	}
+			block, _ := pem.Decode(certPEM)
+			if block == nil {
+				t.Fatal("failed to decode PEM block")
+			}
 
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		t.Fatalf("failed to parse certificate: %v", err)
-	}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				t.Fatalf("failed to parse certificate: %v", err)
+			}
 
-	// Test: Certificate is a CA
-	if !cert.IsCA {
-		t.Error("certificate should be marked as CA")
-	}
+			// Test: Certificate is a CA
+			if !cert.IsCA {
+				t.Error("certificate should be marked as CA")
+			}
 
-	// Test: Basic constraints are valid
-	if !cert.BasicConstraintsValid {
-		t.Error("basic constraints should be valid")
-	}
+			// Test: Basic constraints are valid
+			if !cert.BasicConstraintsValid {
+				t.Error("basic constraints should be valid")
+			}
 
-	// Test: Organization is "kinder"
-	if len(cert.Subject.Organization) == 0 || cert.Subject.Organization[0] != "kinder" {
-		t.Errorf("expected Organization 'kinder', got %v", cert.Subject.Organization)
-	}
+			// Test: Organization
+			if len(cert.Subject.Organization) == 0 || cert.Subject.Organization[0] != tt.expectedOrg {
+				t.Errorf("expected Organization '%s', got %v", tt.expectedOrg, cert.Subject.Organization)
+			}
 
-	// Test: CN contains hostname and "kinder Root CA"
-	hostname, _ := os.Hostname()
-	expectedCN := fmt.Sprintf("kinder Root CA (%s)", hostname)
-	if cert.Subject.CommonName != expectedCN {
-		t.Errorf("expected CN '%s', got '%s'", expectedCN, cert.Subject.CommonName)
-	}
+			// Test: CN
+			if cert.Subject.CommonName != tt.expectedCN {
+				t.Errorf("expected CN '%s', got '%s'", tt.expectedCN, cert.Subject.CommonName)
+			}
 
-	// Test: Has correct key usage (root CA only needs CertSign and CRLSign)
-	expectedKeyUsage := x509.KeyUsageCertSign | x509.KeyUsageCRLSign
-	if cert.KeyUsage != expectedKeyUsage {
-		t.Errorf("expected key usage %d, got %d", expectedKeyUsage, cert.KeyUsage)
-	}
+			// Test: Has correct key usage (root CA only needs CertSign and CRLSign)
+			expectedKeyUsage := x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+			if cert.KeyUsage != expectedKeyUsage {
+				t.Errorf("expected key usage %d, got %d", expectedKeyUsage, cert.KeyUsage)
+			}
 
-	// Test: Root CA should NOT have extended key usage
-	if len(cert.ExtKeyUsage) > 0 {
-		t.Errorf("root CA should not have extended key usage, got %v", cert.ExtKeyUsage)
+			// Test: Root CA should NOT have extended key usage
+			if len(cert.ExtKeyUsage) > 0 {
+				t.Errorf("root CA should not have extended key usage, got %v", cert.ExtKeyUsage)
+			}
+		})
 	}
 }
 
@@ -278,3 +301,49 @@ func TestCertificateValidity(t *testing.T) {
 		t.Errorf("certificate verification failed: %v", err)
 	}
 }
+
+func TestValidateDomain(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "ca-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+
+	if err := GenerateCAWithDomain(certPath, keyPath, "example.test"); err != nil {
+		t.Fatalf("GenerateCAWithDomain failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		domain  string
+		wantErr bool
+	}{
+		{"matching domain", "example.test", false},
+		{"subdomain of constraint", "dev.example.test", false},
+		{"case insensitive", "Example.TEST", false},
+		{"mismatched domain", "other.test", true},
+		{"suffix without dot boundary", "badexample.test", true},
+		{"default domain against custom CA", "c0000201.sslip.io", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDomain(certPath, tt.domain)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDomain(%q) error = %v, wantErr %v", tt.domain, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "ca generate --domain "+tt.domain) {
+				t.Errorf("expected error to suggest regenerating the CA, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateDomainMissingCert(t *testing.T) {
+	if err := ValidateDomain("/nonexistent/ca.crt", "example.test"); err == nil {
+		t.Error("expected error when certificate does not exist")
+	}
+}
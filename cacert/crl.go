@@ -0,0 +1,120 @@
+package cacert
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Revocation is a certificate revoked by the kinder CA
+type Revocation struct {
+	// Serial is the certificate serial number in hex
+	Serial    string    `json:"serial"`
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// RevocationList is the store of revoked serials a CRL is generated from
+type RevocationList struct {
+	// Number is the number of the last CRL generated from the list
+	Number  int64        `json:"number"`
+	Revoked []Revocation `json:"revoked"`
+}
+
+// LoadRevocations reads a revocation list, returning an empty one if path
+// does not exist
+func LoadRevocations(path string) (*RevocationList, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &RevocationList{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revocation list: %w", err)
+	}
+
+	var list RevocationList
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse revocation list %s: %w", path, err)
+	}
+	return &list, nil
+}
+
+// Save writes the revocation list to path
+func (l *RevocationList) Save(path string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode revocation list: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write revocation list: %w", err)
+	}
+	return nil
+}
+
+// ParseSerial parses a certificate serial number in hex, as printed by
+// "kinder ca print" or openssl, with or without colons or a 0x prefix
+func ParseSerial(serial string) (*big.Int, error) {
+	s := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(serial), ":", ""))
+	s = strings.TrimPrefix(s, "0x")
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok || n.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid serial number %q: expected a positive hex number", serial)
+	}
+	return n, nil
+}
+
+// Revoke adds serial to the list, revoked at the given time. It returns
+// false if the serial was already revoked.
+func (l *RevocationList) Revoke(serial string, at time.Time) (bool, error) {
+	n, err := ParseSerial(serial)
+	if err != nil {
+		return false, err
+	}
+
+	hex := n.Text(16)
+	for _, r := range l.Revoked {
+		if r.Serial == hex {
+			return false, nil
+		}
+	}
+	l.Revoked = append(l.Revoked, Revocation{Serial: hex, RevokedAt: at.UTC()})
+	return true, nil
+}
+
+// GenerateCRL returns a DER CRL of the revoked serials, signed by the root CA
+// and valid until now+validity. The list's CRL number is incremented, so
+// save the list afterwards.
+func GenerateCRL(rootCertPath, rootKeyPath string, list *RevocationList, now time.Time, validity time.Duration) ([]byte, error) {
+	rootCert, rootKey, err := loadRoot(rootCertPath, rootKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]x509.RevocationListEntry, 0, len(list.Revoked))
+	for _, r := range list.Revoked {
+		serial, err := ParseSerial(r.Serial)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: r.RevokedAt})
+	}
+
+	list.Number++
+	template := &x509.RevocationList{
+		RevokedCertificateEntries: entries,
+		Number:                    big.NewInt(list.Number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(validity),
+	}
+
+	der, err := x509.CreateRevocationList(rand.Reader, template, rootCert, rootKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRL: %w", err)
+	}
+	return der, nil
+}
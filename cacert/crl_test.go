@@ -0,0 +1,142 @@
+package cacert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRevocationListRevoke(t *testing.T) {
+	tests := []struct {
+		name    string
+		serial  string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain hex", serial: "3fa2910c", want: "3fa2910c"},
+		{name: "colons", serial: "3F:A2:91:0C", want: "3fa2910c"},
+		{name: "0x prefix", serial: "0x01ff", want: "1ff"},
+		{name: "not hex", serial: "xyz", wantErr: true},
+		{name: "zero", serial: "00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var list RevocationList
+			added, err := list.Revoke(tt.serial, time.Now())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error for serial %q", tt.serial)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Revoke failed: %v", err)
+			}
+			if !added || len(list.Revoked) != 1 || list.Revoked[0].Serial != tt.want {
+				t.Fatalf("expected serial %s to be revoked, got %+v", tt.want, list.Revoked)
+			}
+
+			added, err = list.Revoke(tt.serial, time.Now())
+			if err != nil || added {
+				t.Errorf("expected revoking again to be a no-op, got added=%v err=%v", added, err)
+			}
+		})
+	}
+}
+
+func TestGenerateCRL(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+
+	revokedPath := filepath.Join(tmpDir, "revoked.json")
+	list, err := LoadRevocations(revokedPath)
+	if err != nil {
+		t.Fatalf("LoadRevocations failed: %v", err)
+	}
+	if _, err := list.Revoke("3f:a2:91:0c", time.Now()); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+
+	der, err := GenerateCRL(certPath, keyPath, list, time.Now(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCRL failed: %v", err)
+	}
+	if err := list.Save(revokedPath); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatalf("failed to parse CRL: %v", err)
+	}
+	root, err := LoadCertificate(certPath)
+	if err != nil {
+		t.Fatalf("failed to load CA: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(root); err != nil {
+		t.Errorf("CRL not signed by the CA: %v", err)
+	}
+	if len(crl.RevokedCertificateEntries) != 1 || crl.RevokedCertificateEntries[0].SerialNumber.Text(16) != "3fa2910c" {
+		t.Errorf("unexpected revoked entries: %+v", crl.RevokedCertificateEntries)
+	}
+	if crl.Number.Int64() != 1 {
+		t.Errorf("expected CRL number 1, got %s", crl.Number)
+	}
+
+	// The CRL number is kept in the store and increases with each CRL
+	reloaded, err := LoadRevocations(revokedPath)
+	if err != nil {
+		t.Fatalf("LoadRevocations failed: %v", err)
+	}
+	der, err = GenerateCRL(certPath, keyPath, reloaded, time.Now(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateCRL failed: %v", err)
+	}
+	crl, err = x509.ParseRevocationList(der)
+	if err != nil {
+		t.Fatalf("failed to parse CRL: %v", err)
+	}
+	if crl.Number.Int64() != 2 {
+		t.Errorf("expected CRL number 2, got %s", crl.Number)
+	}
+}
+
+func TestGenerateIntermediateWithCRL(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	keyPath := filepath.Join(tmpDir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+
+	intCertPath := filepath.Join(tmpDir, "intermediate.crt")
+	intKeyPath := filepath.Join(tmpDir, "intermediate.key")
+	const crlURL = "https://crl.kinder.internal/ca.crl"
+	if err := GenerateIntermediateWithCRL(certPath, keyPath, intCertPath, intKeyPath, crlURL); err != nil {
+		t.Fatalf("GenerateIntermediateWithCRL failed: %v", err)
+	}
+
+	certPEM, err := os.ReadFile(intCertPath)
+	if err != nil {
+		t.Fatalf("failed to read intermediate: %v", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("failed to decode intermediate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse intermediate: %v", err)
+	}
+	if len(cert.CRLDistributionPoints) != 1 || cert.CRLDistributionPoints[0] != crlURL {
+		t.Errorf("expected CRL distribution point %s, got %v", crlURL, cert.CRLDistributionPoints)
+	}
+}
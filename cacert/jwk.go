@@ -0,0 +1,100 @@
+package cacert
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// JWE parameters used to encrypt provisioner keys, matching what
+// "step ca provisioner add --type JWK" produces
+const (
+	jweAlg        = jose.PBES2_HS256_A128KW
+	jweEnc        = jose.A256GCM
+	jweIterations = 100000
+)
+
+// JWK is an EC P-256 JSON Web Key. D is only set for private keys.
+type JWK struct {
+	Use string `json:"use,omitempty"`
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Crv string `json:"crv"`
+	Alg string `json:"alg,omitempty"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d,omitempty"`
+}
+
+// JWKProvisionerKey is the key material for a step-ca JWK provisioner: the
+// public key and the private key encrypted with the provisioner password
+type JWKProvisionerKey struct {
+	Key          JWK
+	EncryptedKey string
+}
+
+// GenerateJWKProvisionerKey generates an ES256 signing key for a step-ca JWK
+// provisioner and encrypts it with password, so "step ca token" and friends
+// can use the provisioner with that password
+func GenerateJWKProvisionerKey(password string) (*JWKProvisionerKey, error) {
+	if password == "" {
+		return nil, fmt.Errorf("provisioner password is required")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	private := jose.JSONWebKey{Key: key, Use: "sig", Algorithm: string(jose.ES256)}
+	// step uses the RFC 7638 thumbprint as the key ID
+	thumbprint, err := private.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute key ID: %w", err)
+	}
+	private.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint)
+
+	plaintext, err := private.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	public, err := private.Public().MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	var publicKey JWK
+	if err := json.Unmarshal(public, &publicKey); err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	encrypted, err := encryptJWE(plaintext, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	return &JWKProvisionerKey{Key: publicKey, EncryptedKey: encrypted}, nil
+}
+
+// encryptJWE encrypts plaintext with a password as a compact JWE using
+// PBES2-HS256+A128KW key wrapping and A256GCM content encryption (RFC 7518)
+func encryptJWE(plaintext []byte, password string) (string, error) {
+	encrypter, err := jose.NewEncrypter(jweEnc, jose.Recipient{
+		Algorithm:  jweAlg,
+		Key:        []byte(password),
+		PBES2Count: jweIterations,
+	}, (&jose.EncrypterOptions{}).WithContentType("jwk+json"))
+	if err != nil {
+		return "", err
+	}
+	jwe, err := encrypter.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return jwe.CompactSerialize()
+}
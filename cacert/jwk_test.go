@@ -0,0 +1,74 @@
+package cacert
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// decryptJWE decrypts a provisioner key the way step does, with go-jose
+func decryptJWE(t *testing.T, compact, password string) []byte {
+	t.Helper()
+	jwe, err := jose.ParseEncryptedCompact(compact, []jose.KeyAlgorithm{jweAlg}, []jose.ContentEncryption{jweEnc})
+	if err != nil {
+		t.Fatalf("invalid JWE: %v", err)
+	}
+	if cty := jwe.Header.ExtraHeaders[jose.HeaderContentType]; cty != "jwk+json" {
+		t.Errorf("expected content type jwk+json, got %v", cty)
+	}
+	plaintext, err := jwe.Decrypt([]byte(password))
+	if err != nil {
+		t.Fatalf("failed to decrypt JWE: %v", err)
+	}
+	return plaintext
+}
+
+func TestGenerateJWKProvisionerKey(t *testing.T) {
+	key, err := GenerateJWKProvisionerKey("s3cret")
+	if err != nil {
+		t.Fatalf("GenerateJWKProvisionerKey failed: %v", err)
+	}
+
+	if key.Key.Kty != "EC" || key.Key.Crv != "P-256" || key.Key.Alg != "ES256" || key.Key.Use != "sig" || key.Key.D != "" {
+		t.Errorf("unexpected public key %+v", key.Key)
+	}
+
+	plaintext := decryptJWE(t, key.EncryptedKey, "s3cret")
+	var private jose.JSONWebKey
+	if err := json.Unmarshal(plaintext, &private); err != nil {
+		t.Fatalf("decrypted key is not a JWK: %v", err)
+	}
+	if private.IsPublic() || private.KeyID != key.Key.Kid {
+		t.Errorf("expected the private key with kid %s, got %+v", key.Key.Kid, private)
+	}
+	var decoded JWK
+	if err := json.Unmarshal(plaintext, &decoded); err != nil {
+		t.Fatalf("decrypted key is not a JWK: %v", err)
+	}
+	if decoded.X != key.Key.X || decoded.Y != key.Key.Y {
+		t.Errorf("decrypted key does not match the public key: %+v", decoded)
+	}
+
+	thumbprint, err := private.Thumbprint(crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to compute thumbprint: %v", err)
+	}
+	if key.Key.Kid != base64.RawURLEncoding.EncodeToString(thumbprint) {
+		t.Errorf("expected kid to be the key thumbprint")
+	}
+
+	jwe, err := jose.ParseEncryptedCompact(key.EncryptedKey, []jose.KeyAlgorithm{jweAlg}, []jose.ContentEncryption{jweEnc})
+	if err != nil {
+		t.Fatalf("invalid JWE: %v", err)
+	}
+	if _, err := jwe.Decrypt([]byte("wrong")); err == nil {
+		t.Error("expected decryption with the wrong password to fail")
+	}
+
+	if _, err := GenerateJWKProvisionerKey(""); err == nil {
+		t.Error("expected error for empty password")
+	}
+}
@@ -0,0 +1,87 @@
+package cacert
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Fingerprint returns the hex-encoded SHA-256 fingerprint of a certificate,
+// in the same format used by "step ca bootstrap --fingerprint"
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// FetchStepCARoot downloads the root certificate of an external Step CA and
+// verifies it against the expected fingerprint. The TLS connection is not
+// verified, since the root is not yet trusted; the fingerprint check is what
+// establishes trust, as with "step ca bootstrap". Returns the PEM-encoded root.
+func FetchStepCARoot(ctx context.Context, caURL, fingerprint string) ([]byte, error) {
+	fingerprint = strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+	if fingerprint == "" {
+		return nil, fmt.Errorf("fingerprint is required")
+	}
+
+	url := strings.TrimSuffix(caURL, "/") + "/root/" + fingerprint
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact Step CA: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch root certificate: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var root struct {
+		CA string `json:"ca"`
+	}
+	if err := json.Unmarshal(body, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse root certificate response: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(root.CA))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("response does not contain a PEM certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse root certificate: %w", err)
+	}
+
+	if actual := Fingerprint(cert); actual != fingerprint {
+		return nil, fmt.Errorf("root certificate fingerprint mismatch: expected %s, got %s", fingerprint, actual)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("certificate with fingerprint %s is not a CA", fingerprint)
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
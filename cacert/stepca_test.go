@@ -0,0 +1,96 @@
+package cacert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFetchStepCARoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	if err := GenerateCA(certPath, filepath.Join(tmpDir, "ca.key")); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	rootPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("failed to read certificate: %v", err)
+	}
+	cert, err := LoadCertificate(certPath)
+	if err != nil {
+		t.Fatalf("LoadCertificate failed: %v", err)
+	}
+	fingerprint := Fingerprint(cert)
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/root/"+fingerprint {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"ca": string(rootPEM)})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	t.Run("matching fingerprint", func(t *testing.T) {
+		got, err := FetchStepCARoot(ctx, server.URL, fingerprint)
+		if err != nil {
+			t.Fatalf("FetchStepCARoot failed: %v", err)
+		}
+		if strings.TrimSpace(string(got)) != strings.TrimSpace(string(rootPEM)) {
+			t.Error("expected fetched root to match the served certificate")
+		}
+	})
+
+	t.Run("colon separated uppercase fingerprint", func(t *testing.T) {
+		var parts []string
+		upper := strings.ToUpper(fingerprint)
+		for i := 0; i < len(upper); i += 2 {
+			parts = append(parts, upper[i:i+2])
+		}
+		if _, err := FetchStepCARoot(ctx, server.URL+"/", strings.Join(parts, ":")); err != nil {
+			t.Fatalf("FetchStepCARoot failed: %v", err)
+		}
+	})
+
+	t.Run("unknown fingerprint", func(t *testing.T) {
+		if _, err := FetchStepCARoot(ctx, server.URL, strings.Repeat("0", 64)); err == nil {
+			t.Error("expected error for unknown fingerprint")
+		}
+	})
+
+	t.Run("empty fingerprint", func(t *testing.T) {
+		if _, err := FetchStepCARoot(ctx, server.URL, ""); err == nil {
+			t.Error("expected error for empty fingerprint")
+		}
+	})
+}
+
+func TestFetchStepCARoot_FingerprintMismatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	certPath := filepath.Join(tmpDir, "ca.crt")
+	if err := GenerateCA(certPath, filepath.Join(tmpDir, "ca.key")); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	rootPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatalf("failed to read certificate: %v", err)
+	}
+
+	// A server that returns a different root than the one requested
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"ca": string(rootPEM)})
+	}))
+	defer server.Close()
+
+	_, err = FetchStepCARoot(context.Background(), server.URL, strings.Repeat("a", 64))
+	if err == nil || !strings.Contains(err.Error(), "mismatch") {
+		t.Errorf("expected fingerprint mismatch error, got: %v", err)
+	}
+}
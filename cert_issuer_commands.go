@@ -1,11 +1,12 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"codeberg.org/hipkoi/kinder/config"
 	"codeberg.org/hipkoi/kinder/kubernetes"
 	"github.com/spf13/cobra"
 )
@@ -13,16 +14,24 @@ import (
 var (
 	// Cert issuer flags
 	certIssuerName           string
+	certIssuerMode           string
+	certIssuerAllowCAKey     bool
+	certIssuerScope          string
+	certIssuerNamespace      string
 	certIssuerEmail          string
 	certIssuerACMEServer     string
 	certIssuerIngressClass   string
 	certIssuerUseDNS01       bool
 	certIssuerDNS01Provider  string
+	certIssuerDNS01APIToken  string
+	certIssuerDNS01File      string
+	certIssuerDNS01KeyID     string
+	certIssuerDNS01Region    string
 	certIssuerImageName      string
 	certIssuerImageTag       string
 	certIssuerSaveLocal      bool
 	certIssuerIncludeExample bool
-	certIssuerExampleDomain  string
+	certIssuerExampleDomain  []string
 )
 
 var certIssuerCmd = &cobra.Command{
@@ -43,6 +52,7 @@ var certIssuerPushCmd = &cobra.Command{
 The artifact contains:
   - kustomization.yaml: Kustomize bundle referencing the manifests
   - clusterissuer.yaml: ClusterIssuer with embedded CA bundle
+  - dns01-secret.yaml: (with --dns01) Secret holding the DNS provider credential
   - example-certificate.yaml: (optional) Example Certificate resource
 
 The ClusterIssuer is configured to:
@@ -50,6 +60,19 @@ The ClusterIssuer is configured to:
   - Trust the kinder root CA via embedded caBundle
   - Use HTTP-01 solver with Traefik by default
 
+With --dns01, the solver uses Cloudflare (--dns01-api-token) or Route53
+(--dns01-api-token holding the secret access key, plus --dns01-access-key-id
+and --dns01-region). Use --dns01-secret-file to keep the credential out of
+shell history. The credential is stored in the artifact, so only push it to a
+registry you trust.
+
+With --mode ca, the issuer is a cert-manager CA issuer that signs certificates
+in-cluster with the kinder CA key, so no ACME solver or Traefik is needed. The
+key is stored in ca-secret.yaml, which requires --allow-ca-key-in-cluster.
+
+With --scope namespace, a namespace-scoped Issuer is emitted in
+--issuer-namespace instead of a ClusterIssuer.
+
 ArgoCD can reference this artifact directly:
   apiVersion: argoproj.io/v1alpha1
   kind: Application
@@ -58,7 +81,7 @@ ArgoCD can reference this artifact directly:
       - repoURL: zot:5000/cert-manager-issuer
         targetRevision: latest`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Get data directory and cert path
 		dataDir, err := getDataDir()
@@ -68,7 +91,9 @@ ArgoCD can reference this artifact directly:
 
 		caCertPath := certPath
 		if caCertPath == "" {
-			caCertPath = filepath.Join(dataDir, CACertFilename)
+			if caCertPath, err = config.CACertPath(); err != nil {
+				return err
+			}
 		}
 
 		// Check if CA certificate exists
@@ -76,12 +101,37 @@ ArgoCD can reference this artifact directly:
 			return fmt.Errorf("CA certificate not found at %s. Run 'kinder start' or 'kinder ca generate' first", caCertPath)
 		}
 
+		dns01Secret, err := dns01Credential()
+		if err != nil {
+			return err
+		}
+		platforms, err := parseArtifactPlatforms(ctx)
+		if err != nil {
+			return err
+		}
+
+		// The CA issuer puts the kinder CA key into the artifact and the cluster
+		if certIssuerMode == kubernetes.IssuerModeCA {
+			if !certIssuerAllowCAKey {
+				return fmt.Errorf("--mode ca stores the kinder CA private key in the cluster; pass --allow-ca-key-in-cluster to confirm")
+			}
+			Warn("The kinder CA private key will be stored in the artifact and as a Secret in the cluster\n")
+		}
+		caKey, err := certIssuerCAKey()
+		if err != nil {
+			return err
+		}
+
 		cfg := kubernetes.CertManagerIssuerConfig{
 			RootCACertPath:     caCertPath,
-			RegistryURL:        "localhost:5000",
+			CAKey:              caKey,
+			Mode:               certIssuerMode,
+			RegistryURL:        localRegistry(),
 			ImageName:          certIssuerImageName,
 			ImageTag:           certIssuerImageTag,
 			IssuerName:         certIssuerName,
+			Scope:              certIssuerScope,
+			Namespace:          certIssuerNamespace,
 			Email:              certIssuerEmail,
 			ACMEServerURL:      certIssuerACMEServer,
 			Domain:             traefikDomain,
@@ -89,8 +139,13 @@ ArgoCD can reference this artifact directly:
 			IngressClass:       certIssuerIngressClass,
 			UseDNS01:           certIssuerUseDNS01,
 			DNS01Provider:      certIssuerDNS01Provider,
+			DNS01Secret:        dns01Secret,
+			DNS01AccessKeyID:   certIssuerDNS01KeyID,
+			DNS01Region:        certIssuerDNS01Region,
 			IncludeExampleCert: certIssuerIncludeExample,
-			ExampleCertDomain:  certIssuerExampleDomain,
+			ExampleCertDomains: certIssuerExampleDomain,
+			Platforms:          platforms,
+			Force:              forcePush,
 		}
 
 		Header("Building cert-manager issuer bundle...")
@@ -98,11 +153,16 @@ ArgoCD can reference this artifact directly:
 
 		// Build and push the bundle
 		ProgressStart("📦", "Building OCI artifact")
-		if err := kubernetes.BuildAndPushCertManagerIssuer(ctx, cfg); err != nil {
+		pushed, err := kubernetes.BuildAndPushCertManagerIssuer(ctx, cfg)
+		if err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to push cert-manager issuer bundle: %w", err)
 		}
-		ProgressDone(true, fmt.Sprintf("Pushed to localhost:5000/%s:%s", cfg.ImageName, cfg.ImageTag))
+		if pushed {
+			ProgressDone(true, fmt.Sprintf("Pushed to %s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag))
+		} else {
+			ProgressDone(true, fmt.Sprintf("Unchanged at %s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag))
+		}
 
 		// Optionally save manifests locally for inspection
 		if certIssuerSaveLocal {
@@ -135,7 +195,7 @@ ArgoCD can reference this artifact directly:
 		BlankLine()
 
 		Header("Usage with ArgoCD:")
-		Output("  Image: localhost:5000/%s:%s\n", cfg.ImageName, cfg.ImageTag)
+		Output("  Image: %s/%s:%s\n", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag)
 		Output("  From Kind: zot:5000/%s:%s\n", cfg.ImageName, cfg.ImageTag)
 		BlankLine()
 
@@ -146,7 +206,11 @@ ArgoCD can reference this artifact directly:
 		Output("  spec:\n")
 		Output("    issuerRef:\n")
 		Output("      name: %s\n", certIssuerName)
-		Output("      kind: ClusterIssuer\n")
+		if certIssuerScope == kubernetes.IssuerScopeNamespace {
+			Output("      kind: Issuer\n")
+		} else {
+			Output("      kind: ClusterIssuer\n")
+		}
 
 		return nil
 	},
@@ -157,15 +221,13 @@ var certIssuerShowCmd = &cobra.Command{
 	Short: "Show the generated cert-manager issuer manifests",
 	Long:  `Display the Kubernetes manifests that would be included in the cert-manager issuer bundle.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Get data directory and cert path
-		dataDir, err := getDataDir()
-		if err != nil {
-			return fmt.Errorf("failed to get data directory: %w", err)
-		}
-
 		caCertPath := certPath
 		if caCertPath == "" {
-			caCertPath = filepath.Join(dataDir, CACertFilename)
+			path, err := config.CACertPath()
+			if err != nil {
+				return err
+			}
+			caCertPath = path
 		}
 
 		// Check if CA certificate exists
@@ -179,9 +241,23 @@ var certIssuerShowCmd = &cobra.Command{
 			return fmt.Errorf("failed to read CA certificate: %w", err)
 		}
 
+		dns01Secret, err := dns01Credential()
+		if err != nil {
+			return err
+		}
+
+		caKey, err := certIssuerCAKey()
+		if err != nil {
+			return err
+		}
+
 		cfg := kubernetes.CertManagerIssuerConfig{
 			RootCACertPath:     caCertPath,
+			CAKey:              caKey,
+			Mode:               certIssuerMode,
 			IssuerName:         certIssuerName,
+			Scope:              certIssuerScope,
+			Namespace:          certIssuerNamespace,
 			Email:              certIssuerEmail,
 			ACMEServerURL:      certIssuerACMEServer,
 			Domain:             traefikDomain,
@@ -189,8 +265,11 @@ var certIssuerShowCmd = &cobra.Command{
 			IngressClass:       certIssuerIngressClass,
 			UseDNS01:           certIssuerUseDNS01,
 			DNS01Provider:      certIssuerDNS01Provider,
+			DNS01Secret:        dns01Secret,
+			DNS01AccessKeyID:   certIssuerDNS01KeyID,
+			DNS01Region:        certIssuerDNS01Region,
 			IncludeExampleCert: certIssuerIncludeExample,
-			ExampleCertDomain:  certIssuerExampleDomain,
+			ExampleCertDomains: certIssuerExampleDomain,
 		}
 
 		manifests, err := kubernetes.GenerateCertManagerIssuerManifests(cfg, kinderCA)
@@ -209,33 +288,96 @@ var certIssuerShowCmd = &cobra.Command{
 			Output("---\n%s", string(manifests.ExampleCert))
 		}
 
+		if len(manifests.DNS01Secret) > 0 {
+			Output("# dns01-secret.yaml (not shown, contains the DNS provider credential)\n")
+		}
+		if len(manifests.CASecret) > 0 {
+			Output("# ca-secret.yaml (not shown, contains the kinder CA private key)\n")
+		}
+
 		return nil
 	},
 }
 
+// certIssuerCAKey reads the kinder CA key for --mode ca from --key or the
+// configured key path. It returns nil in acme mode, which does not need the key.
+func certIssuerCAKey() ([]byte, error) {
+	if certIssuerMode != kubernetes.IssuerModeCA {
+		return nil, nil
+	}
+
+	caKeyPath := keyPath
+	if caKeyPath == "" {
+		path, err := config.CAKeyPath()
+		if err != nil {
+			return nil, err
+		}
+		caKeyPath = path
+	}
+	key, err := os.ReadFile(caKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+	return key, nil
+}
+
+// dns01Credential returns the DNS-01 provider credential from --dns01-api-token
+// or --dns01-secret-file
+func dns01Credential() (string, error) {
+	if certIssuerDNS01File == "" {
+		return certIssuerDNS01APIToken, nil
+	}
+	if certIssuerDNS01APIToken != "" {
+		return "", fmt.Errorf("--dns01-api-token and --dns01-secret-file are mutually exclusive")
+	}
+
+	data, err := os.ReadFile(certIssuerDNS01File)
+	if err != nil {
+		return "", fmt.Errorf("failed to read DNS-01 secret file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 func init() {
 	// Setup flags for cert-issuer push command
 	certIssuerPushCmd.Flags().StringVar(&certIssuerName, "issuer-name", kubernetes.CertManagerIssuerName, "Name of the ClusterIssuer")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerMode, "mode", kubernetes.IssuerModeACME, "Issuer mode: acme (Step CA ACME) or ca (sign with the kinder CA key)")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerScope, "scope", kubernetes.IssuerScopeCluster, "Issuer scope: cluster (ClusterIssuer) or namespace (Issuer)")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerNamespace, "issuer-namespace", "", "Namespace of the Issuer with --scope namespace (default: default)")
 	certIssuerPushCmd.Flags().StringVar(&certIssuerEmail, "email", kubernetes.CertManagerIssuerEmail, "ACME account email address")
 	certIssuerPushCmd.Flags().StringVar(&certIssuerACMEServer, "acme-server", "", "ACME server URL (default: derived from domain/port)")
 	certIssuerPushCmd.Flags().StringVar(&certIssuerIngressClass, "ingress-class", kubernetes.CertManagerIssuerIngressClass, "Ingress class for HTTP-01 solver")
 	certIssuerPushCmd.Flags().BoolVar(&certIssuerUseDNS01, "dns01", false, "Use DNS-01 solver instead of HTTP-01")
-	certIssuerPushCmd.Flags().StringVar(&certIssuerDNS01Provider, "dns01-provider", "", "DNS provider for DNS-01 (e.g., cloudflare, route53)")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerDNS01Provider, "dns01-provider", "", "DNS provider for DNS-01 (cloudflare, route53)")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerDNS01APIToken, "dns01-api-token", "", "DNS-01 credential: Cloudflare API token or Route53 secret access key")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerDNS01File, "dns01-secret-file", "", "Read the DNS-01 credential from a file instead")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerDNS01KeyID, "dns01-access-key-id", "", "AWS access key ID for Route53")
+	certIssuerPushCmd.Flags().StringVar(&certIssuerDNS01Region, "dns01-region", "", "AWS region for Route53")
 	certIssuerPushCmd.Flags().StringVar(&certIssuerImageName, "image-name", kubernetes.CertManagerIssuerImageName, "Image name for the bundle")
 	certIssuerPushCmd.Flags().StringVar(&certIssuerImageTag, "image-tag", kubernetes.CertManagerIssuerImageTag, "Image tag for the bundle")
 	certIssuerPushCmd.Flags().BoolVar(&certIssuerSaveLocal, "save-local", false, "Also save manifests to local data directory")
 	certIssuerPushCmd.Flags().BoolVar(&certIssuerIncludeExample, "include-example", false, "Include an example Certificate resource")
-	certIssuerPushCmd.Flags().StringVar(&certIssuerExampleDomain, "example-domain", "", "Domain for the example certificate (default: example.<domain>)")
+	certIssuerPushCmd.Flags().StringSliceVar(&certIssuerExampleDomain, "example-domain", nil, "DNS name for the example certificate, repeatable (default: example.<domain>)")
+	certIssuerPushCmd.Flags().BoolVar(&certIssuerAllowCAKey, "allow-ca-key-in-cluster", false, "Confirm that --mode ca may store the kinder CA private key in the cluster")
+	certIssuerPushCmd.Flags().BoolVar(&forcePush, "force", false, "Push even if the registry already has identical content")
+	certIssuerPushCmd.Flags().StringArrayVar(&artifactPlatforms, "platform", nil, "Platform to build the image for as os/arch[/variant] (repeatable; several push an image index)")
 
 	// Setup flags for cert-issuer show command
 	certIssuerShowCmd.Flags().StringVar(&certIssuerName, "issuer-name", kubernetes.CertManagerIssuerName, "Name of the ClusterIssuer")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerMode, "mode", kubernetes.IssuerModeACME, "Issuer mode: acme (Step CA ACME) or ca (sign with the kinder CA key)")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerScope, "scope", kubernetes.IssuerScopeCluster, "Issuer scope: cluster (ClusterIssuer) or namespace (Issuer)")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerNamespace, "issuer-namespace", "", "Namespace of the Issuer with --scope namespace (default: default)")
 	certIssuerShowCmd.Flags().StringVar(&certIssuerEmail, "email", kubernetes.CertManagerIssuerEmail, "ACME account email address")
 	certIssuerShowCmd.Flags().StringVar(&certIssuerACMEServer, "acme-server", "", "ACME server URL (default: derived from domain/port)")
 	certIssuerShowCmd.Flags().StringVar(&certIssuerIngressClass, "ingress-class", kubernetes.CertManagerIssuerIngressClass, "Ingress class for HTTP-01 solver")
 	certIssuerShowCmd.Flags().BoolVar(&certIssuerUseDNS01, "dns01", false, "Use DNS-01 solver instead of HTTP-01")
-	certIssuerShowCmd.Flags().StringVar(&certIssuerDNS01Provider, "dns01-provider", "", "DNS provider for DNS-01")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerDNS01Provider, "dns01-provider", "", "DNS provider for DNS-01 (cloudflare, route53)")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerDNS01APIToken, "dns01-api-token", "", "DNS-01 credential: Cloudflare API token or Route53 secret access key")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerDNS01File, "dns01-secret-file", "", "Read the DNS-01 credential from a file instead")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerDNS01KeyID, "dns01-access-key-id", "", "AWS access key ID for Route53")
+	certIssuerShowCmd.Flags().StringVar(&certIssuerDNS01Region, "dns01-region", "", "AWS region for Route53")
 	certIssuerShowCmd.Flags().BoolVar(&certIssuerIncludeExample, "include-example", false, "Include an example Certificate resource")
-	certIssuerShowCmd.Flags().StringVar(&certIssuerExampleDomain, "example-domain", "", "Domain for the example certificate")
+	certIssuerShowCmd.Flags().StringSliceVar(&certIssuerExampleDomain, "example-domain", nil, "DNS name for the example certificate, repeatable")
 
 	// Add subcommands
 	certIssuerCmd.AddCommand(certIssuerPushCmd)
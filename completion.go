@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"github.com/spf13/cobra"
+)
+
+// serviceNames lists the services accepted by the container commands
+var serviceNames = []string{"stepca", "zot", "gatus", "traefik", "kind"}
+
+// completeServiceNames completes the single service argument of the
+// container commands
+func completeServiceNames(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(serviceNames, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfileNames completes --profile with the profiles found in the
+// base data directory. PersistentPreRunE does not run during completion, so
+// the config is loaded here to honour --config, --data-dir and KINDER_DATADIR.
+func completeProfileNames(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if err := config.Initialize(configPath); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	bindFlagsToViper(cmd)
+
+	baseDir, err := config.GetDataDir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	profiles, err := listProfiles(baseDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return filterCompletions(profiles, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// filterCompletions returns the candidates starting with prefix
+func filterCompletions(candidates []string, prefix string) []cobra.Completion {
+	var matches []cobra.Completion
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteServiceNames(t *testing.T) {
+	got, directive := completeServiceNames(containerStartCmd, nil, "t")
+	if !slices.Equal(got, []cobra.Completion{"traefik"}) {
+		t.Errorf("expected [traefik], got %v", got)
+	}
+	if directive != cobra.ShellCompDirectiveNoFileComp {
+		t.Errorf("expected no file completion, got %v", directive)
+	}
+
+	if got, _ := completeServiceNames(containerStartCmd, []string{"zot"}, ""); len(got) != 0 {
+		t.Errorf("expected no completions after the service argument, got %v", got)
+	}
+}
+
+func TestCompleteProfileNames(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("KINDER_DATADIR", dataDir)
+	for _, name := range []string{"dev", "demo", "staging", "Invalid"} {
+		if err := ensureProfileDataDir(filepath.Join(dataDir, name)); err != nil {
+			t.Fatalf("failed to create profile dir: %v", err)
+		}
+	}
+
+	got, _ := completeProfileNames(rootCmd, nil, "de")
+	if !slices.Equal(got, []cobra.Completion{"demo", "dev"}) {
+		t.Errorf("expected [demo dev], got %v", got)
+	}
+}
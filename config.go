@@ -80,6 +80,9 @@ func NewConfigFromFile(fileCfg *config.FileConfig) *Config {
 	if fileCfg.Domain != "" {
 		cfg.TraefikDomain = fileCfg.Domain
 	}
+	if fileCfg.DataDir != "" {
+		cfg.DataDir = fileCfg.DataDir
+	}
 	if fileCfg.Network.Name != "" {
 		cfg.NetworkName = fileCfg.Network.Name
 	}
@@ -104,6 +107,12 @@ func NewConfigFromFile(fileCfg *config.FileConfig) *Config {
 	if fileCfg.Images.Traefik != "" {
 		cfg.TraefikImage = fileCfg.Images.Traefik
 	}
+	if fileCfg.CertPath != "" {
+		cfg.CertPath = fileCfg.CertPath
+	}
+	if fileCfg.KeyPath != "" {
+		cfg.KeyPath = fileCfg.KeyPath
+	}
 	if len(fileCfg.RegistryMirrors) > 0 {
 		cfg.RegistryMirrors = fileCfg.RegistryMirrors
 	}
@@ -199,23 +208,55 @@ func bindFlag(f *pflag.Flag) {
 	}
 }
 
+// applyServiceIP sets the domain to the sslip.io name of --service-ip, so the
+// CA name constraints and service URLs follow the chosen address
+func applyServiceIP(cmd *cobra.Command) error {
+	if serviceIP == "" {
+		return nil
+	}
+	if cmd.Flags().Changed("traefik-domain") || cmd.Flags().Changed("domain") {
+		return fmt.Errorf("--service-ip sets the domain; it cannot be combined with --traefik-domain")
+	}
+
+	domain := config.SslipDomain(serviceIP)
+	if domain == "" {
+		return fmt.Errorf("invalid --service-ip %q: expected an IPv4 address", serviceIP)
+	}
+	traefikDomain = domain
+	config.Set(config.KeyDomain, domain)
+	Verbose("Using domain %s for service IP %s\n", domain, serviceIP)
+	return nil
+}
+
 // flagToViperKey maps CLI flag names to Viper configuration keys
 func flagToViperKey(flagName string) string {
 	mapping := map[string]string{
-		"cert":           config.KeyCertPath,
-		"key":            config.KeyKeyPath,
-		"data-dir":       config.KeyDataDir,
-		"network":        config.KeyNetworkName,
-		"cidr":           config.KeyNetworkCIDR,
-		"domain":         config.KeyDomain,
-		"traefik-port":   config.KeyTraefikPort,
-		"traefik-domain": config.KeyDomain,
-		"port":           config.KeyTraefikPort,
-		"stepca-image":   config.KeyImagesStepCA,
-		"zot-image":      config.KeyImagesZot,
-		"gatus-image":    config.KeyImagesGatus,
-		"traefik-image":  config.KeyImagesTraefik,
-		"image":          "", // Context-dependent, handled separately
+		"cert":              config.KeyCertPath,
+		"key":               config.KeyKeyPath,
+		"ca-org":            config.KeyCAOrganization,
+		"ca-cn":             config.KeyCACommonName,
+		"data-dir":          config.KeyDataDir,
+		"network":           config.KeyNetworkName,
+		"cidr":              config.KeyNetworkCIDR,
+		"ipv6":              config.KeyNetworkIPv6,
+		"ipv6-cidr":         config.KeyNetworkIPv6CIDR,
+		"domain":            config.KeyDomain,
+		"traefik-port":      config.KeyTraefikPort,
+		"traefik-domain":    config.KeyDomain,
+		"port":              config.KeyTraefikPort,
+		"stepca-image":      config.KeyImagesStepCA,
+		"zot-image":         config.KeyImagesZot,
+		"zot-port":          config.KeyZotPort,
+		"gatus-image":       config.KeyImagesGatus,
+		"traefik-image":     config.KeyImagesTraefik,
+		"skip-trust-bundle": config.KeySkipTrustBundle,
+		"skip-cert-issuer":  config.KeySkipCertIssuer,
+		"mozilla-ca-file":   config.KeyMozillaCAFile,
+		"mozilla-ca-sha256": config.KeyMozillaCASHA256,
+		"pod-subnet":        config.KeyKindPodSubnet,
+		"service-subnet":    config.KeyKindServiceSubnet,
+		"expose-argocd":     config.KeyArgocdExpose,
+		"image":             "", // Context-dependent, handled separately
 	}
 	return mapping[flagName]
 }
@@ -2,7 +2,9 @@
 package config
 
 import (
+	"encoding/hex"
 	"fmt"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"strings"
@@ -18,6 +20,7 @@ const (
 	DefaultNetworkCIDR  = "172.28.28.0/24"
 	DefaultBridgeName   = "kindbr0"
 	DefaultTraefikPort  = "8443"
+	DefaultZotPort      = "5000"
 	DefaultStepCAImage  = "smallstep/step-ca:latest"
 	DefaultZotImage     = "ghcr.io/project-zot/zot-linux-amd64:latest"
 	DefaultGatusImage   = "twinproduction/gatus:latest"
@@ -26,8 +29,43 @@ const (
 	// Current stable: v3.2.x, so default to latest v3.1.x for stability
 	DefaultArgocdVersion     = "v3.1.10"
 	DefaultArgocdManifestURL = "https://raw.githubusercontent.com/mattwillsher/kinder-argo/refs/heads/main/root-app.yaml"
+	// DefaultMozillaCACacheTTL is how long a downloaded Mozilla CA bundle is reused
+	DefaultMozillaCACacheTTL = "24h"
 )
 
+// SslipDomain returns the sslip.io domain that resolves to an IPv4 address,
+// with the address as eight hex digits: 192.0.2.1 gives c0000201.sslip.io.
+// It returns "" if ip is not an IPv4 address.
+func SslipDomain(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || !addr.Is4() {
+		return ""
+	}
+	return fmt.Sprintf("%x.sslip.io", addr.As4())
+}
+
+// SslipAddress returns the IPv4 address encoded in an sslip.io domain made
+// by SslipDomain, and whether domain is one
+func SslipAddress(domain string) (string, bool) {
+	label, ok := strings.CutSuffix(strings.ToLower(domain), ".sslip.io")
+	if !ok || len(label) != 8 {
+		return "", false
+	}
+	b, err := hex.DecodeString(label)
+	if err != nil {
+		return "", false
+	}
+	return netip.AddrFrom4([4]byte(b)).String(), true
+}
+
+// DefaultNetworkIPv6CIDR is the IPv6 subnet of the network when IPv6 is
+// enabled, a unique local range
+const DefaultNetworkIPv6CIDR = "fd00:28:28::/64"
+
+// AutoNetworkCIDR as the network CIDR picks a free private /24 when the
+// network is created
+const AutoNetworkCIDR = "auto"
+
 // Config keys for Viper (use these constants to avoid typos)
 const (
 	KeyAppName           = "appName"
@@ -36,6 +74,12 @@ const (
 	KeyNetworkName       = "network.name"
 	KeyNetworkCIDR       = "network.cidr"
 	KeyNetworkBridge     = "network.bridge"
+	KeyNetworkIPv6       = "network.ipv6"
+	KeyNetworkIPv6CIDR   = "network.ipv6Cidr"
+	KeyNetworkStepCAIP   = "network.stepcaIP"
+	KeyNetworkZotIP      = "network.zotIP"
+	KeyNetworkGatusIP    = "network.gatusIP"
+	KeyNetworkTraefikIP  = "network.traefikIP"
 	KeyTraefikPort       = "traefik.port"
 	KeyImagesStepCA      = "images.stepca"
 	KeyImagesZot         = "images.zot"
@@ -44,8 +88,23 @@ const (
 	KeyRegistryMirrors   = "registryMirrors"
 	KeyCertPath          = "certPath"
 	KeyKeyPath           = "keyPath"
+	KeyCAOrganization    = "ca.organization"
+	KeyCACommonName      = "ca.commonName"
 	KeyArgocdVersion     = "argocd.version"
 	KeyArgocdManifestURL = "argocd.manifestURL"
+	KeyArgocdExpose      = "argocd.expose"
+	KeySkipTrustBundle   = "skipTrustBundle"
+	KeySkipCertIssuer    = "skipCertIssuer"
+	KeyMozillaCAFile     = "mozillaCA.file"
+	KeyMozillaCACacheTTL = "mozillaCA.cacheTTL"
+	KeyMozillaCASHA256   = "mozillaCA.sha256"
+	KeyExternalCAURL     = "externalCA.url"
+	KeyZotPort           = "zot.port"
+	KeyZotUsername       = "zot.username"
+	KeyZotPassword       = "zot.password"
+	KeyKindPodSubnet     = "kind.podSubnet"
+	KeyKindServiceSubnet = "kind.serviceSubnet"
+	KeyGatusAlerts       = "gatusAlerts"
 )
 
 // DefaultRegistryMirrors is the default list of registries to mirror
@@ -61,6 +120,16 @@ type NetworkConfig struct {
 	Name   string `mapstructure:"name" yaml:"name,omitempty"`
 	CIDR   string `mapstructure:"cidr" yaml:"cidr,omitempty"`
 	Bridge string `mapstructure:"bridge" yaml:"bridge,omitempty"`
+	// IPv6 adds an IPv6 subnet to the network and makes the Kind cluster
+	// dual-stack
+	IPv6     bool   `mapstructure:"ipv6" yaml:"ipv6,omitempty"`
+	IPv6CIDR string `mapstructure:"ipv6Cidr" yaml:"ipv6Cidr,omitempty"`
+	// Static IPv4 addresses of the service containers (empty for one
+	// assigned by Docker)
+	StepCAIP  string `mapstructure:"stepcaIP" yaml:"stepcaIP,omitempty"`
+	ZotIP     string `mapstructure:"zotIP" yaml:"zotIP,omitempty"`
+	GatusIP   string `mapstructure:"gatusIP" yaml:"gatusIP,omitempty"`
+	TraefikIP string `mapstructure:"traefikIP" yaml:"traefikIP,omitempty"`
 }
 
 // TraefikConfig holds Traefik-related configuration
@@ -72,6 +141,41 @@ type TraefikConfig struct {
 type ArgocdConfig struct {
 	Version     string `mapstructure:"version" yaml:"version,omitempty"`
 	ManifestURL string `mapstructure:"manifestURL" yaml:"manifestURL,omitempty"`
+	// Expose routes https://argocd.<domain> through Traefik
+	Expose bool `mapstructure:"expose" yaml:"expose,omitempty"`
+}
+
+// CAConfig holds the subject of a generated kinder CA
+type CAConfig struct {
+	Organization string `mapstructure:"organization" yaml:"organization,omitempty"`
+	// CommonName may contain {hostname}, replaced with the machine's hostname
+	CommonName string `mapstructure:"commonName" yaml:"commonName,omitempty"`
+}
+
+// MozillaCAConfig holds configuration for the Mozilla CA bundle included in trust bundles
+type MozillaCAConfig struct {
+	File     string `mapstructure:"file" yaml:"file,omitempty"`
+	CacheTTL string `mapstructure:"cacheTTL" yaml:"cacheTTL,omitempty"`
+	SHA256   string `mapstructure:"sha256" yaml:"sha256,omitempty"`
+}
+
+// ExternalCAConfig holds configuration for adopting an existing Step CA
+type ExternalCAConfig struct {
+	URL string `mapstructure:"url" yaml:"url,omitempty"`
+}
+
+// ZotConfig holds the Zot host port and credentials for an authenticated
+// Zot registry
+type ZotConfig struct {
+	Port     string `mapstructure:"port" yaml:"port,omitempty"`
+	Username string `mapstructure:"username" yaml:"username,omitempty"`
+	Password string `mapstructure:"password" yaml:"password,omitempty"`
+}
+
+// KindConfig holds Kind cluster networking configuration
+type KindConfig struct {
+	PodSubnet     string `mapstructure:"podSubnet" yaml:"podSubnet,omitempty"`
+	ServiceSubnet string `mapstructure:"serviceSubnet" yaml:"serviceSubnet,omitempty"`
 }
 
 // ImagesConfig holds container image configuration
@@ -84,16 +188,26 @@ type ImagesConfig struct {
 
 // FileConfig represents the configuration file structure
 type FileConfig struct {
-	AppName         string        `mapstructure:"appName" yaml:"appName,omitempty"`
-	DataDir         string        `mapstructure:"dataDir" yaml:"dataDir,omitempty"`
-	Domain          string        `mapstructure:"domain" yaml:"domain,omitempty"`
-	Network         NetworkConfig `mapstructure:"network" yaml:"network,omitempty"`
-	Traefik         TraefikConfig `mapstructure:"traefik" yaml:"traefik,omitempty"`
-	Argocd          ArgocdConfig  `mapstructure:"argocd" yaml:"argocd,omitempty"`
-	Images          ImagesConfig  `mapstructure:"images" yaml:"images,omitempty"`
-	RegistryMirrors []string      `mapstructure:"registryMirrors" yaml:"registryMirrors,omitempty"`
-	CertPath        string        `mapstructure:"certPath" yaml:"certPath,omitempty"`
-	KeyPath         string        `mapstructure:"keyPath" yaml:"keyPath,omitempty"`
+	AppName         string           `mapstructure:"appName" yaml:"appName,omitempty"`
+	DataDir         string           `mapstructure:"dataDir" yaml:"dataDir,omitempty"`
+	Domain          string           `mapstructure:"domain" yaml:"domain,omitempty"`
+	Network         NetworkConfig    `mapstructure:"network" yaml:"network,omitempty"`
+	Traefik         TraefikConfig    `mapstructure:"traefik" yaml:"traefik,omitempty"`
+	Argocd          ArgocdConfig     `mapstructure:"argocd" yaml:"argocd,omitempty"`
+	Images          ImagesConfig     `mapstructure:"images" yaml:"images,omitempty"`
+	RegistryMirrors []string         `mapstructure:"registryMirrors" yaml:"registryMirrors,omitempty"`
+	CertPath        string           `mapstructure:"certPath" yaml:"certPath,omitempty"`
+	KeyPath         string           `mapstructure:"keyPath" yaml:"keyPath,omitempty"`
+	CA              CAConfig         `mapstructure:"ca" yaml:"ca,omitempty"`
+	SkipTrustBundle bool             `mapstructure:"skipTrustBundle" yaml:"skipTrustBundle,omitempty"`
+	SkipCertIssuer  bool             `mapstructure:"skipCertIssuer" yaml:"skipCertIssuer,omitempty"`
+	MozillaCA       MozillaCAConfig  `mapstructure:"mozillaCA" yaml:"mozillaCA,omitempty"`
+	ExternalCA      ExternalCAConfig `mapstructure:"externalCA" yaml:"externalCA,omitempty"`
+	Zot             ZotConfig        `mapstructure:"zot" yaml:"zot,omitempty"`
+	Kind            KindConfig       `mapstructure:"kind" yaml:"kind,omitempty"`
+	// GatusAlerts holds Gatus alerting providers (slack, discord, custom, ...)
+	// and is copied as-is into the "alerting" section of the Gatus config
+	GatusAlerts map[string]any `mapstructure:"gatusAlerts" yaml:"gatusAlerts,omitempty"`
 }
 
 // V is the global Viper instance for kinder configuration
@@ -114,19 +228,22 @@ func Initialize(configPath string) error {
 	V.AutomaticEnv()
 
 	// Set up config file
-	if configPath != "" {
-		V.SetConfigFile(configPath)
-	} else {
+	if configPath == "" {
 		configDir, err := GetConfigDir(DefaultAppName)
 		if err != nil {
 			return err
 		}
-		V.SetConfigName("config")
-		V.SetConfigType("yaml")
-		V.AddConfigPath(configDir)
+		configPath = FindConfigFile(configDir)
+	}
+	if configPath != "" {
+		V.SetConfigFile(configPath)
+		V.SetConfigType(ConfigFormat(configPath))
 	}
 
 	// Read config file (ignore "file not found" errors)
+	if configPath == "" {
+		return nil
+	}
 	if err := V.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			// Only return error if it's not a "file not found" error
@@ -146,7 +263,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault(KeyNetworkName, DefaultNetworkName)
 	v.SetDefault(KeyNetworkCIDR, DefaultNetworkCIDR)
 	v.SetDefault(KeyNetworkBridge, DefaultBridgeName)
+	v.SetDefault(KeyNetworkIPv6CIDR, DefaultNetworkIPv6CIDR)
 	v.SetDefault(KeyTraefikPort, DefaultTraefikPort)
+	v.SetDefault(KeyZotPort, DefaultZotPort)
 	v.SetDefault(KeyArgocdVersion, DefaultArgocdVersion)
 	v.SetDefault(KeyArgocdManifestURL, DefaultArgocdManifestURL)
 	v.SetDefault(KeyImagesStepCA, DefaultStepCAImage)
@@ -154,6 +273,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault(KeyImagesGatus, DefaultGatusImage)
 	v.SetDefault(KeyImagesTraefik, DefaultTraefikImage)
 	v.SetDefault(KeyRegistryMirrors, DefaultRegistryMirrors)
+	v.SetDefault(KeyMozillaCACacheTTL, DefaultMozillaCACacheTTL)
 }
 
 // GetDataDir returns the data directory for kinder.
@@ -188,6 +308,35 @@ func GetDataDir() (string, error) {
 	return filepath.Join(baseDir, appName), nil
 }
 
+// Filenames of the CA certificate and key in the data directory
+const (
+	CACertFilename = "ca.crt"
+	CAKeyFilename  = "ca.key"
+)
+
+// CACertPath returns the path of the CA certificate: certPath when it is
+// configured, otherwise ca.crt in the data directory
+func CACertPath() (string, error) {
+	return caPath(KeyCertPath, CACertFilename)
+}
+
+// CAKeyPath returns the path of the CA private key: keyPath when it is
+// configured, otherwise ca.key in the data directory
+func CAKeyPath() (string, error) {
+	return caPath(KeyKeyPath, CAKeyFilename)
+}
+
+func caPath(key, filename string) (string, error) {
+	if path := GetString(key); path != "" {
+		return path, nil
+	}
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get data directory: %w", err)
+	}
+	return filepath.Join(dataDir, filename), nil
+}
+
 // GetConfigDir returns the configuration directory path following XDG Base Directory spec.
 // It checks XDG_CONFIG_HOME first, then falls back to ~/.config
 func GetConfigDir(appName string) (string, error) {
@@ -206,12 +355,45 @@ func GetConfigDir(appName string) (string, error) {
 	return filepath.Join(baseDir, appName), nil
 }
 
-// GetConfigPath returns the full path to the config file
+// configFileNames are the config file names looked for in the config
+// directory, in order of preference
+var configFileNames = []string{"config.yaml", "config.yml", "config.toml", "config.json"}
+
+// FindConfigFile returns the first config file that exists in dir, or empty
+// if there is none
+func FindConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// ConfigFormat returns the format of a config file from its extension:
+// yaml, toml or json. Files with any other extension are read as YAML.
+func ConfigFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return "toml"
+	case ".json":
+		return "json"
+	default:
+		return "yaml"
+	}
+}
+
+// GetConfigPath returns the full path to the config file: the existing one
+// in the config directory, or config.yaml if there is none
 func GetConfigPath(appName string) (string, error) {
 	configDir, err := GetConfigDir(appName)
 	if err != nil {
 		return "", err
 	}
+	if path := FindConfigFile(configDir); path != "" {
+		return path, nil
+	}
 	return filepath.Join(configDir, "config.yaml"), nil
 }
 
@@ -249,6 +431,14 @@ func GetString(key string) string {
 	return V.GetString(key)
 }
 
+// GetBool returns a boolean configuration value
+func GetBool(key string) bool {
+	if V == nil {
+		return false
+	}
+	return V.GetBool(key)
+}
+
 // GetStringSlice returns a string slice configuration value
 func GetStringSlice(key string) []string {
 	if V == nil {
@@ -257,6 +447,14 @@ func GetStringSlice(key string) []string {
 	return V.GetStringSlice(key)
 }
 
+// GetStringMap returns a map configuration value
+func GetStringMap(key string) map[string]any {
+	if V == nil {
+		return nil
+	}
+	return V.GetStringMap(key)
+}
+
 // Set sets a configuration value (useful for CLI flag overrides)
 func Set(key string, value interface{}) {
 	if V == nil {
@@ -301,9 +499,15 @@ func (c *FileConfig) ApplyDefaults() {
 	if c.Network.Bridge == "" {
 		c.Network.Bridge = c.AppName + "br0"
 	}
+	if c.Network.IPv6CIDR == "" {
+		c.Network.IPv6CIDR = DefaultNetworkIPv6CIDR
+	}
 	if c.Traefik.Port == "" {
 		c.Traefik.Port = DefaultTraefikPort
 	}
+	if c.Zot.Port == "" {
+		c.Zot.Port = DefaultZotPort
+	}
 	if c.Argocd.Version == "" {
 		c.Argocd.Version = DefaultArgocdVersion
 	}
@@ -325,6 +529,9 @@ func (c *FileConfig) ApplyDefaults() {
 	if len(c.RegistryMirrors) == 0 {
 		c.RegistryMirrors = DefaultRegistryMirrors
 	}
+	if c.MozillaCA.CacheTTL == "" {
+		c.MozillaCA.CacheTTL = DefaultMozillaCACacheTTL
+	}
 }
 
 // ContainerName returns a container name with the app name prefix
@@ -339,3 +546,37 @@ func ConfigFile() string {
 	}
 	return V.ConfigFileUsed()
 }
+
+// Sources a configuration value can come from, lowest precedence first
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// EnvVar returns the environment variable that overrides key, such as
+// KINDER_TRAEFIK_PORT for traefik.port
+func EnvVar(key string) string {
+	return "KINDER_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// ValueSource reports where the value of key comes from: the environment,
+// the config file or the defaults. Flags are not tracked by Viper, so callers
+// check them separately.
+func ValueSource(key string) string {
+	if os.Getenv(EnvVar(key)) != "" {
+		return SourceEnv
+	}
+	if V != nil && V.InConfig(key) {
+		return SourceFile
+	}
+	return SourceDefault
+}
+
+// DefaultValue returns the built-in default of key, or nil if it has none
+func DefaultValue(key string) any {
+	v := viper.New()
+	setDefaults(v)
+	return v.Get(key)
+}
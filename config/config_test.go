@@ -149,6 +149,70 @@ images:
 	}
 }
 
+func TestLoadConfigFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"toml", "config.toml", "domain = \"toml.local\"\n\n[traefik]\nport = \"9000\"\n"},
+		{"json", "config.json", `{"domain": "json.local", "traefik": {"port": "9000"}}`},
+		{"yml", "config.yml", "domain: yml.local\ntraefik:\n  port: \"9000\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Found in the config directory without --config
+			tmpDir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", tmpDir)
+			configPath := filepath.Join(tmpDir, DefaultAppName, tt.file)
+			if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+				t.Fatalf("failed to create config dir: %v", err)
+			}
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			if err := Initialize(""); err != nil {
+				t.Fatalf("failed to initialize: %v", err)
+			}
+			if got := GetString(KeyDomain); got != tt.name+".local" {
+				t.Errorf("expected domain %q, got %q", tt.name+".local", got)
+			}
+			if got := GetString(KeyTraefikPort); got != "9000" {
+				t.Errorf("expected traefik port %q, got %q", "9000", got)
+			}
+			if ConfigFile() != configPath {
+				t.Errorf("expected ConfigFile() %q, got %q", configPath, ConfigFile())
+			}
+			if path, _ := GetConfigPath(DefaultAppName); path != configPath {
+				t.Errorf("expected GetConfigPath() %q, got %q", configPath, path)
+			}
+		})
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"config.yaml", "yaml"},
+		{"config.yml", "yaml"},
+		{"config.toml", "toml"},
+		{"/etc/kinder/Config.JSON", "json"},
+		{"kinderrc", "yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ConfigFormat(tt.path); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestApplyDefaults(t *testing.T) {
 	cfg := &FileConfig{}
 	cfg.ApplyDefaults()
@@ -260,3 +324,112 @@ func TestGetDataDirFallback(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, dataDir)
 	}
 }
+
+func TestValueSource(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("domain: file.local\ntraefik:\n  port: \"9000\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	t.Setenv(EnvVar(KeyTraefikPort), "9443")
+
+	if err := Initialize(configPath); err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{KeyDomain, SourceFile},
+		{KeyTraefikPort, SourceEnv},
+		{KeyNetworkCIDR, SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := ValueSource(tt.key); got != tt.expected {
+				t.Errorf("expected source %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if got := GetString(KeyTraefikPort); got != "9443" {
+		t.Errorf("expected env to win with %q, got %q", "9443", got)
+	}
+}
+
+func TestEnvVar(t *testing.T) {
+	if got := EnvVar(KeyNetworkStepCAIP); got != "KINDER_NETWORK_STEPCAIP" {
+		t.Errorf("expected %q, got %q", "KINDER_NETWORK_STEPCAIP", got)
+	}
+}
+
+func TestSslipDomain(t *testing.T) {
+	tests := []struct {
+		ip       string
+		expected string
+	}{
+		{"192.0.2.1", DefaultDomain},
+		{"10.0.0.5", "0a000005.sslip.io"},
+		{"198.51.100.255", "c63364ff.sslip.io"},
+		{"2001:db8::1", ""},
+		{"not-an-ip", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := SslipDomain(tt.ip); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSslipAddress(t *testing.T) {
+	tests := []struct {
+		domain   string
+		expected string
+		ok       bool
+	}{
+		{DefaultDomain, "192.0.2.1", true},
+		{"0A000005.sslip.io", "10.0.0.5", true},
+		{"c0000201.nip.io", "", false},
+		{"app.c0000201.sslip.io", "", false},
+		{"zz000201.sslip.io", "", false},
+		{"example.test", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			got, ok := SslipAddress(tt.domain)
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("expected %q, %v, got %q, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+}
+
+func TestCAPaths(t *testing.T) {
+	t.Setenv("KINDER_DATADIR", "/tmp/custom-data-dir")
+	if err := Initialize(""); err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+
+	certPath, err := CACertPath()
+	if err != nil || certPath != "/tmp/custom-data-dir/ca.crt" {
+		t.Errorf("expected ca.crt in the data directory, got %q (%v)", certPath, err)
+	}
+	keyPath, err := CAKeyPath()
+	if err != nil || keyPath != "/tmp/custom-data-dir/ca.key" {
+		t.Errorf("expected ca.key in the data directory, got %q (%v)", keyPath, err)
+	}
+
+	Set(KeyCertPath, "/etc/kinder/root.crt")
+	Set(KeyKeyPath, "/etc/kinder/root.key")
+	if certPath, _ := CACertPath(); certPath != "/etc/kinder/root.crt" {
+		t.Errorf("expected configured certPath, got %q", certPath)
+	}
+	if keyPath, _ := CAKeyPath(); keyPath != "/etc/kinder/root.key" {
+		t.Errorf("expected configured keyPath, got %q", keyPath)
+	}
+}
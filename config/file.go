@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidKeys returns all known configuration keys in sorted order
+func ValidKeys() []string {
+	keys := []string{
+		KeyAppName,
+		KeyDataDir,
+		KeyDomain,
+		KeyNetworkName,
+		KeyNetworkCIDR,
+		KeyNetworkBridge,
+		KeyNetworkIPv6,
+		KeyNetworkIPv6CIDR,
+		KeyNetworkStepCAIP,
+		KeyNetworkZotIP,
+		KeyNetworkGatusIP,
+		KeyNetworkTraefikIP,
+		KeyTraefikPort,
+		KeyImagesStepCA,
+		KeyImagesZot,
+		KeyImagesGatus,
+		KeyImagesTraefik,
+		KeyRegistryMirrors,
+		KeyCertPath,
+		KeyKeyPath,
+		KeyCAOrganization,
+		KeyCACommonName,
+		KeyArgocdVersion,
+		KeyArgocdManifestURL,
+		KeyArgocdExpose,
+		KeySkipTrustBundle,
+		KeySkipCertIssuer,
+		KeyMozillaCAFile,
+		KeyMozillaCACacheTTL,
+		KeyMozillaCASHA256,
+		KeyExternalCAURL,
+		KeyZotPort,
+		KeyZotUsername,
+		KeyZotPassword,
+		KeyKindPodSubnet,
+		KeyKindServiceSubnet,
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsValidKey reports whether key is a known configuration key
+func IsValidKey(key string) bool {
+	for _, k := range ValidKeys() {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// listKeys are configuration keys holding a list; values are given comma-separated
+var listKeys = map[string]bool{
+	KeyRegistryMirrors: true,
+}
+
+// boolKeys are configuration keys holding a boolean
+var boolKeys = map[string]bool{
+	KeyNetworkIPv6:     true,
+	KeyArgocdExpose:    true,
+	KeySkipTrustBundle: true,
+	KeySkipCertIssuer:  true,
+}
+
+// SetFileValue sets a single key in the YAML config file at path, creating the
+// file if needed. Existing structure and comments in the file are preserved.
+func SetFileValue(path, key, value string) error {
+	if !IsValidKey(key) {
+		return fmt.Errorf("unknown config key %q (valid keys: %s)", key, strings.Join(ValidKeys(), ", "))
+	}
+	if format := ConfigFormat(path); format != "yaml" {
+		return fmt.Errorf("config file %s is %s; only YAML config files can be edited, change it by hand", path, format)
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+
+	setNodeValue(root, strings.Split(key, "."), valueNode(key, value))
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// valueNode builds the YAML node for a config value
+func valueNode(key, value string) *yaml.Node {
+	if listKeys[key] {
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		return seq
+	}
+	if boolKeys[key] {
+		if b, err := strconv.ParseBool(value); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}
+		}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// setNodeValue sets the value at the given key path within a mapping node,
+// creating intermediate mappings as needed
+func setNodeValue(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			mapping.Content[i+1] = value
+			return
+		}
+		child := mapping.Content[i+1]
+		if child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			mapping.Content[i+1] = child
+		}
+		setNodeValue(child, path[1:], value)
+		return
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, keyNode, value)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	mapping.Content = append(mapping.Content, keyNode, child)
+	setNodeValue(child, path[1:], value)
+}
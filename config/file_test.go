@@ -0,0 +1,112 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsValidKey(t *testing.T) {
+	for _, key := range []string{KeyDomain, KeyNetworkCIDR, KeyRegistryMirrors} {
+		if !IsValidKey(key) {
+			t.Errorf("expected %q to be valid", key)
+		}
+	}
+	for _, key := range []string{"", "network", "traefik.prot"} {
+		if IsValidKey(key) {
+			t.Errorf("expected %q to be invalid", key)
+		}
+	}
+}
+
+func TestSetFileValue(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "nested", "config.yaml")
+
+	// Creates the file and parent directory when missing
+	if err := SetFileValue(configPath, KeyTraefikPort, "9443"); err != nil {
+		t.Fatalf("SetFileValue failed: %v", err)
+	}
+	if err := SetFileValue(configPath, KeyRegistryMirrors, "ghcr.io, quay.io"); err != nil {
+		t.Fatalf("SetFileValue failed: %v", err)
+	}
+
+	if err := Initialize(configPath); err != nil {
+		t.Fatalf("failed to initialize with config file: %v", err)
+	}
+	if port := GetString(KeyTraefikPort); port != "9443" {
+		t.Errorf("expected port %q, got %q", "9443", port)
+	}
+	mirrors := GetStringSlice(KeyRegistryMirrors)
+	if len(mirrors) != 2 || mirrors[0] != "ghcr.io" || mirrors[1] != "quay.io" {
+		t.Errorf("expected mirrors [ghcr.io quay.io], got %v", mirrors)
+	}
+}
+
+func TestSetFileValue_PreservesContent(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := `# my settings
+domain: example.test
+network:
+  name: mynet # keep me
+  cidr: 10.0.0.0/24
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	if err := SetFileValue(configPath, KeyNetworkCIDR, "10.1.0.0/24"); err != nil {
+		t.Fatalf("SetFileValue failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	out := string(data)
+
+	for _, want := range []string{"# my settings", "domain: example.test", "name: mynet # keep me", "cidr: 10.1.0.0/24"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected config to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "10.0.0.0/24") {
+		t.Error("expected old CIDR to be replaced")
+	}
+}
+
+func TestSetFileValue_UnknownKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := SetFileValue(configPath, "traefik.prot", "9443")
+	if err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+	if !strings.Contains(err.Error(), KeyTraefikPort) {
+		t.Errorf("expected error to list valid keys, got: %v", err)
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		t.Error("config file should not be created for an unknown key")
+	}
+}
+
+func TestSetFileValue_NonYAML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(configPath, []byte("domain = \"example.com\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	if err := SetFileValue(configPath, KeyTraefikPort, "9443"); err == nil {
+		t.Fatal("expected error for a TOML config file")
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config file: %v", err)
+	}
+	if string(data) != "domain = \"example.com\"\n" {
+		t.Errorf("config file should be unchanged, got: %s", data)
+	}
+}
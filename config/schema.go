@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// schemaDialect is the JSON Schema draft the generated schema conforms to
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema for the config file. It is derived from the
+// yaml tags of FileConfig, with defaults taken from ApplyDefaults, so it stays
+// in sync with the keys kinder accepts.
+func Schema() map[string]any {
+	defaults := &FileConfig{}
+	defaults.ApplyDefaults()
+
+	schema := objectSchema(reflect.ValueOf(*defaults), "")
+	schema["$schema"] = schemaDialect
+	schema["title"] = "kinder configuration"
+	return schema
+}
+
+// keyConstraints narrows the schema of keys whose values are restricted,
+// mirroring the checks in Validate so editors flag the same mistakes
+var keyConstraints = map[string]map[string]any{
+	KeyDomain:            {"format": "hostname"},
+	KeyNetworkCIDR:       {"anyOf": []any{map[string]any{"const": AutoNetworkCIDR}, map[string]any{"pattern": `^[0-9.]+/[0-9]+$`}}},
+	KeyNetworkIPv6CIDR:   {"pattern": `^[0-9a-fA-F:]+/[0-9]+$`},
+	KeyNetworkStepCAIP:   {"format": "ipv4"},
+	KeyNetworkZotIP:      {"format": "ipv4"},
+	KeyNetworkGatusIP:    {"format": "ipv4"},
+	KeyNetworkTraefikIP:  {"format": "ipv4"},
+	KeyTraefikPort:       {"pattern": `^[0-9]{1,5}$`},
+	KeyZotPort:           {"pattern": `^[0-9]{1,5}$`},
+	KeyMozillaCACacheTTL: {"pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`},
+	KeyMozillaCASHA256:   {"pattern": sha256Pattern.String()},
+	KeyExternalCAURL:     {"format": "uri", "pattern": "^https://"},
+	KeyKindPodSubnet:     {"pattern": `^[0-9a-fA-F.:]+/[0-9]+(, *[0-9a-fA-F.:]+/[0-9]+)?$`},
+	KeyKindServiceSubnet: {"pattern": `^[0-9a-fA-F.:]+/[0-9]+(, *[0-9a-fA-F.:]+/[0-9]+)?$`},
+}
+
+// objectSchema describes a struct, using its yaml tags as property names.
+// prefix is the dotted key of the struct, empty for the top level.
+func objectSchema(v reflect.Value, prefix string) map[string]any {
+	properties := make(map[string]any)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		properties[name] = fieldSchema(v.Field(i), prefix+name)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema describes a single config value, including its default if set
+// and any constraint on the values it accepts
+func fieldSchema(v reflect.Value, key string) map[string]any {
+	switch v.Kind() {
+	case reflect.Struct:
+		return objectSchema(v, key+".")
+	case reflect.Slice:
+		schema := map[string]any{
+			"type":  "array",
+			"items": map[string]any{"type": "string"},
+		}
+		if v.Len() > 0 {
+			schema["default"] = v.Interface()
+		}
+		return schema
+	case reflect.Map:
+		// Free-form sections passed through to another tool's config
+		return map[string]any{"type": "object"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean", "default": v.Bool()}
+	default:
+		schema := map[string]any{"type": "string"}
+		if v.String() != "" {
+			schema["default"] = v.String()
+		}
+		for k, c := range keyConstraints[key] {
+			schema[k] = c
+		}
+		return schema
+	}
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	// Round-trip through JSON, as "kinder config schema" prints it
+	data, err := json.Marshal(Schema())
+	if err != nil {
+		t.Fatalf("failed to encode schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	if schema["$schema"] != schemaDialect {
+		t.Errorf("expected $schema %q, got %v", schemaDialect, schema["$schema"])
+	}
+
+	// lookup walks a dotted key through nested properties
+	lookup := func(key string) map[string]any {
+		node := schema
+		for _, part := range strings.Split(key, ".") {
+			props, ok := node["properties"].(map[string]any)
+			if !ok {
+				return nil
+			}
+			node, ok = props[part].(map[string]any)
+			if !ok {
+				return nil
+			}
+		}
+		return node
+	}
+
+	t.Run("all keys present", func(t *testing.T) {
+		for _, key := range ValidKeys() {
+			if lookup(key) == nil {
+				t.Errorf("schema is missing key %s", key)
+			}
+		}
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		tests := []struct {
+			key      string
+			expected string
+		}{
+			{KeyAppName, DefaultAppName},
+			{KeyTraefikPort, DefaultTraefikPort},
+			{KeyNetworkCIDR, DefaultNetworkCIDR},
+			{KeyMozillaCACacheTTL, DefaultMozillaCACacheTTL},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.key, func(t *testing.T) {
+				node := lookup(tt.key)
+				if node == nil {
+					t.Fatalf("schema is missing key %s", tt.key)
+				}
+				if node["default"] != tt.expected {
+					t.Errorf("expected default %q, got %v", tt.expected, node["default"])
+				}
+			})
+		}
+	})
+
+	t.Run("constraints", func(t *testing.T) {
+		tests := []struct {
+			key     string
+			valid   string
+			invalid string
+		}{
+			{KeyTraefikPort, DefaultTraefikPort, "https"},
+			{KeyMozillaCACacheTTL, DefaultMozillaCACacheTTL, "1 day"},
+			{KeyMozillaCASHA256, strings.Repeat("a", 64), "abc"},
+			{KeyExternalCAURL, "https://ca.example.com", "http://ca.example.com"},
+			{KeyNetworkIPv6CIDR, DefaultNetworkIPv6CIDR, "fd00::"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.key, func(t *testing.T) {
+				node := lookup(tt.key)
+				if node == nil {
+					t.Fatalf("schema is missing key %s", tt.key)
+				}
+				pattern, ok := node["pattern"].(string)
+				if !ok {
+					t.Fatalf("expected a pattern for %s, got %v", tt.key, node)
+				}
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					t.Fatalf("invalid pattern %q: %v", pattern, err)
+				}
+				if !re.MatchString(tt.valid) {
+					t.Errorf("pattern %q rejects %q", pattern, tt.valid)
+				}
+				if re.MatchString(tt.invalid) {
+					t.Errorf("pattern %q accepts %q", pattern, tt.invalid)
+				}
+			})
+		}
+
+		if node := lookup(KeyNetworkCIDR); node == nil || node["anyOf"] == nil {
+			t.Errorf("expected %s to allow %q or a CIDR, got %v", KeyNetworkCIDR, AutoNetworkCIDR, node)
+		}
+	})
+
+	t.Run("types", func(t *testing.T) {
+		if node := lookup(KeyRegistryMirrors); node == nil || node["type"] != "array" {
+			t.Errorf("expected %s to be an array, got %v", KeyRegistryMirrors, node)
+		}
+		if node := lookup(KeySkipTrustBundle); node == nil || node["type"] != "boolean" {
+			t.Errorf("expected %s to be a boolean, got %v", KeySkipTrustBundle, node)
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hostnamePattern matches a DNS hostname made of RFC 1123 labels
+var hostnamePattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// sha256Pattern matches a hex-encoded SHA-256 digest
+var sha256Pattern = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// Validate checks configuration values that would otherwise only fail deep
+// inside Docker or Kind. All problems are reported in a single combined error.
+func Validate(cfg *FileConfig) error {
+	var errs []error
+
+	if cfg.Network.CIDR != "" && cfg.Network.CIDR != AutoNetworkCIDR {
+		if _, _, err := net.ParseCIDR(cfg.Network.CIDR); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid CIDR %q", KeyNetworkCIDR, cfg.Network.CIDR))
+		}
+	}
+
+	errs = append(errs, validateServiceIPs(cfg.Network)...)
+
+	if cfg.Network.IPv6CIDR != "" {
+		if ip, _, err := net.ParseCIDR(cfg.Network.IPv6CIDR); err != nil || ip.To4() != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid IPv6 CIDR %q", KeyNetworkIPv6CIDR, cfg.Network.IPv6CIDR))
+		}
+	}
+
+	for _, subnet := range []struct {
+		key   string
+		value string
+	}{
+		{KeyKindPodSubnet, cfg.Kind.PodSubnet},
+		{KeyKindServiceSubnet, cfg.Kind.ServiceSubnet},
+	} {
+		if subnet.value == "" {
+			continue
+		}
+		// Dual-stack clusters take an IPv4 and an IPv6 CIDR separated by a comma
+		cidrs := strings.Split(subnet.value, ",")
+		if len(cidrs) > 2 {
+			errs = append(errs, fmt.Errorf("%s: at most two CIDRs allowed, got %q", subnet.key, subnet.value))
+			continue
+		}
+		for _, cidr := range cidrs {
+			if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid CIDR %q", subnet.key, subnet.value))
+				break
+			}
+		}
+	}
+
+	if cfg.Traefik.Port != "" {
+		if err := validatePort(cfg.Traefik.Port); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", KeyTraefikPort, err))
+		}
+	}
+
+	if cfg.Zot.Port != "" {
+		if err := validatePort(cfg.Zot.Port); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", KeyZotPort, err))
+		}
+	}
+
+	if cfg.Domain != "" {
+		if len(cfg.Domain) > 253 || !hostnamePattern.MatchString(cfg.Domain) {
+			errs = append(errs, fmt.Errorf("%s: invalid domain %q", KeyDomain, cfg.Domain))
+		}
+	}
+
+	images := []struct {
+		key   string
+		value string
+	}{
+		{KeyImagesStepCA, cfg.Images.StepCA},
+		{KeyImagesZot, cfg.Images.Zot},
+		{KeyImagesGatus, cfg.Images.Gatus},
+		{KeyImagesTraefik, cfg.Images.Traefik},
+	}
+	for _, img := range images {
+		if err := validateImageRef(img.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", img.key, err))
+		}
+	}
+
+	if cfg.MozillaCA.CacheTTL != "" {
+		if ttl, err := time.ParseDuration(cfg.MozillaCA.CacheTTL); err != nil || ttl < 0 {
+			errs = append(errs, fmt.Errorf("%s: invalid duration %q", KeyMozillaCACacheTTL, cfg.MozillaCA.CacheTTL))
+		}
+	}
+
+	if cfg.MozillaCA.SHA256 != "" && !sha256Pattern.MatchString(cfg.MozillaCA.SHA256) {
+		errs = append(errs, fmt.Errorf("%s: %q is not a hex-encoded SHA-256 digest", KeyMozillaCASHA256, cfg.MozillaCA.SHA256))
+	}
+
+	if cfg.ExternalCA.URL != "" {
+		if u, err := url.Parse(cfg.ExternalCA.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: %q must be an https URL", KeyExternalCAURL, cfg.ExternalCA.URL))
+		}
+	}
+
+	if (cfg.Zot.Username == "") != (cfg.Zot.Password == "") {
+		errs = append(errs, fmt.Errorf("%s and %s must be set together", KeyZotUsername, KeyZotPassword))
+	}
+
+	for provider, settings := range cfg.GatusAlerts {
+		if _, ok := settings.(map[string]any); !ok {
+			errs = append(errs, fmt.Errorf("%s.%s: provider settings must be a map", KeyGatusAlerts, provider))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+}
+
+// validatePort checks that a port is a number between 1 and 65535
+func validatePort(port string) error {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("port %q is not a number", port)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("port %d is out of range (1-65535)", n)
+	}
+	return nil
+}
+
+// validateImageRef checks that an image reference is non-empty and well formed
+func validateImageRef(ref string) error {
+	if strings.TrimSpace(ref) == "" {
+		return fmt.Errorf("image reference is empty")
+	}
+	if strings.ContainsAny(ref, " \t\n") {
+		return fmt.Errorf("image reference %q contains whitespace", ref)
+	}
+	if strings.HasPrefix(ref, ":") || strings.HasPrefix(ref, "@") || strings.HasSuffix(ref, ":") || strings.HasSuffix(ref, "/") {
+		return fmt.Errorf("image reference %q is malformed", ref)
+	}
+	return nil
+}
+
+// validateServiceIPs checks the static service addresses are IPv4, distinct
+// and usable host addresses inside the network CIDR. The CIDR check is
+// skipped when the CIDR is picked automatically.
+func validateServiceIPs(network NetworkConfig) []error {
+	var errs []error
+
+	var subnet *net.IPNet
+	if network.CIDR != AutoNetworkCIDR {
+		_, subnet, _ = net.ParseCIDR(network.CIDR)
+	}
+
+	seen := map[string]string{}
+	for _, svc := range []struct {
+		key   string
+		value string
+	}{
+		{KeyNetworkStepCAIP, network.StepCAIP},
+		{KeyNetworkZotIP, network.ZotIP},
+		{KeyNetworkGatusIP, network.GatusIP},
+		{KeyNetworkTraefikIP, network.TraefikIP},
+	} {
+		if svc.value == "" {
+			continue
+		}
+		ip := net.ParseIP(svc.value).To4()
+		if ip == nil {
+			errs = append(errs, fmt.Errorf("%s: invalid IPv4 address %q", svc.key, svc.value))
+			continue
+		}
+		if other, ok := seen[ip.String()]; ok {
+			errs = append(errs, fmt.Errorf("%s: %s is already used by %s", svc.key, svc.value, other))
+			continue
+		}
+		seen[ip.String()] = svc.key
+
+		if subnet == nil {
+			continue
+		}
+		if !subnet.Contains(ip) {
+			errs = append(errs, fmt.Errorf("%s: %s is outside the network CIDR %s", svc.key, svc.value, network.CIDR))
+			continue
+		}
+		// The network address, the gateway (the first host address) and the
+		// broadcast address cannot be given to a container
+		base := subnet.IP.To4()
+		gateway := net.IPv4(base[0], base[1], base[2], base[3]+1).To4()
+		broadcast := make(net.IP, 4)
+		for i := range broadcast {
+			broadcast[i] = base[i] | ^subnet.Mask[i]
+		}
+		if ip.Equal(base) || ip.Equal(gateway) || ip.Equal(broadcast) {
+			errs = append(errs, fmt.Errorf("%s: %s is reserved in %s", svc.key, svc.value, subnet))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func validConfig() *FileConfig {
+	cfg := &FileConfig{}
+	cfg.ApplyDefaults()
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate(validConfig()); err != nil {
+		t.Errorf("expected default config to be valid, got: %v", err)
+	}
+
+	static := validConfig()
+	static.Network.StepCAIP = "172.28.28.100"
+	static.Network.ZotIP = "172.28.28.101"
+	if err := Validate(static); err != nil {
+		t.Errorf("expected static IPs in the CIDR to be valid, got: %v", err)
+	}
+
+	auto := validConfig()
+	auto.Network.CIDR = AutoNetworkCIDR
+	auto.Network.ZotIP = "10.0.0.5"
+	if err := Validate(auto); err != nil {
+		t.Errorf("expected auto CIDR to be valid, got: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*FileConfig)
+		want   string
+	}{
+		{"bad CIDR", func(c *FileConfig) { c.Network.CIDR = "172.28.28.0/33" }, KeyNetworkCIDR},
+		{"IPv4 as IPv6 CIDR", func(c *FileConfig) { c.Network.IPv6CIDR = "10.0.0.0/8" }, KeyNetworkIPv6CIDR},
+		{"static IP outside CIDR", func(c *FileConfig) { c.Network.ZotIP = "10.0.0.5" }, KeyNetworkZotIP},
+		{"static IP on gateway", func(c *FileConfig) { c.Network.StepCAIP = "172.28.28.1" }, KeyNetworkStepCAIP},
+		{"static IP on broadcast", func(c *FileConfig) { c.Network.GatusIP = "172.28.28.255" }, KeyNetworkGatusIP},
+		{"IPv6 static IP", func(c *FileConfig) { c.Network.TraefikIP = "fd00:28:28::5" }, KeyNetworkTraefikIP},
+		{"duplicate static IP", func(c *FileConfig) {
+			c.Network.GatusIP = "172.28.28.100"
+			c.Network.TraefikIP = "172.28.28.100"
+		}, KeyNetworkTraefikIP},
+		{"bad pod subnet", func(c *FileConfig) { c.Kind.PodSubnet = "10.244.0.0" }, KeyKindPodSubnet},
+		{"bad dual-stack service subnet", func(c *FileConfig) { c.Kind.ServiceSubnet = "10.96.0.0/16,fd00:10:96::" }, KeyKindServiceSubnet},
+		{"bad service subnet", func(c *FileConfig) { c.Kind.ServiceSubnet = "services" }, KeyKindServiceSubnet},
+		{"non-numeric port", func(c *FileConfig) { c.Traefik.Port = "https" }, KeyTraefikPort},
+		{"port out of range", func(c *FileConfig) { c.Traefik.Port = "70000" }, KeyTraefikPort},
+		{"port zero", func(c *FileConfig) { c.Traefik.Port = "0" }, KeyTraefikPort},
+		{"non-numeric Zot port", func(c *FileConfig) { c.Zot.Port = "registry" }, KeyZotPort},
+		{"bad domain", func(c *FileConfig) { c.Domain = "bad_domain..test" }, KeyDomain},
+		{"empty image", func(c *FileConfig) { c.Images.Zot = " " }, KeyImagesZot},
+		{"image with whitespace", func(c *FileConfig) { c.Images.Traefik = "traefik latest" }, KeyImagesTraefik},
+		{"bad cache TTL", func(c *FileConfig) { c.MozillaCA.CacheTTL = "a day" }, KeyMozillaCACacheTTL},
+		{"negative cache TTL", func(c *FileConfig) { c.MozillaCA.CacheTTL = "-1h" }, KeyMozillaCACacheTTL},
+		{"short SHA-256", func(c *FileConfig) { c.MozillaCA.SHA256 = "abc123" }, KeyMozillaCASHA256},
+		{"plain HTTP external CA", func(c *FileConfig) { c.ExternalCA.URL = "http://ca.example.com" }, KeyExternalCAURL},
+		{"Zot username without password", func(c *FileConfig) { c.Zot.Username = "admin" }, KeyZotPassword},
+		{"Gatus alert provider not a map", func(c *FileConfig) { c.GatusAlerts = map[string]any{"slack": "https://hooks.slack.com/x"} }, KeyGatusAlerts + ".slack"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := Validate(cfg)
+			if err == nil {
+				t.Fatal("expected validation error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error to mention %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.Network.CIDR = "not-a-cidr"
+	cfg.Traefik.Port = "abc"
+	cfg.Images.StepCA = ""
+
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, key := range []string{KeyNetworkCIDR, KeyTraefikPort, KeyImagesStepCA} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected error to mention %q, got: %v", key, err)
+		}
+	}
+}
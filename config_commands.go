@@ -1,11 +1,17 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
 	"codeberg.org/hipkoi/kinder/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v3"
 )
 
@@ -52,7 +58,7 @@ The output can be redirected to create a config file:
 		fmt.Println("# kinder configuration")
 		fmt.Println("# Generated with current settings including defaults")
 		if configFile := config.ConfigFile(); configFile != "" {
-			fmt.Printf("# Loaded from: %s\n", configFile)
+			fmt.Printf("# Loaded from: %s (%s)\n", configFile, config.ConfigFormat(configFile))
 		}
 		fmt.Println()
 
@@ -84,7 +90,7 @@ var configPathCmd = &cobra.Command{
 
 		// Show if a config file was loaded
 		if loadedPath := config.ConfigFile(); loadedPath != "" {
-			fmt.Println("Loaded from:", loadedPath)
+			fmt.Printf("Loaded from: %s (%s)\n", loadedPath, config.ConfigFormat(loadedPath))
 		}
 
 		return nil
@@ -144,7 +150,281 @@ to customize your configuration.`,
 		fmt.Fprintln(file)
 		file.Write(output)
 
-		fmt.Printf("Created config file at: %s\n", configPath)
+		Output("Created config file at: %s\n", configPath)
+		return nil
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Get a configuration value",
+	Long: `Print the effective value of a configuration key.
+
+The value reflects the config file, environment variables and defaults.
+List values are printed comma-separated.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		if !config.IsValidKey(key) {
+			return fmt.Errorf("unknown config key %q (valid keys: %s)", key, strings.Join(config.ValidKeys(), ", "))
+		}
+
+		fmt.Println(formatConfigValue(config.V.Get(key)))
+		return nil
+	},
+}
+
+var configDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show configuration values that differ from the defaults",
+	Long: `Show each configuration value that is not a built-in default, with where it
+comes from: the config file, an environment variable, a flag or --profile.
+
+Use this to find out why a setting has the value it has. Use --all to list
+every key, including those left at their defaults.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		overrides := make(map[string]string)
+		cmd.Flags().Visit(func(f *pflag.Flag) {
+			if key := flagToViperKey(f.Name); key != "" {
+				overrides[key] = config.SourceFlag
+			}
+		})
+		if profile != "" {
+			// applyProfile replaces these after flags are bound
+			for _, key := range []string{config.KeyAppName, config.KeyDataDir, config.KeyNetworkName, config.KeyNetworkBridge} {
+				overrides[key] = "profile"
+			}
+			if overrides[config.KeyNetworkCIDR] == "" && config.ValueSource(config.KeyNetworkCIDR) == config.SourceDefault && config.GetString(config.KeyNetworkCIDR) == config.AutoNetworkCIDR {
+				overrides[config.KeyNetworkCIDR] = "profile"
+			}
+		}
+
+		writeConfigDiff(os.Stdout, configValues(overrides), configDiffAll)
+		return nil
+	},
+}
+
+// configValue is the effective value of a config key and where it comes from
+type configValue struct {
+	Key     string
+	Value   string
+	Source  string
+	Default string
+}
+
+// configValues returns every config key with its effective value and source.
+// overrides gives the source of keys set by flags or the profile, which Viper
+// does not track.
+func configValues(overrides map[string]string) []configValue {
+	var values []configValue
+	for _, key := range config.ValidKeys() {
+		source := overrides[key]
+		if source == "" {
+			source = config.ValueSource(key)
+		}
+		if source == config.SourceEnv {
+			source += " (" + config.EnvVar(key) + ")"
+		}
+
+		values = append(values, configValue{
+			Key:     key,
+			Value:   formatConfigValue(config.V.Get(key)),
+			Source:  source,
+			Default: formatConfigValue(config.DefaultValue(key)),
+		})
+	}
+	return values
+}
+
+// writeConfigDiff writes config values as a table, skipping those left at
+// their defaults unless all is set
+func writeConfigDiff(w io.Writer, values []configValue, all bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE\tDEFAULT")
+	for _, v := range values {
+		if v.Source == config.SourceDefault && !all {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Key, orDash(v.Value), v.Source, orDash(v.Default))
+	}
+	tw.Flush()
+}
+
+// formatConfigValue formats a config value for display, with lists
+// comma-separated
+func formatConfigValue(value any) string {
+	switch value := value.(type) {
+	case []string:
+		return strings.Join(value, ",")
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ",")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a configuration value",
+	Long: `Set a configuration value in the config file.
+
+The config file is created if it does not exist. Other values and comments
+in the file are preserved. List values are given comma-separated:
+  kinder config set registryMirrors ghcr.io,quay.io`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		if err := config.SetFileValue(path, args[0], args[1]); err != nil {
+			return err
+		}
+
+		Output("Set %s = %s in %s\n", args[0], args[1], path)
+		return nil
+	},
+}
+
+var configMigrateDataCmd = &cobra.Command{
+	Use:   "migrate-data --to <path>",
+	Short: "Move the data directory to a new location",
+	Long: `Move the data directory, including the CA, the Zot cache and all profiles,
+to a new location and set dataDir in the config file to it.
+
+Running services are stopped first, as their containers bind mount the data
+directory, and started again afterwards. The new location must not exist or
+must be an empty directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		if profile != "" {
+			return fmt.Errorf("migrate-data moves the base data directory, including every profile; run it without --profile")
+		}
+
+		src, err := getDataDir()
+		if err != nil {
+			return fmt.Errorf("failed to get data directory: %w", err)
+		}
+		dst, err := filepath.Abs(migrateDataTo)
+		if err != nil {
+			return fmt.Errorf("invalid path %s: %w", migrateDataTo, err)
+		}
+		if err := checkMigrateTarget(src, dst); err != nil {
+			return err
+		}
+
+		cfg, err := buildConfigFromFlags()
+		if err != nil {
+			return fmt.Errorf("failed to build config: %w", err)
+		}
+
+		wasRunning := existingContainers(ctx, cfg.AllContainerNames())
+		if len(wasRunning) > 0 {
+			Section("🛑", "Stopping services")
+			if err := runSubcommand(ctx, stopCmd); err != nil {
+				return fmt.Errorf("failed to stop services: %w", err)
+			}
+			if remaining := existingContainers(ctx, cfg.AllContainerNames()); len(remaining) > 0 {
+				return fmt.Errorf("containers still use the data directory: %s", strings.Join(remaining, ", "))
+			}
+		}
+
+		Section("📦", "Moving data")
+		Verbose("From: %s\n", src)
+		Verbose("To:   %s\n", dst)
+		if err := moveDir(src, dst); err != nil {
+			return err
+		}
+
+		path, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		if err := config.SetFileValue(path, config.KeyDataDir, dst); err != nil {
+			return err
+		}
+		config.Set(config.KeyDataDir, dst)
+		Output("Set %s = %s in %s\n", config.KeyDataDir, dst, path)
+		if os.Getenv("KINDER_DATADIR") != "" {
+			Warn("KINDER_DATADIR is set and overrides the config file; update it to %s\n", dst)
+		}
+		for _, p := range []string{cfg.CertPath, cfg.KeyPath} {
+			if p != "" && isWithin(src, p) {
+				Warn("%s is inside the old data directory; update certPath and keyPath\n", p)
+			}
+		}
+
+		if len(wasRunning) > 0 {
+			Section("🚀", "Starting services")
+			if err := runSubcommand(ctx, startCmd); err != nil {
+				return fmt.Errorf("data moved, but failed to start services: %w", err)
+			}
+		}
+
+		Success("Data directory moved to " + dst)
+		return nil
+	},
+}
+
+// configFilePath returns the config file given by --config, or the default location
+func configFilePath() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+
+	path, err := config.GetConfigPath(config.DefaultAppName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get config path: %w", err)
+	}
+	return path, nil
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema for the config file",
+	Long: `Print a JSON Schema describing the config file, including defaults.
+
+Save it and reference it from your editor for validation and completion, for
+example with the YAML language server:
+  kinder config schema > ~/.config/kinder/config.schema.json
+  # yaml-language-server: $schema=config.schema.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode schema: %w", err)
+		}
+
+		fmt.Println(string(data))
+		return nil
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration",
+	Long: `Check the effective configuration for invalid values.
+
+Reports every problem found, such as a malformed network CIDR, an out of
+range Traefik port, an invalid domain or an empty image reference.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Get()
+		if err != nil {
+			return fmt.Errorf("failed to get configuration: %w", err)
+		}
+
+		if err := config.Validate(cfg); err != nil {
+			return err
+		}
+
+		fmt.Println("Configuration is valid")
 		return nil
 	},
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"codeberg.org/hipkoi/kinder/config"
+)
+
+func TestWriteConfigDiff(t *testing.T) {
+	values := []configValue{
+		{Key: config.KeyDomain, Value: "example.com", Source: config.SourceFile, Default: config.DefaultDomain},
+		{Key: config.KeyTraefikPort, Value: "9443", Source: "env (KINDER_TRAEFIK_PORT)", Default: config.DefaultTraefikPort},
+		{Key: config.KeyNetworkCIDR, Value: config.DefaultNetworkCIDR, Source: config.SourceDefault, Default: config.DefaultNetworkCIDR},
+		{Key: config.KeyCertPath, Value: "/tmp/cert.pem", Source: config.SourceFlag},
+	}
+
+	t.Run("overrides only", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeConfigDiff(&buf, values, false)
+		out := buf.String()
+
+		for _, want := range []string{"KEY", "example.com", "env (KINDER_TRAEFIK_PORT)", "/tmp/cert.pem"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expected output to contain %q, got:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, config.KeyNetworkCIDR) {
+			t.Errorf("expected defaults to be skipped, got:\n%s", out)
+		}
+	})
+
+	t.Run("all", func(t *testing.T) {
+		var buf bytes.Buffer
+		writeConfigDiff(&buf, values, true)
+		if !strings.Contains(buf.String(), config.KeyNetworkCIDR) {
+			t.Errorf("expected defaults to be listed, got:\n%s", buf.String())
+		}
+	})
+}
+
+func TestConfigValues(t *testing.T) {
+	if err := config.Initialize(t.TempDir() + "/missing.yaml"); err != nil {
+		t.Fatalf("failed to initialize config: %v", err)
+	}
+	t.Setenv(config.EnvVar(config.KeyDomain), "env.example.com")
+	config.Set(config.KeyTraefikPort, "9443")
+
+	sources := make(map[string]configValue)
+	for _, v := range configValues(map[string]string{config.KeyTraefikPort: config.SourceFlag}) {
+		sources[v.Key] = v
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{config.KeyDomain, "env.example.com", "env (KINDER_DOMAIN)"},
+		{config.KeyTraefikPort, "9443", config.SourceFlag},
+		{config.KeyRegistryMirrors, strings.Join(config.DefaultRegistryMirrors, ","), config.SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			v := sources[tt.key]
+			if v.Value != tt.value {
+				t.Errorf("expected value %q, got %q", tt.value, v.Value)
+			}
+			if v.Source != tt.source {
+				t.Errorf("expected source %q, got %q", tt.source, v.Source)
+			}
+		})
+	}
+}
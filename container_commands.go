@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
-	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
@@ -35,6 +39,25 @@ func printContainerInfo(info containerInfo) {
 	}
 }
 
+// defaultWaitTimeout is how long the start functions wait by default for a
+// service to pass its readiness probe
+const defaultWaitTimeout = 60 * time.Second
+
+// addWaitFlags registers the flags that control waiting for a started
+// service to become ready
+func addWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&waitReady, "wait", true, "Wait for the service to be ready before returning")
+	cmd.Flags().BoolVar(&noWait, "no-wait", false, "Return as soon as the container is started")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", defaultWaitTimeout, "How long to wait for the service to be ready")
+	cmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
+}
+
+// waitForReady reports whether the start functions should wait for services
+// to become ready (--wait, unless --no-wait is set)
+func waitForReady() bool {
+	return waitReady && !noWait
+}
+
 // checkPrerequisites checks if network exists
 func checkPrerequisites(ctx context.Context, networkName string) error {
 	networkExists, err := docker.NetworkExists(ctx, networkName)
@@ -84,10 +107,15 @@ var containerStartCmd = &cobra.Command{
 	Long: `Start a kinder service container.
 
 Available services: stepca, zot, gatus, traefik, kind`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeServiceNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		service := args[0]
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		if _, err := applyPullPolicy(); err != nil {
+			return err
+		}
 
 		switch service {
 		case "stepca":
@@ -112,10 +140,11 @@ var containerStopCmd = &cobra.Command{
 	Long: `Stop and remove a kinder service container.
 
 Available services: stepca, zot, gatus, traefik, kind`,
-	Args: cobra.ExactArgs(1),
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeServiceNames,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		service := args[0]
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		switch service {
 		case "stepca":
@@ -134,22 +163,55 @@ Available services: stepca, zot, gatus, traefik, kind`,
 	},
 }
 
+// stepCAJWKProvisionerPassword returns the JWK provisioner password, given
+// directly or read from the file named by the password file flag ("-" for
+// stdin), which keeps it out of the process list
+func stepCAJWKProvisionerPassword(stdin io.Reader) (string, error) {
+	if stepCAJWKPassFile == "" {
+		return stepCAJWKPassword, nil
+	}
+	if stepCAJWKPassword != "" {
+		return "", fmt.Errorf("a JWK provisioner password and password file cannot be used together")
+	}
+
+	var data []byte
+	var err error
+	if stepCAJWKPassFile == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(stepCAJWKPassFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read JWK provisioner password: %w", err)
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("JWK provisioner password file %s is empty", stepCAJWKPassFile)
+	}
+	return password, nil
+}
+
 // Step CA functions
 func startStepCA(ctx context.Context) error {
+	jwkPassword, err := stepCAJWKProvisionerPassword(os.Stdin)
+	if err != nil {
+		return err
+	}
+
 	if certPath == "" {
-		dataDir, err := getDataDir()
+		path, err := config.CACertPath()
 		if err != nil {
-			return fmt.Errorf("failed to get data directory: %w", err)
+			return err
 		}
-		certPath = filepath.Join(dataDir, CACertFilename)
+		certPath = path
 	}
 
 	if keyPath == "" {
-		dataDir, err := getDataDir()
+		path, err := config.CAKeyPath()
 		if err != nil {
-			return fmt.Errorf("failed to get data directory: %w", err)
+			return err
 		}
-		keyPath = filepath.Join(dataDir, CAKeyFilename)
+		keyPath = path
 	}
 
 	if _, err := os.Stat(certPath); os.IsNotExist(err) {
@@ -169,19 +231,41 @@ func startStepCA(ctx context.Context) error {
 	}
 
 	config := docker.StepCAConfig{
-		ContainerName: stepCAContainerName,
-		Hostname:      docker.StepCAHostname,
-		NetworkName:   networkName,
-		CACertPath:    certPath,
-		CAKeyPath:     keyPath,
-		DataDir:       dataDir,
-		Image:         stepCAImage,
+		ContainerName:          stepCAContainerName,
+		Hostname:               docker.StepCAHostname,
+		NetworkName:            networkName,
+		CACertPath:             certPath,
+		CAKeyPath:              keyPath,
+		DataDir:                dataDir,
+		Image:                  stepCAImage,
+		IPAddress:              config.GetString(config.KeyNetworkStepCAIP),
+		ACMEProvisioner:        !stepCANoACME,
+		JWKProvisionerPassword: jwkPassword,
+		DefaultCertDuration:    stepCACertDuration,
+		MaxCertDuration:        stepCAMaxDuration,
+		CRLURL:                 stepCACRLURL,
+	}
+	if stepCANoACME {
+		Warn("ACME provisioner disabled; Traefik and the cert-manager issuer cannot get certificates\n")
 	}
 
 	containerID, err := docker.CreateStepCAContainer(ctx, config)
 	if err != nil {
 		return fmt.Errorf("failed to create Step CA container: %w", err)
 	}
+	if waitForReady() {
+		if err := docker.WaitForStepCA(ctx, stepCAContainerName, waitTimeout); err != nil {
+			return err
+		}
+	}
+
+	var provisioners []string
+	if config.ACMEProvisioner {
+		provisioners = append(provisioners, "Provisioner: "+docker.ACMEProvisionerName+" (ACME)")
+	}
+	if config.JWKProvisionerPassword != "" {
+		provisioners = append(provisioners, "Provisioner: "+docker.JWKProvisionerName+" (JWK)")
+	}
 
 	Verbose("Step CA container started successfully:\n")
 	printContainerInfo(containerInfo{
@@ -190,6 +274,7 @@ func startStepCA(ctx context.Context) error {
 		ContainerID: containerID,
 		IPAddress:   getContainerIPSafe(ctx, stepCAContainerName, networkName),
 		Network:     networkName,
+		ExtraInfo:   provisioners,
 	})
 
 	return nil
@@ -217,6 +302,8 @@ func startZot(ctx context.Context) error {
 		NetworkName:     networkName,
 		DataDir:         dataDir,
 		Image:           zotImage,
+		IPAddress:       config.GetString(config.KeyNetworkZotIP),
+		HostPort:        zotHostPort(),
 		RegistryMirrors: config.DefaultRegistryMirrors,
 	}
 
@@ -224,6 +311,11 @@ func startZot(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create Zot container: %w", err)
 	}
+	if waitForReady() {
+		if err := docker.WaitForZot(ctx, zotHostPort(), waitTimeout); err != nil {
+			return err
+		}
+	}
 
 	Verbose("Zot registry container started successfully:\n")
 	printContainerInfo(containerInfo{
@@ -232,7 +324,7 @@ func startZot(ctx context.Context) error {
 		ContainerID: containerID,
 		IPAddress:   getContainerIPSafe(ctx, zotContainerName, networkName),
 		Network:     networkName,
-		ExtraInfo:   []string{"Registry URL: http://localhost:5000"},
+		ExtraInfo:   []string{"Registry URL: http://" + localRegistry()},
 	})
 
 	return nil
@@ -254,15 +346,42 @@ func startGatus(ctx context.Context) error {
 		return fmt.Errorf("failed to get data directory: %w", err)
 	}
 
-	config := docker.GatusConfig{
+	caCertPath, err := config.CACertPath()
+	if err != nil {
+		return err
+	}
+
+	extra, err := parseGatusEndpoints(gatusExtraEndpoints)
+	if err != nil {
+		return err
+	}
+	if gatusAlertWebhook != "" && !isHTTPURL(gatusAlertWebhook) {
+		return fmt.Errorf("Gatus alert webhook %q must be an http or https URL", gatusAlertWebhook)
+	}
+
+	appName := config.GetString(config.KeyAppName)
+	if appName == "" {
+		appName = config.DefaultAppName
+	}
+
+	gatusCfg := docker.GatusConfig{
 		ContainerName: gatusContainerName,
 		Hostname:      docker.GatusHostname,
 		NetworkName:   networkName,
 		DataDir:       dataDir,
+		CACertPath:    caCertPath,
 		Image:         gatusImage,
+		IPAddress:     config.GetString(config.KeyNetworkGatusIP),
+		Domain:        config.GetString(config.KeyDomain),
+		ClusterName:   appName,
+		StepCAURL:     externalCAURL(),
+		Extra:         extra,
+		Dashboard:     !traefikNoDashboard,
+		Alerting:      config.GetStringMap(config.KeyGatusAlerts),
+		AlertWebhook:  gatusAlertWebhook,
 	}
 
-	containerID, err := docker.CreateGatusContainer(ctx, config)
+	containerID, err := docker.CreateGatusContainer(ctx, gatusCfg)
 	if err != nil {
 		return fmt.Errorf("failed to create Gatus container: %w", err)
 	}
@@ -279,6 +398,28 @@ func startGatus(ctx context.Context) error {
 	return nil
 }
 
+// parseGatusEndpoints parses extra Gatus endpoints of the form name=url
+func parseGatusEndpoints(values []string) ([]docker.GatusEndpoint, error) {
+	var endpoints []docker.GatusEndpoint
+	for _, v := range values {
+		name, rawURL, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("extra Gatus endpoint must be name=url, got %q", v)
+		}
+		if !isHTTPURL(rawURL) {
+			return nil, fmt.Errorf("extra Gatus endpoint %s: %q must be an http or https URL", name, rawURL)
+		}
+		endpoints = append(endpoints, docker.GatusEndpoint{Name: name, URL: rawURL})
+	}
+	return endpoints, nil
+}
+
+// isHTTPURL reports whether raw is an absolute http or https URL
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 func stopGatus(ctx context.Context) error {
 	stopContainerSafe(ctx, gatusContainerName, docker.RemoveGatusContainer)
 	return nil
@@ -290,25 +431,68 @@ func startTraefik(ctx context.Context) error {
 		return err
 	}
 
+	if traefikDashboardPort != "" {
+		if traefikNoDashboard {
+			return fmt.Errorf("the Traefik dashboard port cannot be used with --no-dashboard")
+		}
+		if port, err := strconv.Atoi(traefikDashboardPort); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid Traefik dashboard port %q", traefikDashboardPort)
+		}
+	}
+
+	if traefikHTTPPort != "" {
+		if port, err := strconv.Atoi(traefikHTTPPort); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid Traefik HTTP port %q", traefikHTTPPort)
+		}
+	}
+
+	logLevel := strings.ToUpper(traefikLogLevel)
+	if logLevel != "" && !slices.Contains(docker.TraefikLogLevels, logLevel) {
+		return fmt.Errorf("invalid Traefik log level %q (valid: %s)", traefikLogLevel, strings.Join(docker.TraefikLogLevels, ", "))
+	}
+
 	dataDir, err := getDataDir()
 	if err != nil {
 		return fmt.Errorf("failed to get data directory: %w", err)
 	}
+	caCertPath, err := config.CACertPath()
+	if err != nil {
+		return err
+	}
 
 	config := docker.TraefikConfig{
-		ContainerName: traefikContainerName,
-		Hostname:      docker.TraefikHostname,
-		NetworkName:   networkName,
-		DataDir:       dataDir,
-		Image:         traefikImage,
-		Port:          traefikPort,
-		Domain:        traefikDomain,
+		ContainerName:      traefikContainerName,
+		Hostname:           docker.TraefikHostname,
+		NetworkName:        networkName,
+		DataDir:            dataDir,
+		CACertPath:         caCertPath,
+		Image:              traefikImage,
+		IPAddress:          config.GetString(config.KeyNetworkTraefikIP),
+		Port:               traefikPort,
+		HTTPPort:           traefikHTTPPort,
+		EnableHTTPRedirect: traefikHTTPRedirect,
+		Domain:             traefikDomain,
+		ACMEServer:         externalACMEServerURL(),
+		EnableDashboard:    !traefikNoDashboard,
+		DashboardPort:      traefikDashboardPort,
+		LogLevel:           logLevel,
+		AccessLog:          traefikAccessLog,
+		ArgoCDURL:          argocdBackendURL(),
 	}
 
 	containerID, err := docker.CreateTraefikContainer(ctx, config)
 	if err != nil {
 		return fmt.Errorf("failed to create Traefik container: %w", err)
 	}
+	if waitForReady() {
+		port := config.Port
+		if port == "" {
+			port = docker.DefaultTraefikPort
+		}
+		if err := docker.WaitForTraefik(ctx, port, waitTimeout); err != nil {
+			return err
+		}
+	}
 
 	Verbose("Traefik reverse proxy container started successfully:\n")
 	printContainerInfo(containerInfo{
@@ -317,12 +501,31 @@ func startTraefik(ctx context.Context) error {
 		ContainerID: containerID,
 		IPAddress:   getContainerIPSafe(ctx, traefikContainerName, networkName),
 		Network:     networkName,
-		ExtraInfo:   []string{"Dashboard: http://localhost:8080"},
+		ExtraInfo:   traefikDashboardInfo(config),
 	})
 
 	return nil
 }
 
+// traefikDashboardInfo returns where the Traefik dashboard can be reached
+func traefikDashboardInfo(cfg docker.TraefikConfig) []string {
+	if !cfg.EnableDashboard {
+		return []string{"Dashboard: disabled"}
+	}
+	port, domain := cfg.Port, cfg.Domain
+	if port == "" {
+		port = docker.DefaultTraefikPort
+	}
+	if domain == "" {
+		domain = docker.DefaultTraefikDomain
+	}
+	info := []string{fmt.Sprintf("Dashboard: https://traefik.%s:%s/dashboard/", domain, port)}
+	if cfg.DashboardPort != "" {
+		info = append(info, fmt.Sprintf("Dashboard: http://localhost:%s/dashboard/", cfg.DashboardPort))
+	}
+	return info
+}
+
 func stopTraefik(ctx context.Context) error {
 	stopContainerSafe(ctx, traefikContainerName, docker.RemoveTraefikContainer)
 	return nil
@@ -330,32 +533,9 @@ func stopTraefik(ctx context.Context) error {
 
 // Kind cluster functions
 func startKind(ctx context.Context) error {
-	// Get data directory for CA cert path
-	dataDir, err := getDataDir()
+	kindCfg, err := kindClusterConfig()
 	if err != nil {
-		return fmt.Errorf("failed to get data directory: %w", err)
-	}
-
-	appName := config.GetString(config.KeyAppName)
-	if appName == "" {
-		appName = config.DefaultAppName
-	}
-
-	// Check if CA certificate exists
-	caCertPath := filepath.Join(dataDir, CACertFilename)
-	if _, err := os.Stat(caCertPath); os.IsNotExist(err) {
-		return fmt.Errorf("CA certificate not found at %s - run 'kinder start' first", caCertPath)
-	}
-
-	kindCfg := kubernetes.KindConfig{
-		ClusterName:     appName,
-		NodeImage:       kindNodeImage,
-		CACertPath:      caCertPath,
-		NetworkName:     networkName,
-		RegistryMirrors: buildRegistryMirrorMap(),
-		ZotHostname:     "zot",
-		WorkerNodes:     kindWorkerNodes,
-		Verbose:         IsVerbose(),
+		return err
 	}
 
 	// Check if cluster already exists
@@ -365,10 +545,27 @@ func startKind(ctx context.Context) error {
 	}
 
 	if exists {
-		Verbose("Kind cluster '%s' already exists\n", kindCfg.ClusterName)
-		return nil
+		recreate, err := recreateOnImageChange(ctx, kindCfg)
+		if err != nil {
+			return err
+		}
+		if !recreate {
+			Verbose("Kind cluster '%s' already exists\n", kindCfg.ClusterName)
+			return nil
+		}
+	}
+
+	// Kind would pull a missing node image itself, ignoring the pull policy
+	nodeImage := kindCfg.NodeImage
+	if nodeImage == "" {
+		nodeImage = kubernetes.KindNodeImage
+	}
+	if _, err := docker.EnsureImage(ctx, nodeImage, nil); err != nil {
+		return err
 	}
 
+	warnIfNoCNI(kindCfg)
+	logKindWait(kindCfg)
 	if kindCfg.WorkerNodes > 0 {
 		Verbose("Creating Kind cluster '%s' with %d worker nodes...\n", kindCfg.ClusterName, kindCfg.WorkerNodes)
 	} else {
@@ -400,9 +597,14 @@ func stopKind(ctx context.Context) error {
 		return nil
 	}
 
+	if err := ensureKinderCluster(ctx, appName, kindForceDelete); err != nil {
+		return err
+	}
+
 	kindCfg := kubernetes.KindConfig{
 		ClusterName: appName,
 		Verbose:     IsVerbose(),
+		Logger:      kindLog{},
 	}
 
 	if err := kubernetes.StopKind(kindCfg); err != nil {
@@ -415,18 +617,23 @@ func stopKind(ctx context.Context) error {
 
 // ArgoCD functions
 func bootstrapArgoCD(ctx context.Context) error {
-	dataDir, err := getDataDir()
+	caCertPath, err := config.CACertPath()
 	if err != nil {
-		return fmt.Errorf("failed to get data directory: %w", err)
+		return err
 	}
 
-	caCertPEM, _ := os.ReadFile(filepath.Join(dataDir, CACertFilename))
+	caCertPEM, _ := os.ReadFile(caCertPath)
 
 	appName := config.GetString(config.KeyAppName)
 	if appName == "" {
 		appName = config.DefaultAppName
 	}
 
+	credType, err := startGitCredentialType()
+	if err != nil {
+		return err
+	}
+
 	cfg := kubernetes.ArgoCDConfig{
 		Version:           config.GetString(config.KeyArgocdVersion),
 		ManifestURL:       config.GetString(config.KeyArgocdManifestURL),
@@ -434,10 +641,51 @@ func bootstrapArgoCD(ctx context.Context) error {
 		Port:              config.GetString(config.KeyTraefikPort),
 		CACertPEM:         string(caCertPEM),
 		WaitTimeout:       5 * time.Minute,
-		SkipInitialApp:    true,
-		IncludeKinderApps: true,
+		RepoURL:           startArgocdRepoURL,
+		RepoPath:          startArgocdRepoPath,
+		RepoBranch:        startArgocdRepoBranch,
+		SkipInitialApp:    startArgocdRepoURL == "",
+		CredentialType:    credType,
+		HTTPUsername:      startArgocdGitUsername,
+		HTTPPassword:      startArgocdGitPassword,
+		SSHPrivateKeyPath: startArgocdGitSSHKey,
+		IncludeKinderApps: startArgocdIncludeKinder,
+		AutoSync:          true,
+		Prune:             true,
+		SelfHeal:          true,
 		KubeContext:       "kind-" + appName,
+		Expose:            config.GetBool(config.KeyArgocdExpose),
 	}
 
 	return kubernetes.Install(ctx, cfg, nil)
 }
+
+// startGitCredentialType checks the --argocd-git-* flags of start and restart
+func startGitCredentialType() (kubernetes.GitCredentialType, error) {
+	return gitCredentialType("--argocd-git-", startArgocdGitUsername, startArgocdGitPassword, startArgocdGitSSHKey)
+}
+
+// argocdStartFlags registers the flags start and restart pass to the ArgoCD
+// bootstrap, mirroring those of 'argocd bootstrap'
+func argocdStartFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&startArgocdRepoURL, "argocd-repo-url", "", "Git repository for an initial ArgoCD Application")
+	cmd.Flags().StringVar(&startArgocdRepoPath, "argocd-repo-path", ".", "Path within the ArgoCD repository")
+	cmd.Flags().StringVar(&startArgocdRepoBranch, "argocd-repo-branch", "main", "Branch of the ArgoCD repository to track")
+	cmd.Flags().BoolVar(&startArgocdIncludeKinder, "argocd-include-kinder", true, "Create ArgoCD Applications for trust-bundle and cert-issuer")
+	cmd.Flags().StringVar(&startArgocdGitUsername, "argocd-git-username", "", "Git username for the ArgoCD repository")
+	cmd.Flags().StringVar(&startArgocdGitPassword, "argocd-git-password", "", "Git password or token for the ArgoCD repository")
+	cmd.Flags().StringVar(&startArgocdGitSSHKey, "argocd-git-ssh-key", "", "SSH private key file for the ArgoCD repository")
+}
+
+// argocdBackendURL returns the URL Traefik reaches the exposed ArgoCD server
+// on, the node port of the Kind control-plane, or "" if ArgoCD is not exposed
+func argocdBackendURL() string {
+	if !config.GetBool(config.KeyArgocdExpose) {
+		return ""
+	}
+	appName := config.GetString(config.KeyAppName)
+	if appName == "" {
+		appName = config.DefaultAppName
+	}
+	return fmt.Sprintf("http://%s-control-plane:%d", appName, kubernetes.ArgoCDNodePort)
+}
@@ -5,17 +5,19 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
 	"codeberg.org/hipkoi/kinder/docker"
 	"codeberg.org/hipkoi/kinder/kubernetes"
+	"codeberg.org/hipkoi/kinder/ociartifact"
 	"github.com/spf13/cobra"
 )
 
@@ -31,137 +33,273 @@ Checks:
   - Required containers running
   - Service endpoints (Step CA, Zot, Gatus, Traefik)
   - Registry and Kubernetes end-to-end test (if Kind cluster is running)
-  - ArgoCD installation and health (if installed in Kind cluster)`,
+  - ArgoCD installation and health (if installed in Kind cluster)
+  - Trust bundle in the local registry
+
+With --fix, failures that kinder can remediate are fixed: a missing CA is
+generated, a missing network is created, stopped or missing service containers
+are started and the trust bundle is pushed again. Each fix is confirmed first
+unless --yes is set, and its check is re-run afterwards.
+
+The whole run is limited by --timeout (default 60s, 0 for no limit), so a hung
+Docker daemon or cluster cannot stall it. On timeout the check in progress is
+reported. With --fix there is no limit unless --timeout is given, since fixes
+wait for confirmation and may pull images.
+
+The Kubernetes checks use the kind-<cluster> context; use --kubeconfig and
+--context to point them at a different kubeconfig or context.
+
+Checks that need kubectl are skipped when the tool is not installed.
+Use --tools to list the external tools kinder uses, whether each is installed
+and its version.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		if diagnosticsTools {
+			fmt.Fprintln(reportOut, "🧰 External tools")
+			writeToolReport(ctx, reportOut, toolVersion)
+			return nil
+		}
 
-		fmt.Println("🔍 Running kinder diagnostics...")
-		fmt.Println()
+		// Fixes wait on the user and on image pulls, so the default limit
+		// only applies to a plain check run
+		timeout := diagnosticsTimeout
+		if diagnosticsFix && !cmd.Flags().Changed("timeout") {
+			timeout = 0
+		}
 
-		allPassed := true
+		return runWithTimeout(ctx, timeout, runDiagnostics)
+	},
+}
 
-		// Check 1: Docker availability
-		fmt.Println("1️⃣  Checking Docker availability...")
-		if err := checkDockerAvailability(ctx); err != nil {
-			fmt.Printf("   ❌ FAILED: %v\n", err)
-			allPassed = false
-		} else {
-			fmt.Println("   ✅ Docker daemon is running and accessible")
+// runWithTimeout calls run, giving up once timeout has passed. The context
+// passed to run carries the deadline, but a check that ignores it is abandoned
+// rather than waited for, so the run always ends in time. The error names the
+// check in progress at the deadline. A zero timeout disables the limit.
+//
+// Output to reportOut is cut off at the deadline so an abandoned check cannot
+// print into the timeout report, and stays cut off afterwards as the check may
+// still be running.
+func runWithTimeout(ctx context.Context, timeout time.Duration, run func(ctx context.Context, begin func(heading string)) error) error {
+	if timeout <= 0 {
+		return run(ctx, func(heading string) { fmt.Fprintln(reportOut, heading) })
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out := &cutoffWriter{w: reportOut}
+	reportOut = out
+
+	var current atomic.Pointer[string]
+	begin := func(heading string) {
+		fmt.Fprintln(reportOut, heading)
+		// Checks started after the deadline fail at once, so keep the one
+		// that ran out of time
+		if ctx.Err() == nil {
+			current.Store(&heading)
 		}
-		fmt.Println()
+	}
 
-		// Check 2: IP 192.0.2.1 reachability (checks if IP is routable)
-		fmt.Println("2️⃣  Checking IP 192.0.2.1 reachability...")
-		if err := checkIPReachability(ctx, "192.0.2.1"); err != nil {
-			fmt.Printf("   ❌ FAILED: %v\n", err)
-			allPassed = false
-		} else {
-			fmt.Println("   ✅ IP 192.0.2.1 is routable")
+	done := make(chan error, 1)
+	go func() { done <- run(ctx, begin) }()
+
+	select {
+	case err := <-done:
+		if ctx.Err() == nil {
+			reportOut = out.w
+			return err
 		}
-		fmt.Println()
+	case <-ctx.Done():
+	}
+	out.cutOff()
 
-		// Check 3: CA certificate existence and validity
-		fmt.Println("3️⃣  Checking CA certificate...")
-		dataDir, err := getDataDir()
-		if err != nil {
-			fmt.Printf("   ❌ FAILED: %v\n", err)
-			allPassed = false
-		} else {
-			caCertPath := filepath.Join(dataDir, CACertFilename)
-			if err := checkCACertificate(caCertPath); err != nil {
-				fmt.Printf("   ❌ FAILED: %v\n", err)
+	check := "before the first check"
+	if heading := current.Load(); heading != nil {
+		_, name, _ := strings.Cut(*heading, "Checking ")
+		check = "while checking " + strings.TrimSuffix(name, "...")
+	}
+	fmt.Fprintln(out.w)
+	fmt.Fprintln(out.w, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintf(out.w, "⏱️  Diagnostics timed out after %s %s\n", timeout, check)
+	return fmt.Errorf("diagnostics timed out after %s %s", timeout, check)
+}
+
+// cutoffWriter passes writes through until it is cut off, then discards them
+type cutoffWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closed bool
+}
+
+func (c *cutoffWriter) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return len(p), nil
+	}
+	return c.w.Write(p)
+}
+
+// cutOff discards all further writes
+func (c *cutoffWriter) cutOff() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+}
+
+// runDiagnostics runs each check in turn, calling begin with its heading as
+// it starts
+func runDiagnostics(ctx context.Context, begin func(heading string)) error {
+	fmt.Fprintln(reportOut, "🔍 Running kinder diagnostics...")
+	fmt.Fprintln(reportOut)
+
+	allPassed := true
+
+	// Check 1: Docker availability
+	begin("1️⃣  Checking Docker availability...")
+	if err := checkDockerAvailability(ctx); err != nil {
+		fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+		allPassed = false
+	} else {
+		fmt.Fprintln(reportOut, "   ✅ Docker daemon is running and accessible")
+	}
+	fmt.Fprintln(reportOut)
+
+	// Check 2: IP 192.0.2.1 reachability (checks if IP is routable)
+	begin("2️⃣  Checking IP 192.0.2.1 reachability...")
+	if err := checkIPReachability(ctx, "192.0.2.1"); err != nil {
+		fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+		allPassed = false
+	} else {
+		fmt.Fprintln(reportOut, "   ✅ IP 192.0.2.1 is routable")
+	}
+	fmt.Fprintln(reportOut)
+
+	// Check 3: CA certificate existence and validity
+	begin("3️⃣  Checking CA certificate...")
+	caCertPath, err := config.CACertPath()
+	if err != nil {
+		fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+		allPassed = false
+	} else {
+		if err := checkCACertificate(caCertPath); err != nil {
+			fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+			// Only generate a CA where there is none, never replace one
+			_, statErr := os.Stat(caCertPath)
+			if !diagnosticsFix || !os.IsNotExist(statErr) || !runFix(ctx, generateCAFix(caCertPath)) {
 				allPassed = false
-			} else {
-				fmt.Printf("   ✅ CA certificate exists and is valid (%s)\n", caCertPath)
 			}
+		} else {
+			fmt.Fprintf(reportOut, "   ✅ CA certificate exists and is valid (%s)\n", caCertPath)
 		}
-		fmt.Println()
+	}
+	fmt.Fprintln(reportOut)
 
-		// Check 4: Kinder network
-		fmt.Println("4️⃣  Checking kinder network...")
-		if err := checkKinderNetwork(ctx); err != nil {
-			fmt.Printf("   ❌ FAILED: %v\n", err)
+	// Check 4: Kinder network
+	begin("4️⃣  Checking kinder network...")
+	if err := checkKinderNetwork(ctx); err != nil {
+		fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+		if !diagnosticsFix || !runFix(ctx, createNetworkFix()) {
 			allPassed = false
-		} else {
-			fmt.Println("   ✅ Kinder network exists")
 		}
-		fmt.Println()
+	} else {
+		fmt.Fprintln(reportOut, "   ✅ Kinder network exists")
+	}
+	fmt.Fprintln(reportOut)
 
-		// Check 5: Running containers
-		fmt.Println("5️⃣  Checking running containers...")
-		containersPassed := checkRunningContainers(ctx)
-		if !containersPassed {
+	// Check 5: Running containers
+	begin("5️⃣  Checking running containers...")
+	containersPassed := checkRunningContainers(ctx)
+	if !containersPassed {
+		allPassed = false
+	}
+	fmt.Fprintln(reportOut)
+
+	// Check 6: Service endpoints
+	begin("6️⃣  Checking service endpoints...")
+	if caCertPath != "" {
+		endpointsPassed := checkServiceEndpoints(ctx, caCertPath)
+		if !endpointsPassed {
 			allPassed = false
 		}
-		fmt.Println()
-
-		// Check 6: Service endpoints
-		fmt.Println("6️⃣  Checking service endpoints...")
-		if dataDir != "" {
-			caCertPath := filepath.Join(dataDir, CACertFilename)
-			endpointsPassed := checkServiceEndpoints(ctx, caCertPath)
-			if !endpointsPassed {
-				allPassed = false
-			}
-		} else {
-			fmt.Println("   ⚠️  Skipped (data directory not available)")
-		}
-		fmt.Println()
+	} else {
+		fmt.Fprintln(reportOut, "   ⚠️  Skipped (CA certificate path not available)")
+	}
+	fmt.Fprintln(reportOut)
 
-		// Check 7: Registry and Kubernetes end-to-end test (only if Kind is running)
-		fmt.Println("7️⃣  Checking registry and Kubernetes end-to-end...")
-		appName := config.GetString(config.KeyAppName)
-		if appName == "" {
-			appName = config.DefaultAppName
+	// Check 7: Registry and Kubernetes end-to-end test (only if Kind is running)
+	begin("7️⃣  Checking registry and Kubernetes end-to-end...")
+	appName := config.GetString(config.KeyAppName)
+	if appName == "" {
+		appName = config.DefaultAppName
+	}
+	kindExists, err := kubernetes.KindExists(appName)
+	if err != nil {
+		fmt.Fprintf(reportOut, "   ⚠️  Skipped (failed to check Kind status: %v)\n", err)
+	} else if !kindExists {
+		fmt.Fprintln(reportOut, "   ⚠️  Skipped (Kind cluster not running)")
+	} else {
+		if err := checkRegistryK8sEndToEnd(ctx, clusterTarget(appName)); err != nil {
+			fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+			allPassed = false
+		} else {
+			fmt.Fprintln(reportOut, "   ✅ Registry and Kubernetes end-to-end test passed")
 		}
-		kindExists, err := kubernetes.KindExists(appName)
-		if err != nil {
-			fmt.Printf("   ⚠️  Skipped (failed to check Kind status: %v)\n", err)
-		} else if !kindExists {
-			fmt.Println("   ⚠️  Skipped (Kind cluster not running)")
+	}
+	fmt.Fprintln(reportOut)
+
+	// Check 8: ArgoCD health (only if Kind is running and ArgoCD is installed)
+	begin("8️⃣  Checking ArgoCD installation...")
+	if !kindExists {
+		fmt.Fprintln(reportOut, "   ⚠️  Skipped (Kind cluster not running)")
+	} else if err := checkTool("kubectl"); err != nil {
+		fmt.Fprintf(reportOut, "   ⚠️  Skipped (%v)\n", err)
+	} else {
+		argocdResult := checkArgoCDHealth(ctx, clusterTarget(appName))
+		if argocdResult.skipped {
+			fmt.Fprintf(reportOut, "   ⚠️  Skipped (%s)\n", argocdResult.message)
+		} else if argocdResult.err != nil {
+			fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", argocdResult.err)
+			allPassed = false
 		} else {
-			if err := checkRegistryK8sEndToEnd(ctx, appName); err != nil {
-				fmt.Printf("   ❌ FAILED: %v\n", err)
-				allPassed = false
-			} else {
-				fmt.Println("   ✅ Registry and Kubernetes end-to-end test passed")
-			}
+			fmt.Fprintf(reportOut, "   ✅ %s\n", argocdResult.message)
 		}
-		fmt.Println()
+	}
+	fmt.Fprintln(reportOut)
 
-		// Check 8: ArgoCD health (only if Kind is running and ArgoCD is installed)
-		fmt.Println("8️⃣  Checking ArgoCD installation...")
-		if !kindExists {
-			fmt.Println("   ⚠️  Skipped (Kind cluster not running)")
-		} else {
-			argocdResult := checkArgoCDHealth(ctx, appName)
-			if argocdResult.skipped {
-				fmt.Printf("   ⚠️  Skipped (%s)\n", argocdResult.message)
-			} else if argocdResult.err != nil {
-				fmt.Printf("   ❌ FAILED: %v\n", argocdResult.err)
-				allPassed = false
-			} else {
-				fmt.Printf("   ✅ %s\n", argocdResult.message)
-			}
+	// Check 9: Trust bundle in the local registry
+	begin("9️⃣  Checking trust bundle...")
+	if config.GetBool(config.KeySkipTrustBundle) {
+		fmt.Fprintln(reportOut, "   ⚠️  Skipped (trust bundle disabled)")
+	} else if err := checkTrustBundle(ctx); err != nil {
+		fmt.Fprintf(reportOut, "   ❌ FAILED: %v\n", err)
+		if !diagnosticsFix || caCertPath == "" || !runFix(ctx, pushTrustBundleFix(caCertPath)) {
+			allPassed = false
 		}
-		fmt.Println()
-
-		// Final summary
-		fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-		if allPassed {
-			fmt.Println("✅ All diagnostics passed!")
-			fmt.Println()
-			fmt.Println("Your kinder environment is fully functional.")
-			return nil
-		} else {
-			fmt.Println("❌ Some diagnostics failed")
-			fmt.Println()
-			fmt.Println("Please review the failures above and run:")
-			fmt.Println("  - 'kinder start' to ensure all services are running")
-			fmt.Println("  - 'kinder ca generate' if CA certificate is missing")
-			return fmt.Errorf("diagnostics failed")
+	} else {
+		fmt.Fprintln(reportOut, "   ✅ Trust bundle is in the local registry")
+	}
+	fmt.Fprintln(reportOut)
+
+	// Final summary
+	fmt.Fprintln(reportOut, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	if allPassed {
+		fmt.Fprintln(reportOut, "✅ All diagnostics passed!")
+		fmt.Fprintln(reportOut)
+		fmt.Fprintln(reportOut, "Your kinder environment is fully functional.")
+		return nil
+	} else {
+		fmt.Fprintln(reportOut, "❌ Some diagnostics failed")
+		fmt.Fprintln(reportOut)
+		fmt.Fprintln(reportOut, "Please review the failures above and run:")
+		fmt.Fprintln(reportOut, "  - 'kinder start' to ensure all services are running")
+		fmt.Fprintln(reportOut, "  - 'kinder ca generate' if CA certificate is missing")
+		if !diagnosticsFix {
+			fmt.Fprintln(reportOut, "  - 'kinder diagnostics --fix' to fix what kinder can")
 		}
-	},
+		return fmt.Errorf("diagnostics failed")
+	}
 }
 
 func checkDockerAvailability(ctx context.Context) error {
@@ -235,42 +373,55 @@ func checkRunningContainers(ctx context.Context) bool {
 		name     string
 		varName  string
 		required bool
+		start    func(context.Context) error
 	}{
-		{stepCAContainerName, "Step CA", true},
-		{zotContainerName, "Zot Registry", true},
-		{gatusContainerName, "Gatus", true},
-		{traefikContainerName, "Traefik", true},
+		{stepCAContainerName, "Step CA", true, startStepCA},
+		{zotContainerName, "Zot Registry", true, startZot},
+		{gatusContainerName, "Gatus", true, startGatus},
+		{traefikContainerName, "Traefik", true, startTraefik},
 	}
 
 	allRunning := true
 	for _, c := range containers {
-		exists, err := docker.ContainerExists(ctx, c.name)
-		if err != nil {
-			fmt.Printf("   ❌ %s: Failed to check (%v)\n", c.varName, err)
-			if c.required {
+		if err := checkContainerRunning(ctx, c.name); err != nil {
+			fmt.Fprintf(reportOut, "   ❌ %s: %v\n", c.varName, err)
+			fixed := diagnosticsFix && c.required && runFix(ctx, startContainerFix(c.varName, c.name, c.start))
+			if c.required && !fixed {
 				allRunning = false
 			}
 			continue
 		}
-
-		if !exists {
-			fmt.Printf("   ❌ %s: Container not found (%s)\n", c.varName, c.name)
-			if c.required {
-				allRunning = false
-			}
-		} else {
-			fmt.Printf("   ✅ %s: Running (%s)\n", c.varName, c.name)
-		}
+		fmt.Fprintf(reportOut, "   ✅ %s: Running (%s)\n", c.varName, c.name)
 	}
 
 	return allRunning
 }
 
+// checkContainerRunning checks that a container exists and is running
+func checkContainerRunning(ctx context.Context, containerName string) error {
+	exists, err := docker.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("Failed to check (%v)", err)
+	}
+	if !exists {
+		return fmt.Errorf("Container not found (%s)", containerName)
+	}
+
+	running, err := docker.ContainerRunning(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("Failed to check (%v)", err)
+	}
+	if !running {
+		return fmt.Errorf("Container stopped (%s)", containerName)
+	}
+	return nil
+}
+
 func checkServiceEndpoints(ctx context.Context, caCertPath string) bool {
 	// Load CA certificate
 	caCert, err := os.ReadFile(caCertPath)
 	if err != nil {
-		fmt.Printf("   ⚠️  Cannot load CA certificate: %v\n", err)
+		fmt.Fprintf(reportOut, "   ⚠️  Cannot load CA certificate: %v\n", err)
 		return false
 	}
 
@@ -285,19 +436,32 @@ func checkServiceEndpoints(ctx context.Context, caCertPath string) bool {
 		useTLS     bool
 		skipVerify bool
 	}{
-		{"Zot Registry (direct)", "http://localhost:5000/v2/", false, false},
+		{"Zot Registry (direct)", "http://" + localRegistry() + "/v2/", false, false},
 		{"Step CA", fmt.Sprintf("https://ca.%s:%s/health", traefikDomain, traefikPort), true, false},
 		{"Zot Registry", fmt.Sprintf("https://registry.%s:%s/v2/", traefikDomain, traefikPort), true, false},
 		{"Gatus Dashboard", fmt.Sprintf("https://gatus.%s:%s/", traefikDomain, traefikPort), true, false},
 		{"Traefik Dashboard", fmt.Sprintf("https://traefik.%s:%s/dashboard/", traefikDomain, traefikPort), true, false},
 	}
 
-	for _, endpoint := range endpoints {
-		if err := checkEndpoint(ctx, endpoint.name, endpoint.url, endpoint.useTLS, endpoint.skipVerify, caCertPool); err != nil {
-			fmt.Printf("   ❌ %s: %v\n", endpoint.name, err)
+	// Probe all endpoints at once so down services don't add up their
+	// timeouts; results are reported in order
+	errs := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = checkEndpoint(ctx, endpoint.name, endpoint.url, endpoint.useTLS, endpoint.skipVerify, caCertPool)
+		}()
+	}
+	wg.Wait()
+
+	for i, endpoint := range endpoints {
+		if err := errs[i]; err != nil {
+			fmt.Fprintf(reportOut, "   ❌ %s: %v\n", endpoint.name, err)
 			allPassed = false
 		} else {
-			fmt.Printf("   ✅ %s: OK (200)\n", endpoint.name)
+			fmt.Fprintf(reportOut, "   ✅ %s: OK (200)\n", endpoint.name)
 		}
 	}
 
@@ -346,97 +510,35 @@ func checkEndpoint(ctx context.Context, name, url string, useTLS bool, skipVerif
 }
 
 // checkRegistryK8sEndToEnd performs an end-to-end test:
-// 1. Copy a small image to the local Zot registry (using skopeo for OCI format)
+// 1. Copy a small image to the local Zot registry
 // 2. Create a pod in Kubernetes using that image
 // 3. Verify the pod is running
 // 4. Clean up all created resources
-func checkRegistryK8sEndToEnd(ctx context.Context, clusterName string) error {
+// Both steps run in-process, so neither skopeo nor kubectl is needed.
+func checkRegistryK8sEndToEnd(ctx context.Context, target kubeTarget) error {
 	const (
-		sourceImage  = "docker://busybox:1.36"
-		destImage    = "docker://localhost:5000/kinder-diag-test:latest"
-		k8sImage     = "localhost:5000/kinder-diag-test:latest" // Mapped to zot:5000 via containerd hosts.toml
-		testPodName  = "kinder-diag-test"
-		testPodNS    = "default"
-		pollInterval = 2 * time.Second
-		pollTimeout  = 60 * time.Second
+		sourceImage = "busybox:1.36"
+		k8sImage    = "localhost:5000/kinder-diag-test:latest" // Mapped to zot:5000 via containerd hosts.toml
+		testPodName = "kinder-diag-test"
+		testPodNS   = "default"
+		podTimeout  = 60 * time.Second
 	)
+	destImage := localRegistry() + "/kinder-diag-test:latest"
 
-	// Cleanup function to ensure resources are removed
-	cleanup := func() {
-		// Delete the test pod (ignore errors)
-		kubectlCmd := exec.Command("kubectl", "--context", "kind-"+clusterName,
-			"delete", "pod", testPodName, "-n", testPodNS, "--ignore-not-found", "--wait=false")
-		kubectlCmd.Run()
-	}
-
-	// Ensure cleanup runs even on failure
-	defer cleanup()
-
-	// Step 1: Copy image to local registry using skopeo
-	// Skopeo converts Docker manifest to OCI format, which Zot requires
+	// Step 1: Copy image to local registry, as "kinder registry warmup" does
 	Verbose("   Copying %s to local registry...\n", sourceImage)
-	copyCmd := exec.Command("skopeo", "copy",
-		"--insecure-policy",
-		"--dest-tls-verify=false",
-		sourceImage, destImage)
-	if output, err := copyCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to copy image to registry (requires skopeo): %w\n%s", err, output)
+	if err := ociartifact.Copy(ctx, sourceImage, destImage); err != nil {
+		return fmt.Errorf("failed to copy image to registry: %w", err)
 	}
 
-	// Step 2: Create a test pod in Kubernetes
+	// Step 2: Run a test pod from it and wait for it to be running
 	Verbose("   Creating test pod in Kubernetes...\n")
-	podManifest := fmt.Sprintf(`apiVersion: v1
-kind: Pod
-metadata:
-  name: %s
-  namespace: %s
-  labels:
-    app: kinder-diag-test
-spec:
-  containers:
-  - name: test
-    image: %s
-    command: ["sleep", "300"]
-  restartPolicy: Never
-`, testPodName, testPodNS, k8sImage)
-
-	applyCmd := exec.Command("kubectl", "--context", "kind-"+clusterName, "apply", "-f", "-")
-	applyCmd.Stdin = strings.NewReader(podManifest)
-	if output, err := applyCmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to create pod: %w\n%s", err, output)
-	}
-
-	// Step 3: Wait for pod to be running
-	Verbose("   Waiting for pod to be running...\n")
-	deadline := time.Now().Add(pollTimeout)
-	for time.Now().Before(deadline) {
-		statusCmd := exec.Command("kubectl", "--context", "kind-"+clusterName,
-			"get", "pod", testPodName, "-n", testPodNS,
-			"-o", "jsonpath={.status.phase}")
-		output, err := statusCmd.Output()
-		if err == nil {
-			phase := strings.TrimSpace(string(output))
-			if phase == "Running" {
-				Verbose("   Pod is running!\n")
-				return nil
-			}
-			if phase == "Failed" || phase == "Error" {
-				// Get pod events for debugging
-				eventsCmd := exec.Command("kubectl", "--context", "kind-"+clusterName,
-					"describe", "pod", testPodName, "-n", testPodNS)
-				eventsOutput, _ := eventsCmd.CombinedOutput()
-				return fmt.Errorf("pod failed to start (phase: %s)\n%s", phase, eventsOutput)
-			}
-			Verbose("   Pod phase: %s\n", phase)
-		}
-		time.Sleep(pollInterval)
+	if err := kubernetes.RunPod(ctx, target.kubeconfig, target.context, testPodNS, testPodName, k8sImage,
+		[]string{"sleep", "300"}, podTimeout); err != nil {
+		return fmt.Errorf("test pod did not start: %w", err)
 	}
-
-	// Timeout - get pod description for debugging
-	describeCmd := exec.Command("kubectl", "--context", "kind-"+clusterName,
-		"describe", "pod", testPodName, "-n", testPodNS)
-	describeOutput, _ := describeCmd.CombinedOutput()
-	return fmt.Errorf("timeout waiting for pod to be running\n%s", describeOutput)
+	Verbose("   Pod is running!\n")
+	return nil
 }
 
 // argoCDHealthResult holds the result of ArgoCD health check
@@ -448,12 +550,9 @@ type argoCDHealthResult struct {
 
 // checkArgoCDHealth verifies ArgoCD installation and health
 // Since ArgoCD may not have an ingress, we check via kubectl instead of HTTP
-func checkArgoCDHealth(ctx context.Context, clusterName string) argoCDHealthResult {
-	kubectlContext := "kind-" + clusterName
-
+func checkArgoCDHealth(ctx context.Context, target kubeTarget) argoCDHealthResult {
 	// Check if argocd namespace exists
-	nsCmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-		"get", "namespace", "argocd", "-o", "name")
+	nsCmd := target.kubectl(ctx, "get", "namespace", "argocd", "-o", "name")
 	if err := nsCmd.Run(); err != nil {
 		return argoCDHealthResult{skipped: true, message: "ArgoCD not installed"}
 	}
@@ -469,8 +568,7 @@ func checkArgoCDHealth(ctx context.Context, clusterName string) argoCDHealthResu
 	var unhealthyDeployments []string
 
 	for _, deploy := range deployments {
-		cmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-			"get", "deployment", deploy, "-n", "argocd",
+		cmd := target.kubectl(ctx, "get", "deployment", deploy, "-n", "argocd",
 			"-o", "jsonpath={.status.availableReplicas}/{.status.replicas}")
 		output, err := cmd.Output()
 		if err != nil {
@@ -489,8 +587,7 @@ func checkArgoCDHealth(ctx context.Context, clusterName string) argoCDHealthResu
 	}
 
 	// Also check the statefulset (argocd-application-controller)
-	ssCmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-		"get", "statefulset", "argocd-application-controller", "-n", "argocd",
+	ssCmd := target.kubectl(ctx, "get", "statefulset", "argocd-application-controller", "-n", "argocd",
 		"-o", "jsonpath={.status.readyReplicas}/{.status.replicas}")
 	if output, err := ssCmd.Output(); err == nil {
 		total++
@@ -517,7 +614,7 @@ func checkArgoCDHealth(ctx context.Context, clusterName string) argoCDHealthResu
 	}
 
 	// Get version for the success message
-	version := getArgoCDVersionFromCluster(ctx, kubectlContext)
+	version := getArgoCDVersionFromCluster(ctx, target)
 	if version != "" {
 		return argoCDHealthResult{
 			message: fmt.Sprintf("ArgoCD healthy (%d/%d components, %s)", healthy, total, version),
@@ -530,9 +627,8 @@ func checkArgoCDHealth(ctx context.Context, clusterName string) argoCDHealthResu
 }
 
 // getArgoCDVersionFromCluster extracts the ArgoCD version from the argocd-server image
-func getArgoCDVersionFromCluster(ctx context.Context, kubectlContext string) string {
-	cmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-		"get", "deployment", "argocd-server", "-n", "argocd",
+func getArgoCDVersionFromCluster(ctx context.Context, target kubeTarget) string {
+	cmd := target.kubectl(ctx, "get", "deployment", "argocd-server", "-n", "argocd",
 		"-o", "jsonpath={.spec.template.spec.containers[0].image}")
 	output, err := cmd.Output()
 	if err != nil {
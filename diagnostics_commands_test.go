@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	orig := reportOut
+	reportOut = &buf
+	t.Cleanup(func() { reportOut = orig })
+
+	t.Run("finishes in time", func(t *testing.T) {
+		buf.Reset()
+		reportOut = &buf
+		want := errors.New("diagnostics failed")
+		err := runWithTimeout(context.Background(), time.Second, func(ctx context.Context, begin func(string)) error {
+			begin("1️⃣  Checking Docker availability...")
+			return want
+		})
+		if err != want {
+			t.Errorf("expected the run's error, got %v", err)
+		}
+	})
+
+	t.Run("check ignores its context", func(t *testing.T) {
+		buf.Reset()
+		reportOut = &buf
+		release := make(chan struct{})
+		defer close(release)
+
+		err := runWithTimeout(context.Background(), 10*time.Millisecond, func(ctx context.Context, begin func(string)) error {
+			begin("1️⃣  Checking Docker availability...")
+			begin("2️⃣  Checking kinder network...")
+			<-release
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), "while checking kinder network") {
+			t.Errorf("expected timeout naming the hung check, got %v", err)
+		}
+		if !strings.Contains(buf.String(), "timed out after 10ms while checking kinder network") {
+			t.Errorf("expected timeout in report, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("abandoned check output is dropped", func(t *testing.T) {
+		buf.Reset()
+		reportOut = &buf
+		release := make(chan struct{})
+		finished := make(chan struct{})
+
+		err := runWithTimeout(context.Background(), 10*time.Millisecond, func(ctx context.Context, begin func(string)) error {
+			defer close(finished)
+			begin("4️⃣  Checking kinder network...")
+			<-release
+			fmt.Fprintln(reportOut, "   ✅ Network exists")
+			return nil
+		})
+		if err == nil {
+			t.Fatal("expected timeout")
+		}
+		close(release)
+		<-finished
+		if strings.Contains(buf.String(), "Network exists") {
+			t.Errorf("expected output after the timeout to be dropped, got:\n%s", buf.String())
+		}
+	})
+
+	t.Run("checks fail once the deadline passes", func(t *testing.T) {
+		buf.Reset()
+		reportOut = &buf
+		finished := make(chan struct{})
+		err := runWithTimeout(context.Background(), 10*time.Millisecond, func(ctx context.Context, begin func(string)) error {
+			defer close(finished)
+			begin("5️⃣  Checking running containers...")
+			<-ctx.Done()
+			begin("6️⃣  Checking service endpoints...")
+			return ctx.Err()
+		})
+		<-finished
+		if err == nil || !strings.Contains(err.Error(), "while checking running containers") {
+			t.Errorf("expected timeout naming the check that ran out of time, got %v", err)
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		buf.Reset()
+		reportOut = &buf
+		err := runWithTimeout(context.Background(), 0, func(ctx context.Context, begin func(string)) error {
+			if _, ok := ctx.Deadline(); ok {
+				t.Error("expected no deadline")
+			}
+			begin("1️⃣  Checking Docker availability...")
+			return nil
+		})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
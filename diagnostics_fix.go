@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"codeberg.org/hipkoi/kinder/cacert"
+	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"codeberg.org/hipkoi/kinder/kubernetes"
+)
+
+// diagnosticFix remediates a failed diagnostics check
+type diagnosticFix struct {
+	// description says what the fix does, e.g. "Create network 'kinder'"
+	description string
+	// apply performs the fix
+	apply func(ctx context.Context) error
+	// recheck re-runs the failed check to confirm the fix worked
+	recheck func(ctx context.Context) error
+}
+
+// runFix applies a fix after confirmation and re-runs its check, reporting
+// whether the check now passes. Without --yes the user is asked on a
+// terminal; otherwise the fix is only described.
+func runFix(ctx context.Context, fix diagnosticFix) bool {
+	if !diagnosticsYes {
+		if !isTerminal(os.Stdin) {
+			fmt.Fprintf(reportOut, "   🔧 Fixable: %s (rerun with --yes to apply)\n", fix.description)
+			return false
+		}
+		if !confirm(os.Stdin, fmt.Sprintf("   🔧 %s?", fix.description)) {
+			return false
+		}
+	}
+
+	fmt.Fprintf(reportOut, "   🔧 %s...\n", fix.description)
+	if err := fix.apply(ctx); err != nil {
+		fmt.Fprintf(reportOut, "   ❌ Fix failed: %v\n", err)
+		return false
+	}
+	if err := fix.recheck(ctx); err != nil {
+		fmt.Fprintf(reportOut, "   ❌ Still failing after fix: %v\n", err)
+		return false
+	}
+	fmt.Fprintln(reportOut, "   ✅ Fixed")
+	return true
+}
+
+// confirm asks a yes/no question, defaulting to no
+func confirm(in io.Reader, question string) bool {
+	fmt.Fprintf(reportOut, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// generateCAFix generates a missing CA certificate and key
+func generateCAFix(caCertPath string) diagnosticFix {
+	return diagnosticFix{
+		description: "Generate the CA certificate",
+		apply: func(ctx context.Context) error {
+			domain := traefikDomain
+			if domain == "" {
+				domain = docker.DefaultTraefikDomain
+			}
+			caKeyPath, err := config.CAKeyPath()
+			if err != nil {
+				return err
+			}
+			for _, dir := range []string{filepath.Dir(caCertPath), filepath.Dir(caKeyPath)} {
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					return fmt.Errorf("failed to create directory %s: %w", dir, err)
+				}
+			}
+			return cacert.GenerateCAWithOptions(caCertPath, caKeyPath, domain, caOptions())
+		},
+		recheck: func(ctx context.Context) error {
+			return checkCACertificate(caCertPath)
+		},
+	}
+}
+
+// createNetworkFix creates the missing kinder network
+func createNetworkFix() diagnosticFix {
+	return diagnosticFix{
+		description: fmt.Sprintf("Create network '%s'", networkName),
+		apply: func(ctx context.Context) error {
+			if err := resolveNetworkCIDR(ctx); err != nil {
+				return err
+			}
+			if _, err := docker.CreateNetwork(ctx, kinderNetworkConfig()); err != nil {
+				return fmt.Errorf("failed to create network: %w", err)
+			}
+			return nil
+		},
+		recheck: checkKinderNetwork,
+	}
+}
+
+// startContainerFix starts a stopped service container, or creates it if it
+// is missing
+func startContainerFix(label, containerName string, start func(context.Context) error) diagnosticFix {
+	return diagnosticFix{
+		description: fmt.Sprintf("Start %s (%s)", label, containerName),
+		apply:       start,
+		recheck: func(ctx context.Context) error {
+			return checkContainerRunning(ctx, containerName)
+		},
+	}
+}
+
+// pushTrustBundleFix re-pushes the trust bundle to the local registry
+func pushTrustBundleFix(caCertPath string) diagnosticFix {
+	return diagnosticFix{
+		description: "Push the trust bundle",
+		apply: func(ctx context.Context) error {
+			if _, err := pushTrustBundle(ctx, caCertPath); err != nil {
+				return fmt.Errorf("failed to push trust bundle: %w", err)
+			}
+			return nil
+		},
+		recheck: checkTrustBundle,
+	}
+}
+
+// checkTrustBundle checks that the trust bundle is in the local registry
+func checkTrustBundle(ctx context.Context) error {
+	if _, err := kubernetes.GetTrustBundleDigest(ctx, localRegistry()); err != nil {
+		return fmt.Errorf("trust bundle not found in registry: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConfirm(t *testing.T) {
+	tests := map[string]bool{
+		"y\n":   true,
+		"YES\n": true,
+		"n\n":   false,
+		"\n":    false,
+		"":      false,
+	}
+	for input, expected := range tests {
+		if got := confirm(strings.NewReader(input), "Fix?"); got != expected {
+			t.Errorf("confirm(%q) = %v, want %v", input, got, expected)
+		}
+	}
+}
+
+func TestRunFix(t *testing.T) {
+	diagnosticsYes = true
+	t.Cleanup(func() { diagnosticsYes = false })
+
+	fixed := false
+	fix := diagnosticFix{
+		description: "Fix the thing",
+		apply:       func(ctx context.Context) error { fixed = true; return nil },
+		recheck: func(ctx context.Context) error {
+			if !fixed {
+				return errors.New("still broken")
+			}
+			return nil
+		},
+	}
+	if !runFix(context.Background(), fix) {
+		t.Error("expected fix to succeed")
+	}
+
+	fix.apply = func(ctx context.Context) error { return nil }
+	fixed = false
+	if runFix(context.Background(), fix) {
+		t.Error("expected fix to fail when the recheck still fails")
+	}
+}
@@ -0,0 +1,129 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	cliconfig "github.com/docker/cli/cli/config"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// dockerHubAuthKey is the key Docker Hub credentials are stored under in
+// ~/.docker/config.json
+const dockerHubAuthKey = "https://index.docker.io/v1/"
+
+// registryCredential is a username and password for images under a prefix
+type registryCredential struct {
+	prefix   string
+	username string
+	password string
+}
+
+var (
+	registryCredentials []registryCredential
+	registryCredMu      sync.Mutex
+)
+
+// SetRegistryAuth sets credentials for images under a prefix of whole path
+// segments, such as "registry.example.com/mirror". Values are
+// "username:password". They take precedence over ~/.docker/config.json; the
+// longest matching prefix wins.
+func SetRegistryAuth(creds map[string]string) error {
+	parsed := make([]registryCredential, 0, len(creds))
+	for prefix, value := range creds {
+		username, password, ok := strings.Cut(value, ":")
+		if !ok || username == "" {
+			return fmt.Errorf("credentials for %s must be username:password", prefix)
+		}
+		parsed = append(parsed, registryCredential{prefix: prefix, username: username, password: password})
+	}
+	sort.Slice(parsed, func(i, j int) bool {
+		return len(parsed[i].prefix) > len(parsed[j].prefix)
+	})
+
+	registryCredMu.Lock()
+	defer registryCredMu.Unlock()
+	registryCredentials = parsed
+	return nil
+}
+
+// registryAuth returns the encoded RegistryAuth for pulling ref: a matching
+// SetRegistryAuth credential, else the Docker CLI's stored credentials for
+// the image's registry. It returns "" for anonymous pulls, including when
+// the Docker CLI's credentials can't be read.
+func registryAuth(ref string) (string, error) {
+	auth, err := lookupRegistryAuth(ref)
+	if err != nil || auth == (registry.AuthConfig{}) {
+		return "", err
+	}
+	encoded, err := registry.EncodeAuthConfig(auth)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode registry credentials: %w", err)
+	}
+	return encoded, nil
+}
+
+func lookupRegistryAuth(ref string) (registry.AuthConfig, error) {
+	parsed, err := name.ParseReference(ref)
+	if err != nil {
+		return registry.AuthConfig{}, fmt.Errorf("invalid image reference %q: %w", ref, err)
+	}
+	host := parsed.Context().RegistryStr()
+
+	registryCredMu.Lock()
+	creds := registryCredentials
+	registryCredMu.Unlock()
+	for _, c := range creds {
+		// Match what the user wrote as well as the normalised name, so
+		// "docker.io/library" and "index.docker.io/library" both work
+		if hasRefPrefix(ref, c.prefix) || hasRefPrefix(parsed.Name(), c.prefix) {
+			return registry.AuthConfig{Username: c.username, Password: c.password, ServerAddress: host}, nil
+		}
+	}
+
+	// A broken Docker config or credential helper shouldn't stop public
+	// images from being pulled, so fall back to an anonymous pull
+	cf, err := cliconfig.Load(cliconfig.Dir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load Docker config, pulling %s anonymously: %v\n", ref, err)
+		return registry.AuthConfig{}, nil
+	}
+	key := host
+	if host == name.DefaultRegistry {
+		key = dockerHubAuthKey
+	}
+	stored, err := cf.GetAuthConfig(key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get credentials for %s, pulling %s anonymously: %v\n", host, ref, err)
+		return registry.AuthConfig{}, nil
+	}
+	if stored.Username == "" && stored.Password == "" && stored.IdentityToken == "" && stored.RegistryToken == "" {
+		return registry.AuthConfig{}, nil
+	}
+	return registry.AuthConfig{
+		Username:      stored.Username,
+		Password:      stored.Password,
+		ServerAddress: host,
+		IdentityToken: stored.IdentityToken,
+		RegistryToken: stored.RegistryToken,
+	}, nil
+}
+
+// hasRefPrefix reports whether prefix names ref or a path above it, so
+// "ghcr.io/foo" matches "ghcr.io/foo/app" and "ghcr.io/foo:v1" but not
+// "ghcr.io/foobar"
+func hasRefPrefix(ref, prefix string) bool {
+	if !strings.HasPrefix(ref, prefix) {
+		return false
+	}
+	rest := ref[len(prefix):]
+	if rest == "" || strings.HasSuffix(prefix, "/") || rest[0] == '/' {
+		return true
+	}
+	// A tag or digest only follows the last path segment
+	return (rest[0] == ':' || rest[0] == '@') && !strings.Contains(rest, "/")
+}
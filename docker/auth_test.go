@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cliconfig "github.com/docker/cli/cli/config"
+)
+
+func TestLookupRegistryAuth(t *testing.T) {
+	dir := t.TempDir()
+	hubAuth := base64.StdEncoding.EncodeToString([]byte("hubuser:hubpass"))
+	configJSON := `{"auths":{"https://index.docker.io/v1/":{"auth":"` + hubAuth + `"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(configJSON), 0600); err != nil {
+		t.Fatalf("failed to write docker config: %v", err)
+	}
+	cliconfig.SetDir(dir)
+
+	if err := SetRegistryAuth(map[string]string{
+		"registry.example.com":        "team:teampass",
+		"registry.example.com/mirror": "mirror:mirrorpass",
+	}); err != nil {
+		t.Fatalf("SetRegistryAuth failed: %v", err)
+	}
+	t.Cleanup(func() { _ = SetRegistryAuth(nil) })
+
+	tests := []struct {
+		ref      string
+		username string
+		server   string
+	}{
+		{"registry.example.com/mirror/traefik:v3", "mirror", "registry.example.com"},
+		{"registry.example.com/other/zot:v2", "team", "registry.example.com"},
+		{"traefik:v3", "hubuser", "index.docker.io"},
+		{"ghcr.io/project-zot/zot:v2", "", ""},
+		{"registry.example.com.evil.io/app:v1", "", ""},
+		{"registry.example.com/mirrors/app:v1", "team", "registry.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			auth, err := lookupRegistryAuth(tt.ref)
+			if err != nil {
+				t.Fatalf("lookupRegistryAuth failed: %v", err)
+			}
+			if auth.Username != tt.username || auth.ServerAddress != tt.server {
+				t.Errorf("expected %s at %s, got %s at %s", tt.username, tt.server, auth.Username, auth.ServerAddress)
+			}
+		})
+	}
+
+	if err := SetRegistryAuth(map[string]string{"registry.example.com": "nopassword"}); err == nil {
+		t.Error("expected error for credentials without a password separator")
+	}
+}
+
+func TestHasRefPrefix(t *testing.T) {
+	tests := []struct {
+		ref    string
+		prefix string
+		want   bool
+	}{
+		{"ghcr.io/foo/app:v1", "ghcr.io/foo", true},
+		{"ghcr.io/foo:v1", "ghcr.io/foo", true},
+		{"ghcr.io/foo@sha256:abc", "ghcr.io/foo", true},
+		{"ghcr.io/foo", "ghcr.io/foo", true},
+		{"ghcr.io/foo/app", "ghcr.io/", true},
+		{"ghcr.io/foobar/app", "ghcr.io/foo", false},
+		{"localhost:5000/app", "localhost", false},
+		{"quay.io/foo/app", "ghcr.io/foo", false},
+	}
+	for _, tt := range tests {
+		if got := hasRefPrefix(tt.ref, tt.prefix); got != tt.want {
+			t.Errorf("hasRefPrefix(%q, %q) = %v, want %v", tt.ref, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestLookupRegistryAuth_BrokenDockerConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"malformed config", `{"auths":`},
+		{"failing credential helper", `{"credsStore":"kinder-test-missing"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(tt.config), 0600); err != nil {
+				t.Fatalf("failed to write docker config: %v", err)
+			}
+			cliconfig.SetDir(dir)
+
+			auth, err := lookupRegistryAuth("ghcr.io/project-zot/zot:v2")
+			if err != nil {
+				t.Fatalf("expected fallback to an anonymous pull, got %v", err)
+			}
+			if auth.Username != "" {
+				t.Errorf("expected no credentials, got %s", auth.Username)
+			}
+		})
+	}
+}
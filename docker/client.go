@@ -108,6 +108,16 @@ func (c *Client) Ping(ctx context.Context) error {
 	return err
 }
 
+// Architecture returns the Docker daemon's architecture as reported by
+// "docker info", such as "x86_64" or "aarch64"
+func (c *Client) Architecture(ctx context.Context) (string, error) {
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get Docker info: %w", err)
+	}
+	return info.Architecture, nil
+}
+
 // Raw returns the underlying Docker client for advanced operations.
 // Use sparingly - prefer adding methods to Client instead.
 func (c *Client) Raw() *client.Client {
@@ -1,15 +1,18 @@
 package docker
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
@@ -23,6 +26,7 @@ type ContainerConfig struct {
 	// Network configuration
 	NetworkName    string
 	NetworkAliases []string
+	IPAddress      string // Static IPv4 address on the network ("" for one assigned by Docker)
 
 	// Environment variables
 	Env []string
@@ -72,15 +76,9 @@ func CreateContainer(ctx context.Context, config ContainerConfig) (string, error
 		return inspect.ID, nil
 	}
 
-	// Pull the image
-	reader, err := cli.ImagePull(ctx, config.Image, image.PullOptions{})
-	if err != nil {
-		return "", fmt.Errorf("failed to pull image: %w", err)
-	}
-	defer reader.Close()
-	// Consume the pull output and check for errors
-	if _, err := io.Copy(io.Discard, reader); err != nil {
-		return "", fmt.Errorf("failed to complete image pull: %w", err)
+	// Pull the image unless it was already pulled, e.g. by a pre-pull
+	if _, err := EnsureImage(ctx, config.Image, nil); err != nil {
+		return "", err
 	}
 
 	// Create container configuration
@@ -111,11 +109,15 @@ func CreateContainer(ctx context.Context, config ContainerConfig) (string, error
 	// Create network configuration
 	var networkConfig *network.NetworkingConfig
 	if config.NetworkName != "" {
+		endpoint := &network.EndpointSettings{
+			Aliases: config.NetworkAliases,
+		}
+		if config.IPAddress != "" {
+			endpoint.IPAMConfig = &network.EndpointIPAMConfig{IPv4Address: config.IPAddress}
+		}
 		networkConfig = &network.NetworkingConfig{
 			EndpointsConfig: map[string]*network.EndpointSettings{
-				config.NetworkName: {
-					Aliases: config.NetworkAliases,
-				},
+				config.NetworkName: endpoint,
 			},
 		}
 	}
@@ -193,6 +195,62 @@ func ContainerExists(ctx context.Context, containerName string) (bool, error) {
 	return false, nil
 }
 
+// ContainerRunning checks if a container exists and is running
+func ContainerRunning(ctx context.Context, containerName string) (bool, error) {
+	c, err := GetSharedClient()
+	if err != nil {
+		return false, err
+	}
+
+	inspect, err := c.Raw().ContainerInspect(ctx, containerName)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	return inspect.State != nil && inspect.State.Running, nil
+}
+
+// ExecInContainer runs a command in a running container and returns its
+// combined output. A non-zero exit code is returned as an error.
+func ExecInContainer(ctx context.Context, containerName string, cmd []string) (string, error) {
+	c, err := GetSharedClient()
+	if err != nil {
+		return "", err
+	}
+	cli := c.Raw()
+
+	exec, err := cli.ContainerExecCreate(ctx, containerName, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec: %w", err)
+	}
+
+	resp, err := cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach to exec: %w", err)
+	}
+	defer resp.Close()
+
+	var out bytes.Buffer
+	if _, err := stdcopy.StdCopy(&out, &out, resp.Reader); err != nil {
+		return "", fmt.Errorf("failed to read exec output: %w", err)
+	}
+
+	inspect, err := cli.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect exec: %w", err)
+	}
+	if inspect.ExitCode != 0 {
+		return out.String(), fmt.Errorf("%s exited with code %d: %s", cmd[0], inspect.ExitCode, strings.TrimSpace(out.String()))
+	}
+	return out.String(), nil
+}
+
 // CopyFile copies a file from src to dst
 func CopyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
@@ -3,12 +3,15 @@ package docker
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/go-connections/nat"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -27,6 +30,21 @@ type GatusConfig struct {
 	NetworkName   string
 	DataDir       string
 	Image         string
+	CACertPath    string          // CA certificate to trust (default: ca.crt in DataDir)
+	IPAddress     string          // Static IPv4 address on the network ("" for one assigned by Docker)
+	Domain        string          // Base domain of the Traefik routes to monitor ("" to skip them)
+	Dashboard     bool            // Whether the Traefik dashboard route is monitored
+	ClusterName   string          // Kind cluster whose API server is monitored ("" to skip it)
+	StepCAURL     string          // Step CA to monitor (default: the local container)
+	Extra         []GatusEndpoint // Additional endpoints to monitor
+	Alerting      map[string]any  // Alerting providers, written as-is to the config (nil for none)
+	AlertWebhook  string          // URL to POST alerts to via Gatus' custom provider
+}
+
+// GatusEndpoint is an additional URL for Gatus to monitor
+type GatusEndpoint struct {
+	Name string
+	URL  string
 }
 
 // CreateGatusContainer creates and starts a Gatus health dashboard container
@@ -39,12 +57,15 @@ func CreateGatusContainer(ctx context.Context, config GatusConfig) (string, erro
 
 	// Generate Gatus config
 	configPath := filepath.Join(gatusDir, "config.yaml")
-	if err := generateGatusConfig(configPath); err != nil {
+	if err := generateGatusConfig(configPath, config); err != nil {
 		return "", fmt.Errorf("failed to generate Gatus config: %w", err)
 	}
 
 	// Path to CA certificate
-	caCertPath := filepath.Join(config.DataDir, "ca.crt")
+	caCertPath := config.CACertPath
+	if caCertPath == "" {
+		caCertPath = filepath.Join(config.DataDir, "ca.crt")
+	}
 
 	// Build generic container configuration
 	containerConfig := ContainerConfig{
@@ -53,6 +74,7 @@ func CreateGatusContainer(ctx context.Context, config GatusConfig) (string, erro
 		Hostname:       config.Hostname,
 		NetworkName:    config.NetworkName,
 		NetworkAliases: []string{config.Hostname},
+		IPAddress:      config.IPAddress,
 		ExposedPorts: nat.PortSet{
 			"8080/tcp": struct{}{},
 		},
@@ -87,35 +109,135 @@ func RemoveGatusContainer(ctx context.Context, containerName string) error {
 	return RemoveContainer(ctx, containerName)
 }
 
-// generateGatusConfig creates a configuration file for Gatus
-func generateGatusConfig(path string) error {
-	config := `# Gatus configuration for kinder
-endpoints:
-  - name: Step CA
-    url: "https://stepca:9000/health"
-    interval: 30s
-    conditions:
-      - "[STATUS] == 200"
-
-  - name: Zot Registry
-    url: "http://zot:5000/v2/"
-    interval: 30s
-    conditions:
-      - "[STATUS] == 200"
-
-  - name: Kubernetes API
-    url: "https://kinder-control-plane:6443/livez"
-    interval: 30s
-    client:
-      insecure: true
-    conditions:
-      - "[STATUS] == 200"
-
-web:
-  port: 8080
-`
-
-	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+// gatusFile is the Gatus configuration file
+type gatusFile struct {
+	Alerting  map[string]any  `yaml:"alerting,omitempty"`
+	Endpoints []gatusEndpoint `yaml:"endpoints"`
+	Web       struct {
+		Port int `yaml:"port"`
+	} `yaml:"web"`
+}
+
+type gatusEndpoint struct {
+	Name       string       `yaml:"name"`
+	Group      string       `yaml:"group,omitempty"`
+	URL        string       `yaml:"url"`
+	Interval   string       `yaml:"interval"`
+	Client     *gatusClient `yaml:"client,omitempty"`
+	Conditions []string     `yaml:"conditions"`
+	Alerts     []gatusAlert `yaml:"alerts,omitempty"`
+}
+
+type gatusClient struct {
+	Insecure bool `yaml:"insecure"`
+}
+
+type gatusAlert struct {
+	Type           string `yaml:"type"`
+	SendOnResolved bool   `yaml:"send-on-resolved"`
+}
+
+// gatusAlerting returns the alerting providers for the config: the configured
+// providers plus a custom provider posting to AlertWebhook, which replaces any
+// configured custom provider
+func gatusAlerting(config GatusConfig) map[string]any {
+	if len(config.Alerting) == 0 && config.AlertWebhook == "" {
+		return nil
+	}
+
+	alerting := maps.Clone(config.Alerting)
+	if alerting == nil {
+		alerting = make(map[string]any)
+	}
+	if config.AlertWebhook != "" {
+		alerting["custom"] = map[string]any{
+			"url":    config.AlertWebhook,
+			"method": "POST",
+			"headers": map[string]string{
+				"Content-Type": "application/json",
+			},
+			"body": `{"text": "[ALERT_TRIGGERED_OR_RESOLVED]: [ENDPOINT_GROUP]/[ENDPOINT_NAME] - [ALERT_DESCRIPTION]"}`,
+		}
+	}
+	return alerting
+}
+
+// gatusEndpoints returns the endpoints Gatus monitors: the kinder services
+// directly on the network, the Kind API server, the Traefik routes shown by
+// "kinder start" (verified against the kinder CA) and any extra endpoints
+func gatusEndpoints(config GatusConfig) []gatusEndpoint {
+	endpoint := func(group, name, url string, conditions ...string) gatusEndpoint {
+		return gatusEndpoint{
+			Name:       name,
+			Group:      group,
+			URL:        url,
+			Interval:   "30s",
+			Conditions: append([]string{"[STATUS] == 200"}, conditions...),
+		}
+	}
+
+	stepCAURL := config.StepCAURL
+	if stepCAURL == "" {
+		stepCAURL = "https://stepca:9000"
+	}
+	endpoints := []gatusEndpoint{
+		endpoint("services", "Step CA", stepCAURL+"/health"),
+		endpoint("services", "Zot Registry", "http://zot:5000/v2/"),
+	}
+
+	if config.ClusterName != "" {
+		kubeAPI := endpoint("services", "Kubernetes API", fmt.Sprintf("https://%s-control-plane:6443/livez", config.ClusterName))
+		kubeAPI.Client = &gatusClient{Insecure: true}
+		endpoints = append(endpoints, kubeAPI)
+	}
+
+	if config.Domain != "" {
+		// Traefik answers for these names on the network, so the checks use
+		// its internal HTTPS port and verify the certificates it serves
+		const certValid = "[CERTIFICATE_EXPIRATION] > 24h"
+		for _, route := range []struct{ name, host, path string }{
+			{"Traefik", "traefik", "/dashboard/"},
+			{"Step CA", "ca", "/health"},
+			{"Registry", "registry", "/v2/"},
+			{"Gatus", "gatus", "/health"},
+		} {
+			if route.host == "traefik" && !config.Dashboard {
+				continue
+			}
+			url := fmt.Sprintf("https://%s.%s%s", route.host, config.Domain, route.path)
+			endpoints = append(endpoints, endpoint("routes", route.name, url, certValid))
+		}
+	}
+
+	for _, extra := range config.Extra {
+		endpoints = append(endpoints, endpoint("extra", extra.Name, extra.URL))
+	}
+
+	return endpoints
+}
+
+// generateGatusConfig creates a configuration file for Gatus. Every endpoint
+// alerts through each configured provider.
+func generateGatusConfig(path string, config GatusConfig) error {
+	file := gatusFile{
+		Alerting:  gatusAlerting(config),
+		Endpoints: gatusEndpoints(config),
+	}
+	file.Web.Port = 8080
+
+	for _, provider := range slices.Sorted(maps.Keys(file.Alerting)) {
+		for i := range file.Endpoints {
+			file.Endpoints[i].Alerts = append(file.Endpoints[i].Alerts, gatusAlert{Type: provider, SendOnResolved: true})
+		}
+	}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode Gatus config: %w", err)
+	}
+	data = append([]byte("# Gatus configuration for kinder, generated on start\n"), data...)
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write Gatus config: %w", err)
 	}
 
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestGatusConfig(t *testing.T) {
@@ -52,50 +54,144 @@ func TestGenerateGatusConfig(t *testing.T) {
 
 	configPath := filepath.Join(tmpDir, "config.yaml")
 
-	err = generateGatusConfig(configPath)
+	err = generateGatusConfig(configPath, GatusConfig{
+		Domain:      "dev.test",
+		Dashboard:   true,
+		ClusterName: "kinder",
+		Extra:       []GatusEndpoint{{Name: "My App", URL: "https://app.dev.test/healthz"}},
+	})
 	if err != nil {
 		t.Fatalf("generateGatusConfig failed: %v", err)
 	}
 
-	// Verify file was created
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		t.Error("config.yaml was not created")
-	}
-
 	// Read and verify content
 	content, err := os.ReadFile(configPath)
 	if err != nil {
 		t.Fatalf("failed to read config.yaml: %v", err)
 	}
 
-	contentStr := string(content)
+	var file gatusFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		t.Fatalf("config.yaml is not valid YAML: %v", err)
+	}
+	if file.Web.Port != 8080 {
+		t.Errorf("expected web port 8080, got %d", file.Web.Port)
+	}
+
+	urls := make(map[string]gatusEndpoint)
+	for _, e := range file.Endpoints {
+		urls[e.URL] = e
+	}
 
-	// Check for expected content
-	expectedStrings := []string{
-		"endpoints:",
-		"Step CA",
-		"https://stepca:9000",
-		"Zot Registry",
-		"http://zot:5000",
-		"Kubernetes API",
+	expected := []string{
+		"https://stepca:9000/health",
+		"http://zot:5000/v2/",
 		"https://kinder-control-plane:6443/livez",
-		"insecure: true",
-		"interval: 30s",
-		"conditions:",
-		"web:",
-		"port: 8080",
+		"https://traefik.dev.test/dashboard/",
+		"https://ca.dev.test/health",
+		"https://registry.dev.test/v2/",
+		"https://gatus.dev.test/health",
+		"https://app.dev.test/healthz",
 	}
+	if len(file.Endpoints) != len(expected) {
+		t.Errorf("expected %d endpoints, got %d", len(expected), len(file.Endpoints))
+	}
+	for _, url := range expected {
+		if _, ok := urls[url]; !ok {
+			t.Errorf("config.yaml does not monitor %s", url)
+		}
+	}
+
+	if e := urls["https://kinder-control-plane:6443/livez"]; e.Client == nil || !e.Client.Insecure {
+		t.Error("expected the Kubernetes API check to skip TLS verification")
+	}
+	route := urls["https://registry.dev.test/v2/"]
+	if route.Client != nil || !strings.Contains(strings.Join(route.Conditions, " "), "[CERTIFICATE_EXPIRATION]") {
+		t.Errorf("expected routed checks to verify the certificate, got %+v", route)
+	}
+}
 
-	for _, expected := range expectedStrings {
-		if !strings.Contains(contentStr, expected) {
-			t.Errorf("config.yaml does not contain expected string: %s", expected)
+func TestGenerateGatusConfig_Minimal(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := generateGatusConfig(configPath, GatusConfig{StepCAURL: "https://ca.example.com"})
+	if err != nil {
+		t.Fatalf("generateGatusConfig failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config.yaml: %v", err)
+	}
+	for _, unexpected := range []string{"stepca:9000", "control-plane", "dashboard"} {
+		if strings.Contains(string(content), unexpected) {
+			t.Errorf("expected no %q endpoint without a cluster or domain:\n%s", unexpected, content)
 		}
 	}
+	if !strings.Contains(string(content), "https://ca.example.com/health") {
+		t.Errorf("expected external Step CA to be monitored:\n%s", content)
+	}
 }
 
 func TestGenerateGatusConfig_InvalidPath(t *testing.T) {
-	err := generateGatusConfig("/nonexistent/path/config.yaml")
+	err := generateGatusConfig("/nonexistent/path/config.yaml", GatusConfig{})
 	if err == nil {
 		t.Error("expected error when writing to invalid path")
 	}
 }
+
+func TestGenerateGatusConfig_Alerting(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := generateGatusConfig(configPath, GatusConfig{
+		Alerting: map[string]any{
+			"slack":  map[string]any{"webhook-url": "https://hooks.slack.com/services/x"},
+			"custom": map[string]any{"url": "https://replaced.example.com"},
+		},
+		AlertWebhook: "https://alerts.example.com/hook",
+	})
+	if err != nil {
+		t.Fatalf("generateGatusConfig failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config.yaml: %v", err)
+	}
+
+	var file gatusFile
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		t.Fatalf("config.yaml is not valid YAML: %v", err)
+	}
+
+	slack, _ := file.Alerting["slack"].(map[string]any)
+	if slack["webhook-url"] != "https://hooks.slack.com/services/x" {
+		t.Errorf("expected slack provider to be passed through, got %v", file.Alerting["slack"])
+	}
+	custom, _ := file.Alerting["custom"].(map[string]any)
+	if custom["url"] != "https://alerts.example.com/hook" {
+		t.Errorf("expected webhook to replace the custom provider, got %v", file.Alerting["custom"])
+	}
+
+	for _, e := range file.Endpoints {
+		if len(e.Alerts) != 2 || e.Alerts[0].Type != "custom" || e.Alerts[1].Type != "slack" {
+			t.Errorf("expected %s to alert through custom and slack, got %+v", e.Name, e.Alerts)
+		}
+	}
+}
+
+func TestGenerateGatusConfig_NoAlerting(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := generateGatusConfig(configPath, GatusConfig{}); err != nil {
+		t.Fatalf("generateGatusConfig failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read config.yaml: %v", err)
+	}
+	if strings.Contains(string(content), "alert") {
+		t.Errorf("expected no alerting without providers:\n%s", content)
+	}
+}
@@ -0,0 +1,189 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+)
+
+// PullPolicy controls when service images are pulled
+type PullPolicy string
+
+const (
+	// PullAlways pulls every image, picking up new versions of moving tags
+	PullAlways PullPolicy = "always"
+	// PullMissing pulls only images not already present locally
+	PullMissing PullPolicy = "missing"
+	// PullNever never pulls and fails if an image is not present locally
+	PullNever PullPolicy = "never"
+)
+
+// PullPolicies lists the valid pull policies
+var PullPolicies = []PullPolicy{PullAlways, PullMissing, PullNever}
+
+var (
+	pullPolicy = PullMissing
+	// pulledImages records the images pulled by this process, so PullAlways
+	// doesn't pull an image again after a pre-pull
+	pulledImages = map[string]bool{}
+	pullMu       sync.Mutex
+)
+
+// SetPullPolicy sets when EnsureImage pulls images, e.g. as containers are
+// created. The default is PullMissing.
+func SetPullPolicy(policy PullPolicy) {
+	pullMu.Lock()
+	defer pullMu.Unlock()
+	pullPolicy = policy
+}
+
+// PullProgress is the combined download progress of an image's layers
+type PullProgress struct {
+	// Current is the number of bytes downloaded so far
+	Current int64
+	// Total is the size of the layers whose size is known yet
+	Total int64
+}
+
+// pullMessage is one line of the JSON stream returned by ImagePull
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error       string `json:"error"`
+	ErrorDetail struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// ImageExists reports whether an image is present locally
+func ImageExists(ctx context.Context, ref string) (bool, error) {
+	c, err := GetSharedClient()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := c.Raw().ImageInspect(ctx, ref); err != nil {
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to inspect image: %w", err)
+	}
+	return true, nil
+}
+
+// PullImage pulls an image, calling onProgress (if not nil) as layers
+// download
+func PullImage(ctx context.Context, ref string, onProgress func(PullProgress)) error {
+	c, err := GetSharedClient()
+	if err != nil {
+		return err
+	}
+
+	auth, err := registryAuth(ref)
+	if err != nil {
+		return err
+	}
+
+	reader, err := c.Raw().ImagePull(ctx, ref, image.PullOptions{RegistryAuth: auth})
+	if err != nil {
+		return fmt.Errorf("failed to pull image: %w", err)
+	}
+	defer reader.Close()
+
+	if err := readPullStream(reader, onProgress); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", ref, err)
+	}
+
+	pullMu.Lock()
+	defer pullMu.Unlock()
+	pulledImages[ref] = true
+	return nil
+}
+
+// EnsureImage makes an image available locally according to the pull policy
+// set with SetPullPolicy, reporting whether it was pulled. PullAlways skips
+// images this process has already pulled; PullNever fails if the image is
+// missing.
+func EnsureImage(ctx context.Context, ref string, onProgress func(PullProgress)) (bool, error) {
+	pullMu.Lock()
+	policy, pulled := pullPolicy, pulledImages[ref]
+	pullMu.Unlock()
+
+	exists, err := ImageExists(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	if policy == PullNever {
+		if !exists {
+			return false, fmt.Errorf("image %s is not present locally and the pull policy is never", ref)
+		}
+		return false, nil
+	}
+	if exists && (policy == PullMissing || pulled) {
+		return false, nil
+	}
+	if err := PullImage(ctx, ref, onProgress); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// readPullStream consumes an ImagePull JSON stream, summing the progress of
+// each layer. Errors reported in the stream are returned.
+func readPullStream(r io.Reader, onProgress func(PullProgress)) error {
+	type layer struct{ current, total int64 }
+	layers := map[string]*layer{}
+	var order []string
+
+	dec := json.NewDecoder(r)
+	for {
+		var msg pullMessage
+		if err := dec.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read pull output: %w", err)
+		}
+		if msg.Error != "" {
+			if msg.ErrorDetail.Message != "" {
+				return errors.New(msg.ErrorDetail.Message)
+			}
+			return errors.New(msg.Error)
+		}
+		if msg.ID == "" || onProgress == nil {
+			continue
+		}
+
+		l, ok := layers[msg.ID]
+		if !ok {
+			l = &layer{}
+			layers[msg.ID] = l
+			order = append(order, msg.ID)
+		}
+		switch msg.Status {
+		case "Downloading":
+			l.current, l.total = msg.ProgressDetail.Current, msg.ProgressDetail.Total
+		case "Download complete", "Pull complete", "Already exists":
+			l.current = l.total
+		default:
+			continue
+		}
+
+		var p PullProgress
+		for _, id := range order {
+			p.Current += layers[id].current
+			p.Total += layers[id].total
+		}
+		onProgress(p)
+	}
+}
@@ -0,0 +1,39 @@
+package docker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPullStream(t *testing.T) {
+	stream := `{"status":"Pulling from library/traefik","id":"v3"}
+{"status":"Pulling fs layer","id":"a"}
+{"status":"Pulling fs layer","id":"b"}
+{"status":"Downloading","progressDetail":{"current":50,"total":100},"id":"a"}
+{"status":"Downloading","progressDetail":{"current":100,"total":300},"id":"b"}
+{"status":"Download complete","id":"a"}
+{"status":"Pull complete","id":"a"}
+{"status":"Digest: sha256:abc"}
+`
+	var updates []PullProgress
+	if err := readPullStream(strings.NewReader(stream), func(p PullProgress) {
+		updates = append(updates, p)
+	}); err != nil {
+		t.Fatalf("readPullStream failed: %v", err)
+	}
+
+	expected := []PullProgress{{50, 100}, {150, 400}, {200, 400}, {200, 400}}
+	if len(updates) != len(expected) {
+		t.Fatalf("expected %d updates, got %d: %v", len(expected), len(updates), updates)
+	}
+	for i := range expected {
+		if updates[i] != expected[i] {
+			t.Errorf("update %d: expected %+v, got %+v", i, expected[i], updates[i])
+		}
+	}
+
+	err := readPullStream(strings.NewReader(`{"error":"manifest unknown","errorDetail":{"message":"manifest unknown: tag v9"}}`), nil)
+	if err == nil || err.Error() != "manifest unknown: tag v9" {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
@@ -1,9 +1,16 @@
 package docker
 
 import (
+	"bufio"
 	"context"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/docker/docker/api/types/network"
 )
@@ -13,14 +20,23 @@ const (
 	DefaultNetworkCIDR = "172.28.28.0/24"
 	// DefaultNetworkName is the name of the docker network
 	DefaultNetworkName = "kind"
+	// DefaultNetworkIPv6CIDR is the IPv6 subnet used when IPv6 is enabled
+	DefaultNetworkIPv6CIDR = "fd00:28:28::/64"
 )
 
+// privateRanges are searched in order for a free /24 by PickFreeCIDR
+var privateRanges = []string{"172.16.0.0/12", "192.168.0.0/16", "10.0.0.0/8"}
+
 // NetworkConfig holds configuration for creating a docker network
 type NetworkConfig struct {
 	Name       string
 	CIDR       string
 	Driver     string
 	BridgeName string
+	// EnableIPv6 adds IPv6CIDR (DefaultNetworkIPv6CIDR if empty) to the
+	// network alongside CIDR
+	EnableIPv6 bool
+	IPv6CIDR   string
 }
 
 // CreateNetwork creates a docker network with the specified configuration
@@ -51,13 +67,28 @@ func CreateNetwork(ctx context.Context, config NetworkConfig) (string, error) {
 
 	// Create network with restricted IP range for containers
 	// Container DHCP uses first half of subnet, second half reserved for MetalLB
-	ipamConfig := network.IPAMConfig{
+	ipamConfigs := []network.IPAMConfig{{
 		Subnet:  config.CIDR,
 		IPRange: ipRange,
 		Gateway: gateway,
-	}
+	}}
 
-	enableIPv6 := false
+	enableIPv6 := config.EnableIPv6
+	if enableIPv6 {
+		ipv6CIDR := config.IPv6CIDR
+		if ipv6CIDR == "" {
+			ipv6CIDR = DefaultNetworkIPv6CIDR
+		}
+		gateway, ipRange, err := deriveNetworkConfig(ipv6CIDR)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse network IPv6 CIDR: %w", err)
+		}
+		ipamConfigs = append(ipamConfigs, network.IPAMConfig{
+			Subnet:  ipv6CIDR,
+			IPRange: ipRange,
+			Gateway: gateway,
+		})
+	}
 	// Use bridge name from config, fallback to network name + "br0"
 	bridgeName := config.BridgeName
 	if bridgeName == "" {
@@ -67,7 +98,7 @@ func CreateNetwork(ctx context.Context, config NetworkConfig) (string, error) {
 	networkCreate := network.CreateOptions{
 		Driver: config.Driver,
 		IPAM: &network.IPAM{
-			Config: []network.IPAMConfig{ipamConfig},
+			Config: ipamConfigs,
 		},
 		EnableIPv6: &enableIPv6,
 		Options: map[string]string{
@@ -139,6 +170,225 @@ func GetNetworkID(ctx context.Context, name string) (string, error) {
 	return "", fmt.Errorf("network %s not found", name)
 }
 
+// NetworkDetails describes a network and the containers connected to it
+type NetworkDetails struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Driver     string             `json:"driver"`
+	Subnets    []SubnetDetails    `json:"subnets"`
+	Containers []NetworkContainer `json:"containers"`
+}
+
+// SubnetDetails is one IPAM subnet of a network
+type SubnetDetails struct {
+	Subnet  string `json:"subnet"`
+	Gateway string `json:"gateway,omitempty"`
+	IPRange string `json:"ipRange,omitempty"`
+}
+
+// NetworkContainer is a container's endpoint on a network
+type NetworkContainer struct {
+	Name        string   `json:"name"`
+	IPv4Address string   `json:"ipv4Address,omitempty"`
+	IPv6Address string   `json:"ipv6Address,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+}
+
+// InspectNetwork returns a network's subnets and connected containers,
+// sorted by name. Aliases come from inspecting each container, as the
+// network only lists their addresses.
+func InspectNetwork(ctx context.Context, name string) (*NetworkDetails, error) {
+	c, err := GetSharedClient()
+	if err != nil {
+		return nil, err
+	}
+	cli := c.Raw()
+
+	inspect, err := cli.NetworkInspect(ctx, name, network.InspectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect network: %w", err)
+	}
+
+	details := &NetworkDetails{
+		ID:         inspect.ID,
+		Name:       inspect.Name,
+		Driver:     inspect.Driver,
+		Subnets:    []SubnetDetails{},
+		Containers: []NetworkContainer{},
+	}
+	for _, cfg := range inspect.IPAM.Config {
+		details.Subnets = append(details.Subnets, SubnetDetails{
+			Subnet:  cfg.Subnet,
+			Gateway: cfg.Gateway,
+			IPRange: cfg.IPRange,
+		})
+	}
+
+	for id, endpoint := range inspect.Containers {
+		ctr := NetworkContainer{
+			Name:        endpoint.Name,
+			IPv4Address: endpoint.IPv4Address,
+			IPv6Address: endpoint.IPv6Address,
+		}
+		if info, err := cli.ContainerInspect(ctx, id); err == nil && info.NetworkSettings != nil {
+			if settings, ok := info.NetworkSettings.Networks[inspect.Name]; ok {
+				ctr.Aliases = networkAliases(settings.Aliases, settings.DNSNames, endpoint.Name, id)
+			}
+		}
+		details.Containers = append(details.Containers, ctr)
+	}
+	sort.Slice(details.Containers, func(i, j int) bool {
+		return details.Containers[i].Name < details.Containers[j].Name
+	})
+
+	return details, nil
+}
+
+// networkAliases merges a container's aliases and DNS names, leaving out
+// its own name and the container ID prefixes Docker adds
+func networkAliases(aliases, dnsNames []string, name, id string) []string {
+	var result []string
+	seen := map[string]bool{name: true}
+	for _, alias := range append(aliases, dnsNames...) {
+		if seen[alias] || strings.HasPrefix(id, alias) {
+			continue
+		}
+		seen[alias] = true
+		result = append(result, alias)
+	}
+	return result
+}
+
+// NetworkSubnet returns the first IPv4 subnet of a network by name
+func NetworkSubnet(ctx context.Context, name string) (string, error) {
+	c, err := GetSharedClient()
+	if err != nil {
+		return "", err
+	}
+
+	inspect, err := c.Raw().NetworkInspect(ctx, name, network.InspectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect network: %w", err)
+	}
+	for _, cfg := range inspect.IPAM.Config {
+		if ip, _, err := net.ParseCIDR(cfg.Subnet); err == nil && ip.To4() != nil {
+			return cfg.Subnet, nil
+		}
+	}
+	return "", fmt.Errorf("network %s has no IPv4 subnet", name)
+}
+
+// PickFreeCIDR returns a private /24 that overlaps no Docker network and no
+// route on the host, such as one added by a VPN. DefaultNetworkCIDR is
+// preferred when it is free.
+func PickFreeCIDR(ctx context.Context) (string, error) {
+	c, err := GetSharedClient()
+	if err != nil {
+		return "", err
+	}
+
+	networks, err := c.Raw().NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	var used []*net.IPNet
+	for _, n := range networks {
+		for _, cfg := range n.IPAM.Config {
+			if _, ipNet, err := net.ParseCIDR(cfg.Subnet); err == nil {
+				used = append(used, ipNet)
+			}
+		}
+	}
+
+	routes, err := hostRoutes()
+	if err != nil {
+		return "", err
+	}
+	used = append(used, routes...)
+
+	return pickFreeCIDR(used)
+}
+
+// pickFreeCIDR returns the first /24 in DefaultNetworkCIDR or privateRanges
+// that overlaps none of used
+func pickFreeCIDR(used []*net.IPNet) (string, error) {
+	free := func(candidate *net.IPNet) bool {
+		for _, u := range used {
+			if u.Contains(candidate.IP) || candidate.Contains(u.IP) {
+				return false
+			}
+		}
+		return true
+	}
+
+	_, preferred, _ := net.ParseCIDR(DefaultNetworkCIDR)
+	if free(preferred) {
+		return preferred.String(), nil
+	}
+
+	for _, r := range privateRanges {
+		_, block, _ := net.ParseCIDR(r)
+		start := binary.BigEndian.Uint32(block.IP.To4())
+		ones, _ := block.Mask.Size()
+		for i := uint32(0); i < 1<<(24-ones); i++ {
+			ip := make(net.IP, 4)
+			binary.BigEndian.PutUint32(ip, start+i<<8)
+			candidate := &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 32)}
+			if free(candidate) {
+				return candidate.String(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no free /24 found in the private address ranges")
+}
+
+// hostRoutes returns the host's IPv4 routes other than the default route.
+// Only Linux is supported; elsewhere Docker runs in a VM whose routes are
+// not the host's, and no routes are returned.
+func hostRoutes() ([]*net.IPNet, error) {
+	f, err := os.Open("/proc/net/route")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read routing table: %w", err)
+	}
+	defer f.Close()
+	return parseProcRoutes(f)
+}
+
+// parseProcRoutes parses /proc/net/route, whose destination and mask
+// columns are little-endian hex
+func parseProcRoutes(r io.Reader) ([]*net.IPNet, error) {
+	var routes []*net.IPNet
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		dest, err1 := hex.DecodeString(fields[1])
+		mask, err2 := hex.DecodeString(fields[7])
+		if err1 != nil || err2 != nil || len(dest) != 4 || len(mask) != 4 {
+			continue
+		}
+		ipNet := &net.IPNet{
+			IP:   net.IPv4(dest[3], dest[2], dest[1], dest[0]).To4(),
+			Mask: net.IPv4Mask(mask[3], mask[2], mask[1], mask[0]),
+		}
+		if ones, _ := ipNet.Mask.Size(); ones == 0 {
+			continue // default route
+		}
+		routes = append(routes, ipNet)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read routing table: %w", err)
+	}
+	return routes, nil
+}
+
 // deriveNetworkConfig calculates gateway and IP range from a CIDR.
 // Gateway is set to the first usable IP (e.g., x.x.x.1).
 // IP range is set to the first half of the subnet (adds 1 to prefix length).
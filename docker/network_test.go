@@ -2,6 +2,8 @@ package docker
 
 import (
 	"context"
+	"net"
+	"strings"
 	"testing"
 )
 
@@ -64,6 +66,13 @@ func TestDeriveNetworkConfig(t *testing.T) {
 			expectedIPRange: "192.168.1.0/25",
 			expectError:     false,
 		},
+		{
+			name:            "IPv6 /64 network",
+			cidr:            "fd00:28:28::/64",
+			expectedGateway: "fd00:28:28::1",
+			expectedIPRange: "fd00:28:28::/65",
+			expectError:     false,
+		},
 		{
 			name:        "invalid CIDR",
 			cidr:        "invalid",
@@ -105,6 +114,65 @@ func TestDeriveNetworkConfig(t *testing.T) {
 
 // TestCreateAndRemoveNetwork tests the full lifecycle of creating and removing a network
 // Note: This test requires Docker to be running and will create/remove a real network
+func TestPickFreeCIDR(t *testing.T) {
+	parse := func(cidrs ...string) []*net.IPNet {
+		var nets []*net.IPNet
+		for _, c := range cidrs {
+			_, n, err := net.ParseCIDR(c)
+			if err != nil {
+				t.Fatalf("invalid CIDR %s: %v", c, err)
+			}
+			nets = append(nets, n)
+		}
+		return nets
+	}
+
+	tests := []struct {
+		name     string
+		used     []*net.IPNet
+		expected string
+	}{
+		{"default free", parse("172.17.0.0/16"), DefaultNetworkCIDR},
+		{"default taken", parse("172.28.28.0/24"), "172.16.0.0/24"},
+		{"overlapping VPN route", parse("172.16.0.0/13", "172.24.0.0/14", "172.28.0.0/16"), "172.29.0.0/24"},
+		{"172.16/12 exhausted", parse("172.16.0.0/12"), "192.168.0.0/24"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickFreeCIDR(tt.used)
+			if err != nil {
+				t.Fatalf("pickFreeCIDR failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := pickFreeCIDR(parse("0.0.0.0/1", "128.0.0.0/1")); err == nil {
+		t.Error("expected error when every range is taken")
+	}
+}
+
+func TestParseProcRoutes(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0100A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0000A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+tun0	0000080A	00000000	0001	0	0	50	0000FFFF	0	0	0
+`
+	routes, err := parseProcRoutes(strings.NewReader(table))
+	if err != nil {
+		t.Fatalf("parseProcRoutes failed: %v", err)
+	}
+	var got []string
+	for _, r := range routes {
+		got = append(got, r.String())
+	}
+	if strings.Join(got, ",") != "192.168.0.0/24,10.8.0.0/16" {
+		t.Errorf("unexpected routes %v", got)
+	}
+}
+
 func TestCreateAndRemoveNetwork(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test in short mode")
@@ -207,3 +275,11 @@ func TestGetNetworkID_Nonexistent(t *testing.T) {
 		t.Error("expected error when getting ID of nonexistent network")
 	}
 }
+
+func TestNetworkAliases(t *testing.T) {
+	got := networkAliases([]string{"zot", "abc123def456"}, []string{"kinder-zot", "zot", "registry"}, "kinder-zot", "abc123def4567890")
+	expected := []string{"zot", "registry"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
@@ -2,9 +2,11 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"codeberg.org/hipkoi/kinder/cacert"
 	"github.com/docker/docker/api/types/container"
@@ -19,6 +21,16 @@ const (
 	StepCAContainerName = "kinder-step-ca"
 	// StepCAHostname is the hostname for the Step CA container
 	StepCAHostname = "stepca"
+	// ACMEProvisionerName is the name of the ACME provisioner, as in
+	// https://stepca:9000/acme/acme/directory
+	ACMEProvisionerName = "acme"
+	// JWKProvisionerName is the name of the JWK provisioner for "step ca" commands
+	JWKProvisionerName = "kinder-admin"
+
+	// stepCAMinCertDuration and stepCAMaxCertDuration are step-ca's default
+	// minimum and maximum TLS certificate lifetimes
+	stepCAMinCertDuration = 5 * time.Minute
+	stepCAMaxCertDuration = 24 * time.Hour
 )
 
 // StepCAConfig holds configuration for the Step CA container
@@ -30,10 +42,28 @@ type StepCAConfig struct {
 	CAKeyPath     string
 	DataDir       string
 	Image         string
+	IPAddress     string // Static IPv4 address on the network ("" for one assigned by Docker)
+	// ACMEProvisioner enables the ACME provisioner Traefik and the
+	// cert-manager issuer request certificates from
+	ACMEProvisioner bool
+	// JWKProvisionerPassword adds a JWK provisioner whose key is encrypted
+	// with this password ("" for none)
+	JWKProvisionerPassword string
+	// DefaultCertDuration and MaxCertDuration set the lifetime of issued
+	// certificates (0 for step-ca's defaults of 24h for both)
+	DefaultCertDuration time.Duration
+	MaxCertDuration     time.Duration
+	// CRLURL is embedded as the CRL distribution point of the intermediate
+	// certificate ("" for none)
+	CRLURL string
 }
 
 // CreateStepCAContainer creates and starts a Step CA container using the provided root CA
 func CreateStepCAContainer(ctx context.Context, config StepCAConfig) (string, error) {
+	if _, err := stepCAClaims(config); err != nil {
+		return "", err
+	}
+
 	// Create Step CA data directory
 	stepCADir := filepath.Join(config.DataDir, "step-ca")
 	if err := os.MkdirAll(stepCADir, 0755); err != nil {
@@ -73,7 +103,7 @@ func CreateStepCAContainer(ctx context.Context, config StepCAConfig) (string, er
 	intermediateCertPath := filepath.Join(certsDir, "intermediate_ca.crt")
 	intermediateKeyPath := filepath.Join(secretsDir, "intermediate_ca_key")
 
-	if err := cacert.GenerateIntermediate(config.CACertPath, config.CAKeyPath, intermediateCertPath, intermediateKeyPath); err != nil {
+	if err := cacert.GenerateIntermediateWithCRL(config.CACertPath, config.CAKeyPath, intermediateCertPath, intermediateKeyPath, config.CRLURL); err != nil {
 		return "", fmt.Errorf("failed to generate intermediate CA: %w", err)
 	}
 
@@ -87,10 +117,10 @@ func CreateStepCAContainer(ctx context.Context, config StepCAConfig) (string, er
 		return "", fmt.Errorf("failed to create password file: %w", err)
 	}
 
-	// Generate Step CA config with ACME
+	// Generate Step CA config with the requested provisioners
 	configPath := filepath.Join(stepCADir, "config", "ca.json")
 	stepCADNSNames := []string{config.Hostname, "localhost", "*.localhost"}
-	if err := generateStepCAConfigWithACME(configPath, stepCADNSNames); err != nil {
+	if err := generateStepCAConfig(configPath, stepCADNSNames, config); err != nil {
 		return "", fmt.Errorf("failed to generate Step CA config: %w", err)
 	}
 
@@ -101,10 +131,11 @@ func CreateStepCAContainer(ctx context.Context, config StepCAConfig) (string, er
 		Hostname:       config.Hostname,
 		NetworkName:    config.NetworkName,
 		NetworkAliases: []string{config.Hostname},
+		IPAddress:      config.IPAddress,
 		Env: []string{
 			"DOCKER_STEPCA_INIT_NAME=kinder",
 			"DOCKER_STEPCA_INIT_DNS_NAMES=" + config.Hostname,
-			"DOCKER_STEPCA_INIT_PROVISIONER_NAME=kinder-admin",
+			"DOCKER_STEPCA_INIT_PROVISIONER_NAME=" + JWKProvisionerName,
 		},
 		ExposedPorts: nat.PortSet{
 			"9000/tcp": struct{}{},
@@ -135,55 +166,154 @@ func RemoveStepCAContainer(ctx context.Context, containerName string) error {
 	return RemoveContainer(ctx, containerName)
 }
 
-// generateStepCAConfigWithACME creates a configuration file for Step CA with ACME enabled
-func generateStepCAConfigWithACME(path string, dnsNames []string) error {
+// stepCAFile is the subset of step-ca's ca.json that kinder generates
+type stepCAFile struct {
+	Root           string          `json:"root"`
+	FederatedRoots []string        `json:"federatedRoots"`
+	Crt            string          `json:"crt"`
+	Key            string          `json:"key"`
+	Address        string          `json:"address"`
+	DNSNames       []string        `json:"dnsNames"`
+	Logger         map[string]any  `json:"logger"`
+	DB             stepCADB        `json:"db"`
+	Authority      stepCAAuthority `json:"authority"`
+	TLS            stepCATLS       `json:"tls"`
+}
+
+type stepCADB struct {
+	Type       string `json:"type"`
+	DataSource string `json:"dataSource"`
+}
+
+type stepCAAuthority struct {
+	Claims       *stepCAClaimsConfig `json:"claims,omitempty"`
+	Provisioners []stepCAProvisioner `json:"provisioners"`
+}
+
+// stepCAClaimsConfig holds the authority-wide certificate lifetimes
+type stepCAClaimsConfig struct {
+	DefaultTLSCertDuration string `json:"defaultTLSCertDuration,omitempty"`
+	MaxTLSCertDuration     string `json:"maxTLSCertDuration,omitempty"`
+}
+
+type stepCAProvisioner struct {
+	Type         string      `json:"type"`
+	Name         string      `json:"name"`
+	Key          *cacert.JWK `json:"key,omitempty"`
+	EncryptedKey string      `json:"encryptedKey,omitempty"`
+}
+
+type stepCATLS struct {
+	CipherSuites  []string `json:"cipherSuites"`
+	MinVersion    float64  `json:"minVersion"`
+	MaxVersion    float64  `json:"maxVersion"`
+	Renegotiation bool     `json:"renegotiation"`
+}
+
+// stepCAProvisioners returns the provisioners enabled by the config: the
+// "acme" ACME provisioner used by Traefik and the cert-manager issuer, and a
+// JWK provisioner for "step ca" commands when a password is set
+func stepCAProvisioners(config StepCAConfig) ([]stepCAProvisioner, error) {
+	var provisioners []stepCAProvisioner
+	if config.ACMEProvisioner {
+		provisioners = append(provisioners, stepCAProvisioner{Type: "ACME", Name: ACMEProvisionerName})
+	}
+	if config.JWKProvisionerPassword != "" {
+		key, err := cacert.GenerateJWKProvisionerKey(config.JWKProvisionerPassword)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JWK provisioner key: %w", err)
+		}
+		provisioners = append(provisioners, stepCAProvisioner{
+			Type:         "JWK",
+			Name:         JWKProvisionerName,
+			Key:          &key.Key,
+			EncryptedKey: key.EncryptedKey,
+		})
+	}
+	if len(provisioners) == 0 {
+		return nil, fmt.Errorf("step-ca needs at least one provisioner: enable the ACME provisioner or set a JWK provisioner password")
+	}
+	return provisioners, nil
+}
+
+// stepCAClaims returns the certificate lifetimes for the config, or nil to
+// keep step-ca's defaults. It checks they are positive, within step-ca's 5m
+// minimum and that the default does not exceed the maximum.
+func stepCAClaims(config StepCAConfig) (*stepCAClaimsConfig, error) {
+	defaultDuration, maxDuration := config.DefaultCertDuration, config.MaxCertDuration
+	if defaultDuration == 0 && maxDuration == 0 {
+		return nil, nil
+	}
+	if defaultDuration < 0 || maxDuration < 0 {
+		return nil, fmt.Errorf("certificate durations must be positive")
+	}
+
+	// Fill in whichever is unset so the pair stays consistent
+	if defaultDuration == 0 {
+		defaultDuration = min(stepCAMaxCertDuration, maxDuration)
+	}
+	if maxDuration == 0 {
+		maxDuration = max(stepCAMaxCertDuration, defaultDuration)
+	}
+	if defaultDuration < stepCAMinCertDuration {
+		return nil, fmt.Errorf("certificate duration %s is below step-ca's minimum of %s", defaultDuration, stepCAMinCertDuration)
+	}
+	if defaultDuration > maxDuration {
+		return nil, fmt.Errorf("certificate duration %s exceeds the maximum of %s", defaultDuration, maxDuration)
+	}
+
+	return &stepCAClaimsConfig{
+		DefaultTLSCertDuration: defaultDuration.String(),
+		MaxTLSCertDuration:     maxDuration.String(),
+	}, nil
+}
+
+// generateStepCAConfig creates a configuration file for Step CA with the
+// provisioners enabled by config
+func generateStepCAConfig(path string, dnsNames []string, config StepCAConfig) error {
+	provisioners, err := stepCAProvisioners(config)
+	if err != nil {
+		return err
+	}
+	claims, err := stepCAClaims(config)
+	if err != nil {
+		return err
+	}
+
 	// Create config directory if it doesn't exist
 	configDir := filepath.Dir(path)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Build DNS names JSON array
-	dnsNamesJSON := ""
-	for i, name := range dnsNames {
-		if i > 0 {
-			dnsNamesJSON += ", "
-		}
-		dnsNamesJSON += fmt.Sprintf(`"%s"`, name)
-	}
-
-	config := fmt.Sprintf(`{
-  "root": "/home/step/root_ca.crt",
-  "federatedRoots": null,
-  "crt": "/home/step/certs/intermediate_ca.crt",
-  "key": "/home/step/secrets/intermediate_ca_key",
-  "address": ":9000",
-  "dnsNames": [%s],
-  "logger": {"format": "text"},
-  "db": {
-    "type": "badger",
-    "dataSource": "/home/step/db"
-  },
-  "authority": {
-    "provisioners": [
-      {
-        "type": "ACME",
-        "name": "acme"
-      }
-    ]
-  },
-  "tls": {
-    "cipherSuites": [
-      "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
-      "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"
-    ],
-    "minVersion": 1.2,
-    "maxVersion": 1.3,
-    "renegotiation": false
-  }
-}`, dnsNamesJSON)
-
-	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+	file := stepCAFile{
+		Root:     "/home/step/root_ca.crt",
+		Crt:      "/home/step/certs/intermediate_ca.crt",
+		Key:      "/home/step/secrets/intermediate_ca_key",
+		Address:  ":9000",
+		DNSNames: dnsNames,
+		Logger:   map[string]any{"format": "text"},
+		DB: stepCADB{
+			Type:       "badger",
+			DataSource: "/home/step/db",
+		},
+		Authority: stepCAAuthority{Claims: claims, Provisioners: provisioners},
+		TLS: stepCATLS{
+			CipherSuites: []string{
+				"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+				"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256",
+			},
+			MinVersion: 1.2,
+			MaxVersion: 1.3,
+		},
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode Step CA config: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write Step CA config: %w", err)
 	}
 
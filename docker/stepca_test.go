@@ -1,7 +1,11 @@
 package docker
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestStepCAConfig(t *testing.T) {
@@ -42,6 +46,66 @@ func TestStepCAConstants(t *testing.T) {
 	}
 }
 
+func TestGenerateStepCAConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  StepCAConfig
+		want    []string
+		wantErr bool
+	}{
+		{"ACME only", StepCAConfig{ACMEProvisioner: true}, []string{"ACME/acme"}, false},
+		{"ACME and JWK", StepCAConfig{ACMEProvisioner: true, JWKProvisionerPassword: "pw"}, []string{"ACME/acme", "JWK/kinder-admin"}, false},
+		{"JWK only", StepCAConfig{JWKProvisionerPassword: "pw"}, []string{"JWK/kinder-admin"}, false},
+		{"no provisioners", StepCAConfig{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The config directory is created if needed
+			path := filepath.Join(t.TempDir(), "config", "ca.json")
+			err := generateStepCAConfig(path, []string{"stepca", "localhost"}, tt.config)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generateStepCAConfig failed: %v", err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read ca.json: %v", err)
+			}
+			var file stepCAFile
+			if err := json.Unmarshal(data, &file); err != nil {
+				t.Fatalf("ca.json is not valid JSON: %v", err)
+			}
+
+			if file.Address != ":9000" || len(file.DNSNames) != 2 {
+				t.Errorf("unexpected address or DNS names: %s %v", file.Address, file.DNSNames)
+			}
+
+			var got []string
+			for _, p := range file.Authority.Provisioners {
+				got = append(got, p.Type+"/"+p.Name)
+				if p.Type == "JWK" && (p.Key == nil || p.Key.D != "" || p.EncryptedKey == "") {
+					t.Errorf("expected JWK provisioner to have a public key and encrypted private key, got %+v", p)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected provisioners %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("expected provisioners %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
 // Note: Full integration tests for CreateStepCAContainer and RemoveStepCAContainer
 // are intentionally not included as they require:
 // - Docker daemon running
@@ -49,3 +113,46 @@ func TestStepCAConstants(t *testing.T) {
 // - Image pulling
 // - CA certificate/key files
 // These should be tested manually or in a dedicated integration test environment.
+
+func TestStepCAClaims(t *testing.T) {
+	tests := []struct {
+		name        string
+		def, max    time.Duration
+		wantDefault string
+		wantMax     string
+		wantErr     bool
+	}{
+		{name: "defaults"},
+		{name: "both", def: time.Hour, max: 2 * time.Hour, wantDefault: "1h0m0s", wantMax: "2h0m0s"},
+		{name: "short default", def: 10 * time.Minute, wantDefault: "10m0s", wantMax: "24h0m0s"},
+		{name: "long default raises max", def: 48 * time.Hour, wantDefault: "48h0m0s", wantMax: "48h0m0s"},
+		{name: "short max lowers default", max: time.Hour, wantDefault: "1h0m0s", wantMax: "1h0m0s"},
+		{name: "default above max", def: 2 * time.Hour, max: time.Hour, wantErr: true},
+		{name: "below minimum", def: time.Minute, wantErr: true},
+		{name: "negative", max: -time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := stepCAClaims(StepCAConfig{DefaultCertDuration: tt.def, MaxCertDuration: tt.max})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("stepCAClaims failed: %v", err)
+			}
+			if tt.wantDefault == "" {
+				if claims != nil {
+					t.Errorf("expected no claims, got %+v", claims)
+				}
+				return
+			}
+			if claims.DefaultTLSCertDuration != tt.wantDefault || claims.MaxTLSCertDuration != tt.wantMax {
+				t.Errorf("expected %s/%s, got %+v", tt.wantDefault, tt.wantMax, claims)
+			}
+		})
+	}
+}
@@ -20,19 +20,46 @@ const (
 	TraefikHostname = "traefik"
 	// DefaultTraefikPort is the default HTTPS port for Traefik on localhost
 	DefaultTraefikPort = "8443"
+	// DefaultTraefikHTTPPort is the default plain HTTP port for Traefik on localhost
+	DefaultTraefikHTTPPort = "80"
 	// DefaultTraefikDomain is the default sslip.io domain for Traefik
 	DefaultTraefikDomain = "c0000201.sslip.io"
+	// DefaultACMEServerURL is the ACME directory of the local Step CA container
+	DefaultACMEServerURL = "https://stepca:9000/acme/acme/directory"
+	// DefaultTraefikLogLevel is Traefik's log level unless configured otherwise
+	DefaultTraefikLogLevel = "INFO"
 )
 
+// TraefikLogLevels are the log levels Traefik accepts
+var TraefikLogLevels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC"}
+
 // TraefikConfig holds configuration for the Traefik reverse proxy container
 type TraefikConfig struct {
 	ContainerName string
 	Hostname      string
 	NetworkName   string
 	DataDir       string
+	CACertPath    string // CA certificate to trust (default: ca.crt in DataDir)
 	Image         string
+	IPAddress     string // Static IPv4 address on the network ("" for one assigned by Docker)
 	Port          string // Localhost HTTPS port (default: 8443)
 	Domain        string // Base domain for services (default: c0000201.sslip.io)
+	ACMEServer    string // ACME directory URL for certificates (default: local Step CA)
+	// HTTPPort publishes the plain HTTP entrypoint on this localhost port
+	// ("" to not publish it)
+	HTTPPort string
+	// EnableHTTPRedirect redirects all plain HTTP requests to HTTPS
+	EnableHTTPRedirect bool
+	// EnableDashboard serves the dashboard at https://traefik.<domain>
+	EnableDashboard bool
+	// DashboardPort also publishes the dashboard over plain HTTP on this
+	// localhost port ("" to not publish it)
+	DashboardPort string
+	LogLevel      string // Traefik log level (default: INFO)
+	AccessLog     bool   // Write access logs to the container's stdout
+	// ArgoCDURL is the argocd-server backend to route argocd.<domain> to
+	// ("" for no route)
+	ArgoCDURL string
 }
 
 // CreateTraefikContainer creates and starts a Traefik reverse proxy container
@@ -44,6 +71,12 @@ func CreateTraefikContainer(ctx context.Context, config TraefikConfig) (string,
 	if config.Domain == "" {
 		config.Domain = DefaultTraefikDomain
 	}
+	if config.ACMEServer == "" {
+		config.ACMEServer = DefaultACMEServerURL
+	}
+	if config.LogLevel == "" {
+		config.LogLevel = DefaultTraefikLogLevel
+	}
 
 	// Create Traefik data directory
 	traefikDir := filepath.Join(config.DataDir, "traefik")
@@ -52,7 +85,10 @@ func CreateTraefikContainer(ctx context.Context, config TraefikConfig) (string,
 	}
 
 	// Copy CA certificate to Traefik directory
-	caCertSrc := filepath.Join(config.DataDir, "ca.crt")
+	caCertSrc := config.CACertPath
+	if caCertSrc == "" {
+		caCertSrc = filepath.Join(config.DataDir, "ca.crt")
+	}
 	caCertDest := filepath.Join(traefikDir, "ca.crt")
 	if err := CopyFile(caCertSrc, caCertDest); err != nil {
 		return "", fmt.Errorf("failed to copy CA certificate: %w", err)
@@ -60,42 +96,58 @@ func CreateTraefikContainer(ctx context.Context, config TraefikConfig) (string,
 
 	// Generate Traefik static config
 	staticConfigPath := filepath.Join(traefikDir, "traefik.yaml")
-	if err := generateTraefikStaticConfig(staticConfigPath); err != nil {
+	if err := generateTraefikStaticConfig(staticConfigPath, config); err != nil {
 		return "", fmt.Errorf("failed to generate Traefik static config: %w", err)
 	}
 
 	// Generate Traefik dynamic config
 	dynamicConfigPath := filepath.Join(traefikDir, "dynamic.yaml")
-	if err := generateTraefikDynamicConfig(dynamicConfigPath, config.Domain); err != nil {
+	if err := generateTraefikDynamicConfig(dynamicConfigPath, config); err != nil {
 		return "", fmt.Errorf("failed to generate Traefik dynamic config: %w", err)
 	}
 
+	exposedPorts := nat.PortSet{
+		"443/tcp": struct{}{},
+	}
+	portBindings := nat.PortMap{
+		"443/tcp": []nat.PortBinding{
+			{
+				HostPort: config.Port,
+			},
+		},
+	}
+	if config.HTTPPort != "" {
+		exposedPorts["80/tcp"] = struct{}{}
+		portBindings["80/tcp"] = []nat.PortBinding{
+			{
+				HostPort: config.HTTPPort,
+			},
+		}
+	}
+	if config.EnableDashboard && config.DashboardPort != "" {
+		// The insecure API has no authentication, so only publish it locally
+		exposedPorts["8080/tcp"] = struct{}{}
+		portBindings["8080/tcp"] = []nat.PortBinding{
+			{
+				HostIP:   "127.0.0.1",
+				HostPort: config.DashboardPort,
+			},
+		}
+	}
+
 	// Build generic container configuration
 	containerConfig := ContainerConfig{
 		Name:           config.ContainerName,
 		Image:          config.Image,
 		Hostname:       config.Hostname,
 		NetworkName:    config.NetworkName,
-		NetworkAliases: []string{config.Hostname},
+		NetworkAliases: traefikAliases(config),
+		IPAddress:      config.IPAddress,
 		Cmd: []string{
 			"--configFile=/etc/traefik/traefik.yaml",
 		},
-		ExposedPorts: nat.PortSet{
-			"80/tcp":  struct{}{},
-			"443/tcp": struct{}{},
-		},
-		PortBindings: nat.PortMap{
-			"80/tcp": []nat.PortBinding{
-				{
-					HostPort: "80",
-				},
-			},
-			"443/tcp": []nat.PortBinding{
-				{
-					HostPort: config.Port,
-				},
-			},
-		},
+		ExposedPorts: exposedPorts,
+		PortBindings: portBindings,
 		Env: []string{
 			"SSL_CERT_FILE=/etc/traefik/ca.crt",
 		},
@@ -120,34 +172,76 @@ func CreateTraefikContainer(ctx context.Context, config TraefikConfig) (string,
 	return containerID, nil
 }
 
+// traefikAliases returns the container's network aliases: its hostname and
+// the routed service names, so other containers (such as Gatus) reach the
+// routes, with matching certificates, without leaving the network
+func traefikAliases(config TraefikConfig) []string {
+	aliases := []string{config.Hostname}
+	hosts := []string{"traefik", "ca", "registry", "gatus"}
+	if config.ArgoCDURL != "" {
+		hosts = append(hosts, "argocd")
+	}
+	for _, host := range hosts {
+		aliases = append(aliases, host+"."+config.Domain)
+	}
+	return aliases
+}
+
 // RemoveTraefikContainer stops and removes the Traefik container
 func RemoveTraefikContainer(ctx context.Context, containerName string) error {
 	return RemoveContainer(ctx, containerName)
 }
 
+// traefikAPIConfig returns the static config's api section and any extra
+// entry point for it. Without the dashboard the API is left disabled.
+func traefikAPIConfig(config TraefikConfig) (api, entryPoint string) {
+	if !config.EnableDashboard {
+		return "", ""
+	}
+	if config.DashboardPort == "" {
+		return "api:\n  dashboard: true\n\n", ""
+	}
+	return "api:\n  dashboard: true\n  insecure: true\n\n", "  traefik:\n    address: \":8080\"\n"
+}
+
 // generateTraefikStaticConfig creates the static configuration file for Traefik
-func generateTraefikStaticConfig(path string) error {
-	config := `# Traefik static configuration for kinder
-api:
-  dashboard: true
+func generateTraefikStaticConfig(path string, traefikConfig TraefikConfig) error {
+	api, apiEntryPoint := traefikAPIConfig(traefikConfig)
 
-entryPoints:
-  web:
-    address: ":80"
+	logLevel := traefikConfig.LogLevel
+	if logLevel == "" {
+		logLevel = DefaultTraefikLogLevel
+	}
+	// Without a filePath Traefik writes access logs to stdout, alongside its
+	// own log, so both show up in "docker logs"
+	accessLog := ""
+	if traefikConfig.AccessLog {
+		accessLog = "\naccessLog: {}\n"
+	}
+	// The web entrypoint is always there for ACME HTTP challenges on the
+	// kinder network, whether or not it is published on the host
+	redirect := ""
+	if traefikConfig.EnableHTTPRedirect {
+		redirect = `
     http:
       redirections:
         entryPoint:
           to: websecure
-          scheme: https
+          scheme: https`
+	}
+	config := fmt.Sprintf(`# Traefik static configuration for kinder
+%sentryPoints:
+  web:
+    address: ":80"%s
   websecure:
     address: ":443"
-
+%s
 certificatesResolvers:
   stepca:
     acme:
       email: admin@localhost
       storage: /etc/traefik/acme.json
-      caServer: https://stepca:9000/acme/acme/directory
+      caServer: %s
       certificatesDuration: 2160
       httpChallenge:
         entryPoint: web
@@ -160,8 +254,8 @@ providers:
     watch: true
 
 log:
-  level: INFO
-`
+  level: %s
+%s`, api, redirect, apiEntryPoint, traefikConfig.ACMEServer, logLevel, accessLog)
 
 	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write Traefik static config: %w", err)
@@ -171,11 +265,13 @@ log:
 }
 
 // generateTraefikDynamicConfig creates the dynamic configuration file for Traefik
-func generateTraefikDynamicConfig(path, domain string) error {
-	config := fmt.Sprintf(`# Traefik dynamic configuration for kinder
-http:
-  routers:
-    traefik-router:
+func generateTraefikDynamicConfig(path string, traefikConfig TraefikConfig) error {
+	domain := traefikConfig.Domain
+
+	// The dashboard route needs the API, which is off without the dashboard
+	dashboardRouter := ""
+	if traefikConfig.EnableDashboard {
+		dashboardRouter = fmt.Sprintf(`    traefik-router:
       rule: "Host(`+"`traefik.%s`"+`)"
       service: api@internal
       entryPoints:
@@ -183,7 +279,33 @@ http:
       tls:
         certResolver: stepca
 
-    zot-router:
+`, domain)
+	}
+
+	// ArgoCD runs in the Kind cluster and is only routed when exposed
+	argocdRouter, argocdService := "", ""
+	if traefikConfig.ArgoCDURL != "" {
+		argocdRouter = fmt.Sprintf(`    argocd-router:
+      rule: "Host(`+"`argocd.%s`"+`)"
+      service: argocd-service
+      entryPoints:
+        - websecure
+      tls:
+        certResolver: stepca
+
+`, domain)
+		argocdService = fmt.Sprintf(`    argocd-service:
+      loadBalancer:
+        servers:
+          - url: "%s"
+
+`, traefikConfig.ArgoCDURL)
+	}
+
+	config := fmt.Sprintf(`# Traefik dynamic configuration for kinder
+http:
+  routers:
+%s%s    zot-router:
       rule: "Host(`+"`registry.%s`"+`)"
       service: zot-service
       entryPoints:
@@ -208,7 +330,7 @@ http:
         certResolver: stepca
 
   services:
-    zot-service:
+%s    zot-service:
       loadBalancer:
         servers:
           - url: "http://zot:5000"
@@ -226,7 +348,7 @@ http:
 
   serversTransports:
     stepca-transport: {}
-`, domain, domain, domain, domain)
+`, dashboardRouter, argocdRouter, domain, domain, domain, argocdService)
 
 	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
 		return fmt.Errorf("failed to write Traefik dynamic config: %w", err)
@@ -3,8 +3,11 @@ package docker
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestTraefikConfig(t *testing.T) {
@@ -52,7 +55,7 @@ func TestGenerateTraefikStaticConfig(t *testing.T) {
 
 	configPath := filepath.Join(tmpDir, "traefik.yaml")
 
-	err = generateTraefikStaticConfig(configPath)
+	err = generateTraefikStaticConfig(configPath, TraefikConfig{ACMEServer: DefaultACMEServerURL, EnableDashboard: true})
 	if err != nil {
 		t.Fatalf("generateTraefikStaticConfig failed: %v", err)
 	}
@@ -88,6 +91,7 @@ func TestGenerateTraefikStaticConfig(t *testing.T) {
 		"certificatesResolvers:",
 		"stepca:",
 		"httpChallenge:",
+		"caServer: " + DefaultACMEServerURL,
 	}
 
 	for _, expected := range expectedStrings {
@@ -107,7 +111,7 @@ func TestGenerateTraefikDynamicConfig(t *testing.T) {
 	configPath := filepath.Join(tmpDir, "dynamic.yaml")
 	testDomain := "c0000201.sslip.io"
 
-	err = generateTraefikDynamicConfig(configPath, testDomain)
+	err = generateTraefikDynamicConfig(configPath, TraefikConfig{Domain: testDomain, EnableDashboard: true})
 	if err != nil {
 		t.Fatalf("generateTraefikDynamicConfig failed: %v", err)
 	}
@@ -129,6 +133,8 @@ func TestGenerateTraefikDynamicConfig(t *testing.T) {
 	expectedStrings := []string{
 		"http:",
 		"routers:",
+		"traefik-router:",
+		"api@internal",
 		"zot-router:",
 		"registry." + testDomain,
 		"gatus-router:",
@@ -153,16 +159,246 @@ func TestGenerateTraefikDynamicConfig(t *testing.T) {
 	}
 }
 
+func TestGenerateTraefikStaticConfig_ExternalACME(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "traefik.yaml")
+	acmeServer := "https://ca.example.com/acme/acme/directory"
+
+	if err := generateTraefikStaticConfig(configPath, TraefikConfig{ACMEServer: acmeServer}); err != nil {
+		t.Fatalf("generateTraefikStaticConfig failed: %v", err)
+	}
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("failed to read traefik.yaml: %v", err)
+	}
+	if !strings.Contains(string(content), "caServer: "+acmeServer) {
+		t.Errorf("expected caServer to point at %s, got:\n%s", acmeServer, content)
+	}
+}
+
 func TestGenerateTraefikStaticConfig_InvalidPath(t *testing.T) {
-	err := generateTraefikStaticConfig("/nonexistent/path/traefik.yaml")
+	err := generateTraefikStaticConfig("/nonexistent/path/traefik.yaml", TraefikConfig{ACMEServer: DefaultACMEServerURL})
 	if err == nil {
 		t.Error("expected error when writing to invalid path")
 	}
 }
 
 func TestGenerateTraefikDynamicConfig_InvalidPath(t *testing.T) {
-	err := generateTraefikDynamicConfig("/nonexistent/path/dynamic.yaml", "test.example.com")
+	err := generateTraefikDynamicConfig("/nonexistent/path/dynamic.yaml", TraefikConfig{Domain: "test.example.com"})
 	if err == nil {
 		t.Error("expected error when writing to invalid path")
 	}
 }
+
+func TestGenerateTraefikConfig_Dashboard(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    TraefikConfig
+		static    []string
+		notStatic []string
+		hasRouter bool
+	}{
+		{
+			name:      "disabled",
+			config:    TraefikConfig{Domain: "dev.test"},
+			notStatic: []string{"api:", "insecure:", ":8080"},
+		},
+		{
+			name:      "routed only",
+			config:    TraefikConfig{Domain: "dev.test", EnableDashboard: true},
+			static:    []string{"api:\n  dashboard: true\n"},
+			notStatic: []string{"insecure:", ":8080"},
+			hasRouter: true,
+		},
+		{
+			name:      "published on localhost",
+			config:    TraefikConfig{Domain: "dev.test", EnableDashboard: true, DashboardPort: "9090"},
+			static:    []string{"insecure: true", "traefik:\n    address: \":8080\""},
+			hasRouter: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			staticPath := filepath.Join(dir, "traefik.yaml")
+			dynamicPath := filepath.Join(dir, "dynamic.yaml")
+			if err := generateTraefikStaticConfig(staticPath, tt.config); err != nil {
+				t.Fatalf("generateTraefikStaticConfig failed: %v", err)
+			}
+			if err := generateTraefikDynamicConfig(dynamicPath, tt.config); err != nil {
+				t.Fatalf("generateTraefikDynamicConfig failed: %v", err)
+			}
+
+			static, _ := os.ReadFile(staticPath)
+			for _, want := range tt.static {
+				if !strings.Contains(string(static), want) {
+					t.Errorf("expected traefik.yaml to contain %q:\n%s", want, static)
+				}
+			}
+			for _, unwanted := range tt.notStatic {
+				if strings.Contains(string(static), unwanted) {
+					t.Errorf("expected traefik.yaml not to contain %q:\n%s", unwanted, static)
+				}
+			}
+
+			var parsed map[string]any
+			if err := yaml.Unmarshal(static, &parsed); err != nil {
+				t.Errorf("traefik.yaml is not valid YAML: %v", err)
+			}
+
+			dynamic, _ := os.ReadFile(dynamicPath)
+			if got := strings.Contains(string(dynamic), "api@internal"); got != tt.hasRouter {
+				t.Errorf("expected dashboard router %v, got %v:\n%s", tt.hasRouter, got, dynamic)
+			}
+		})
+	}
+}
+
+func TestGenerateTraefikDynamicConfig_ArgoCD(t *testing.T) {
+	tests := []struct {
+		name      string
+		argocdURL string
+	}{
+		{"not exposed", ""},
+		{"exposed", "http://kinder-control-plane:30080"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dynamic.yaml")
+			config := TraefikConfig{Domain: "dev.test", ArgoCDURL: tt.argocdURL}
+			if err := generateTraefikDynamicConfig(path, config); err != nil {
+				t.Fatalf("generateTraefikDynamicConfig failed: %v", err)
+			}
+			content, _ := os.ReadFile(path)
+
+			var parsed struct {
+				HTTP struct {
+					Routers  map[string]map[string]any `yaml:"routers"`
+					Services map[string]struct {
+						LoadBalancer struct {
+							Servers []struct {
+								URL string `yaml:"url"`
+							} `yaml:"servers"`
+						} `yaml:"loadBalancer"`
+					} `yaml:"services"`
+				} `yaml:"http"`
+			}
+			if err := yaml.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("dynamic.yaml is not valid YAML: %v\n%s", err, content)
+			}
+
+			router, routed := parsed.HTTP.Routers["argocd-router"]
+			if routed != (tt.argocdURL != "") {
+				t.Fatalf("expected argocd router %v, got:\n%s", tt.argocdURL != "", content)
+			}
+			if !routed {
+				return
+			}
+			if router["rule"] != "Host(`argocd.dev.test`)" {
+				t.Errorf("unexpected argocd rule %v", router["rule"])
+			}
+			servers := parsed.HTTP.Services["argocd-service"].LoadBalancer.Servers
+			if len(servers) != 1 || servers[0].URL != tt.argocdURL {
+				t.Errorf("expected argocd backend %s, got %+v", tt.argocdURL, servers)
+			}
+			if !slices.Contains(traefikAliases(config), "argocd.dev.test") {
+				t.Errorf("expected argocd alias, got %v", traefikAliases(config))
+			}
+		})
+	}
+}
+
+func TestGenerateTraefikStaticConfig_Logging(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    TraefikConfig
+		level     string
+		accessLog bool
+	}{
+		{"defaults", TraefikConfig{}, DefaultTraefikLogLevel, false},
+		{"debug with access log", TraefikConfig{LogLevel: "DEBUG", AccessLog: true}, "DEBUG", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "traefik.yaml")
+			if err := generateTraefikStaticConfig(configPath, tt.config); err != nil {
+				t.Fatalf("generateTraefikStaticConfig failed: %v", err)
+			}
+
+			content, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatalf("failed to read traefik.yaml: %v", err)
+			}
+
+			var parsed struct {
+				Log struct {
+					Level string `yaml:"level"`
+				} `yaml:"log"`
+				AccessLog *struct{} `yaml:"accessLog"`
+			}
+			if err := yaml.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("traefik.yaml is not valid YAML: %v", err)
+			}
+			if parsed.Log.Level != tt.level {
+				t.Errorf("expected log level %s, got %s", tt.level, parsed.Log.Level)
+			}
+			if got := parsed.AccessLog != nil; got != tt.accessLog {
+				t.Errorf("expected access log %v, got %v:\n%s", tt.accessLog, got, content)
+			}
+		})
+	}
+}
+
+func TestGenerateTraefikStaticConfig_HTTPRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   TraefikConfig
+		redirect bool
+	}{
+		{"no redirect", TraefikConfig{}, false},
+		{"redirect", TraefikConfig{EnableHTTPRedirect: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "traefik.yaml")
+			if err := generateTraefikStaticConfig(configPath, tt.config); err != nil {
+				t.Fatalf("generateTraefikStaticConfig failed: %v", err)
+			}
+
+			content, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatalf("failed to read traefik.yaml: %v", err)
+			}
+
+			var parsed struct {
+				EntryPoints map[string]struct {
+					Address string `yaml:"address"`
+					HTTP    struct {
+						Redirections struct {
+							EntryPoint struct {
+								To     string `yaml:"to"`
+								Scheme string `yaml:"scheme"`
+							} `yaml:"entryPoint"`
+						} `yaml:"redirections"`
+					} `yaml:"http"`
+				} `yaml:"entryPoints"`
+			}
+			if err := yaml.Unmarshal(content, &parsed); err != nil {
+				t.Fatalf("traefik.yaml is not valid YAML: %v", err)
+			}
+
+			web, ok := parsed.EntryPoints["web"]
+			if !ok || web.Address != ":80" {
+				t.Fatalf("expected web entrypoint on :80 for ACME challenges, got:\n%s", content)
+			}
+			to := web.HTTP.Redirections.EntryPoint
+			if got := to.To == "websecure" && to.Scheme == "https"; got != tt.redirect {
+				t.Errorf("expected redirect %v, got %+v", tt.redirect, to)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package docker
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// readyProbeInterval is how long to wait between readiness probes
+const readyProbeInterval = 500 * time.Millisecond
+
+// waitFor runs probe until it succeeds or timeout elapses. The timeout error
+// includes the last probe result, which usually says what is still wrong.
+func waitFor(ctx context.Context, service string, timeout time.Duration, probe func(context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+
+	var lastErr error
+	for time.Now().Before(deadline) {
+		if lastErr = probe(ctx); lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readyProbeInterval):
+			// Retry
+		}
+	}
+
+	return fmt.Errorf("timeout waiting for %s to be ready: %w", service, lastErr)
+}
+
+// probeHTTP checks that url answers with a status below 400
+func probeHTTP(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s returned HTTP %d", url, resp.StatusCode)
+	}
+	return nil
+}
+
+// WaitForStepCA waits for Step CA to pass its health check. Its port is not
+// published, so the check runs inside the container.
+func WaitForStepCA(ctx context.Context, containerName string, timeout time.Duration) error {
+	return waitFor(ctx, "Step CA", timeout, func(ctx context.Context) error {
+		out, err := ExecInContainer(ctx, containerName, []string{
+			"step", "ca", "health",
+			"--ca-url", "https://localhost:9000",
+			"--root", "/home/step/root_ca.crt",
+		})
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(out) != "ok" {
+			return fmt.Errorf("health check returned %q", strings.TrimSpace(out))
+		}
+		return nil
+	})
+}
+
+// WaitForTraefik waits for Traefik to accept HTTPS connections on its
+// localhost port. Any HTTP response counts, as no router matches localhost.
+func WaitForTraefik(ctx context.Context, port string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			// Traefik serves its default certificate until ACME issues one
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	url := fmt.Sprintf("https://localhost:%s/", port)
+
+	return waitFor(ctx, "Traefik", timeout, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitFor(t *testing.T) {
+	calls := 0
+	err := waitFor(context.Background(), "test", time.Second, func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected probe to succeed, got %v", err)
+	}
+
+	err = waitFor(context.Background(), "test", 10*time.Millisecond, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	if err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("expected timeout error with the last probe result, got %v", err)
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	if err := probeHTTP(context.Background(), server.Client(), server.URL); err == nil || !strings.Contains(err.Error(), "HTTP 503") {
+		t.Errorf("expected HTTP 503 error, got %v", err)
+	}
+
+	status = http.StatusOK
+	if err := probeHTTP(context.Background(), server.Client(), server.URL); err != nil {
+		t.Errorf("expected success, got %v", err)
+	}
+}
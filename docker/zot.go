@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types/container"
@@ -21,6 +24,8 @@ const (
 	ZotContainerName = "kinder-zot"
 	// ZotHostname is the hostname for the Zot container
 	ZotHostname = "zot"
+	// DefaultZotPort is the default host port Zot is published on
+	DefaultZotPort = "5000"
 )
 
 // ZotConfig holds configuration for the Zot registry container
@@ -30,11 +35,17 @@ type ZotConfig struct {
 	NetworkName     string
 	DataDir         string
 	Image           string
+	IPAddress       string   // Static IPv4 address on the network ("" for one assigned by Docker)
+	HostPort        string   // Host port to publish the registry on (default: 5000)
 	RegistryMirrors []string // List of registries to mirror (e.g., "ghcr.io", "registry-1.docker.io")
 }
 
 // CreateZotContainer creates and starts a Zot registry container
 func CreateZotContainer(ctx context.Context, config ZotConfig) (string, error) {
+	if config.HostPort == "" {
+		config.HostPort = DefaultZotPort
+	}
+
 	// Create Zot data directory
 	zotDir := filepath.Join(config.DataDir, "zot")
 	zotDataDir := filepath.Join(zotDir, "data")
@@ -55,6 +66,7 @@ func CreateZotContainer(ctx context.Context, config ZotConfig) (string, error) {
 		Hostname:       config.Hostname,
 		NetworkName:    config.NetworkName,
 		NetworkAliases: []string{config.Hostname},
+		IPAddress:      config.IPAddress,
 		Cmd:            []string{"serve", "/etc/zot/config.json"},
 		ExposedPorts: nat.PortSet{
 			"5000/tcp": struct{}{},
@@ -67,7 +79,7 @@ func CreateZotContainer(ctx context.Context, config ZotConfig) (string, error) {
 			"5000/tcp": []nat.PortBinding{
 				{
 					HostIP:   "0.0.0.0",
-					HostPort: "5000",
+					HostPort: config.HostPort,
 				},
 			},
 		},
@@ -102,34 +114,105 @@ func RemoveZotContainer(ctx context.Context, containerName string) error {
 	return RemoveContainer(ctx, containerName)
 }
 
-// WaitForZot waits for the Zot registry to be ready to accept connections
-func WaitForZot(ctx context.Context, timeout time.Duration) error {
+// WaitForZot waits for the Zot registry published on hostPort to be ready to
+// accept connections
+func WaitForZot(ctx context.Context, hostPort string, timeout time.Duration) error {
 	client := &http.Client{Timeout: 2 * time.Second}
-	deadline := time.Now().Add(timeout)
+	return waitFor(ctx, "Zot registry", timeout, func(ctx context.Context) error {
+		return probeHTTP(ctx, client, "http://localhost:"+hostPort+"/v2/")
+	})
+}
+
+// ListRepositories returns the repositories in a registry, such as
+// "http://localhost:5000", from its /v2/_catalog endpoint
+func ListRepositories(ctx context.Context, registryURL string) ([]string, error) {
+	var repos []string
+	err := getPages(ctx, registryURL, "/v2/_catalog", func(body io.Reader) error {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		repos = append(repos, page.Repositories...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	return repos, nil
+}
+
+// ListTags returns the tags of a repository in a registry from its
+// /v2/<repo>/tags/list endpoint
+func ListTags(ctx context.Context, registryURL, repo string) ([]string, error) {
+	tags := []string{}
+	err := getPages(ctx, registryURL, "/v2/"+repo+"/tags/list", func(body io.Reader) error {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		if err := json.NewDecoder(body).Decode(&page); err != nil {
+			return err
+		}
+		tags = append(tags, page.Tags...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", repo, err)
+	}
+	return tags, nil
+}
 
-	for time.Now().Before(deadline) {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost:5000/v2/", nil)
+// getPages fetches path from a registry and decodes each page, following the
+// rel="next" Link headers the registry sends for paginated results
+func getPages(ctx context.Context, registryURL, path string, decode func(io.Reader) error) error {
+	base, err := url.Parse(registryURL)
+	if err != nil {
+		return fmt.Errorf("invalid registry URL: %w", err)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	for next := path; next != ""; {
+		u, err := base.Parse(next)
+		if err != nil {
+			return fmt.Errorf("invalid page link %q: %w", next, err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 		if err != nil {
 			return fmt.Errorf("failed to create request: %w", err)
 		}
 
 		resp, err := client.Do(req)
-		if err == nil {
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
 			resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				return nil
-			}
+			return fmt.Errorf("%s returned HTTP %d", u.Path, resp.StatusCode)
 		}
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(500 * time.Millisecond):
-			// Retry
+		err = decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", u.Path, err)
 		}
+
+		next = nextLink(resp.Header.Get("Link"))
 	}
+	return nil
+}
 
-	return fmt.Errorf("timeout waiting for Zot registry to be ready")
+// nextLink returns the target of the rel="next" entry in a Link header, such
+// as `</v2/_catalog?last=b&n=100>; rel="next"`, or "" if there is none
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		target = strings.TrimSpace(target)
+		return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">")
+	}
+	return ""
 }
 
 // zotContentConfig represents the content prefix/destination mapping
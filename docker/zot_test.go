@@ -1,8 +1,13 @@
 package docker
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -165,3 +170,61 @@ func TestGenerateZotConfig_ContentPrefixes(t *testing.T) {
 		}
 	}
 }
+
+func TestListRepositoriesAndTags(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/_catalog" && r.URL.Query().Get("last") == "":
+			w.Header().Set("Link", `</v2/_catalog?last=library/nginx&n=2>; rel="next"`)
+			fmt.Fprint(w, `{"repositories":["kindest/node","library/nginx"]}`)
+		case r.URL.Path == "/v2/_catalog":
+			fmt.Fprint(w, `{"repositories":["trust-bundle"]}`)
+		case r.URL.Path == "/v2/library/nginx/tags/list":
+			fmt.Fprint(w, `{"name":"library/nginx","tags":["1.27","latest"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	repos, err := ListRepositories(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("ListRepositories failed: %v", err)
+	}
+	if want := []string{"kindest/node", "library/nginx", "trust-bundle"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("expected repositories %v, got %v", want, repos)
+	}
+
+	tags, err := ListTags(ctx, server.URL, "library/nginx")
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if want := []string{"1.27", "latest"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("expected tags %v, got %v", want, tags)
+	}
+
+	if _, err := ListTags(ctx, server.URL, "missing"); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{`</v2/_catalog?last=b&n=100>; rel="next"`, "/v2/_catalog?last=b&n=100"},
+		{`</v2/_catalog?n=100>; rel="prev", </v2/_catalog?last=c&n=100>; rel="next"`, "/v2/_catalog?last=c&n=100"},
+		{`</v2/_catalog?n=100>; rel="prev"`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := nextLink(tt.header); got != tt.want {
+				t.Errorf("nextLink(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
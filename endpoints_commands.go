@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"github.com/spf13/cobra"
+)
+
+// serviceEndpoint is a URL (or, for ArgoCD, a command) for reaching a service
+type serviceEndpoint struct {
+	Name   string `json:"name"`
+	EnvVar string `json:"env"`
+	URL    string `json:"url"`
+}
+
+var endpointsCmd = &cobra.Command{
+	Use:   "endpoints",
+	Short: "Print service endpoints",
+	Long: `Print the URLs of the kinder services, derived from the current config.
+
+Use --output json for scripting, or --output env for KINDER_*_URL variables:
+
+  eval $(kinder endpoints --output env)`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		domain := config.GetString(config.KeyDomain)
+		if domain == "" {
+			domain = docker.DefaultTraefikDomain
+		}
+		port := config.GetString(config.KeyTraefikPort)
+		if port == "" {
+			port = docker.DefaultTraefikPort
+		}
+
+		return writeEndpoints(os.Stdout, endpointsOutput, serviceEndpoints(domain, port, true, config.GetBool(config.KeyArgocdExpose)))
+	},
+}
+
+// serviceEndpoints returns the service endpoints for a Traefik domain and
+// port. It is shared by the start summary and the endpoints command so the
+// two never disagree. ArgoCD is reached with a port-forward unless it is
+// exposed through Traefik.
+func serviceEndpoints(domain, port string, dashboard, argocd bool) []serviceEndpoint {
+	var endpoints []serviceEndpoint
+	if dashboard {
+		endpoints = append(endpoints, serviceEndpoint{"Traefik", "KINDER_TRAEFIK_URL", fmt.Sprintf("https://traefik.%s:%s", domain, port)})
+	}
+	endpoints = append(endpoints,
+		serviceEndpoint{"Step CA", "KINDER_CA_URL", fmt.Sprintf("https://ca.%s:%s", domain, port)},
+		serviceEndpoint{"Registry", "KINDER_REGISTRY_URL", fmt.Sprintf("https://registry.%s:%s", domain, port)},
+		serviceEndpoint{"Gatus", "KINDER_GATUS_URL", fmt.Sprintf("https://gatus.%s:%s", domain, port)},
+		serviceEndpoint{"Zot (direct)", "KINDER_ZOT_URL", "http://" + localRegistry()},
+	)
+	if argocd {
+		return append(endpoints, serviceEndpoint{"ArgoCD", "KINDER_ARGOCD_URL", fmt.Sprintf("https://argocd.%s:%s", domain, port)})
+	}
+	return append(endpoints, serviceEndpoint{"ArgoCD", "KINDER_ARGOCD_PORT_FORWARD", "kubectl port-forward svc/argocd-server -n argocd 8080:443"})
+}
+
+// printEndpoints prints the endpoints summary at the end of start and restart
+func printEndpoints() {
+	Header("Endpoints:")
+	for _, e := range serviceEndpoints(traefikDomain, traefikPort, !traefikNoDashboard, config.GetBool(config.KeyArgocdExpose)) {
+		ServiceInfo(e.Name, e.URL)
+	}
+}
+
+// writeEndpoints writes endpoints as text, json or env
+func writeEndpoints(w io.Writer, format string, endpoints []serviceEndpoint) error {
+	switch format {
+	case "text":
+		for _, e := range endpoints {
+			fmt.Fprintf(w, "%s: %s\n", e.Name, e.URL)
+		}
+	case "json":
+		data, err := json.MarshalIndent(endpoints, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal endpoints: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	case "env":
+		for _, e := range endpoints {
+			fmt.Fprintf(w, "%s=%s\n", e.EnvVar, shellQuote(e.URL))
+		}
+	default:
+		return fmt.Errorf("invalid output format %q (valid: text, json, env)", format)
+	}
+	return nil
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestServiceEndpoints(t *testing.T) {
+	endpoints := serviceEndpoints("example.test", "9443", false, false)
+	if endpoints[0].Name != "Step CA" || endpoints[0].URL != "https://ca.example.test:9443" {
+		t.Errorf("expected Step CA first without the dashboard, got %+v", endpoints[0])
+	}
+
+	withDashboard := serviceEndpoints("example.test", "9443", true, false)
+	if len(withDashboard) != len(endpoints)+1 || withDashboard[0].Name != "Traefik" {
+		t.Errorf("expected Traefik dashboard endpoint first, got %+v", withDashboard)
+	}
+
+	argocd := endpoints[len(endpoints)-1]
+	if argocd.EnvVar != "KINDER_ARGOCD_PORT_FORWARD" {
+		t.Errorf("expected an ArgoCD port-forward without --expose-argocd, got %+v", argocd)
+	}
+	exposed := serviceEndpoints("example.test", "9443", false, true)
+	argocd = exposed[len(exposed)-1]
+	if argocd.EnvVar != "KINDER_ARGOCD_URL" || argocd.URL != "https://argocd.example.test:9443" {
+		t.Errorf("expected the ArgoCD URL when exposed, got %+v", argocd)
+	}
+}
+
+func TestWriteEndpoints(t *testing.T) {
+	endpoints := []serviceEndpoint{
+		{"Step CA", "KINDER_CA_URL", "https://ca.example.test:8443"},
+		{"ArgoCD", "KINDER_ARGOCD_PORT_FORWARD", "it's a command"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeEndpoints(&buf, "env", endpoints); err != nil {
+		t.Fatalf("writeEndpoints failed: %v", err)
+	}
+	expected := "KINDER_CA_URL='https://ca.example.test:8443'\nKINDER_ARGOCD_PORT_FORWARD='it'\\''s a command'\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeEndpoints(&buf, "json", endpoints); err != nil {
+		t.Fatalf("writeEndpoints failed: %v", err)
+	}
+	var decoded []serviceEndpoint
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded) != 2 {
+		t.Errorf("expected two endpoints in JSON, got %q (%v)", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := writeEndpoints(&buf, "text", endpoints); err != nil || !strings.HasPrefix(buf.String(), "Step CA: https://") {
+		t.Errorf("unexpected text output %q (%v)", buf.String(), err)
+	}
+
+	if err := writeEndpoints(&buf, "yaml", endpoints); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
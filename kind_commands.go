@@ -5,15 +5,39 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
 	"codeberg.org/hipkoi/kinder/kubernetes"
 	"github.com/spf13/cobra"
 )
 
 var (
-	kindWorkerNodes int
-	kindNodeImage   string
+	kindWorkerNodes       int
+	kindNodeImage         string
+	kindCNI               string
+	kindPodSubnet         string
+	kindServiceSubnet     string
+	kindKubeconfigMinify  bool
+	kindKubeconfigFlat    bool
+	kindForceDelete       bool
+	pullSecretNamespaces  []string
+	pullSecretAllNS       bool
+	pullSecretName        string
+	pullSecretPatchSA     bool
+	kindPreserveWorkload  bool
+	kindRegistryInsecure  bool
+	kindWorkerLabels      []string
+	kindWorkerTaints      []string
+	kindPortMaps          []string
+	kindK8sVersion        string
+	kindKubeadmPatchFiles []string
+	kindDisableCNI        bool
+	kindCNIManifest       string
+	kindWaitTimeout       time.Duration
+	kindRecreateOnImage   bool
 )
 
 var kindCmd = &cobra.Command{
@@ -28,9 +52,14 @@ var kindStartCmd = &cobra.Command{
 	Long: `Start a Kind Kubernetes cluster configured to:
 - Trust the kinder CA certificate
 - Use Zot registry as a pull-through cache for container images
-- Connect to the kinder Docker network`,
+- Connect to the kinder Docker network
+
+Use --cni to replace Kind's default CNI (kindnet) with Calico or Cilium, or
+"none" to install your own. Non-default choices disable kindnet and apply the
+chosen CNI's manifests once the cluster is created. Both Calico and Cilium use
+the cluster's pod subnet, so no extra configuration is needed.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 		return startKindCluster(ctx)
 	},
 }
@@ -38,9 +67,14 @@ var kindStartCmd = &cobra.Command{
 var kindStopCmd = &cobra.Command{
 	Use:   "stop",
 	Short: "Stop and delete the Kind cluster",
-	Long:  `Stop and delete the Kind Kubernetes cluster.`,
+	Long: `Stop and delete the Kind Kubernetes cluster.
+
+Only clusters created by kinder are deleted. A Kind cluster that happens to
+share the app name but does not mount the kinder CA is left alone unless
+--force is given.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stopKindCluster()
+		ctx := cmd.Context()
+		return stopKindCluster(ctx)
 	},
 }
 
@@ -49,7 +83,7 @@ var kindStatusCmd = &cobra.Command{
 	Short: "Show Kind cluster status",
 	Long:  `Display the status of the Kind Kubernetes cluster.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 		return showKindStatus(ctx)
 	},
 }
@@ -57,7 +91,11 @@ var kindStatusCmd = &cobra.Command{
 var kindKubeconfigCmd = &cobra.Command{
 	Use:   "kubeconfig",
 	Short: "Print kubeconfig for the Kind cluster",
-	Long:  `Print the kubeconfig needed to connect to the Kind cluster.`,
+	Long: `Print the kubeconfig needed to connect to the Kind cluster.
+
+Use --minify to keep only the Kind cluster's context, cluster and user, and
+--flatten to inline any referenced certificate files. Together they produce a
+portable single-context kubeconfig suitable for CI secrets.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		clusterName := config.GetString(config.KeyAppName)
 		if clusterName == "" {
@@ -69,18 +107,306 @@ var kindKubeconfigCmd = &cobra.Command{
 			return fmt.Errorf("failed to get kubeconfig: %w", err)
 		}
 
+		if kindKubeconfigMinify {
+			kubeconfig, err = kubernetes.MinifyKubeconfig(kubeconfig, "kind-"+clusterName)
+			if err != nil {
+				return fmt.Errorf("failed to minify kubeconfig: %w", err)
+			}
+		}
+
+		if kindKubeconfigFlat {
+			kubeconfig, err = kubernetes.FlattenKubeconfig(kubeconfig)
+			if err != nil {
+				return fmt.Errorf("failed to flatten kubeconfig: %w", err)
+			}
+		}
+
 		fmt.Println(kubeconfig)
 		return nil
 	},
 }
 
+var kindDumpCmd = &cobra.Command{
+	Use:   "dump [dir]",
+	Short: "Dump Kubernetes cluster state for debugging",
+	Long: `Run "kubectl cluster-info dump" against the Kind cluster, writing the state of
+all namespaces, including pod logs, to a directory.
+
+The directory defaults to a timestamped directory under <dataDir>/dumps. Attach
+it to bug reports alongside Kind's node-level logs.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		clusterName := config.GetString(config.KeyAppName)
+		if clusterName == "" {
+			clusterName = kubernetes.KindClusterName
+		}
+
+		exists, err := kubernetes.KindExists(clusterName)
+		if err != nil {
+			return fmt.Errorf("failed to check cluster status: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("Kind cluster '%s' does not exist", clusterName)
+		}
+
+		var outputDir string
+		if len(args) > 0 {
+			outputDir = args[0]
+		} else {
+			dataDir, err := config.GetDataDir()
+			if err != nil {
+				return fmt.Errorf("failed to get data directory: %w", err)
+			}
+			outputDir = filepath.Join(dataDir, "dumps", "cluster-info-"+time.Now().Format("20060102-150405"))
+		}
+
+		ProgressStart("📋", "Cluster info dump")
+		if err := kubernetes.DumpClusterInfo(ctx, clusterName, outputDir); err != nil {
+			ProgressDone(false, err.Error())
+			return err
+		}
+		ProgressDone(true, outputDir)
+
+		return nil
+	},
+}
+
+var kindPullSecretCmd = &cobra.Command{
+	Use:   "set-node-image-pull-secret",
+	Short: "Create an image pull secret for the Zot registry",
+	Long: `Create a kubernetes.io/dockerconfigjson secret holding the Zot registry
+credentials (zot.username and zot.password) so workloads can pull from an
+authenticated Zot as zot:5000 or localhost:5000.
+
+The secret is created in the namespaces given with --namespace, or in every
+namespace with --all-namespaces. Use --patch-service-account to also add it to
+each namespace's default ServiceAccount, so pods use it without listing
+imagePullSecrets themselves.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		clusterName := config.GetString(config.KeyAppName)
+		if clusterName == "" {
+			clusterName = kubernetes.KindClusterName
+		}
+
+		username := config.GetString(config.KeyZotUsername)
+		password := config.GetString(config.KeyZotPassword)
+		if username == "" || password == "" {
+			return fmt.Errorf("Zot credentials not configured. Set %s and %s with 'kinder config set'", config.KeyZotUsername, config.KeyZotPassword)
+		}
+
+		if !pullSecretAllNS && len(pullSecretNamespaces) == 0 {
+			return fmt.Errorf("specify --namespace or --all-namespaces")
+		}
+
+		exists, err := kubernetes.KindExists(clusterName)
+		if err != nil {
+			return fmt.Errorf("failed to check cluster status: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("Kind cluster '%s' does not exist", clusterName)
+		}
+
+		ProgressStart("🔑", "Pull secret")
+		namespaces, err := kubernetes.CreateImagePullSecrets(ctx, kubernetes.ImagePullSecretConfig{
+			ClusterName:         clusterName,
+			Name:                pullSecretName,
+			Namespaces:          pullSecretNamespaces,
+			AllNamespaces:       pullSecretAllNS,
+			Username:            username,
+			Password:            password,
+			PatchServiceAccount: pullSecretPatchSA,
+		})
+		if err != nil {
+			ProgressDone(false, err.Error())
+			return err
+		}
+		ProgressDone(true, fmt.Sprintf("%s in %d namespace(s)", pullSecretName, len(namespaces)))
+
+		return nil
+	},
+}
+
+var kindRecreateCmd = &cobra.Command{
+	Use:   "recreate",
+	Short: "Delete and recreate the Kind cluster with the current settings",
+	Long: `Delete the Kind cluster, if it exists, and create it again, applying the
+current configuration and flags (CNI, node image, workers, subnets). The
+containerd registry configuration (certs.d) is regenerated. The existing CA
+and network are reused and other kinder services are left running.
+
+With --preserve-workloads, workloads in non-system namespaces are saved before
+the cluster is deleted and re-applied once the new cluster is ready. Saved
+kinds are namespaces, ServiceAccounts, ConfigMaps, Secrets,
+PersistentVolumeClaims, Roles, RoleBindings, NetworkPolicies, Services,
+Deployments, StatefulSets, DaemonSets, CronJobs and Ingresses. Cluster-scoped
+resources (CRDs, ClusterRoles), custom resources, Jobs, bare Pods and the data
+in persistent volumes are not preserved.
+
+The snapshot is written to <dataDir>/snapshots. If re-applying it fails, the
+restored namespaces are removed again so the new cluster is left clean, and
+the snapshot is kept for a manual 'kubectl apply -f'.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		return recreateKindCluster(ctx)
+	},
+}
+
+func recreateKindCluster(ctx context.Context) error {
+	kindCfg, err := kindClusterConfig()
+	if err != nil {
+		return err
+	}
+
+	// The cluster is recreated on the existing network; other services are
+	// left alone
+	networkExists, err := docker.NetworkExists(ctx, kindCfg.NetworkName)
+	if err != nil {
+		return fmt.Errorf("failed to check network: %w", err)
+	}
+	if !networkExists {
+		return fmt.Errorf("network %s not found - run 'kinder start' first", kindCfg.NetworkName)
+	}
+
+	exists, err := kubernetes.KindExists(kindCfg.ClusterName)
+	if err != nil {
+		return fmt.Errorf("failed to check cluster status: %w", err)
+	}
+	if exists {
+		if err := ensureKinderCluster(ctx, kindCfg.ClusterName, kindForceDelete); err != nil {
+			return err
+		}
+	}
+
+	// Save workloads before anything is deleted, so a failure here is harmless
+	var snapshotPath string
+	var namespaces []string
+	if kindPreserveWorkload && exists {
+		dataDir, err := config.GetDataDir()
+		if err != nil {
+			return fmt.Errorf("failed to get data directory: %w", err)
+		}
+		snapshotDir := filepath.Join(dataDir, "snapshots")
+		if err := os.MkdirAll(snapshotDir, 0700); err != nil {
+			return fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		snapshotPath = filepath.Join(snapshotDir, "workloads-"+time.Now().Format("20060102-150405")+".yaml")
+
+		ProgressStart("💾", "Save workloads")
+		namespaces, err = kubernetes.SaveWorkloads(ctx, kindCfg.ClusterName, snapshotPath)
+		if err != nil {
+			ProgressDone(false, err.Error())
+			return err
+		}
+		ProgressDone(true, fmt.Sprintf("%d namespace(s)", len(namespaces)))
+	}
+
+	ProgressStart("🗑️", "Delete cluster")
+	if exists {
+		if err := kubernetes.StopKind(kindCfg); err != nil {
+			ProgressDone(false, err.Error())
+			return fmt.Errorf("failed to stop Kind cluster: %w", err)
+		}
+		ProgressDone(true, kindCfg.ClusterName)
+	} else {
+		ProgressSkip("not found")
+	}
+
+	warnIfNoCNI(kindCfg)
+	logKindWait(kindCfg)
+	ProgressStartLong("☸️", "Create cluster")
+	if err := kubernetes.StartKind(ctx, kindCfg); err != nil {
+		ProgressDone(false, err.Error())
+		if snapshotPath != "" {
+			Output("Saved workloads are kept in %s\n", snapshotPath)
+		}
+		return fmt.Errorf("failed to start Kind cluster: %w", err)
+	}
+	ProgressDone(true, "Ready")
+
+	if snapshotPath != "" {
+		ProgressStart("📦", "Restore workloads")
+		if err := kubernetes.RestoreWorkloads(ctx, kindCfg.ClusterName, snapshotPath); err != nil {
+			ProgressDone(false, err.Error())
+
+			// Roll back the partial restore so the new cluster is left clean
+			if cleanupErr := kubernetes.DeleteNamespaces(ctx, kindCfg.ClusterName, namespaces); cleanupErr != nil {
+				Output("Warning: failed to roll back restored workloads: %v\n", cleanupErr)
+			}
+			Output("Saved workloads are kept in %s\n", snapshotPath)
+			return err
+		}
+		ProgressDone(true, snapshotPath)
+	}
+
+	return nil
+}
+
 func startKindCluster(ctx context.Context) error {
-	// Get configuration
-	dataDir, err := config.GetDataDir()
+	kindCfg, err := kindClusterConfig()
 	if err != nil {
-		return fmt.Errorf("failed to get data directory: %w", err)
+		return err
+	}
+
+	// Check if cluster already exists
+	exists, err := kubernetes.KindExists(kindCfg.ClusterName)
+	if err != nil {
+		return fmt.Errorf("failed to check cluster status: %w", err)
+	}
+
+	if exists {
+		recreate, err := recreateOnImageChange(ctx, kindCfg)
+		if err != nil {
+			return err
+		}
+		if !recreate {
+			Output("  ✓ Kind cluster '%s' already exists\n", kindCfg.ClusterName)
+			return nil
+		}
+	}
+
+	warnIfNoCNI(kindCfg)
+	logKindWait(kindCfg)
+	Output("Creating Kind cluster '%s'...\n", kindCfg.ClusterName)
+	if err := kubernetes.StartKind(ctx, kindCfg); err != nil {
+		return fmt.Errorf("failed to start Kind cluster: %w", err)
 	}
 
+	Output("  ✓ Kind cluster '%s' created\n", kindCfg.ClusterName)
+	BlankLine()
+	Output("To use the cluster:\n")
+	Output("  export KUBECONFIG=\"$(kind get kubeconfig-path --name=%s)\"\n", kindCfg.ClusterName)
+	Output("  # or\n")
+	Output("  kubectl cluster-info --context kind-%s\n", kindCfg.ClusterName)
+
+	return nil
+}
+
+// addKindFlags registers the flags that shape a new Kind cluster, read by
+// kindClusterConfig
+func addKindFlags(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&kindWorkerNodes, "workers", 0, "Number of Kind worker nodes (0 = control-plane only)")
+	cmd.Flags().DurationVar(&kindWaitTimeout, "kind-timeout", kubernetes.DefaultKindWaitTimeout, "How long to wait for Kind nodes to become Ready (0 = don't wait)")
+	cmd.Flags().StringArrayVar(&kindWorkerLabels, "worker-label", nil, "Label for worker nodes as key=value (repeatable)")
+	cmd.Flags().StringArrayVar(&kindWorkerTaints, "worker-taint", nil, "Taint for worker nodes as key=value:Effect (repeatable)")
+	cmd.Flags().StringArrayVar(&kindPortMaps, "port-map", nil, "Publish a control-plane port on the host as containerPort:hostPort[/protocol] (repeatable)")
+	cmd.Flags().StringArrayVar(&kindKubeadmPatchFiles, "kubeadm-patch-file", nil, "File with a kubeadm config patch applied to every node (repeatable)")
+	cmd.Flags().StringVar(&kindNodeImage, "node-image", kubernetes.KindNodeImage, "Kind node image to use")
+	cmd.Flags().StringVar(&kindK8sVersion, "k8s-version", "", "Kubernetes version for the Kind node image, e.g. v1.35 (--node-image overrides)")
+	cmd.Flags().StringVar(&kindCNI, "cni", kubernetes.CNIKindnet, "CNI plugin to use (kindnet, calico, cilium, none)")
+	cmd.Flags().BoolVar(&kindDisableCNI, "disable-cni", false, "Create the cluster without the default CNI (kindnet)")
+	cmd.Flags().StringVar(&kindCNIManifest, "cni-manifest", "", "URL of a CNI manifest to apply after creation instead of kindnet")
+	cmd.Flags().StringVar(&kindPodSubnet, "pod-subnet", "", "Kind pod subnet CIDR (default: Kind's 10.244.0.0/16)")
+	cmd.Flags().StringVar(&kindServiceSubnet, "service-subnet", "", "Kind service subnet CIDR (default: Kind's 10.96.0.0/16)")
+	cmd.Flags().BoolVar(&kindRegistryInsecure, "registry-insecure", true, "Pull from Zot over plain HTTP; set to false to pull via the TLS route with CA verification")
+}
+
+// kindClusterConfig builds the Kind cluster configuration from config and the
+// flags added by addKindFlags
+func kindClusterConfig() (kubernetes.KindConfig, error) {
 	appName := config.GetString(config.KeyAppName)
 	if appName == "" {
 		appName = config.DefaultAppName
@@ -92,48 +418,154 @@ func startKindCluster(ctx context.Context) error {
 	}
 
 	// Check if CA certificate exists
-	caCertPath := filepath.Join(dataDir, CACertFilename)
+	caCertPath, err := config.CACertPath()
+	if err != nil {
+		return kubernetes.KindConfig{}, err
+	}
 	if _, err := os.Stat(caCertPath); os.IsNotExist(err) {
-		return fmt.Errorf("CA certificate not found at %s - run 'kinder start' first", caCertPath)
+		return kubernetes.KindConfig{}, fmt.Errorf("CA certificate not found at %s - run 'kinder start' first", caCertPath)
 	}
 
-	kindCfg := kubernetes.KindConfig{
-		ClusterName:     appName,
-		NodeImage:       kindNodeImage,
-		CACertPath:      caCertPath,
-		NetworkName:     networkName,
-		RegistryMirrors: buildRegistryMirrorMap(),
-		ZotHostname:     "zot",
-		WorkerNodes:     kindWorkerNodes,
+	workerLabels, err := parseKeyValues("--worker-label", kindWorkerLabels)
+	if err != nil {
+		return kubernetes.KindConfig{}, err
+	}
+	portMappings, err := parsePortMaps(kindPortMaps)
+	if err != nil {
+		return kubernetes.KindConfig{}, err
+	}
+	nodeImage, pinned, err := resolveNodeImage()
+	if err != nil {
+		return kubernetes.KindConfig{}, err
+	}
+	if !pinned {
+		Warn("no pinned node image for Kubernetes %s (pinned: %s), using %s without a digest\n",
+			kindK8sVersion, strings.Join(kubernetes.PinnedK8sVersions(), ", "), nodeImage)
+	}
+	kubeadmPatches, err := kubernetes.LoadKubeadmPatches(kindKubeadmPatchFiles)
+	if err != nil {
+		return kubernetes.KindConfig{}, err
 	}
 
-	// Check if cluster already exists
-	exists, err := kubernetes.KindExists(kindCfg.ClusterName)
+	return kubernetes.KindConfig{
+		ClusterName:       appName,
+		NodeImage:         nodeImage,
+		CACertPath:        caCertPath,
+		NetworkName:       networkName,
+		ConnectToNetwork:  networkName != "kind",
+		RegistryMirrors:   buildRegistryMirrorMap(),
+		ZotHostname:       "zot",
+		RegistryTLSHost:   registryTLSHost(),
+		WorkerNodes:       kindWorkerNodes,
+		WorkerLabels:      workerLabels,
+		WorkerTaints:      kindWorkerTaints,
+		CNI:               kindCNI,
+		DisableDefaultCNI: kindDisableCNI,
+		CNIManifestURL:    kindCNIManifest,
+		ExtraPortMappings: portMappings,
+		KubeadmPatches:    kubeadmPatches,
+		PodSubnet:         config.GetString(config.KeyKindPodSubnet),
+		ServiceSubnet:     config.GetString(config.KeyKindServiceSubnet),
+		NetworkCIDR:       kindNetworkCIDR(),
+		IPv6:              config.GetBool(config.KeyNetworkIPv6),
+		WaitForReady:      kindWaitTimeout,
+		Verbose:           IsVerbose(),
+		Logger:            kindLog{},
+	}, nil
+}
+
+// warnIfNoCNI warns when the cluster will be created without any CNI
+func warnIfNoCNI(cfg kubernetes.KindConfig) {
+	if kubernetes.WaitsForCNI(cfg) {
+		Warn("the default CNI is disabled; nodes will stay NotReady until you install a CNI\n")
+	}
+}
+
+// recreateOnImageChange checks an existing cluster's node image against the
+// requested one. On a mismatch it warns, or with --recreate-on-image-change
+// deletes the cluster and reports that it should be created again.
+func recreateOnImageChange(ctx context.Context, cfg kubernetes.KindConfig) (bool, error) {
+	current, err := kubernetes.NodeImage(ctx, cfg.ClusterName)
 	if err != nil {
-		return fmt.Errorf("failed to check cluster status: %w", err)
+		Verbose("Could not check the node image: %v\n", err)
+		return false, nil
+	}
+	// Kind creates clusters with the default image when none is set
+	want := cfg.NodeImage
+	if want == "" {
+		want = kubernetes.KindNodeImage
+	}
+	if kubernetes.SameNodeImage(current, want) {
+		return false, nil
 	}
 
-	if exists {
-		fmt.Printf("  ✓ Kind cluster '%s' already exists\n", kindCfg.ClusterName)
-		return nil
+	if !kindRecreateOnImage {
+		Warn("Kind cluster '%s' runs %s, not %s; use --recreate-on-image-change or 'kinder kind recreate' to rebuild it\n", cfg.ClusterName, current, want)
+		return false, nil
 	}
 
-	fmt.Printf("Creating Kind cluster '%s'...\n", kindCfg.ClusterName)
-	if err := kubernetes.StartKind(ctx, kindCfg); err != nil {
-		return fmt.Errorf("failed to start Kind cluster: %w", err)
+	if err := ensureKinderCluster(ctx, cfg.ClusterName, false); err != nil {
+		return false, err
+	}
+	Verbose("Recreating Kind cluster '%s': node image changed from %s to %s\n", cfg.ClusterName, current, want)
+	if err := kubernetes.StopKind(cfg); err != nil {
+		return false, fmt.Errorf("failed to delete Kind cluster: %w", err)
 	}
+	return true, nil
+}
 
-	fmt.Printf("  ✓ Kind cluster '%s' created\n", kindCfg.ClusterName)
-	fmt.Println()
-	fmt.Println("To use the cluster:")
-	fmt.Printf("  export KUBECONFIG=\"$(kind get kubeconfig-path --name=%s)\"\n", kindCfg.ClusterName)
-	fmt.Println("  # or")
-	fmt.Printf("  kubectl cluster-info --context kind-%s\n", kindCfg.ClusterName)
+// logKindWait reports in verbose output how long creation waits for nodes
+func logKindWait(cfg kubernetes.KindConfig) {
+	if wait := kubernetes.ReadyWait(cfg); wait > 0 {
+		Verbose("Waiting up to %s for nodes to become Ready\n", wait)
+	} else {
+		Verbose("Not waiting for nodes to become Ready\n")
+	}
+}
 
-	return nil
+// resolveNodeImage returns the Kind node image: --node-image when changed from
+// the default, otherwise the image for --k8s-version if given. pinned is false
+// only for a --k8s-version without a known digest.
+func resolveNodeImage() (image string, pinned bool, err error) {
+	if kindK8sVersion == "" || kindNodeImage != kubernetes.KindNodeImage {
+		return kindNodeImage, true, nil
+	}
+	return kubernetes.NodeImageForVersion(kindK8sVersion)
 }
 
-func stopKindCluster() error {
+// parsePortMaps parses the --port-map flag values
+func parsePortMaps(values []string) ([]kubernetes.PortMapping, error) {
+	var mappings []kubernetes.PortMapping
+	for _, v := range values {
+		pm, err := kubernetes.ParsePortMapping(v)
+		if err != nil {
+			return nil, fmt.Errorf("--port-map: %w", err)
+		}
+		mappings = append(mappings, pm)
+	}
+	return mappings, nil
+}
+
+// kindNetworkCIDR returns the network CIDR for the Kind subnet overlap
+// checks, or "" when it is auto and has not been resolved yet
+func kindNetworkCIDR() string {
+	cidr := config.GetString(config.KeyNetworkCIDR)
+	if cidr == config.AutoNetworkCIDR {
+		return ""
+	}
+	return cidr
+}
+
+// registryTLSHost returns Zot's TLS route for nodes to pull through when
+// --registry-insecure=false, or "" to keep the plain HTTP path
+func registryTLSHost() string {
+	if kindRegistryInsecure {
+		return ""
+	}
+	return fmt.Sprintf("registry.%s:%s", config.GetString(config.KeyDomain), config.GetString(config.KeyTraefikPort))
+}
+
+func stopKindCluster(ctx context.Context) error {
 	appName := config.GetString(config.KeyAppName)
 	if appName == "" {
 		appName = config.DefaultAppName
@@ -150,16 +582,38 @@ func stopKindCluster() error {
 	}
 
 	if !exists {
-		fmt.Printf("  ✓ Kind cluster '%s' does not exist\n", kindCfg.ClusterName)
+		Output("  ✓ Kind cluster '%s' does not exist\n", kindCfg.ClusterName)
 		return nil
 	}
 
-	fmt.Printf("Deleting Kind cluster '%s'...\n", kindCfg.ClusterName)
+	if err := ensureKinderCluster(ctx, kindCfg.ClusterName, kindForceDelete); err != nil {
+		return err
+	}
+
+	Output("Deleting Kind cluster '%s'...\n", kindCfg.ClusterName)
 	if err := kubernetes.StopKind(kindCfg); err != nil {
 		return fmt.Errorf("failed to stop Kind cluster: %w", err)
 	}
 
-	fmt.Printf("  ✓ Kind cluster '%s' deleted\n", kindCfg.ClusterName)
+	Output("  ✓ Kind cluster '%s' deleted\n", kindCfg.ClusterName)
+	return nil
+}
+
+// ensureKinderCluster returns an error if the named Kind cluster was not
+// created by kinder, unless force is set
+func ensureKinderCluster(ctx context.Context, clusterName string, force bool) error {
+	if force {
+		return nil
+	}
+
+	owned, err := kubernetes.IsKinderCluster(ctx, clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to check cluster ownership: %w", err)
+	}
+	if !owned {
+		return fmt.Errorf("Kind cluster '%s' was not created by kinder; use --force to delete it anyway", clusterName)
+	}
+
 	return nil
 }
 
@@ -1,13 +1,11 @@
 package kubernetes
 
 import (
-	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
 	"net/url"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"time"
@@ -17,11 +15,23 @@ import (
 
 var k8sNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
 
+// labelNameRegex matches the name part of a label or annotation key, and label values
+var labelNameRegex = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// labelPrefixRegex matches the optional DNS subdomain prefix of a label or annotation key
+var labelPrefixRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
 const (
 	ArgoCDNamespace  = "argocd"
 	ArgoCDInstallURL = "https://raw.githubusercontent.com/argoproj/argo-cd"
+	// ArgoCDNodePort is the node port argocd-server is published on when
+	// exposed, for Traefik to route argocd.<domain> to
+	ArgoCDNodePort = 30080
 )
 
+// kinderAppNames are the Applications created for kinder's OCI artifacts
+var kinderAppNames = []string{"kinder-trust-bundle", "kinder-cert-issuer"}
+
 type GitCredentialType string
 
 const (
@@ -44,6 +54,35 @@ type ArgoCDConfig struct {
 	Domain            string
 	Port              string
 
+	// Extra metadata for every generated Application
+	AppLabels      map[string]string
+	AppAnnotations map[string]string
+
+	// Project is the ArgoCD project for every generated Application
+	Project string
+	// CreateProject generates an AppProject allowing the configured repositories
+	CreateProject bool
+
+	// WaitForApps waits for the generated Applications to become Healthy and
+	// Synced at the end of Install
+	WaitForApps bool
+
+	// Expose publishes argocd-server over plain HTTP on ArgoCDNodePort, so
+	// Traefik can serve it with a kinder-issued certificate
+	Expose bool
+
+	// KeepNamespace leaves the ArgoCD namespace in place on Uninstall
+	KeepNamespace bool
+	// UseKubectl shells out to kubectl instead of talking to the API server
+	// in-process
+	UseKubectl bool
+
+	// AutoSync enables automated sync for generated Applications; Prune and
+	// SelfHeal only apply when it is set
+	AutoSync bool
+	Prune    bool
+	SelfHeal bool
+
 	// Git repository for initial app
 	RepoURL         string
 	RepoPath        string
@@ -58,6 +97,10 @@ type ArgoCDConfig struct {
 	HTTPPassword      string
 	SSHPrivateKey     string
 	SSHPrivateKeyPath string
+
+	// Credentials for pulling the kinder apps from ZotRegistryURL
+	RegistryUsername string
+	RegistryPassword string
 }
 
 // Install installs ArgoCD with anonymous access enabled (no authentication).
@@ -66,15 +109,25 @@ func Install(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error
 	if err := loadSSHKey(&cfg); err != nil {
 		return err
 	}
+	client, err := newClusterClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+	return install(ctx, client, cfg, progress)
+}
+
+// install applies ArgoCD and the configured extras through client. cfg must
+// already have its defaults set.
+func install(ctx context.Context, client clusterClient, cfg ArgoCDConfig, progress func(string)) error {
 
 	steps := []struct {
 		msg string
 		fn  func() error
 	}{
-		{"Creating namespace", func() error { return kubectl(ctx, cfg, namespaceYAML(cfg.Namespace)) }},
-		{"Installing ArgoCD " + cfg.Version, func() error { return kubectlURL(ctx, cfg, installURL(cfg.Version)) }},
-		{"Disabling authentication", func() error { return disableAuth(ctx, cfg) }},
-		{"Waiting for rollout", func() error { return waitReady(ctx, cfg) }},
+		{"Creating namespace", func() error { return client.apply(ctx, namespaceYAML(cfg.Namespace)) }},
+		{"Installing ArgoCD " + cfg.Version, func() error { return client.applyURL(ctx, installURL(cfg.Version)) }},
+		{"Disabling authentication", func() error { return disableAuth(ctx, client) }},
+		{"Waiting for rollout", func() error { return waitReady(ctx, client, cfg.WaitTimeout) }},
 	}
 
 	for _, s := range steps {
@@ -86,12 +139,23 @@ func Install(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error
 		}
 	}
 
+	// Optional: publish the server for Traefik. server.insecure, set by
+	// disableAuth, makes it serve the UI and gRPC-web over plain HTTP.
+	if cfg.Expose {
+		if progress != nil {
+			progress("Exposing ArgoCD server")
+		}
+		if err := client.apply(ctx, exposeServiceYAML(cfg)); err != nil {
+			return fmt.Errorf("expose server: %w", err)
+		}
+	}
+
 	// Optional: mount CA cert for TLS to private registries
 	if cfg.CACertPEM != "" {
-		if err := kubectl(ctx, cfg, caSecretYAML(cfg)); err != nil {
+		if err := client.apply(ctx, caSecretYAML(cfg)); err != nil {
 			return fmt.Errorf("create CA secret: %w", err)
 		}
-		if err := patchRepoServer(ctx, cfg); err != nil {
+		if err := patchRepoServer(ctx, client); err != nil {
 			return fmt.Errorf("patch repo-server: %w", err)
 		}
 	}
@@ -105,11 +169,28 @@ func Install(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error
 		if err != nil {
 			return err
 		}
-		if err := kubectl(ctx, cfg, secret); err != nil {
+		if err := client.apply(ctx, secret); err != nil {
 			return fmt.Errorf("create repo secret: %w", err)
 		}
 	}
 
+	// Optional: create the project before any Application references it
+	if cfg.CreateProject {
+		if progress != nil {
+			progress("Creating project " + cfg.Project)
+		}
+		project, err := appProjectYAML(cfg)
+		if err != nil {
+			return err
+		}
+		if err := client.apply(ctx, project); err != nil {
+			return fmt.Errorf("create project: %w", err)
+		}
+	}
+
+	// Applications created below, for WaitForApps
+	var apps []string
+
 	// Optional: create initial Application
 	if !cfg.SkipInitialApp && cfg.RepoURL != "" {
 		if progress != nil {
@@ -119,9 +200,11 @@ func Install(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error
 		if err != nil {
 			return err
 		}
-		if err := kubectl(ctx, cfg, app); err != nil {
+		if err := client.apply(ctx, app); err != nil {
 			return fmt.Errorf("create application: %w", err)
 		}
+		name, _ := sanitizeName(cfg.AppName) // validated by applicationYAML
+		apps = append(apps, name)
 	}
 
 	// Optional: create kinder OCI apps
@@ -129,9 +212,23 @@ func Install(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error
 		if progress != nil {
 			progress("Creating kinder applications")
 		}
-		if err := kubectl(ctx, cfg, kinderAppsYAML(cfg)); err != nil {
+		if cfg.RegistryUsername != "" || cfg.RegistryPassword != "" {
+			secret, err := registrySecretYAML(cfg)
+			if err != nil {
+				return err
+			}
+			if err := client.apply(ctx, secret); err != nil {
+				return fmt.Errorf("create registry secret: %w", err)
+			}
+		}
+		manifest, err := kinderAppsYAML(cfg)
+		if err != nil {
+			return err
+		}
+		if err := client.apply(ctx, manifest); err != nil {
 			return fmt.Errorf("create kinder apps: %w", err)
 		}
+		apps = append(apps, kinderAppNames...)
 	}
 
 	// Optional: apply app-of-apps manifest from URL
@@ -142,23 +239,160 @@ func Install(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error
 		if progress != nil {
 			progress("Applying " + cfg.ManifestURL)
 		}
-		if err := kubectlURL(ctx, cfg, cfg.ManifestURL); err != nil {
+		if err := client.applyURL(ctx, cfg.ManifestURL); err != nil {
 			return fmt.Errorf("apply manifest URL: %w", err)
 		}
 	}
 
+	if cfg.WaitForApps && len(apps) > 0 {
+		if progress != nil {
+			progress("Waiting for applications")
+		}
+		if err := waitForApplications(ctx, client, apps, cfg.WaitTimeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appStatus is the health and sync status of an ArgoCD Application
+type appStatus struct {
+	Health string
+	Sync   string
+}
+
+// ready reports whether the Application is Healthy and Synced
+func (s appStatus) ready() bool {
+	return s.Health == "Healthy" && s.Sync == "Synced"
+}
+
+func (s appStatus) String() string {
+	health, sync := s.Health, s.Sync
+	if health == "" {
+		health = "Unknown"
+	}
+	if sync == "" {
+		sync = "Unknown"
+	}
+	return fmt.Sprintf("health %s, sync %s", health, sync)
+}
+
+// WaitForApplications waits for the named Applications to become Healthy and
+// Synced. On timeout the error lists the last known status of each
+// Application that was not ready.
+func WaitForApplications(ctx context.Context, cfg ArgoCDConfig, names []string, timeout time.Duration) error {
+	setDefaults(&cfg)
+	client, err := newClusterClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+	return waitForApplications(ctx, client, names, timeout)
+}
+
+func waitForApplications(ctx context.Context, client clusterClient, names []string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	last := make(map[string]string, len(names))
+	for _, name := range names {
+		last[name] = "no status"
+	}
+	for {
+		var pending []string
+		for _, name := range names {
+			status, err := client.applicationStatus(ctx, name)
+			switch {
+			case isNotFound(err):
+				last[name] = "not found"
+			case err != nil && ctx.Err() == nil:
+				return fmt.Errorf("failed to get application %s: %w", name, err)
+			case err == nil:
+				last[name] = status.String()
+				if status.ready() {
+					continue
+				}
+			}
+			pending = append(pending, name)
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			details := make([]string, len(pending))
+			for i, name := range pending {
+				details[i] = fmt.Sprintf("%s (%s)", name, last[name])
+			}
+			return fmt.Errorf("timed out after %s waiting for applications: %s", timeout, strings.Join(details, "; "))
+		case <-time.After(restPollInterval):
+		}
+	}
+}
+
+// Uninstall removes ArgoCD from the cluster. Applications are deleted first,
+// while the controller is still running to process their finalizers, then the
+// install manifests and finally the namespace unless cfg.KeepNamespace is set.
+func Uninstall(ctx context.Context, cfg ArgoCDConfig, progress func(string)) error {
+	setDefaults(&cfg)
+	client, err := newClusterClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to cluster: %w", err)
+	}
+
+	steps := []struct {
+		msg string
+		fn  func() error
+	}{
+		{"Deleting applications", func() error { return deleteApplications(ctx, client, cfg.WaitTimeout) }},
+		{"Removing ArgoCD " + cfg.Version, func() error {
+			return client.deleteURL(ctx, installURL(cfg.Version), cfg.WaitTimeout)
+		}},
+	}
+	if !cfg.KeepNamespace {
+		steps = append(steps, struct {
+			msg string
+			fn  func() error
+		}{"Deleting namespace", func() error {
+			return client.deleteNamespace(ctx, cfg.Namespace, cfg.WaitTimeout)
+		}})
+	}
+
+	for _, s := range steps {
+		if progress != nil {
+			progress(s.msg)
+		}
+		if err := s.fn(); err != nil {
+			return fmt.Errorf("%s: %w", strings.ToLower(s.msg), err)
+		}
+	}
+
 	return nil
 }
 
+// deleteApplications deletes all Applications in the ArgoCD namespace and
+// waits for their finalizers. Does nothing if the Application CRD is absent.
+func deleteApplications(ctx context.Context, client clusterClient, timeout time.Duration) error {
+	installed, err := client.hasCRD(ctx, "applications.argoproj.io")
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return nil // ArgoCD is not installed
+	}
+	return client.deleteAll(ctx, "argoproj.io/v1alpha1", "Application", timeout)
+}
+
 // disableAuth configures ArgoCD for anonymous admin access.
-func disableAuth(ctx context.Context, cfg ArgoCDConfig) error {
+func disableAuth(ctx context.Context, client clusterClient) error {
 	patches := map[string]string{
 		"argocd-cmd-params-cm": `{"data":{"server.insecure":"true"}}`,
 		"argocd-cm":            `{"data":{"users.anonymous.enabled":"true"}}`,
 		"argocd-rbac-cm":       `{"data":{"policy.default":"role:admin"}}`,
 	}
 	for cm, patch := range patches {
-		if err := kubectlPatch(ctx, cfg, "configmap", cm, patch); err != nil {
+		if err := client.patch(ctx, "configmap", cm, patch); err != nil {
 			return fmt.Errorf("patch %s: %w", cm, err)
 		}
 	}
@@ -166,27 +400,25 @@ func disableAuth(ctx context.Context, cfg ArgoCDConfig) error {
 }
 
 // waitReady waits for core ArgoCD deployments.
-func waitReady(ctx context.Context, cfg ArgoCDConfig) error {
+func waitReady(ctx context.Context, client clusterClient, timeout time.Duration) error {
 	deploys := []string{"argocd-server", "argocd-repo-server", "argocd-redis"}
-	timeout := cfg.WaitTimeout.String()
 
 	for _, d := range deploys {
-		args := kubectlArgs(cfg, "rollout", "status", "deployment/"+d, "-n", cfg.Namespace, "--timeout", timeout)
-		if err := exec.CommandContext(ctx, "kubectl", args...).Run(); err != nil {
-			continue // optional deployments may not exist
+		if err := client.waitRollout(ctx, d, timeout); err != nil {
+			return fmt.Errorf("deployment %s: %w", d, err)
 		}
 	}
 	return nil
 }
 
 // patchRepoServer mounts the CA certificate into argocd-repo-server.
-func patchRepoServer(ctx context.Context, cfg ArgoCDConfig) error {
+func patchRepoServer(ctx context.Context, client clusterClient) error {
 	patch := `{"spec":{"template":{"spec":{` +
 		`"volumes":[{"name":"kinder-ca","secret":{"secretName":"kinder-ca-cert"}}],` +
 		`"containers":[{"name":"argocd-repo-server",` +
 		`"env":[{"name":"SSL_CERT_FILE","value":"/etc/ssl/certs/kinder-ca.crt"}],` +
 		`"volumeMounts":[{"name":"kinder-ca","mountPath":"/etc/ssl/certs/kinder-ca.crt","subPath":"ca.crt","readOnly":true}]}]}}}}`
-	return kubectlPatch(ctx, cfg, "deployment", "argocd-repo-server", patch)
+	return client.patch(ctx, "deployment", "argocd-repo-server", patch)
 }
 
 // --- kubectl helpers ---
@@ -201,40 +433,6 @@ func kubectlArgs(cfg ArgoCDConfig, args ...string) []string {
 	return args
 }
 
-func kubectl(ctx context.Context, cfg ArgoCDConfig, manifest string) error {
-	args := kubectlArgs(cfg, "apply", "-f", "-")
-	cmd := exec.CommandContext(ctx, "kubectl", args...)
-	cmd.Stdin = strings.NewReader(manifest)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%w: %s", err, stderr.String())
-	}
-	return nil
-}
-
-func kubectlURL(ctx context.Context, cfg ArgoCDConfig, url string) error {
-	args := kubectlArgs(cfg, "apply", "-f", url, "-n", cfg.Namespace)
-	cmd := exec.CommandContext(ctx, "kubectl", args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%w: %s", err, stderr.String())
-	}
-	return nil
-}
-
-func kubectlPatch(ctx context.Context, cfg ArgoCDConfig, kind, name, patch string) error {
-	args := kubectlArgs(cfg, "patch", kind, name, "-n", cfg.Namespace, "--type=strategic", "-p", patch)
-	cmd := exec.CommandContext(ctx, "kubectl", args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("%w: %s", err, stderr.String())
-	}
-	return nil
-}
-
 // --- YAML generators ---
 
 func namespaceYAML(ns string) string {
@@ -247,6 +445,26 @@ metadata:
 `, ns)
 }
 
+func exposeServiceYAML(cfg ArgoCDConfig) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Service
+metadata:
+  name: argocd-server-kinder
+  namespace: %s
+  labels:
+    app.kubernetes.io/part-of: kinder
+spec:
+  type: NodePort
+  selector:
+    app.kubernetes.io/name: argocd-server
+  ports:
+    - name: http
+      port: 80
+      targetPort: 8080
+      nodePort: %d
+`, cfg.Namespace, ArgoCDNodePort)
+}
+
 func caSecretYAML(cfg ArgoCDConfig) string {
 	return fmt.Sprintf(`apiVersion: v1
 kind: Secret
@@ -296,6 +514,31 @@ metadata:
 `, repoName(cfg.RepoURL), cfg.Namespace, data), nil
 }
 
+// registrySecretYAML creates an ArgoCD repository Secret holding the
+// credentials for the OCI registry serving the kinder apps
+func registrySecretYAML(cfg ArgoCDConfig) (string, error) {
+	if cfg.RegistryUsername == "" || cfg.RegistryPassword == "" {
+		return "", fmt.Errorf("registry credentials require username and password")
+	}
+	return marshalManifests(secretResource{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: objectMeta{
+			Name:      "kinder-registry",
+			Namespace: cfg.Namespace,
+			Labels:    map[string]string{"argocd.argoproj.io/secret-type": "repository"},
+		},
+		Data: map[string][]byte{
+			"type":      []byte("helm"),
+			"name":      []byte("kinder-registry"),
+			"url":       []byte(cfg.ZotRegistryURL),
+			"enableOCI": []byte("true"),
+			"username":  []byte(cfg.RegistryUsername),
+			"password":  []byte(cfg.RegistryPassword),
+		},
+	})
+}
+
 func applicationYAML(cfg ArgoCDConfig) (string, error) {
 	if err := validateGitURL(cfg.RepoURL); err != nil {
 		return "", err
@@ -304,55 +547,132 @@ func applicationYAML(cfg ArgoCDConfig) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
-kind: Application
-metadata:
-  name: %s
-  namespace: %s
-  finalizers:
-    - resources-finalizer.argocd.argoproj.io
-spec:
-  project: default
-  source:
-    repoURL: %s
-    targetRevision: %s
-    path: %s
-  destination:
-    server: https://kubernetes.default.svc
-    namespace: %s
-  syncPolicy:
-    automated:
-      prune: true
-      selfHeal: true
-    syncOptions:
-      - CreateNamespace=true
-`, appName, cfg.Namespace, cfg.RepoURL, cfg.RepoBranch, cfg.RepoPath, cfg.TargetNamespace), nil
-}
-
-func kinderAppsYAML(cfg ArgoCDConfig) string {
-	app := func(name, image, tag string) string {
-		return fmt.Sprintf(`apiVersion: argoproj.io/v1alpha1
-kind: Application
-metadata:
-  name: %s
-  namespace: %s
-spec:
-  project: default
-  source:
-    repoURL: %s/%s
-    targetRevision: %s
-  destination:
-    server: https://kubernetes.default.svc
-    namespace: cert-manager
-  syncPolicy:
-    automated:
-      prune: true
-      selfHeal: true
-`, name, cfg.Namespace, cfg.ZotRegistryURL, image, tag)
-	}
-	return app("kinder-trust-bundle", TrustManagerBundleImageName, TrustManagerBundleImageTag) +
-		"---\n" +
-		app("kinder-cert-issuer", CertManagerIssuerImageName, CertManagerIssuerImageTag)
+	if err := validateAppMetadata(cfg); err != nil {
+		return "", err
+	}
+	project, err := projectName(cfg)
+	if err != nil {
+		return "", err
+	}
+	return marshalManifests(applicationResource{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Application",
+		Metadata: objectMeta{
+			Name:        appName,
+			Namespace:   cfg.Namespace,
+			Labels:      cfg.AppLabels,
+			Annotations: cfg.AppAnnotations,
+			Finalizers:  []string{"resources-finalizer.argocd.argoproj.io"},
+		},
+		Spec: applicationSpec{
+			Project: project,
+			Source: applicationSource{
+				RepoURL:        cfg.RepoURL,
+				TargetRevision: cfg.RepoBranch,
+				Path:           cfg.RepoPath,
+			},
+			Destination: applicationDestination{
+				Server:    "https://kubernetes.default.svc",
+				Namespace: cfg.TargetNamespace,
+			},
+			SyncPolicy: syncPolicyFor(cfg, "CreateNamespace=true"),
+		},
+	})
+}
+
+func kinderAppsYAML(cfg ArgoCDConfig) (string, error) {
+	if err := validateAppMetadata(cfg); err != nil {
+		return "", err
+	}
+	project, err := projectName(cfg)
+	if err != nil {
+		return "", err
+	}
+	app := func(name, image, tag string) applicationResource {
+		return applicationResource{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "Application",
+			Metadata: objectMeta{
+				Name:        name,
+				Namespace:   cfg.Namespace,
+				Labels:      cfg.AppLabels,
+				Annotations: cfg.AppAnnotations,
+			},
+			Spec: applicationSpec{
+				Project: project,
+				Source: applicationSource{
+					RepoURL:        cfg.ZotRegistryURL + "/" + image,
+					TargetRevision: tag,
+				},
+				Destination: applicationDestination{
+					Server:    "https://kubernetes.default.svc",
+					Namespace: CertManagerNamespace,
+				},
+				SyncPolicy: syncPolicyFor(cfg),
+			},
+		}
+	}
+	return marshalManifests(
+		app(kinderAppNames[0], TrustManagerBundleImageName, TrustManagerBundleImageTag),
+		app(kinderAppNames[1], CertManagerIssuerImageName, CertManagerIssuerImageTag),
+	)
+}
+
+// syncPolicyFor returns the sync policy for generated Applications. The
+// automated block is omitted when auto-sync is off, leaving manual sync.
+func syncPolicyFor(cfg ArgoCDConfig, syncOptions ...string) *syncPolicy {
+	policy := &syncPolicy{SyncOptions: syncOptions}
+	if cfg.AutoSync {
+		policy.Automated = &automatedSync{Prune: cfg.Prune, SelfHeal: cfg.SelfHeal}
+	}
+	if policy.Automated == nil && len(policy.SyncOptions) == 0 {
+		return nil
+	}
+	return policy
+}
+
+// appProjectYAML creates an AppProject allowing the initial app's repository
+// and, with the kinder apps, the local registry, to deploy to their target
+// namespaces
+func appProjectYAML(cfg ArgoCDConfig) (string, error) {
+	project, err := projectName(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	sourceRepos := []string{}
+	destinations := []applicationDestination{}
+	if cfg.RepoURL != "" {
+		sourceRepos = append(sourceRepos, cfg.RepoURL)
+		destinations = append(destinations, applicationDestination{
+			Server:    "https://kubernetes.default.svc",
+			Namespace: cfg.TargetNamespace,
+		})
+	}
+	if cfg.IncludeKinderApps {
+		sourceRepos = append(sourceRepos, cfg.ZotRegistryURL+"/*")
+		destinations = append(destinations, applicationDestination{
+			Server:    "https://kubernetes.default.svc",
+			Namespace: CertManagerNamespace,
+		})
+	}
+
+	return marshalManifests(appProjectResource{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "AppProject",
+		Metadata: objectMeta{
+			Name:      project,
+			Namespace: cfg.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "kinder"},
+		},
+		Spec: appProjectSpec{
+			Description:  "Applications created by kinder",
+			SourceRepos:  sourceRepos,
+			Destinations: destinations,
+			// The initial Application creates its target namespace
+			ClusterResourceWhitelist: []groupKind{{Group: "", Kind: "Namespace"}},
+		},
+	})
 }
 
 // --- Helpers ---
@@ -373,6 +693,9 @@ func setDefaults(cfg *ArgoCDConfig) {
 	if cfg.AppName == "" {
 		cfg.AppName = "root"
 	}
+	if cfg.Project == "" {
+		cfg.Project = "default"
+	}
 	if cfg.TargetNamespace == "" {
 		cfg.TargetNamespace = "default"
 	}
@@ -423,6 +746,61 @@ func sanitizeName(name string) (string, error) {
 	return name, nil
 }
 
+// projectName returns the validated ArgoCD project, defaulting to "default"
+func projectName(cfg ArgoCDConfig) (string, error) {
+	if cfg.Project == "" {
+		return "default", nil
+	}
+	project, err := sanitizeName(cfg.Project)
+	if err != nil {
+		return "", fmt.Errorf("invalid project: %w", err)
+	}
+	return project, nil
+}
+
+// validateAppMetadata checks AppLabels and AppAnnotations against the
+// Kubernetes rules for label and annotation keys and label values
+func validateAppMetadata(cfg ArgoCDConfig) error {
+	if err := validateLabels(cfg.AppLabels); err != nil {
+		return err
+	}
+	for key := range cfg.AppAnnotations {
+		if err := validateMetadataKey(key); err != nil {
+			return fmt.Errorf("invalid annotation %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// validateLabels checks label keys and values against the Kubernetes rules
+func validateLabels(labels map[string]string) error {
+	for key, value := range labels {
+		if err := validateMetadataKey(key); err != nil {
+			return fmt.Errorf("invalid label %q: %w", key, err)
+		}
+		if value != "" && (len(value) > 63 || !labelNameRegex.MatchString(value)) {
+			return fmt.Errorf("invalid label %q: value %q must be at most 63 alphanumeric characters, '-', '_' or '.'", key, value)
+		}
+	}
+	return nil
+}
+
+// validateMetadataKey checks a label or annotation key: an optional DNS
+// subdomain prefix and "/", followed by a name of at most 63 characters
+func validateMetadataKey(key string) error {
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) > 253 || !labelPrefixRegex.MatchString(prefix) {
+			return fmt.Errorf("prefix %q must be a DNS subdomain", prefix)
+		}
+		name = rest
+	}
+	if len(name) > 63 || !labelNameRegex.MatchString(name) {
+		return fmt.Errorf("name %q must be 1-63 alphanumeric characters, '-', '_' or '.'", name)
+	}
+	return nil
+}
+
 func validateURL(u string) error {
 	parsed, err := url.Parse(u)
 	if err != nil {
@@ -0,0 +1,441 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestApplicationYAML(t *testing.T) {
+	cfg := ArgoCDConfig{
+		Namespace:       ArgoCDNamespace,
+		RepoURL:         "https://example.com/org/apps.git",
+		RepoPath:        "clusters/dev: {}",
+		RepoBranch:      "main",
+		AppName:         "Root",
+		TargetNamespace: "apps",
+		AppLabels:       map[string]string{"team": "platform"},
+		AppAnnotations:  map[string]string{"argocd.argoproj.io/sync-wave": "-1"},
+		AutoSync:        true,
+		Prune:           true,
+	}
+
+	manifest, err := applicationYAML(cfg)
+	if err != nil {
+		t.Fatalf("applicationYAML failed: %v", err)
+	}
+
+	var app applicationResource
+	if err := yaml.UnmarshalStrict([]byte(manifest), &app); err != nil {
+		t.Fatalf("application is not valid YAML: %v\n%s", err, manifest)
+	}
+
+	if app.Metadata.Name != "root" {
+		t.Errorf("expected sanitized name root, got %q", app.Metadata.Name)
+	}
+	if app.Metadata.Labels["team"] != "platform" {
+		t.Errorf("expected label team=platform, got %v", app.Metadata.Labels)
+	}
+	if app.Metadata.Annotations["argocd.argoproj.io/sync-wave"] != "-1" {
+		t.Errorf("expected sync-wave annotation, got %v", app.Metadata.Annotations)
+	}
+	if app.Spec.Source.Path != cfg.RepoPath {
+		t.Errorf("expected path %q to round-trip, got %q", cfg.RepoPath, app.Spec.Source.Path)
+	}
+	if app.Spec.Destination.Namespace != "apps" {
+		t.Errorf("expected destination namespace apps, got %q", app.Spec.Destination.Namespace)
+	}
+	if app.Spec.SyncPolicy == nil || app.Spec.SyncPolicy.Automated == nil ||
+		!app.Spec.SyncPolicy.Automated.Prune || app.Spec.SyncPolicy.Automated.SelfHeal {
+		t.Errorf("expected automated sync with prune only, got %+v", app.Spec.SyncPolicy)
+	}
+}
+
+func TestKinderAppsYAML(t *testing.T) {
+	cfg := ArgoCDConfig{
+		Namespace:      ArgoCDNamespace,
+		ZotRegistryURL: "registry.dev.test:8443",
+		AppLabels:      map[string]string{"team": "platform"},
+	}
+
+	manifest, err := kinderAppsYAML(cfg)
+	if err != nil {
+		t.Fatalf("kinderAppsYAML failed: %v", err)
+	}
+
+	docs := strings.Split(manifest, "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d:\n%s", len(docs), manifest)
+	}
+
+	want := []string{
+		"registry.dev.test:8443/" + TrustManagerBundleImageName,
+		"registry.dev.test:8443/" + CertManagerIssuerImageName,
+	}
+	for i, doc := range docs {
+		var app applicationResource
+		if err := yaml.UnmarshalStrict([]byte(doc), &app); err != nil {
+			t.Fatalf("document %d is not valid YAML: %v", i, err)
+		}
+		if app.Spec.Source.RepoURL != want[i] {
+			t.Errorf("expected repoURL %q, got %q", want[i], app.Spec.Source.RepoURL)
+		}
+		if app.Metadata.Labels["team"] != "platform" {
+			t.Errorf("expected label team=platform on %s, got %v", app.Metadata.Name, app.Metadata.Labels)
+		}
+	}
+}
+
+func TestValidateAppMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{"empty", nil, nil, false},
+		{"simple label", map[string]string{"team": "platform"}, nil, false},
+		{"prefixed label", map[string]string{"example.com/tier": "backend"}, nil, false},
+		{"empty label value", map[string]string{"team": ""}, nil, false},
+		{"annotation with free-form value", nil, map[string]string{"notifications.argoproj.io/subscribe.on-sync-succeeded.slack": "dev, ops"}, false},
+		{"label value with space", map[string]string{"team": "plat form"}, nil, true},
+		{"label value too long", map[string]string{"team": strings.Repeat("a", 64)}, nil, true},
+		{"label key with bad prefix", map[string]string{"Example.com/tier": "backend"}, nil, true},
+		{"label key with empty name", map[string]string{"example.com/": "x"}, nil, true},
+		{"annotation key too long", nil, map[string]string{strings.Repeat("a", 64): "x"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAppMetadata(ArgoCDConfig{AppLabels: tt.labels, AppAnnotations: tt.annotations})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAppMetadata() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplicationProject(t *testing.T) {
+	cfg := ArgoCDConfig{
+		Namespace:         ArgoCDNamespace,
+		RepoURL:           "https://example.com/org/apps.git",
+		AppName:           "root",
+		TargetNamespace:   "apps",
+		ZotRegistryURL:    "registry.dev.test:8443",
+		IncludeKinderApps: true,
+	}
+
+	t.Run("default", func(t *testing.T) {
+		manifest, err := applicationYAML(cfg)
+		if err != nil {
+			t.Fatalf("applicationYAML failed: %v", err)
+		}
+		var app applicationResource
+		if err := yaml.Unmarshal([]byte(manifest), &app); err != nil {
+			t.Fatalf("application is not valid YAML: %v", err)
+		}
+		if app.Spec.Project != "default" {
+			t.Errorf("expected project default, got %q", app.Spec.Project)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		cfg := cfg
+		cfg.Project = "Platform"
+
+		for _, generate := range []func(ArgoCDConfig) (string, error){applicationYAML, kinderAppsYAML} {
+			manifest, err := generate(cfg)
+			if err != nil {
+				t.Fatalf("generating applications failed: %v", err)
+			}
+			for _, doc := range strings.Split(manifest, "---\n") {
+				var app applicationResource
+				if err := yaml.Unmarshal([]byte(doc), &app); err != nil {
+					t.Fatalf("application is not valid YAML: %v", err)
+				}
+				if app.Spec.Project != "platform" {
+					t.Errorf("expected project platform on %s, got %q", app.Metadata.Name, app.Spec.Project)
+				}
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cfg := cfg
+		cfg.Project = "bad project!"
+		if _, err := applicationYAML(cfg); err == nil {
+			t.Error("expected invalid project to be rejected")
+		}
+	})
+
+	t.Run("app project", func(t *testing.T) {
+		cfg := cfg
+		cfg.Project = "platform"
+
+		manifest, err := appProjectYAML(cfg)
+		if err != nil {
+			t.Fatalf("appProjectYAML failed: %v", err)
+		}
+		var project appProjectResource
+		if err := yaml.UnmarshalStrict([]byte(manifest), &project); err != nil {
+			t.Fatalf("project is not valid YAML: %v\n%s", err, manifest)
+		}
+
+		if project.Metadata.Name != "platform" || project.Metadata.Namespace != ArgoCDNamespace {
+			t.Errorf("unexpected project metadata %+v", project.Metadata)
+		}
+		wantRepos := []string{cfg.RepoURL, "registry.dev.test:8443/*"}
+		if strings.Join(project.Spec.SourceRepos, ",") != strings.Join(wantRepos, ",") {
+			t.Errorf("expected source repos %v, got %v", wantRepos, project.Spec.SourceRepos)
+		}
+		if len(project.Spec.Destinations) != 2 {
+			t.Errorf("expected 2 destinations, got %v", project.Spec.Destinations)
+		}
+	})
+}
+
+func TestSyncPolicy(t *testing.T) {
+	cfg := ArgoCDConfig{
+		Namespace:       ArgoCDNamespace,
+		RepoURL:         "https://example.com/org/apps.git",
+		AppName:         "root",
+		TargetNamespace: "apps",
+		ZotRegistryURL:  "registry.dev.test:8443",
+	}
+
+	t.Run("manual sync", func(t *testing.T) {
+		manifest, err := applicationYAML(cfg)
+		if err != nil {
+			t.Fatalf("applicationYAML failed: %v", err)
+		}
+		if strings.Contains(manifest, "automated") {
+			t.Errorf("expected no automated block:\n%s", manifest)
+		}
+		if !strings.Contains(manifest, "CreateNamespace=true") {
+			t.Errorf("expected sync options to be kept:\n%s", manifest)
+		}
+
+		apps, err := kinderAppsYAML(cfg)
+		if err != nil {
+			t.Fatalf("kinderAppsYAML failed: %v", err)
+		}
+		if strings.Contains(apps, "syncPolicy") {
+			t.Errorf("expected no sync policy on kinder apps:\n%s", apps)
+		}
+	})
+
+	t.Run("auto sync", func(t *testing.T) {
+		cfg := cfg
+		cfg.AutoSync, cfg.Prune, cfg.SelfHeal = true, true, true
+
+		apps, err := kinderAppsYAML(cfg)
+		if err != nil {
+			t.Fatalf("kinderAppsYAML failed: %v", err)
+		}
+		for _, doc := range strings.Split(apps, "---\n") {
+			var app applicationResource
+			if err := yaml.Unmarshal([]byte(doc), &app); err != nil {
+				t.Fatalf("application is not valid YAML: %v", err)
+			}
+			policy := app.Spec.SyncPolicy
+			if policy == nil || policy.Automated == nil || !policy.Automated.Prune || !policy.Automated.SelfHeal {
+				t.Errorf("expected automated sync with prune and self-heal on %s, got %+v", app.Metadata.Name, policy)
+			}
+		}
+	})
+}
+
+func TestRegistrySecretYAML(t *testing.T) {
+	cfg := ArgoCDConfig{
+		Namespace:        ArgoCDNamespace,
+		ZotRegistryURL:   "registry.dev.test:8443",
+		RegistryUsername: "kinder",
+		RegistryPassword: "s3cret: value",
+	}
+
+	manifest, err := registrySecretYAML(cfg)
+	if err != nil {
+		t.Fatalf("registrySecretYAML failed: %v", err)
+	}
+
+	var secret secretResource
+	if err := yaml.UnmarshalStrict([]byte(manifest), &secret); err != nil {
+		t.Fatalf("registry secret is not valid YAML: %v\n%s", err, manifest)
+	}
+	if secret.Metadata.Labels["argocd.argoproj.io/secret-type"] != "repository" {
+		t.Errorf("expected repository secret label, got %v", secret.Metadata.Labels)
+	}
+	want := map[string]string{
+		"type":      "helm",
+		"url":       "registry.dev.test:8443",
+		"enableOCI": "true",
+		"username":  "kinder",
+		"password":  "s3cret: value",
+	}
+	for key, value := range want {
+		if got := string(secret.Data[key]); got != value {
+			t.Errorf("expected %s %q, got %q", key, value, got)
+		}
+	}
+
+	cfg.RegistryPassword = ""
+	if _, err := registrySecretYAML(cfg); err == nil {
+		t.Error("expected error without a password")
+	}
+}
+
+func TestExposeServiceYAML(t *testing.T) {
+	manifest := exposeServiceYAML(ArgoCDConfig{Namespace: ArgoCDNamespace})
+
+	var svc struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Type     string            `json:"type"`
+			Selector map[string]string `json:"selector"`
+			Ports    []struct {
+				Port       int `json:"port"`
+				TargetPort int `json:"targetPort"`
+				NodePort   int `json:"nodePort"`
+			} `json:"ports"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal([]byte(manifest), &svc); err != nil {
+		t.Fatalf("service is not valid YAML: %v\n%s", err, manifest)
+	}
+
+	if svc.Kind != "Service" || svc.Spec.Type != "NodePort" || svc.Metadata.Namespace != ArgoCDNamespace {
+		t.Errorf("expected a NodePort Service in %s, got %+v", ArgoCDNamespace, svc)
+	}
+	if svc.Spec.Selector["app.kubernetes.io/name"] != "argocd-server" {
+		t.Errorf("expected argocd-server selector, got %v", svc.Spec.Selector)
+	}
+	if len(svc.Spec.Ports) != 1 || svc.Spec.Ports[0].TargetPort != 8080 || svc.Spec.Ports[0].NodePort != ArgoCDNodePort {
+		t.Errorf("expected port 8080 on node port %d, got %+v", ArgoCDNodePort, svc.Spec.Ports)
+	}
+}
+
+// recordingClient is a clusterClient that records what install sends
+type recordingClient struct {
+	calls []string
+	// applied holds every applied manifest in order
+	applied []string
+}
+
+func (c *recordingClient) apply(_ context.Context, manifest string) error {
+	c.applied = append(c.applied, manifest)
+	c.calls = append(c.calls, "apply")
+	return nil
+}
+
+func (c *recordingClient) applyURL(_ context.Context, url string) error {
+	c.calls = append(c.calls, "applyURL "+url)
+	return nil
+}
+
+func (c *recordingClient) patch(_ context.Context, kind, name, patch string) error {
+	c.calls = append(c.calls, "patch "+kind+"/"+name+" "+patch)
+	return nil
+}
+
+func (c *recordingClient) waitRollout(_ context.Context, deployment string, _ time.Duration) error {
+	c.calls = append(c.calls, "waitRollout "+deployment)
+	return nil
+}
+
+func (c *recordingClient) hasCRD(context.Context, string) (bool, error) { return true, nil }
+
+func (c *recordingClient) deleteAll(context.Context, string, string, time.Duration) error {
+	return nil
+}
+
+func (c *recordingClient) deleteURL(context.Context, string, time.Duration) error { return nil }
+
+func (c *recordingClient) deleteNamespace(context.Context, string, time.Duration) error { return nil }
+
+func (c *recordingClient) applicationStatus(context.Context, string) (appStatus, error) {
+	return appStatus{}, nil
+}
+
+func TestInstall(t *testing.T) {
+	// The configuration kinder start bootstraps ArgoCD with
+	cfg := ArgoCDConfig{
+		Version:           "v3.1.10",
+		Domain:            "dev.test",
+		Port:              "8443",
+		CACertPEM:         "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		SkipInitialApp:    true,
+		IncludeKinderApps: true,
+		AutoSync:          true,
+		Prune:             true,
+		SelfHeal:          true,
+		Expose:            true,
+	}
+	setDefaults(&cfg)
+
+	var steps []string
+	client := &recordingClient{}
+	if err := install(t.Context(), client, cfg, func(msg string) { steps = append(steps, msg) }); err != nil {
+		t.Fatalf("install failed: %v", err)
+	}
+
+	wantSteps := []string{
+		"Creating namespace",
+		"Installing ArgoCD v3.1.10",
+		"Disabling authentication",
+		"Waiting for rollout",
+		"Exposing ArgoCD server",
+		"Creating kinder applications",
+	}
+	if strings.Join(steps, "; ") != strings.Join(wantSteps, "; ") {
+		t.Errorf("expected steps %q, got %q", wantSteps, steps)
+	}
+
+	calls := strings.Join(client.calls, "\n")
+	for _, want := range []string{
+		"applyURL " + installURL("v3.1.10"),
+		`patch configmap/argocd-cmd-params-cm {"data":{"server.insecure":"true"}}`,
+		`patch configmap/argocd-cm {"data":{"users.anonymous.enabled":"true"}}`,
+		`patch configmap/argocd-rbac-cm {"data":{"policy.default":"role:admin"}}`,
+		"waitRollout argocd-server",
+		"waitRollout argocd-repo-server",
+		"waitRollout argocd-redis",
+		"patch deployment/argocd-repo-server",
+	} {
+		if !strings.Contains(calls, want) {
+			t.Errorf("expected call %q, got:\n%s", want, calls)
+		}
+	}
+
+	// Namespace, exposed Service, CA secret and the kinder apps
+	kinds := make([]string, 0, len(client.applied))
+	for _, manifest := range client.applied {
+		for _, doc := range strings.Split(manifest, "\n---\n") {
+			var obj struct {
+				Kind     string `json:"kind"`
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			}
+			if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+				t.Fatalf("applied manifest is not valid YAML: %v\n%s", err, doc)
+			}
+			kinds = append(kinds, obj.Kind+"/"+obj.Metadata.Name)
+		}
+	}
+	want := []string{
+		"Namespace/argocd",
+		"Service/argocd-server-kinder",
+		"Secret/kinder-ca-cert",
+		"Application/kinder-trust-bundle",
+		"Application/kinder-cert-issuer",
+	}
+	if strings.Join(kinds, ", ") != strings.Join(want, ", ") {
+		t.Errorf("expected applied objects %v, got %v", want, kinds)
+	}
+}
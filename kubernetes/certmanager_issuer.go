@@ -1,25 +1,16 @@
 package kubernetes
 
 import (
-	"archive/tar"
-	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"fmt"
-	"net/http"
 	"os"
-	"time"
+	"regexp"
 
 	"codeberg.org/hipkoi/kinder/config"
-	"github.com/google/go-containerregistry/pkg/authn"
-	"github.com/google/go-containerregistry/pkg/name"
+	"codeberg.org/hipkoi/kinder/ociartifact"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/google/go-containerregistry/pkg/v1/empty"
-	"github.com/google/go-containerregistry/pkg/v1/mutate"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
-	"github.com/google/go-containerregistry/pkg/v1/tarball"
-	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
 const (
@@ -33,12 +24,51 @@ const (
 	CertManagerIssuerEmail = "admin@localhost"
 	// CertManagerIssuerIngressClass is the default ingress class for HTTP-01 solver
 	CertManagerIssuerIngressClass = "traefik"
+	// CertManagerNamespace is the cluster resource namespace where cert-manager
+	// looks up secrets referenced by a ClusterIssuer
+	CertManagerNamespace = "cert-manager"
+)
+
+// dnsNamePattern matches a DNS name made of RFC 1123 labels, optionally with a
+// leading wildcard label
+var dnsNamePattern = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// Issuer scopes
+const (
+	// IssuerScopeCluster emits a cluster-wide ClusterIssuer
+	IssuerScopeCluster = "cluster"
+	// IssuerScopeNamespace emits an Issuer that only serves its own namespace
+	IssuerScopeNamespace = "namespace"
+)
+
+// Issuer modes
+const (
+	// IssuerModeACME issues certificates through the Step CA ACME server
+	IssuerModeACME = "acme"
+	// IssuerModeCA signs certificates in-cluster with the kinder CA key
+	IssuerModeCA = "ca"
+)
+
+// Supported DNS-01 providers
+const (
+	// DNS01ProviderCloudflare authenticates with a Cloudflare API token
+	DNS01ProviderCloudflare = "cloudflare"
+	// DNS01ProviderRoute53 authenticates with an AWS access key
+	DNS01ProviderRoute53 = "route53"
 )
 
 // CertManagerIssuerConfig holds configuration for building cert-manager issuer manifests
 type CertManagerIssuerConfig struct {
 	// RootCACertPath is the path to the kinder root CA certificate
 	RootCACertPath string
+	// RootCAKeyPath is the path to the kinder root CA key, read in ca mode
+	// when CAKey is empty
+	RootCAKeyPath string
+	// CAKey is the PEM-encoded kinder root CA key (ca mode only)
+	CAKey []byte
+	// Mode is acme for the Step CA ACME flow or ca to sign with the kinder CA
+	// key stored in a Secret (default: acme)
+	Mode string
 	// RegistryURL is the registry URL to push to (default: localhost:5000)
 	RegistryURL string
 	// ImageName is the image name (default: cert-manager-issuer)
@@ -47,6 +77,11 @@ type CertManagerIssuerConfig struct {
 	ImageTag string
 	// IssuerName is the name of the ClusterIssuer (default: kinder-ca)
 	IssuerName string
+	// Scope is cluster for a ClusterIssuer or namespace for an Issuer
+	// (default: cluster)
+	Scope string
+	// Namespace is the namespace of a namespace-scoped Issuer (default: default)
+	Namespace string
 	// Email is the ACME account email (default: admin@localhost)
 	Email string
 	// ACMEServerURL is the Step CA ACME server URL
@@ -60,12 +95,25 @@ type CertManagerIssuerConfig struct {
 	IngressClass string
 	// UseDNS01 uses DNS-01 solver instead of HTTP-01
 	UseDNS01 bool
-	// DNS01Provider is the DNS provider for DNS-01 (e.g., cloudflare, route53)
+	// DNS01Provider is the DNS provider for DNS-01 (cloudflare or route53)
 	DNS01Provider string
+	// DNS01Secret is the provider credential stored in the DNS-01 Secret:
+	// the API token for Cloudflare, the secret access key for Route53
+	DNS01Secret string
+	// DNS01AccessKeyID is the AWS access key ID (Route53 only)
+	DNS01AccessKeyID string
+	// DNS01Region is the AWS region (Route53 only)
+	DNS01Region string
 	// IncludeExampleCert includes an example Certificate resource
 	IncludeExampleCert bool
-	// ExampleCertDomain is the domain for the example certificate
-	ExampleCertDomain string
+	// ExampleCertDomains are the DNS names for the example certificate; the
+	// first is also used as the common name (default: example.<domain>)
+	ExampleCertDomains []string
+	// Platforms are the platforms to build the image for; more than one
+	// pushes an image index (default: ociartifact.DefaultPlatform)
+	Platforms []v1.Platform
+	// Force pushes the image even if the registry already has identical content
+	Force bool
 }
 
 // CertManagerIssuerManifests holds the generated Kubernetes manifests
@@ -76,39 +124,56 @@ type CertManagerIssuerManifests struct {
 	ClusterIssuer []byte
 	// ExampleCert is the optional example-certificate.yaml
 	ExampleCert []byte
+	// DNS01Secret is the dns01-secret.yaml holding DNS provider credentials,
+	// present only when the DNS-01 solver is used
+	DNS01Secret []byte
+	// CASecret is the ca-secret.yaml holding the kinder CA key pair, present
+	// only in ca mode
+	CASecret []byte
 }
 
 // BuildAndPushCertManagerIssuer creates an OCI image containing cert-manager
-// issuer manifests and pushes it to the registry
-func BuildAndPushCertManagerIssuer(ctx context.Context, cfg CertManagerIssuerConfig) error {
+// issuer manifests and pushes it to the registry. The push is skipped if the
+// registry already holds an image with the same digest, unless cfg.Force is set.
+// Returns whether the image was pushed.
+func BuildAndPushCertManagerIssuer(ctx context.Context, cfg CertManagerIssuerConfig) (bool, error) {
 	// Apply defaults
 	applyIssuerDefaults(&cfg)
 
 	// Read the kinder root CA
 	kinderCA, err := os.ReadFile(cfg.RootCACertPath)
 	if err != nil {
-		return fmt.Errorf("failed to read kinder CA certificate: %w", err)
+		return false, fmt.Errorf("failed to read kinder CA certificate: %w", err)
+	}
+
+	// The CA issuer needs the key as well
+	if cfg.Mode == IssuerModeCA && len(cfg.CAKey) == 0 {
+		cfg.CAKey, err = os.ReadFile(cfg.RootCAKeyPath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read kinder CA key: %w", err)
+		}
 	}
 
 	// Generate manifests
 	manifests, err := GenerateCertManagerIssuerManifests(cfg, kinderCA)
 	if err != nil {
-		return fmt.Errorf("failed to generate cert-manager issuer manifests: %w", err)
+		return false, fmt.Errorf("failed to generate cert-manager issuer manifests: %w", err)
 	}
 
 	// Create OCI image with the manifests
-	img, err := createCertManagerIssuerImage(manifests)
+	img, err := createCertManagerIssuerImage(manifests, cfg.Platforms)
 	if err != nil {
-		return fmt.Errorf("failed to create cert-manager issuer image: %w", err)
+		return false, fmt.Errorf("failed to create cert-manager issuer image: %w", err)
 	}
 
-	// Push to registry
+	// Push to registry, unless it already has this exact image
 	imageRef := fmt.Sprintf("%s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag)
-	if err := pushImage(ctx, img, imageRef); err != nil {
-		return fmt.Errorf("failed to push cert-manager issuer image: %w", err)
+	pushed, err := ociartifact.PushIfChanged(ctx, img, imageRef, cfg.Force)
+	if err != nil {
+		return false, fmt.Errorf("failed to push cert-manager issuer image: %w", err)
 	}
 
-	return nil
+	return pushed, nil
 }
 
 // applyIssuerDefaults sets default values for unset config fields
@@ -125,6 +190,15 @@ func applyIssuerDefaults(cfg *CertManagerIssuerConfig) {
 	if cfg.IssuerName == "" {
 		cfg.IssuerName = CertManagerIssuerName
 	}
+	if cfg.Mode == "" {
+		cfg.Mode = IssuerModeACME
+	}
+	if cfg.Scope == "" {
+		cfg.Scope = IssuerScopeCluster
+	}
+	if cfg.Scope == IssuerScopeNamespace && cfg.Namespace == "" {
+		cfg.Namespace = "default"
+	}
 	if cfg.Email == "" {
 		cfg.Email = CertManagerIssuerEmail
 	}
@@ -140,8 +214,8 @@ func applyIssuerDefaults(cfg *CertManagerIssuerConfig) {
 	if cfg.ACMEServerURL == "" {
 		cfg.ACMEServerURL = fmt.Sprintf("https://ca.%s:%s/acme/acme/directory", cfg.Domain, cfg.Port)
 	}
-	if cfg.ExampleCertDomain == "" && cfg.IncludeExampleCert {
-		cfg.ExampleCertDomain = fmt.Sprintf("example.%s", cfg.Domain)
+	if len(cfg.ExampleCertDomains) == 0 && cfg.IncludeExampleCert {
+		cfg.ExampleCertDomains = []string{fmt.Sprintf("example.%s", cfg.Domain)}
 	}
 }
 
@@ -150,201 +224,327 @@ func GenerateCertManagerIssuerManifests(cfg CertManagerIssuerConfig, kinderCA []
 	// Apply defaults
 	applyIssuerDefaults(&cfg)
 
+	switch cfg.Scope {
+	case IssuerScopeCluster:
+	case IssuerScopeNamespace:
+		namespace, err := sanitizeName(cfg.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("invalid issuer namespace: %w", err)
+		}
+		if namespace != cfg.Namespace {
+			return nil, fmt.Errorf("invalid issuer namespace %q: must be a lowercase RFC 1123 label", cfg.Namespace)
+		}
+	default:
+		return nil, fmt.Errorf("invalid issuer scope %q: must be %s or %s", cfg.Scope, IssuerScopeCluster, IssuerScopeNamespace)
+	}
+
+	switch cfg.Mode {
+	case IssuerModeACME:
+	case IssuerModeCA:
+		if cfg.UseDNS01 {
+			return nil, fmt.Errorf("the DNS-01 solver only applies to the %s issuer mode", IssuerModeACME)
+		}
+		if _, err := tls.X509KeyPair(kinderCA, cfg.CAKey); err != nil {
+			return nil, fmt.Errorf("invalid kinder CA key pair: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("invalid issuer mode %q: must be %s or %s", cfg.Mode, IssuerModeACME, IssuerModeCA)
+	}
+
 	// Base64 encode the CA certificate for the caBundle field
 	caBundle := base64.StdEncoding.EncodeToString(kinderCA)
 
 	// Generate ClusterIssuer YAML
-	clusterIssuer := generateClusterIssuerYAML(cfg, caBundle)
+	clusterIssuer, err := generateClusterIssuerYAML(cfg, caBundle)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate Kustomization YAML
 	resources := []string{"clusterissuer.yaml"}
 
+	// DNS-01 providers need their credentials in a Secret
+	var dns01Secret []byte
+	if cfg.UseDNS01 {
+		secret, err := generateDNS01SecretYAML(cfg)
+		if err != nil {
+			return nil, err
+		}
+		dns01Secret = []byte(secret)
+		resources = append(resources, "dns01-secret.yaml")
+	}
+
+	// The CA issuer signs with the key pair in a Secret
+	var caSecret []byte
+	if cfg.Mode == IssuerModeCA {
+		secret, err := generateCASecretYAML(cfg, kinderCA)
+		if err != nil {
+			return nil, err
+		}
+		caSecret = []byte(secret)
+		resources = append(resources, "ca-secret.yaml")
+	}
+
 	// Optionally generate example Certificate
 	var exampleCert []byte
 	if cfg.IncludeExampleCert {
-		exampleCert = []byte(generateExampleCertificateYAML(cfg))
+		for _, name := range cfg.ExampleCertDomains {
+			if !dnsNamePattern.MatchString(name) || len(name) > 253 {
+				return nil, fmt.Errorf("invalid example certificate DNS name %q", name)
+			}
+		}
+		cert, err := generateExampleCertificateYAML(cfg)
+		if err != nil {
+			return nil, err
+		}
+		exampleCert = []byte(cert)
 		resources = append(resources, "example-certificate.yaml")
 	}
 
-	kustomization := generateIssuerKustomizationYAML(resources)
+	kustomization, err := generateIssuerKustomizationYAML(resources)
+	if err != nil {
+		return nil, err
+	}
 
 	return &CertManagerIssuerManifests{
 		Kustomization: []byte(kustomization),
 		ClusterIssuer: []byte(clusterIssuer),
 		ExampleCert:   exampleCert,
+		DNS01Secret:   dns01Secret,
+		CASecret:      caSecret,
 	}, nil
 }
 
-// generateClusterIssuerYAML creates the ClusterIssuer manifest
-func generateClusterIssuerYAML(cfg CertManagerIssuerConfig, caBundle string) string {
-	// Build solver configuration
-	var solverYAML string
-	if cfg.UseDNS01 && cfg.DNS01Provider != "" {
-		solverYAML = generateDNS01SolverYAML(cfg.DNS01Provider)
-	} else {
-		solverYAML = generateHTTP01SolverYAML(cfg.IngressClass)
-	}
-
-	return fmt.Sprintf(`apiVersion: cert-manager.io/v1
-kind: ClusterIssuer
-metadata:
-  name: %s
-  labels:
-    app.kubernetes.io/name: kinder-ca-issuer
-    app.kubernetes.io/component: certificate-issuer
-    app.kubernetes.io/managed-by: kinder
-spec:
-  acme:
-    # Step CA ACME server endpoint
-    server: %s
-    # Email for ACME registration
-    email: %s
-    # Secret to store the ACME account private key
-    privateKeySecretRef:
-      name: %s-account-key
-    # CA bundle to trust the Step CA certificate
-    caBundle: %s
-    # Solver configuration
-    solvers:
-%s`, cfg.IssuerName, cfg.ACMEServerURL, cfg.Email, cfg.IssuerName, caBundle, solverYAML)
+// issuerKind returns the cert-manager kind for the configured scope
+func issuerKind(cfg CertManagerIssuerConfig) string {
+	if cfg.Scope == IssuerScopeNamespace {
+		return "Issuer"
+	}
+	return "ClusterIssuer"
 }
 
-// generateHTTP01SolverYAML creates HTTP-01 solver configuration
-func generateHTTP01SolverYAML(ingressClass string) string {
-	return fmt.Sprintf(`      - http01:
-          ingress:
-            ingressClassName: %s
-`, ingressClass)
+// issuerNamespace returns the namespace of a namespaced Issuer, or "" for a
+// ClusterIssuer
+func issuerNamespace(cfg CertManagerIssuerConfig) string {
+	if cfg.Scope == IssuerScopeNamespace {
+		return cfg.Namespace
+	}
+	return ""
 }
 
-// generateDNS01SolverYAML creates DNS-01 solver configuration placeholder
-func generateDNS01SolverYAML(provider string) string {
-	// This is a placeholder - actual DNS-01 config depends heavily on the provider
-	// and requires additional secrets for credentials
-	return fmt.Sprintf(`      - dns01:
-          # Configure your DNS provider here
-          # See: https://cert-manager.io/docs/configuration/acme/dns01/
-          %s: {}
-`, provider)
+// generateClusterIssuerYAML creates the ClusterIssuer, or the Issuer when the
+// scope is namespace
+func generateClusterIssuerYAML(cfg CertManagerIssuerConfig, caBundle string) (string, error) {
+	var spec clusterIssuerSpec
+	if cfg.Mode == IssuerModeCA {
+		spec.CA = &caIssuer{SecretName: caSecretName(cfg)}
+	} else {
+		// Build solver configuration
+		var solver acmeSolver
+		if cfg.UseDNS01 {
+			dns01, err := dns01SolverFor(cfg)
+			if err != nil {
+				return "", err
+			}
+			solver.DNS01 = dns01
+		} else {
+			solver.HTTP01 = &http01Solver{Ingress: http01Ingress{IngressClassName: cfg.IngressClass}}
+		}
+		spec.ACME = &acmeIssuer{
+			Server:              cfg.ACMEServerURL,
+			Email:               cfg.Email,
+			PrivateKeySecretRef: secretReference{Name: cfg.IssuerName + "-account-key"},
+			CABundle:            caBundle,
+			Solvers:             []acmeSolver{solver},
+		}
+	}
+
+	return marshalManifests(clusterIssuerResource{
+		APIVersion: "cert-manager.io/v1",
+		Kind:       issuerKind(cfg),
+		Metadata: objectMeta{
+			Name:      cfg.IssuerName,
+			Namespace: issuerNamespace(cfg),
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "kinder-ca-issuer",
+				"app.kubernetes.io/component":  "certificate-issuer",
+				"app.kubernetes.io/managed-by": "kinder",
+			},
+		},
+		Spec: spec,
+	})
 }
 
-// generateExampleCertificateYAML creates an example Certificate resource
-func generateExampleCertificateYAML(cfg CertManagerIssuerConfig) string {
-	return fmt.Sprintf(`apiVersion: cert-manager.io/v1
-kind: Certificate
-metadata:
-  name: example-cert
-  namespace: default
-  labels:
-    app.kubernetes.io/name: example-certificate
-    app.kubernetes.io/managed-by: kinder
-spec:
-  # Secret that will contain the TLS certificate
-  secretName: example-cert-tls
-  # Duration of the certificate (default: 90 days)
-  duration: 2160h # 90 days
-  # Renew 30 days before expiry
-  renewBefore: 720h # 30 days
-  # Common name (deprecated but still used by some applications)
-  commonName: %s
-  # DNS names for the certificate
-  dnsNames:
-    - %s
-  # Reference to the ClusterIssuer
-  issuerRef:
-    name: %s
-    kind: ClusterIssuer
-    group: cert-manager.io
-`, cfg.ExampleCertDomain, cfg.ExampleCertDomain, cfg.IssuerName)
+// dns01SecretName returns the name of the Secret holding DNS-01 credentials
+func dns01SecretName(cfg CertManagerIssuerConfig) string {
+	return cfg.IssuerName + "-dns01-credentials"
 }
 
-// generateIssuerKustomizationYAML creates the kustomization.yaml
-func generateIssuerKustomizationYAML(resources []string) string {
-	var resourcesYAML string
-	for _, r := range resources {
-		resourcesYAML += fmt.Sprintf("  - %s\n", r)
+// dns01SecretKey returns the key in the DNS-01 Secret holding the credential
+func dns01SecretKey(provider string) string {
+	if provider == DNS01ProviderRoute53 {
+		return "secret-access-key"
 	}
-
-	return fmt.Sprintf(`apiVersion: kustomize.config.k8s.io/v1beta1
-kind: Kustomization
-resources:
-%s`, resourcesYAML)
+	return "api-token"
 }
 
-// createCertManagerIssuerImage creates an OCI image containing the manifests
-func createCertManagerIssuerImage(manifests *CertManagerIssuerManifests) (v1.Image, error) {
-	// Create a tar archive with the manifest files
-	var buf bytes.Buffer
-	tw := tar.NewWriter(&buf)
-
-	files := map[string][]byte{
-		"kustomization.yaml": manifests.Kustomization,
-		"clusterissuer.yaml": manifests.ClusterIssuer,
+// dns01SolverFor creates the DNS-01 solver configuration for the provider,
+// referencing the credentials Secret
+func dns01SolverFor(cfg CertManagerIssuerConfig) (*dns01Solver, error) {
+	if cfg.DNS01Secret == "" {
+		return nil, fmt.Errorf("DNS-01 provider %q requires credentials", cfg.DNS01Provider)
 	}
 
-	// Add example certificate if present
-	if len(manifests.ExampleCert) > 0 {
-		files["example-certificate.yaml"] = manifests.ExampleCert
-	}
+	secretRef := secretKeySelector{Name: dns01SecretName(cfg), Key: dns01SecretKey(cfg.DNS01Provider)}
 
-	for fileName, content := range files {
-		header := &tar.Header{
-			Name:    fileName,
-			Mode:    0644,
-			Size:    int64(len(content)),
-			ModTime: time.Now(),
+	switch cfg.DNS01Provider {
+	case DNS01ProviderCloudflare:
+		return &dns01Solver{Cloudflare: &cloudflareSolver{APITokenSecretRef: secretRef}}, nil
+	case DNS01ProviderRoute53:
+		if cfg.DNS01AccessKeyID == "" || cfg.DNS01Region == "" {
+			return nil, fmt.Errorf("DNS-01 provider %q requires an access key ID and region", cfg.DNS01Provider)
 		}
+		return &dns01Solver{Route53: &route53Solver{
+			Region:                   cfg.DNS01Region,
+			AccessKeyID:              cfg.DNS01AccessKeyID,
+			SecretAccessKeySecretRef: secretRef,
+		}}, nil
+	case "":
+		return nil, fmt.Errorf("DNS-01 solver requires a provider (supported: %s, %s)", DNS01ProviderCloudflare, DNS01ProviderRoute53)
+	default:
+		return nil, fmt.Errorf("unsupported DNS-01 provider %q (supported: %s, %s)", cfg.DNS01Provider, DNS01ProviderCloudflare, DNS01ProviderRoute53)
+	}
+}
 
-		if err := tw.WriteHeader(header); err != nil {
-			return nil, fmt.Errorf("failed to write tar header for %s: %w", fileName, err)
-		}
+// generateDNS01SecretYAML creates the Secret holding the DNS-01 provider
+// credential in the cert-manager namespace, where ClusterIssuers look it up.
+// A namespaced Issuer looks it up in its own namespace instead.
+func generateDNS01SecretYAML(cfg CertManagerIssuerConfig) (string, error) {
+	return marshalManifests(secretResource{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: objectMeta{
+			Name:      dns01SecretName(cfg),
+			Namespace: issuerSecretNamespace(cfg),
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "kinder-ca-issuer",
+				"app.kubernetes.io/managed-by": "kinder",
+			},
+		},
+		Type: "Opaque",
+		Data: map[string][]byte{
+			dns01SecretKey(cfg.DNS01Provider): []byte(cfg.DNS01Secret),
+		},
+	})
+}
 
-		if _, err := tw.Write(content); err != nil {
-			return nil, fmt.Errorf("failed to write %s to tar: %w", fileName, err)
-		}
+// issuerSecretNamespace returns the namespace cert-manager reads the issuer's
+// Secrets from
+func issuerSecretNamespace(cfg CertManagerIssuerConfig) string {
+	if ns := issuerNamespace(cfg); ns != "" {
+		return ns
 	}
+	return CertManagerNamespace
+}
 
-	if err := tw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close tar writer: %w", err)
-	}
+// caSecretName returns the name of the Secret holding the CA key pair
+func caSecretName(cfg CertManagerIssuerConfig) string {
+	return cfg.IssuerName + "-ca-key-pair"
+}
 
-	// Create a layer from the tar archive
-	layer, err := tarball.LayerFromReader(&buf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create layer: %w", err)
-	}
+// generateCASecretYAML creates the TLS Secret holding the kinder CA key pair
+// for the CA issuer. cert-manager signs with tls.crt and tls.key; ca.crt is
+// copied into issued certificate Secrets.
+func generateCASecretYAML(cfg CertManagerIssuerConfig, kinderCA []byte) (string, error) {
+	return marshalManifests(secretResource{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: objectMeta{
+			Name:      caSecretName(cfg),
+			Namespace: issuerSecretNamespace(cfg),
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "kinder-ca-issuer",
+				"app.kubernetes.io/managed-by": "kinder",
+			},
+		},
+		Type: "kubernetes.io/tls",
+		Data: map[string][]byte{
+			"ca.crt":  kinderCA,
+			"tls.crt": kinderCA,
+			"tls.key": cfg.CAKey,
+		},
+	})
+}
 
-	// Start with an empty image and add our layer
-	img := empty.Image
-	img, err = mutate.AppendLayers(img, layer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to append layer: %w", err)
+// generateExampleCertificateYAML creates an example Certificate resource.
+// The first DNS name is also used as the common name, which is deprecated but
+// still used by some applications. With a namespaced Issuer the Certificate
+// must live in the Issuer's namespace.
+func generateExampleCertificateYAML(cfg CertManagerIssuerConfig) (string, error) {
+	namespace := "default"
+	if ns := issuerNamespace(cfg); ns != "" {
+		namespace = ns
+	}
+	return marshalManifests(certificateResource{
+		APIVersion: "cert-manager.io/v1",
+		Kind:       "Certificate",
+		Metadata: objectMeta{
+			Name:      "example-cert",
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "example-certificate",
+				"app.kubernetes.io/managed-by": "kinder",
+			},
+		},
+		Spec: certificateSpec{
+			SecretName: "example-cert-tls",
+			// 90 days, renewed 30 days before expiry
+			Duration:    "2160h",
+			RenewBefore: "720h",
+			CommonName:  cfg.ExampleCertDomains[0],
+			DNSNames:    cfg.ExampleCertDomains,
+			IssuerRef: issuerReference{
+				Name:  cfg.IssuerName,
+				Kind:  issuerKind(cfg),
+				Group: "cert-manager.io",
+			},
+		},
+	})
+}
+
+// generateIssuerKustomizationYAML creates the kustomization.yaml
+func generateIssuerKustomizationYAML(resources []string) (string, error) {
+	return marshalManifests(kustomizationResource{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	})
+}
+
+// createCertManagerIssuerImage creates an OCI image containing the manifests
+func createCertManagerIssuerImage(manifests *CertManagerIssuerManifests, platforms []v1.Platform) (ociartifact.Artifact, error) {
+	files := map[string][]byte{
+		"kustomization.yaml": manifests.Kustomization,
+		"clusterissuer.yaml": manifests.ClusterIssuer,
 	}
 
-	// Set image config with annotations
-	imgCfg, err := img.ConfigFile()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get config file: %w", err)
+	// Add optional manifests if present
+	if len(manifests.ExampleCert) > 0 {
+		files["example-certificate.yaml"] = manifests.ExampleCert
+	}
+	if len(manifests.DNS01Secret) > 0 {
+		files["dns01-secret.yaml"] = manifests.DNS01Secret
+	}
+	if len(manifests.CASecret) > 0 {
+		files["ca-secret.yaml"] = manifests.CASecret
 	}
 
-	imgCfg.Author = "kinder"
-	imgCfg.Created = v1.Time{Time: time.Now()}
-	imgCfg.Config.Labels = map[string]string{
+	return ociartifact.BuildForPlatforms(files, map[string]string{
 		"org.opencontainers.image.title":       "Cert-Manager Issuer",
 		"org.opencontainers.image.description": "ClusterIssuer configuration for Step CA ACME server",
 		"org.opencontainers.image.source":      "https://codeberg.org/hipkoi/kinder",
 		"argocd.argoproj.io/manifest-type":     "kustomize",
-	}
-
-	img, err = mutate.ConfigFile(img, imgCfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to set config: %w", err)
-	}
-
-	// Set the media type to OCI
-	img = mutate.MediaType(img, types.OCIManifestSchema1)
-
-	return img, nil
+	}, platforms)
 }
 
 // GetCertManagerIssuerDigest returns the digest of the cert-manager issuer in the registry
@@ -356,24 +556,7 @@ func GetCertManagerIssuerDigest(ctx context.Context, registryURL, imageName stri
 		imageName = CertManagerIssuerImageName
 	}
 
-	imageRef := fmt.Sprintf("%s/%s:latest", registryURL, imageName)
-	ref, err := name.ParseReference(imageRef, name.Insecure)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse image reference: %w", err)
-	}
-
-	// Create transport for insecure registry
-	tr, err := transport.NewWithContext(ctx, ref.Context().Registry, authn.Anonymous, http.DefaultTransport, []string{ref.Scope(transport.PullScope)})
-	if err != nil {
-		return "", fmt.Errorf("failed to create transport: %w", err)
-	}
-
-	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(authn.Anonymous), remote.WithTransport(tr))
-	if err != nil {
-		return "", fmt.Errorf("failed to get image digest: %w", err)
-	}
-
-	return desc.Digest.String(), nil
+	return ociartifact.Digest(ctx, fmt.Sprintf("%s/%s:latest", registryURL, imageName))
 }
 
 // SaveCertManagerIssuerManifests saves manifests to disk for local inspection
@@ -391,9 +574,20 @@ func SaveCertManagerIssuerManifests(dataDir string, manifests *CertManagerIssuer
 	if len(manifests.ExampleCert) > 0 {
 		files["example-certificate.yaml"] = manifests.ExampleCert
 	}
+	if len(manifests.DNS01Secret) > 0 {
+		files["dns01-secret.yaml"] = manifests.DNS01Secret
+	}
+	if len(manifests.CASecret) > 0 {
+		files["ca-secret.yaml"] = manifests.CASecret
+	}
 
 	for fileName, content := range files {
-		if err := os.WriteFile(manifestsDir+"/"+fileName, content, 0644); err != nil {
+		// The DNS-01 and CA secrets hold credentials
+		mode := os.FileMode(0644)
+		if fileName == "dns01-secret.yaml" || fileName == "ca-secret.yaml" {
+			mode = 0600
+		}
+		if err := os.WriteFile(manifestsDir+"/"+fileName, content, mode); err != nil {
 			return fmt.Errorf("failed to write %s: %w", fileName, err)
 		}
 	}
@@ -0,0 +1,383 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"codeberg.org/hipkoi/kinder/cacert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerateCertManagerIssuerManifests_DNS01(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      CertManagerIssuerConfig
+		wantErr  bool
+		expected []string
+	}{
+		{
+			name: "cloudflare",
+			cfg:  CertManagerIssuerConfig{UseDNS01: true, DNS01Provider: DNS01ProviderCloudflare, DNS01Secret: "cf-token"},
+			expected: []string{
+				"cloudflare:",
+				"apiTokenSecretRef:",
+				"name: kinder-ca-dns01-credentials",
+				"key: api-token",
+			},
+		},
+		{
+			name: "route53",
+			cfg: CertManagerIssuerConfig{
+				UseDNS01:         true,
+				DNS01Provider:    DNS01ProviderRoute53,
+				DNS01Secret:      "aws-secret",
+				DNS01AccessKeyID: "AKIAEXAMPLE",
+				DNS01Region:      "eu-west-1",
+			},
+			expected: []string{
+				"route53:",
+				"region: eu-west-1",
+				"accessKeyID: AKIAEXAMPLE",
+				"secretAccessKeySecretRef:",
+				"key: secret-access-key",
+			},
+		},
+		{
+			name:    "route53 without region",
+			cfg:     CertManagerIssuerConfig{UseDNS01: true, DNS01Provider: DNS01ProviderRoute53, DNS01Secret: "aws-secret", DNS01AccessKeyID: "AKIAEXAMPLE"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown provider",
+			cfg:     CertManagerIssuerConfig{UseDNS01: true, DNS01Provider: "gandi", DNS01Secret: "token"},
+			wantErr: true,
+		},
+		{
+			name:    "missing provider",
+			cfg:     CertManagerIssuerConfig{UseDNS01: true, DNS01Secret: "token"},
+			wantErr: true,
+		},
+		{
+			name:    "missing credential",
+			cfg:     CertManagerIssuerConfig{UseDNS01: true, DNS01Provider: DNS01ProviderCloudflare},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := GenerateCertManagerIssuerManifests(tt.cfg, []byte("kinder-ca"))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+			}
+
+			issuer := string(manifests.ClusterIssuer)
+			for _, want := range tt.expected {
+				if !strings.Contains(issuer, want) {
+					t.Errorf("expected ClusterIssuer to contain %q:\n%s", want, issuer)
+				}
+			}
+
+			if !strings.Contains(string(manifests.Kustomization), "dns01-secret.yaml") {
+				t.Errorf("expected kustomization to include the DNS-01 secret:\n%s", manifests.Kustomization)
+			}
+
+			secret := string(manifests.DNS01Secret)
+			encoded := base64.StdEncoding.EncodeToString([]byte(tt.cfg.DNS01Secret))
+			if !strings.Contains(secret, "namespace: "+CertManagerNamespace) || !strings.Contains(secret, encoded) {
+				t.Errorf("unexpected DNS-01 secret:\n%s", secret)
+			}
+		})
+	}
+}
+
+func TestGenerateCertManagerIssuerManifests_HTTP01(t *testing.T) {
+	manifests, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{}, []byte("kinder-ca"))
+	if err != nil {
+		t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+	}
+
+	if !strings.Contains(string(manifests.ClusterIssuer), "ingressClassName: traefik") {
+		t.Errorf("expected HTTP-01 solver with traefik:\n%s", manifests.ClusterIssuer)
+	}
+	if len(manifests.DNS01Secret) != 0 || strings.Contains(string(manifests.Kustomization), "dns01-secret.yaml") {
+		t.Error("expected no DNS-01 secret for HTTP-01")
+	}
+}
+
+func TestGenerateCertManagerIssuerManifests_ExampleCert(t *testing.T) {
+	// parse decodes the generated example Certificate
+	parse := func(t *testing.T, manifests *CertManagerIssuerManifests) certificateResource {
+		t.Helper()
+		var cert certificateResource
+		if err := yaml.Unmarshal(manifests.ExampleCert, &cert); err != nil {
+			t.Fatalf("example certificate is not valid YAML: %v\n%s", err, manifests.ExampleCert)
+		}
+		return cert
+	}
+
+	t.Run("default name", func(t *testing.T) {
+		manifests, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{
+			Domain:             "dev.test",
+			IncludeExampleCert: true,
+		}, []byte("kinder-ca"))
+		if err != nil {
+			t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+		}
+		cert := parse(t, manifests)
+		if !reflect.DeepEqual(cert.Spec.DNSNames, []string{"example.dev.test"}) {
+			t.Errorf("expected default example DNS name, got %v", cert.Spec.DNSNames)
+		}
+	})
+
+	t.Run("multiple names", func(t *testing.T) {
+		names := []string{"app.dev.test", "api.dev.test", "*.apps.dev.test"}
+		manifests, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{
+			IncludeExampleCert: true,
+			ExampleCertDomains: names,
+		}, []byte("kinder-ca"))
+		if err != nil {
+			t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+		}
+
+		cert := parse(t, manifests)
+		if cert.Spec.CommonName != "app.dev.test" {
+			t.Errorf("expected common name app.dev.test, got %q", cert.Spec.CommonName)
+		}
+		if !reflect.DeepEqual(cert.Spec.DNSNames, names) {
+			t.Errorf("expected DNS names %v, got %v", names, cert.Spec.DNSNames)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		for _, name := range []string{"bad_name.test", "app..test", "-app.test", "a.*.test"} {
+			_, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{
+				IncludeExampleCert: true,
+				ExampleCertDomains: []string{"ok.test", name},
+			}, []byte("kinder-ca"))
+			if err == nil {
+				t.Errorf("expected %q to be rejected", name)
+			}
+		}
+	})
+}
+
+func TestGenerateCertManagerIssuerManifests_Scope(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           CertManagerIssuerConfig
+		wantKind      string
+		wantNamespace string
+		wantSecretNS  string
+		wantCertNS    string
+		wantErr       bool
+	}{
+		{
+			name:         "cluster by default",
+			cfg:          CertManagerIssuerConfig{},
+			wantKind:     "ClusterIssuer",
+			wantSecretNS: CertManagerNamespace,
+			wantCertNS:   "default",
+		},
+		{
+			name:          "namespace with default namespace",
+			cfg:           CertManagerIssuerConfig{Scope: IssuerScopeNamespace},
+			wantKind:      "Issuer",
+			wantNamespace: "default",
+			wantSecretNS:  "default",
+			wantCertNS:    "default",
+		},
+		{
+			name:          "namespace",
+			cfg:           CertManagerIssuerConfig{Scope: IssuerScopeNamespace, Namespace: "team-a"},
+			wantKind:      "Issuer",
+			wantNamespace: "team-a",
+			wantSecretNS:  "team-a",
+			wantCertNS:    "team-a",
+		},
+		{
+			name:    "invalid namespace",
+			cfg:     CertManagerIssuerConfig{Scope: IssuerScopeNamespace, Namespace: "Team_A"},
+			wantErr: true,
+		},
+		{
+			name:    "uppercase namespace",
+			cfg:     CertManagerIssuerConfig{Scope: IssuerScopeNamespace, Namespace: "Team-A"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid scope",
+			cfg:     CertManagerIssuerConfig{Scope: "global"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.IncludeExampleCert = true
+			cfg.UseDNS01 = true
+			cfg.DNS01Provider = DNS01ProviderCloudflare
+			cfg.DNS01Secret = "token"
+
+			manifests, err := GenerateCertManagerIssuerManifests(cfg, []byte("kinder-ca"))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+			}
+
+			var issuer clusterIssuerResource
+			if err := yaml.Unmarshal(manifests.ClusterIssuer, &issuer); err != nil {
+				t.Fatalf("issuer is not valid YAML: %v", err)
+			}
+			if issuer.Kind != tt.wantKind || issuer.Metadata.Namespace != tt.wantNamespace {
+				t.Errorf("expected %s in %q, got %s in %q", tt.wantKind, tt.wantNamespace, issuer.Kind, issuer.Metadata.Namespace)
+			}
+
+			var secret secretResource
+			if err := yaml.Unmarshal(manifests.DNS01Secret, &secret); err != nil {
+				t.Fatalf("secret is not valid YAML: %v", err)
+			}
+			if secret.Metadata.Namespace != tt.wantSecretNS {
+				t.Errorf("expected secret in %q, got %q", tt.wantSecretNS, secret.Metadata.Namespace)
+			}
+
+			var cert certificateResource
+			if err := yaml.Unmarshal(manifests.ExampleCert, &cert); err != nil {
+				t.Fatalf("example certificate is not valid YAML: %v", err)
+			}
+			if cert.Metadata.Namespace != tt.wantCertNS {
+				t.Errorf("expected certificate in %q, got %q", tt.wantCertNS, cert.Metadata.Namespace)
+			}
+			if cert.Spec.IssuerRef.Kind != tt.wantKind {
+				t.Errorf("expected issuerRef kind %s, got %s", tt.wantKind, cert.Spec.IssuerRef.Kind)
+			}
+		})
+	}
+}
+
+func TestGenerateCertManagerIssuerManifests_EscapesValues(t *testing.T) {
+	// A value that would break out of a hand-written template
+	email := "admin@localhost\n  injected: true"
+
+	manifests, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{Email: email}, []byte("kinder-ca"))
+	if err != nil {
+		t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+	}
+
+	var issuer clusterIssuerResource
+	if err := yaml.UnmarshalStrict(manifests.ClusterIssuer, &issuer); err != nil {
+		t.Fatalf("ClusterIssuer is not valid YAML: %v\n%s", err, manifests.ClusterIssuer)
+	}
+	if issuer.Spec.ACME.Email != email {
+		t.Errorf("expected email %q to round-trip, got %q", email, issuer.Spec.ACME.Email)
+	}
+	if issuer.Spec.ACME.CABundle != base64.StdEncoding.EncodeToString([]byte("kinder-ca")) {
+		t.Errorf("unexpected caBundle %q", issuer.Spec.ACME.CABundle)
+	}
+
+	var kustomization kustomizationResource
+	if err := yaml.UnmarshalStrict(manifests.Kustomization, &kustomization); err != nil {
+		t.Fatalf("kustomization is not valid YAML: %v", err)
+	}
+	if !reflect.DeepEqual(kustomization.Resources, []string{"clusterissuer.yaml"}) {
+		t.Errorf("unexpected kustomization resources %v", kustomization.Resources)
+	}
+}
+
+func TestGenerateCertManagerIssuerManifests_CAMode(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := cacert.GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	caCert, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caKey, err := os.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("ca issuer", func(t *testing.T) {
+		manifests, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{
+			Mode:  IssuerModeCA,
+			CAKey: caKey,
+		}, caCert)
+		if err != nil {
+			t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+		}
+
+		var issuer clusterIssuerResource
+		if err := yaml.Unmarshal(manifests.ClusterIssuer, &issuer); err != nil {
+			t.Fatalf("issuer is not valid YAML: %v", err)
+		}
+		if issuer.Spec.ACME != nil {
+			t.Error("expected no acme block in ca mode")
+		}
+		if issuer.Spec.CA == nil || issuer.Spec.CA.SecretName != "kinder-ca-ca-key-pair" {
+			t.Fatalf("expected ca block referencing kinder-ca-ca-key-pair, got %+v", issuer.Spec.CA)
+		}
+
+		var secret secretResource
+		if err := yaml.Unmarshal(manifests.CASecret, &secret); err != nil {
+			t.Fatalf("secret is not valid YAML: %v", err)
+		}
+		if secret.Metadata.Name != issuer.Spec.CA.SecretName || secret.Metadata.Namespace != CertManagerNamespace {
+			t.Errorf("unexpected secret %s/%s", secret.Metadata.Namespace, secret.Metadata.Name)
+		}
+		if secret.Type != "kubernetes.io/tls" {
+			t.Errorf("expected kubernetes.io/tls secret, got %q", secret.Type)
+		}
+		if !bytes.Equal(secret.Data["ca.crt"], caCert) || !bytes.Equal(secret.Data["tls.crt"], caCert) || !bytes.Equal(secret.Data["tls.key"], caKey) {
+			t.Error("expected the CA key pair in the secret")
+		}
+		if !strings.Contains(string(manifests.Kustomization), "ca-secret.yaml") {
+			t.Errorf("expected ca-secret.yaml in kustomization:\n%s", manifests.Kustomization)
+		}
+	})
+
+	t.Run("acme has no ca secret", func(t *testing.T) {
+		manifests, err := GenerateCertManagerIssuerManifests(CertManagerIssuerConfig{}, caCert)
+		if err != nil {
+			t.Fatalf("GenerateCertManagerIssuerManifests failed: %v", err)
+		}
+		if len(manifests.CASecret) != 0 {
+			t.Error("expected no CA secret in acme mode")
+		}
+	})
+
+	errTests := []struct {
+		name string
+		cfg  CertManagerIssuerConfig
+	}{
+		{name: "missing key", cfg: CertManagerIssuerConfig{Mode: IssuerModeCA}},
+		{name: "mismatched key", cfg: CertManagerIssuerConfig{Mode: IssuerModeCA, CAKey: []byte("not a key")}},
+		{name: "dns01", cfg: CertManagerIssuerConfig{Mode: IssuerModeCA, CAKey: caKey, UseDNS01: true, DNS01Provider: DNS01ProviderCloudflare}},
+		{name: "invalid mode", cfg: CertManagerIssuerConfig{Mode: "selfsigned"}},
+	}
+	for _, tt := range errTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateCertManagerIssuerManifests(tt.cfg, caCert); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
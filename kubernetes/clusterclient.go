@@ -0,0 +1,128 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// clusterClient applies and removes the resources kinder manages in a
+// cluster. restClient talks to the API server in-process; kubectlClient shells
+// out to kubectl for users who prefer it.
+type clusterClient interface {
+	// apply server-side applies a multi-document manifest
+	apply(ctx context.Context, manifest string) error
+	// applyURL applies a manifest downloaded from url
+	applyURL(ctx context.Context, url string) error
+	// patch applies a strategic merge patch to a configmap or deployment
+	patch(ctx context.Context, kind, name, patch string) error
+	// waitRollout waits for a deployment to finish rolling out; missing
+	// deployments are skipped
+	waitRollout(ctx context.Context, deployment string, timeout time.Duration) error
+	// hasCRD reports whether a CustomResourceDefinition is installed
+	hasCRD(ctx context.Context, name string) (bool, error)
+	// deleteAll deletes every object of a kind and waits for them to go
+	deleteAll(ctx context.Context, apiVersion, kind string, timeout time.Duration) error
+	// deleteURL deletes the objects in a manifest downloaded from url
+	deleteURL(ctx context.Context, url string, timeout time.Duration) error
+	// deleteNamespace deletes a namespace and waits for it to go
+	deleteNamespace(ctx context.Context, name string, timeout time.Duration) error
+	// applicationStatus returns an ArgoCD Application's health and sync
+	// status, both empty if it has none yet
+	applicationStatus(ctx context.Context, name string) (appStatus, error)
+}
+
+// newClusterClient returns the client for an ArgoCD config: kubectl when
+// cfg.UseKubectl is set, otherwise an in-process client for the kubeconfig
+func newClusterClient(cfg ArgoCDConfig) (clusterClient, error) {
+	if cfg.UseKubectl {
+		return kubectlClient{cfg: cfg}, nil
+	}
+
+	clientConfig, err := kubeClientConfig(cfg.KubeconfigPath, cfg.KubeContext)
+	if err != nil {
+		return nil, err
+	}
+	return newRESTClient(clientConfig, cfg.Namespace)
+}
+
+// kubectlClient implements clusterClient by running kubectl
+type kubectlClient struct {
+	cfg ArgoCDConfig
+}
+
+// run runs kubectl with the config's kubeconfig and context, including
+// stderr in any error
+func (k kubectlClient) run(ctx context.Context, stdin string, args ...string) error {
+	_, err := k.output(ctx, stdin, args...)
+	return err
+}
+
+// output is like run but returns kubectl's stdout
+func (k kubectlClient) output(ctx context.Context, stdin string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "kubectl", kubectlArgs(k.cfg, args...)...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return out, nil
+}
+
+func (k kubectlClient) apply(ctx context.Context, manifest string) error {
+	return k.run(ctx, manifest, "apply", "-f", "-")
+}
+
+func (k kubectlClient) applyURL(ctx context.Context, url string) error {
+	return k.run(ctx, "", "apply", "-f", url, "-n", k.cfg.Namespace)
+}
+
+func (k kubectlClient) patch(ctx context.Context, kind, name, patch string) error {
+	return k.run(ctx, "", "patch", kind, name, "-n", k.cfg.Namespace, "--type=strategic", "-p", patch)
+}
+
+func (k kubectlClient) waitRollout(ctx context.Context, deployment string, timeout time.Duration) error {
+	if err := k.run(ctx, "", "get", "deployment", deployment, "-n", k.cfg.Namespace); err != nil {
+		return nil // optional deployments may not exist
+	}
+	return k.run(ctx, "", "rollout", "status", "deployment/"+deployment, "-n", k.cfg.Namespace, "--timeout", timeout.String())
+}
+
+func (k kubectlClient) hasCRD(ctx context.Context, name string) (bool, error) {
+	return k.run(ctx, "", "get", "crd", name) == nil, nil
+}
+
+func (k kubectlClient) deleteAll(ctx context.Context, apiVersion, kind string, timeout time.Duration) error {
+	// kubectl accepts kind.version.group for non-core kinds
+	resource := kind
+	if group, version, ok := strings.Cut(apiVersion, "/"); ok {
+		resource = kind + "." + version + "." + group
+	}
+	return k.run(ctx, "", "delete", resource, "--all", "-n", k.cfg.Namespace,
+		"--wait", "--timeout="+timeout.String())
+}
+
+func (k kubectlClient) deleteURL(ctx context.Context, url string, timeout time.Duration) error {
+	return k.run(ctx, "", "delete", "-f", url, "-n", k.cfg.Namespace, "--ignore-not-found", "--timeout="+timeout.String())
+}
+
+func (k kubectlClient) deleteNamespace(ctx context.Context, name string, timeout time.Duration) error {
+	return k.run(ctx, "", "delete", "namespace", name, "--ignore-not-found", "--timeout="+timeout.String())
+}
+
+func (k kubectlClient) applicationStatus(ctx context.Context, name string) (appStatus, error) {
+	out, err := k.output(ctx, "", "get", "applications.argoproj.io", name, "-n", k.cfg.Namespace,
+		"-o", "jsonpath={.status.health.status}/{.status.sync.status}")
+	if err != nil {
+		return appStatus{}, err
+	}
+	health, sync, _ := strings.Cut(strings.TrimSpace(string(out)), "/")
+	return appStatus{Health: health, Sync: sync}, nil
+}
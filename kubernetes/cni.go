@@ -0,0 +1,122 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Supported CNI plugins for Kind clusters
+const (
+	// CNIKindnet is Kind's built-in CNI (default)
+	CNIKindnet = "kindnet"
+	// CNICalico installs Calico after cluster creation
+	CNICalico = "calico"
+	// CNICilium installs Cilium after cluster creation
+	CNICilium = "cilium"
+	// CNINone disables the default CNI without installing a replacement
+	CNINone = "none"
+)
+
+// CNI manifest URLs applied after cluster creation.
+// Calico detects the pod CIDR from the kubeadm configuration, so it works with
+// Kind's default pod subnet (10.244.0.0/16) without changes.
+const (
+	CalicoManifestURL = "https://raw.githubusercontent.com/projectcalico/calico/v3.29.1/manifests/calico.yaml"
+	CiliumManifestURL = "https://raw.githubusercontent.com/cilium/cilium/v1.14.5/install/kubernetes/quick-install.yaml"
+)
+
+// cniReadyTimeout is how long to wait for nodes to become Ready after installing a CNI
+const cniReadyTimeout = 5 * time.Minute
+
+// SupportedCNIs returns the list of supported CNI names
+func SupportedCNIs() []string {
+	return []string{CNIKindnet, CNICalico, CNICilium, CNINone}
+}
+
+// ValidateCNI checks that the CNI name is supported. An empty name selects kindnet.
+func ValidateCNI(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, cni := range SupportedCNIs() {
+		if name == cni {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported CNI %q (supported: %s)", name, strings.Join(SupportedCNIs(), ", "))
+}
+
+// disablesDefaultCNI reports whether the CNI choice requires disabling kindnet
+func disablesDefaultCNI(name string) bool {
+	return name != "" && name != CNIKindnet
+}
+
+// skipsDefaultCNI reports whether the cluster is created without kindnet,
+// either for a chosen CNI, a custom CNI manifest or on request
+func skipsDefaultCNI(cfg KindConfig) bool {
+	return disablesDefaultCNI(cfg.CNI) || cfg.DisableDefaultCNI || cfg.CNIManifestURL != ""
+}
+
+// WaitsForCNI reports whether a cluster will stay NotReady after creation
+// because kindnet is disabled and no CNI is installed in its place
+func WaitsForCNI(cfg KindConfig) bool {
+	return skipsDefaultCNI(cfg) && cfg.CNIManifestURL == "" && cniManifestURL(cfg.CNI) == ""
+}
+
+// validateCNIManifestURL checks a custom CNI manifest URL, which cannot be
+// combined with one of the CNIs kinder installs itself
+func validateCNIManifestURL(cfg KindConfig) error {
+	if cfg.CNIManifestURL == "" {
+		return nil
+	}
+	if cniManifestURL(cfg.CNI) != "" {
+		return fmt.Errorf("a CNI manifest cannot be combined with CNI %q", cfg.CNI)
+	}
+	if err := validateURL(cfg.CNIManifestURL); err != nil {
+		return fmt.Errorf("invalid CNI manifest URL: %w", err)
+	}
+	return nil
+}
+
+// cniManifestURL returns the manifest to apply for the CNI, or empty if none is needed
+func cniManifestURL(name string) string {
+	switch name {
+	case CNICalico:
+		return CalicoManifestURL
+	case CNICilium:
+		return CiliumManifestURL
+	default:
+		return ""
+	}
+}
+
+// installCNI applies the chosen CNI's manifests to a newly created cluster and
+// waits for all nodes to become Ready
+func installCNI(ctx context.Context, cfg KindConfig) error {
+	url := cfg.CNIManifestURL
+	name := "CNI"
+	if url == "" {
+		url = cniManifestURL(cfg.CNI)
+		name = cfg.CNI
+	}
+	if url == "" {
+		return nil
+	}
+
+	client, err := newKindRESTClient(cfg.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	if err := client.applyURL(ctx, url); err != nil {
+		return fmt.Errorf("failed to apply %s manifests: %w", name, err)
+	}
+
+	if err := client.waitNodesReady(ctx, cniReadyTimeout); err != nil {
+		return fmt.Errorf("nodes did not become ready after installing %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -1,20 +1,29 @@
 package kubernetes
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"codeberg.org/hipkoi/kinder/docker"
 	"github.com/docker/docker/api/types/container"
+	"sigs.k8s.io/kind/pkg/apis/config/defaults"
 	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
 	"sigs.k8s.io/kind/pkg/cluster"
 	"sigs.k8s.io/kind/pkg/cmd"
 	"sigs.k8s.io/kind/pkg/log"
+	"sigs.k8s.io/yaml"
 )
 
 // kindEnvMutex protects environment variable operations for Kind cluster creation.
@@ -24,10 +33,84 @@ var kindEnvMutex sync.Mutex
 const (
 	// KindClusterName is the default name for the Kind cluster
 	KindClusterName = "kinder"
-	// KindNodeImage is the default Kind node image
-	KindNodeImage = "kindest/node:v1.32.2"
+	// KindNodeImage is the default Kind node image: the one published with
+	// the Kind release kinder builds against, pinned by digest
+	KindNodeImage = defaults.Image
+	// KindClusterLabel is the container label Kind sets to the cluster name on each node
+	KindClusterLabel = "io.x-k8s.kind.cluster"
+	// KindRoleLabel is the container label Kind sets to the node role
+	KindRoleLabel = "io.x-k8s.kind.role"
+	// KinderCAContainerPath is where the kinder CA certificate is mounted in
+	// every node. Its presence marks a cluster as created by kinder.
+	KinderCAContainerPath = "/etc/ssl/certs/kinder-ca.crt"
+	// DefaultKindWaitTimeout is how long cluster creation waits for nodes to
+	// become Ready by default
+	DefaultKindWaitTimeout = 5 * time.Minute
 )
 
+// kindNodeImages pins Kubernetes versions to the kindest/node images published
+// with the Kind release kinder builds against, by digest. Add entries (with
+// their digests) from the Kind release notes when upgrading Kind; a minor
+// version maps to its newest patch release.
+var kindNodeImages = map[string]string{
+	"v1.35":   defaults.Image,
+	"v1.35.0": defaults.Image,
+}
+
+// PinnedK8sVersions returns the Kubernetes versions NodeImageForVersion pins
+// by digest, in ascending order
+func PinnedK8sVersions() []string {
+	versions := make([]string, 0, len(kindNodeImages))
+	for version := range kindNodeImages {
+		versions = append(versions, version)
+	}
+	slices.SortFunc(versions, compareK8sVersions)
+	return versions
+}
+
+// compareK8sVersions orders versions like v1.34 < v1.34.2 < v1.35
+func compareK8sVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, _ := strconv.Atoi(as[i])
+		bn, _ := strconv.Atoi(bs[i])
+		if an != bn {
+			return an - bn
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// k8sVersionPattern matches a Kubernetes minor or patch version
+var k8sVersionPattern = regexp.MustCompile(`^v\d+\.\d+(\.\d+)?$`)
+
+// NodeImageForVersion returns the kindest/node image for a Kubernetes version
+// such as "v1.35" or "1.35.0". Known versions are pinned by digest; for others
+// pinned is false and the image is the unpinned "kindest/node:v<version>" tag.
+func NodeImageForVersion(version string) (image string, pinned bool, err error) {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	if !k8sVersionPattern.MatchString(version) {
+		return "", false, fmt.Errorf("invalid Kubernetes version %q: must be like v1.35 or v1.35.0", version)
+	}
+	if image, ok := kindNodeImages[version]; ok {
+		return image, true, nil
+	}
+	return "kindest/node:" + version, false, nil
+}
+
+// PortMapping publishes a port of the control-plane node on the host
+type PortMapping struct {
+	// ContainerPort is the port in the node, e.g. a NodePort
+	ContainerPort int32
+	// HostPort is the port on the host
+	HostPort int32
+	// Protocol is TCP (default), UDP or SCTP
+	Protocol string
+}
+
 // KindConfig holds configuration for creating a Kind cluster
 type KindConfig struct {
 	// ClusterName is the name of the Kind cluster
@@ -38,15 +121,56 @@ type KindConfig struct {
 	CACertPath string
 	// NetworkName is the Docker network to connect to
 	NetworkName string
+	// ConnectToNetwork attaches every node to NetworkName after creation, in
+	// case Kind placed them elsewhere. Callers normally set it whenever
+	// NetworkName is not Kind's own "kind" network.
+	ConnectToNetwork bool
 	// RegistryMirrors maps registry hosts to their mirror URLs
 	// e.g., "docker.io" -> "http://zot:5000"
 	RegistryMirrors map[string]string
 	// ZotHostname is the hostname of the Zot registry
 	ZotHostname string
+	// RegistryTLSHost is Zot's TLS route through Traefik, e.g.
+	// "registry.example.sslip.io:8443". When set, nodes pull from Zot over
+	// HTTPS, verifying it against the kinder CA, instead of plain HTTP with
+	// skip_verify.
+	RegistryTLSHost string
 	// WorkerNodes is the number of worker nodes (0 = control-plane only)
 	WorkerNodes int
+	// WorkerLabels are Kubernetes labels set on every worker node
+	WorkerLabels map[string]string
+	// WorkerTaints are taints, as key=value:Effect, set on every worker node
+	WorkerTaints []string
+	// ExtraPortMappings publish control-plane ports, such as NodePorts, on the host
+	ExtraPortMappings []PortMapping
+	// KubeadmPatches are raw kubeadm config patches applied to every node,
+	// e.g. to set API server flags or feature gates
+	KubeadmPatches []string
+	// CNI selects the cluster CNI: kindnet (default), calico, cilium or none
+	CNI string
+	// DisableDefaultCNI creates the cluster without kindnet, like CNI "none"
+	DisableDefaultCNI bool
+	// CNIManifestURL is a CNI manifest applied after creation in place of
+	// kindnet; it cannot be combined with calico or cilium
+	CNIManifestURL string
+	// PodSubnet is the CIDR for pod IPs (empty = Kind default)
+	PodSubnet string
+	// ServiceSubnet is the CIDR for service IPs (empty = Kind default)
+	ServiceSubnet string
+	// NetworkCIDR is the Docker network CIDR, used to check for subnet overlaps
+	NetworkCIDR string
+
+	// IPv6 makes the cluster dual-stack; the Docker network must have IPv6
+	// enabled
+	IPv6 bool
+	// WaitForReady is how long creation waits for nodes to become Ready
+	// (0 = don't wait)
+	WaitForReady time.Duration
 	// Verbose enables detailed output from Kind
 	Verbose bool
+	// Logger receives Kind's output when Verbose is set (default: Kind's
+	// own stderr logger)
+	Logger log.Logger
 }
 
 // nullLogger implements a no-op logger for Kind
@@ -64,18 +188,31 @@ func (n nullInfoLogger) Info(message string)                      {}
 func (n nullInfoLogger) Infof(format string, args ...interface{}) {}
 func (n nullInfoLogger) Enabled() bool                            { return false }
 
+// ReadyWait returns how long creating cfg's cluster waits for nodes to become
+// Ready. Nodes cannot become Ready until a CNI is installed, so there is no
+// wait unless Kind provides the default CNI.
+func ReadyWait(cfg KindConfig) time.Duration {
+	if skipsDefaultCNI(cfg) {
+		return 0
+	}
+	return cfg.WaitForReady
+}
+
+// kindLogger returns the logger for Kind's output
+func kindLogger(cfg KindConfig) log.Logger {
+	switch {
+	case !cfg.Verbose:
+		return nullLogger{}
+	case cfg.Logger != nil:
+		return cfg.Logger
+	default:
+		return cmd.NewLogger()
+	}
+}
+
 // StartKind creates and starts a Kind cluster with the given configuration
 func StartKind(ctx context.Context, cfg KindConfig) error {
-	var provider *cluster.Provider
-	if cfg.Verbose {
-		provider = cluster.NewProvider(
-			cluster.ProviderWithLogger(cmd.NewLogger()),
-		)
-	} else {
-		provider = cluster.NewProvider(
-			cluster.ProviderWithLogger(nullLogger{}),
-		)
-	}
+	provider := cluster.NewProvider(cluster.ProviderWithLogger(kindLogger(cfg)))
 
 	// Check if cluster already exists
 	clusters, err := provider.List()
@@ -111,38 +248,41 @@ func StartKind(ctx context.Context, cfg KindConfig) error {
 	}
 	defer kindEnvMutex.Unlock()
 
-	if err := provider.Create(
-		cfg.ClusterName,
+	createOpts := []cluster.CreateOption{
 		cluster.CreateWithV1Alpha4Config(kindConfig),
 		cluster.CreateWithNodeImage(nodeImage),
-		cluster.CreateWithWaitForReady(5*time.Minute),
-	); err != nil {
+	}
+	if wait := ReadyWait(cfg); wait > 0 {
+		createOpts = append(createOpts, cluster.CreateWithWaitForReady(wait))
+	}
+
+	// Kind cannot be cancelled once creation starts, so check first
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := provider.Create(cfg.ClusterName, createOpts...); err != nil {
 		return fmt.Errorf("failed to create cluster: %w", err)
 	}
 
-	// Connect cluster nodes to the kinder network
-	// if cfg.NetworkName != "" {
-	// 	if err := connectKindToNetwork(ctx, cfg.ClusterName, cfg.NetworkName); err != nil {
-	// 		// Log warning but don't fail - cluster is still usable
-	// 		fmt.Printf("Warning: failed to connect to network %s: %v\n", cfg.NetworkName, err)
-	// 	}
-	// }
+	// Connect cluster nodes to the kinder network so they can resolve zot
+	// and stepca by name
+	if cfg.ConnectToNetwork && cfg.NetworkName != "" {
+		if err := connectKindToNetwork(ctx, cfg.ClusterName, cfg.NetworkName); err != nil {
+			// Log warning but don't fail - cluster is still usable
+			fmt.Fprintf(os.Stderr, "Warning: failed to connect to network %s: %v\n", cfg.NetworkName, err)
+		}
+	}
+
+	if err := installCNI(ctx, cfg); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 // StopKind deletes a Kind cluster
 func StopKind(cfg KindConfig) error {
-	var provider *cluster.Provider
-	if cfg.Verbose {
-		provider = cluster.NewProvider(
-			cluster.ProviderWithLogger(cmd.NewLogger()),
-		)
-	} else {
-		provider = cluster.NewProvider(
-			cluster.ProviderWithLogger(nullLogger{}),
-		)
-	}
+	provider := cluster.NewProvider(cluster.ProviderWithLogger(kindLogger(cfg)))
 
 	if err := provider.Delete(cfg.ClusterName, ""); err != nil {
 		return fmt.Errorf("failed to delete cluster: %w", err)
@@ -169,6 +309,119 @@ func KindExists(clusterName string) (bool, error) {
 	return false, nil
 }
 
+// IsKinderCluster reports whether the named Kind cluster was created by
+// kinder, identified by the kinder CA certificate mounted into its nodes
+func IsKinderCluster(ctx context.Context, clusterName string) (bool, error) {
+	c, err := docker.GetSharedClient()
+	if err != nil {
+		return false, err
+	}
+
+	containers, err := c.Raw().ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return false, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	return hasKinderMarker(containers, clusterName), nil
+}
+
+// hasKinderMarker reports whether any node of the named cluster mounts the
+// kinder CA certificate
+func hasKinderMarker(containers []container.Summary, clusterName string) bool {
+	for _, ctr := range containers {
+		if ctr.Labels[KindClusterLabel] != clusterName {
+			continue
+		}
+		for _, m := range ctr.Mounts {
+			if m.Destination == KinderCAContainerPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NodeImage returns the image the named cluster's control-plane node was
+// created from
+func NodeImage(ctx context.Context, clusterName string) (string, error) {
+	c, err := docker.GetSharedClient()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := c.Raw().ContainerInspect(ctx, clusterName+"-control-plane")
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect control-plane node: %w", err)
+	}
+	return info.Config.Image, nil
+}
+
+// SameNodeImage reports whether two node image references name the same
+// image. Digests are compared when both have one, otherwise the repository
+// and tag, ignoring the implicit docker.io registry.
+func SameNodeImage(a, b string) bool {
+	aName, aDigest, _ := strings.Cut(a, "@")
+	bName, bDigest, _ := strings.Cut(b, "@")
+	if aDigest != "" && bDigest != "" {
+		return aDigest == bDigest
+	}
+	return normalizeImageName(aName) == normalizeImageName(bName)
+}
+
+// normalizeImageName strips the docker.io registry and library namespace
+// Docker adds to short image names
+func normalizeImageName(name string) string {
+	name = strings.TrimPrefix(name, "docker.io/")
+	return strings.TrimPrefix(name, "library/")
+}
+
+// KindContext returns the kubeconfig context name Kind uses for a cluster
+func KindContext(clusterName string) string {
+	return "kind-" + clusterName
+}
+
+// DumpClusterInfo writes "kubectl cluster-info dump" output for all namespaces
+// of the Kind cluster to outputDir
+func DumpClusterInfo(ctx context.Context, clusterName, outputDir string) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create dump directory: %w", err)
+	}
+
+	if err := runKubectl(ctx, "--context", KindContext(clusterName),
+		"cluster-info", "dump", "--all-namespaces", "--output-directory", outputDir); err != nil {
+		return fmt.Errorf("failed to dump cluster info: %w", err)
+	}
+
+	return nil
+}
+
+// runKubectl runs kubectl with the given arguments, including stderr in any error
+func runKubectl(ctx context.Context, args ...string) error {
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	return nil
+}
+
+// RunPod runs a bare pod with a single container in namespace, talking to the
+// API server of the kubeconfig context in-process, and waits up to timeout
+// for it to be running. The pod is deleted again before returning. Kind
+// contexts are read from Kind when kubeconfigPath is empty.
+func RunPod(ctx context.Context, kubeconfigPath, kubeContext, namespace, name, image string, command []string, timeout time.Duration) error {
+	clientConfig, err := kubeClientConfig(kubeconfigPath, kubeContext)
+	if err != nil {
+		return fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	c, err := newRESTClient(clientConfig, namespace)
+	if err != nil {
+		return err
+	}
+	return c.runPod(ctx, name, image, command, timeout)
+}
+
 // GetKindKubeconfig returns the kubeconfig for a Kind cluster
 func GetKindKubeconfig(clusterName string) (string, error) {
 	provider := cluster.NewProvider()
@@ -191,6 +444,30 @@ func buildKindConfig(cfg KindConfig) (*v1alpha4.Cluster, error) {
 		Name: cfg.ClusterName,
 	}
 
+	if err := ValidateCNI(cfg.CNI); err != nil {
+		return nil, err
+	}
+
+	if err := validateCNIManifestURL(cfg); err != nil {
+		return nil, err
+	}
+
+	// Disable kindnet when another CNI will be installed after creation
+	if skipsDefaultCNI(cfg) {
+		config.Networking.DisableDefaultCNI = true
+	}
+
+	if err := ValidateSubnets(cfg.PodSubnet, cfg.ServiceSubnet, cfg.NetworkCIDR, cfg.IPv6); err != nil {
+		return nil, err
+	}
+	config.Networking.PodSubnet = cfg.PodSubnet
+	config.Networking.ServiceSubnet = cfg.ServiceSubnet
+	if cfg.IPv6 {
+		config.Networking.IPFamily = v1alpha4.DualStackFamily
+	}
+
+	config.KubeadmConfigPatches = append(config.KubeadmConfigPatches, cfg.KubeadmPatches...)
+
 	// Build containerd config patches for registry mirrors
 	containerdPatches := buildContainerdPatches(cfg)
 	if len(containerdPatches) > 0 {
@@ -200,7 +477,7 @@ func buildKindConfig(cfg KindConfig) (*v1alpha4.Cluster, error) {
 	// Create the certs.d directory structure with hosts.toml files
 	dataDir := filepath.Dir(cfg.CACertPath)
 	if len(cfg.RegistryMirrors) > 0 || cfg.ZotHostname != "" {
-		if err := createCertsDirStructure(dataDir, cfg.CACertPath, cfg.RegistryMirrors, cfg.ZotHostname); err != nil {
+		if err := createCertsDirStructure(dataDir, cfg.CACertPath, cfg.RegistryMirrors, cfg.ZotHostname, cfg.RegistryTLSHost); err != nil {
 			return nil, fmt.Errorf("failed to create certs.d structure: %w", err)
 		}
 	}
@@ -212,7 +489,7 @@ func buildKindConfig(cfg KindConfig) (*v1alpha4.Cluster, error) {
 	if cfg.CACertPath != "" {
 		extraMounts = append(extraMounts, v1alpha4.Mount{
 			HostPath:      cfg.CACertPath,
-			ContainerPath: "/etc/ssl/certs/kinder-ca.crt",
+			ContainerPath: KinderCAContainerPath,
 			Readonly:      true,
 		})
 
@@ -232,20 +509,213 @@ func buildKindConfig(cfg KindConfig) (*v1alpha4.Cluster, error) {
 		Role:        v1alpha4.ControlPlaneRole,
 		ExtraMounts: extraMounts,
 	}
+	for _, pm := range cfg.ExtraPortMappings {
+		protocol := v1alpha4.PortMappingProtocol(strings.ToUpper(pm.Protocol))
+		if protocol == "" {
+			protocol = v1alpha4.PortMappingProtocolTCP
+		}
+		controlPlane.ExtraPortMappings = append(controlPlane.ExtraPortMappings, v1alpha4.PortMapping{
+			ContainerPort: pm.ContainerPort,
+			HostPort:      pm.HostPort,
+			Protocol:      protocol,
+		})
+	}
 	config.Nodes = append(config.Nodes, controlPlane)
 
 	// Add worker nodes with the same mounts
+	if err := validateLabels(cfg.WorkerLabels); err != nil {
+		return nil, fmt.Errorf("worker nodes: %w", err)
+	}
+	taintPatch, err := workerTaintPatch(cfg.WorkerTaints)
+	if err != nil {
+		return nil, err
+	}
 	for i := 0; i < cfg.WorkerNodes; i++ {
 		worker := v1alpha4.Node{
 			Role:        v1alpha4.WorkerRole,
+			Labels:      cfg.WorkerLabels,
 			ExtraMounts: extraMounts,
 		}
+		if taintPatch != "" {
+			worker.KubeadmConfigPatches = []string{taintPatch}
+		}
 		config.Nodes = append(config.Nodes, worker)
 	}
 
 	return config, nil
 }
 
+// ParsePortMapping parses a port mapping in the containerPort:hostPort[/protocol]
+// form, e.g. "30080:8080/tcp" publishes NodePort 30080 on host port 8080
+func ParsePortMapping(mapping string) (PortMapping, error) {
+	ports, protocol, hasProtocol := strings.Cut(mapping, "/")
+	containerPort, hostPort, ok := strings.Cut(ports, ":")
+	if !ok {
+		return PortMapping{}, fmt.Errorf("invalid port mapping %q: must be containerPort:hostPort[/protocol]", mapping)
+	}
+
+	pm := PortMapping{Protocol: "TCP"}
+	for _, p := range []struct {
+		name  string
+		value string
+		port  *int32
+	}{
+		{"container port", containerPort, &pm.ContainerPort},
+		{"host port", hostPort, &pm.HostPort},
+	} {
+		n, err := strconv.ParseUint(p.value, 10, 16)
+		if err != nil || n == 0 {
+			return PortMapping{}, fmt.Errorf("invalid port mapping %q: %s must be 1-65535", mapping, p.name)
+		}
+		*p.port = int32(n)
+	}
+
+	if hasProtocol {
+		pm.Protocol = strings.ToUpper(protocol)
+		switch v1alpha4.PortMappingProtocol(pm.Protocol) {
+		case v1alpha4.PortMappingProtocolTCP, v1alpha4.PortMappingProtocolUDP, v1alpha4.PortMappingProtocolSCTP:
+		default:
+			return PortMapping{}, fmt.Errorf("invalid port mapping %q: protocol must be tcp, udp or sctp", mapping)
+		}
+	}
+	return pm, nil
+}
+
+// LoadKubeadmPatches reads kubeadm config patch files. Each file must be a
+// YAML document naming the kubeadm kind it patches, such as
+// ClusterConfiguration or KubeletConfiguration.
+func LoadKubeadmPatches(paths []string) ([]string, error) {
+	var patches []string
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read kubeadm patch: %w", err)
+		}
+		var doc struct {
+			Kind string `json:"kind"`
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("invalid kubeadm patch %s: %w", path, err)
+		}
+		if doc.Kind == "" {
+			return nil, fmt.Errorf("invalid kubeadm patch %s: missing kind", path)
+		}
+		patches = append(patches, string(data))
+	}
+	return patches, nil
+}
+
+// nodeTaint is a taint in a kubeadm nodeRegistration
+type nodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// ParseTaint parses a taint in the key=value:Effect form used by kubectl
+// taint. The value is optional.
+func ParseTaint(taint string) (key, value, effect string, err error) {
+	spec, effect, ok := strings.Cut(taint, ":")
+	if !ok {
+		return "", "", "", fmt.Errorf("invalid taint %q: must be key=value:Effect", taint)
+	}
+	switch effect {
+	case "NoSchedule", "PreferNoSchedule", "NoExecute":
+	default:
+		return "", "", "", fmt.Errorf("invalid taint %q: effect must be NoSchedule, PreferNoSchedule or NoExecute", taint)
+	}
+	key, value, _ = strings.Cut(spec, "=")
+	if err := validateMetadataKey(key); err != nil {
+		return "", "", "", fmt.Errorf("invalid taint %q: %w", taint, err)
+	}
+	if value != "" && (len(value) > 63 || !labelNameRegex.MatchString(value)) {
+		return "", "", "", fmt.Errorf("invalid taint %q: value must be at most 63 alphanumeric characters, '-', '_' or '.'", taint)
+	}
+	return key, value, effect, nil
+}
+
+// workerTaintPatch returns a kubeadm JoinConfiguration patch registering
+// worker nodes with taints, or "" if there are none
+func workerTaintPatch(taints []string) (string, error) {
+	if len(taints) == 0 {
+		return "", nil
+	}
+
+	var patch struct {
+		Kind             string `json:"kind"`
+		NodeRegistration struct {
+			Taints []nodeTaint `json:"taints"`
+		} `json:"nodeRegistration"`
+	}
+	patch.Kind = "JoinConfiguration"
+	for _, t := range taints {
+		key, value, effect, err := ParseTaint(t)
+		if err != nil {
+			return "", err
+		}
+		patch.NodeRegistration.Taints = append(patch.NodeRegistration.Taints, nodeTaint{Key: key, Value: value, Effect: effect})
+	}
+
+	data, err := yaml.Marshal(patch)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode taint patch: %w", err)
+	}
+	return string(data), nil
+}
+
+// ValidateSubnets checks that the pod and service subnets are valid CIDRs and
+// that none of the pod, service and Docker network ranges overlap.
+// Empty values are skipped. In dual-stack mode the pod and service subnets
+// must each be an IPv4 and an IPv6 CIDR separated by a comma.
+func ValidateSubnets(podSubnet, serviceSubnet, networkCIDR string, dualStack bool) error {
+	type subnet struct {
+		name      string
+		cidr      string
+		nets      []*net.IPNet
+		dualStack bool
+	}
+	subnets := []subnet{
+		{name: "pod subnet", cidr: podSubnet, dualStack: dualStack},
+		{name: "service subnet", cidr: serviceSubnet, dualStack: dualStack},
+		{name: "network CIDR", cidr: networkCIDR},
+	}
+
+	for i := range subnets {
+		s := &subnets[i]
+		if s.cidr == "" {
+			continue
+		}
+		for _, cidr := range strings.Split(s.cidr, ",") {
+			_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				return fmt.Errorf("invalid %s %q: %w", s.name, s.cidr, err)
+			}
+			s.nets = append(s.nets, ipNet)
+		}
+
+		switch {
+		case s.dualStack && (len(s.nets) != 2 || (s.nets[0].IP.To4() == nil) == (s.nets[1].IP.To4() == nil)):
+			return fmt.Errorf("invalid %s %q: dual-stack clusters need one IPv4 and one IPv6 CIDR, e.g. 10.244.0.0/16,fd00:10:244::/56", s.name, s.cidr)
+		case !s.dualStack && len(s.nets) > 1:
+			return fmt.Errorf("invalid %s %q: only dual-stack clusters (--ipv6) take an IPv4 and an IPv6 CIDR", s.name, s.cidr)
+		}
+	}
+
+	for i := range subnets {
+		for j := i + 1; j < len(subnets); j++ {
+			for _, a := range subnets[i].nets {
+				for _, b := range subnets[j].nets {
+					if a.Contains(b.IP) || b.Contains(a.IP) {
+						return fmt.Errorf("%s %s overlaps %s %s", subnets[i].name, subnets[i].cidr, subnets[j].name, subnets[j].cidr)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
 // buildContainerdPatches creates containerd configuration patches for registry mirrors.
 // This only sets the config_path to enable the directory-based hosts.toml configuration.
 // The actual mirror configuration is in the hosts.toml files created by createCertsDirStructure.
@@ -264,7 +734,9 @@ func buildContainerdPatches(cfg KindConfig) []string {
 
 // createCertsDirStructure creates the certs.d directory structure with hosts.toml files
 // for each registry mirror. This is the new containerd registry configuration format.
-func createCertsDirStructure(dataDir string, caCertPath string, mirrors map[string]string, zotHostname string) error {
+// When tlsHost is set, Zot and the mirrors are reached through that TLS route and
+// verified against the CA certificate rather than over plain HTTP.
+func createCertsDirStructure(dataDir string, caCertPath string, mirrors map[string]string, zotHostname string, tlsHost string) error {
 	certsDir := filepath.Join(dataDir, "certs.d")
 
 	// Clean existing certs.d directory to ensure fresh configuration
@@ -282,46 +754,54 @@ func createCertsDirStructure(dataDir string, caCertPath string, mirrors map[stri
 		}
 	}
 
+	if tlsHost != "" && len(caCertData) == 0 {
+		return fmt.Errorf("strict registry TLS requires a CA certificate")
+	}
+
 	// Create hosts.toml for direct access to Zot registry (zot:5000)
 	// This allows pulling images pushed directly to the local registry
 	if zotHostname != "" {
 		zotAddr := zotHostname + ":5000"
-		zotDir := filepath.Join(certsDir, zotAddr)
-		if err := os.MkdirAll(zotDir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory for %s: %w", zotAddr, err)
-		}
-
-		// Configure HTTP access to Zot (no TLS)
-		hostsToml := fmt.Sprintf(`server = "http://%s"
 
-[host."http://%s"]
-  capabilities = ["pull", "resolve"]
-  skip_verify = true
-`, zotAddr, zotAddr)
-
-		hostsPath := filepath.Join(zotDir, "hosts.toml")
-		if err := os.WriteFile(hostsPath, []byte(hostsToml), 0644); err != nil {
-			return fmt.Errorf("failed to write hosts.toml for %s: %w", zotAddr, err)
+		// localhost:5000 is redirected to Zot so images can be pulled using
+		// localhost:5000 from inside Kind nodes
+		hosts := []string{zotAddr, "localhost:5000"}
+		if tlsHost != "" {
+			hosts = append(hosts, tlsHost)
 		}
 
-		// Create hosts.toml for localhost:5000 -> zot:5000 mapping
-		// This allows pulling images using localhost:5000 from inside Kind nodes
-		localhostDir := filepath.Join(certsDir, "localhost:5000")
-		if err := os.MkdirAll(localhostDir, 0755); err != nil {
-			return fmt.Errorf("failed to create directory for localhost:5000: %w", err)
-		}
+		for _, host := range hosts {
+			hostDir := filepath.Join(certsDir, host)
+			if err := os.MkdirAll(hostDir, 0755); err != nil {
+				return fmt.Errorf("failed to create directory for %s: %w", host, err)
+			}
 
-		// Redirect localhost:5000 to zot:5000 (the actual registry on Docker network)
-		localhostHostsToml := fmt.Sprintf(`server = "http://%s"
+			// Configure HTTP access to Zot (no TLS) unless strict TLS is enabled
+			hostsToml := fmt.Sprintf(`server = "http://%s"
 
 [host."http://%s"]
   capabilities = ["pull", "resolve"]
   skip_verify = true
 `, zotAddr, zotAddr)
+			if tlsHost != "" {
+				hostsToml = fmt.Sprintf(`server = "https://%s"
+
+[host."https://%s"]
+  capabilities = ["pull", "resolve"]
+  ca = "%s"
+`, tlsHost, tlsHost, containerCAPath(host))
+			}
+
+			hostsPath := filepath.Join(hostDir, "hosts.toml")
+			if err := os.WriteFile(hostsPath, []byte(hostsToml), 0644); err != nil {
+				return fmt.Errorf("failed to write hosts.toml for %s: %w", host, err)
+			}
 
-		localhostHostsPath := filepath.Join(localhostDir, "hosts.toml")
-		if err := os.WriteFile(localhostHostsPath, []byte(localhostHostsToml), 0644); err != nil {
-			return fmt.Errorf("failed to write hosts.toml for localhost:5000: %w", err)
+			if tlsHost != "" {
+				if err := os.WriteFile(filepath.Join(hostDir, "ca.crt"), caCertData, 0644); err != nil {
+					return fmt.Errorf("failed to write CA cert for %s: %w", host, err)
+				}
+			}
 		}
 	}
 
@@ -347,6 +827,14 @@ func createCertsDirStructure(dataDir string, caCertPath string, mirrors map[stri
 [host."%s"]
   capabilities = ["pull", "resolve"]
 `, upstreamServer, mirrorURL)
+		if tlsHost != "" {
+			hostsToml = fmt.Sprintf(`server = "%s"
+
+[host."https://%s"]
+  capabilities = ["pull", "resolve"]
+  ca = "%s"
+`, upstreamServer, tlsHost, containerCAPath(normalizedName))
+		}
 
 		hostsPath := filepath.Join(registryDir, "hosts.toml")
 		if err := os.WriteFile(hostsPath, []byte(hostsToml), 0644); err != nil {
@@ -365,6 +853,12 @@ func createCertsDirStructure(dataDir string, caCertPath string, mirrors map[stri
 	return nil
 }
 
+// containerCAPath returns where a registry's copy of the CA certificate is
+// found inside a Kind node
+func containerCAPath(host string) string {
+	return "/etc/containerd/certs.d/" + host + "/ca.crt"
+}
+
 // normalizeRegistryName returns the canonical name for a registry as used by containerd.
 // This ensures hosts.toml directories match what containerd expects.
 func normalizeRegistryName(registry string) string {
@@ -384,7 +878,10 @@ func getUpstreamServer(registry string) string {
 	return "https://" + registry
 }
 
-// connectKindToNetwork connects Kind cluster nodes to a Docker network
+// connectKindToNetwork connects every node of a Kind cluster to a Docker
+// network. Nodes already on the network are skipped, so it is safe to call
+// repeatedly. A node that fails to connect does not stop the others; the
+// failures are returned together.
 func connectKindToNetwork(ctx context.Context, clusterName, networkName string) error {
 	c, err := docker.GetSharedClient()
 	if err != nil {
@@ -392,43 +889,40 @@ func connectKindToNetwork(ctx context.Context, clusterName, networkName string)
 	}
 	cli := c.Raw()
 
-	// Get all containers for this Kind cluster
-	// Kind containers are named: <cluster>-control-plane, <cluster>-worker, etc.
 	containers, err := cli.ContainerList(ctx, container.ListOptions{All: true})
 	if err != nil {
 		return fmt.Errorf("failed to list containers: %w", err)
 	}
 
-	prefix := clusterName + "-"
-	for _, ctr := range containers {
-		for _, name := range ctr.Names {
-			// Container names have leading /
-			name = strings.TrimPrefix(name, "/")
-			if strings.HasPrefix(name, prefix) {
-				// Check if already connected
-				inspect, err := cli.ContainerInspect(ctx, ctr.ID)
-				if err != nil {
-					continue
-				}
-
-				alreadyConnected := false
-				for netName := range inspect.NetworkSettings.Networks {
-					if netName == networkName {
-						alreadyConnected = true
-						break
-					}
-				}
-
-				if !alreadyConnected {
-					if err := cli.NetworkConnect(ctx, networkName, ctr.ID, nil); err != nil {
-						return fmt.Errorf("failed to connect %s to network %s: %w", name, networkName, err)
-					}
-				}
+	var errs []error
+	for _, ctr := range nodesToConnect(containers, clusterName, networkName) {
+		if err := cli.NetworkConnect(ctx, networkName, ctr.ID, nil); err != nil {
+			name := ctr.ID
+			if len(ctr.Names) > 0 {
+				name = strings.TrimPrefix(ctr.Names[0], "/")
 			}
+			errs = append(errs, fmt.Errorf("failed to connect %s to network %s: %w", name, networkName, err))
 		}
 	}
+	return errors.Join(errs...)
+}
 
-	return nil
+// nodesToConnect returns the nodes of a Kind cluster that are not yet
+// attached to a network
+func nodesToConnect(containers []container.Summary, clusterName, networkName string) []container.Summary {
+	var nodes []container.Summary
+	for _, ctr := range containers {
+		if ctr.Labels[KindClusterLabel] != clusterName {
+			continue
+		}
+		if ctr.NetworkSettings != nil {
+			if _, ok := ctr.NetworkSettings.Networks[networkName]; ok {
+				continue
+			}
+		}
+		nodes = append(nodes, ctr)
+	}
+	return nodes
 }
 
 // GetKindNodes returns information about Kind cluster nodes.
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"github.com/docker/docker/api/types/container"
 )
 
 // setupTestConfig initializes the config system with a temporary data directory.
@@ -90,7 +92,7 @@ func TestCertsDirStructureIntegration(t *testing.T) {
 	// Use registry mirrors from config
 	mirrors := getRegistryMirrorsFromConfig()
 
-	err := createCertsDirStructure(dataDir, caCertPath, mirrors, "zot")
+	err := createCertsDirStructure(dataDir, caCertPath, mirrors, "zot", "")
 	if err != nil {
 		t.Fatalf("createCertsDirStructure failed: %v", err)
 	}
@@ -448,3 +450,67 @@ func TestBuildKindConfigIntegration(t *testing.T) {
 		}
 	}
 }
+
+// TestKindConnectToNetwork verifies that every node of a cluster ends up on
+// the kinder network, and that connecting again is a no-op
+func TestKindConnectToNetwork(t *testing.T) {
+	if os.Getenv("KINDER_SKIP_KIND_TEST") != "" {
+		t.Skip("Skipping Kind cluster test (KINDER_SKIP_KIND_TEST set)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	dataDir, cleanup := setupTestConfig(t, "kinder-kind-network-*")
+	defer cleanup()
+
+	clusterName := "kinder-network-test"
+	networkName := "kinder-network-test"
+
+	if _, err := docker.CreateNetwork(ctx, docker.NetworkConfig{Name: networkName, CIDR: "172.29.29.0/24"}); err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	defer docker.RemoveNetwork(context.Background(), networkName)
+
+	cfg := KindConfig{
+		ClusterName: clusterName,
+		NodeImage:   KindNodeImage,
+		CACertPath:  createTestCACert(t, dataDir),
+		NetworkName: "bridge", // Kind places the nodes elsewhere first
+		WorkerNodes: 1,
+	}
+	defer StopKind(KindConfig{ClusterName: clusterName})
+
+	if err := StartKind(ctx, cfg); err != nil {
+		t.Fatalf("failed to create Kind cluster: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := connectKindToNetwork(ctx, clusterName, networkName); err != nil {
+			t.Fatalf("connectKindToNetwork (attempt %d) failed: %v", i+1, err)
+		}
+	}
+
+	c, err := docker.GetSharedClient()
+	if err != nil {
+		t.Fatalf("failed to get Docker client: %v", err)
+	}
+	containers, err := c.Raw().ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		t.Fatalf("failed to list containers: %v", err)
+	}
+
+	nodes := 0
+	for _, ctr := range containers {
+		if ctr.Labels[KindClusterLabel] != clusterName {
+			continue
+		}
+		nodes++
+		if _, ok := ctr.NetworkSettings.Networks[networkName]; !ok {
+			t.Errorf("node %v is not on network %s", ctr.Names, networkName)
+		}
+	}
+	if nodes != 2 {
+		t.Errorf("expected 2 nodes, found %d", nodes)
+	}
+}
@@ -3,8 +3,17 @@ package kubernetes
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/kind/pkg/apis/config/defaults"
+	"sigs.k8s.io/kind/pkg/apis/config/v1alpha4"
+	"sigs.k8s.io/kind/pkg/log"
 )
 
 func TestNormalizeRegistryName(t *testing.T) {
@@ -115,7 +124,7 @@ func TestCreateCertsDirStructure(t *testing.T) {
 		"ghcr.io":              "http://zot:5000",
 	}
 
-	err = createCertsDirStructure(tmpDir, caCertPath, mirrors, "zot")
+	err = createCertsDirStructure(tmpDir, caCertPath, mirrors, "zot", "")
 	if err != nil {
 		t.Fatalf("createCertsDirStructure failed: %v", err)
 	}
@@ -180,6 +189,53 @@ func TestCreateCertsDirStructure(t *testing.T) {
 	}
 }
 
+func TestCreateCertsDirStructure_StrictTLS(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	caCertPath := filepath.Join(tmpDir, "ca.crt")
+	if err := os.WriteFile(caCertPath, []byte("-----BEGIN CERTIFICATE-----\ntest\n-----END CERTIFICATE-----"), 0644); err != nil {
+		t.Fatalf("failed to create fake CA cert: %v", err)
+	}
+
+	mirrors := map[string]string{
+		"ghcr.io": "http://zot:5000",
+	}
+	tlsHost := "registry.dev.test:8443"
+
+	if err := createCertsDirStructure(tmpDir, caCertPath, mirrors, "zot", tlsHost); err != nil {
+		t.Fatalf("createCertsDirStructure failed: %v", err)
+	}
+
+	for _, host := range []string{"zot:5000", "localhost:5000", tlsHost, "ghcr.io"} {
+		t.Run(host, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join(tmpDir, "certs.d", host, "hosts.toml"))
+			if err != nil {
+				t.Fatalf("failed to read hosts.toml: %v", err)
+			}
+			contentStr := string(content)
+
+			if strings.Contains(contentStr, "skip_verify") {
+				t.Errorf("strict mode should not skip TLS verification, got:\n%s", contentStr)
+			}
+			if !strings.Contains(contentStr, `[host."https://registry.dev.test:8443"]`) {
+				t.Errorf("hosts.toml should use the TLS registry route, got:\n%s", contentStr)
+			}
+			if !strings.Contains(contentStr, `ca = "/etc/containerd/certs.d/`+host+`/ca.crt"`) {
+				t.Errorf("hosts.toml should reference the copied CA, got:\n%s", contentStr)
+			}
+			if _, err := os.Stat(filepath.Join(tmpDir, "certs.d", host, "ca.crt")); err != nil {
+				t.Errorf("CA cert was not copied: %v", err)
+			}
+		})
+	}
+
+	t.Run("requires CA", func(t *testing.T) {
+		if err := createCertsDirStructure(tmpDir, "", mirrors, "zot", tlsHost); err == nil {
+			t.Error("expected error without a CA certificate")
+		}
+	})
+}
+
 func TestCreateCertsDirStructure_NoCACert(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "kind-certs-noca-test-*")
 	if err != nil {
@@ -192,7 +248,7 @@ func TestCreateCertsDirStructure_NoCACert(t *testing.T) {
 	}
 
 	// Pass empty CA cert path and no zot hostname
-	err = createCertsDirStructure(tmpDir, "", mirrors, "")
+	err = createCertsDirStructure(tmpDir, "", mirrors, "", "")
 	if err != nil {
 		t.Fatalf("createCertsDirStructure failed: %v", err)
 	}
@@ -231,7 +287,7 @@ func TestCreateCertsDirStructure_CleansExisting(t *testing.T) {
 		"ghcr.io": "http://zot:5000",
 	}
 
-	err = createCertsDirStructure(tmpDir, "", mirrors, "")
+	err = createCertsDirStructure(tmpDir, "", mirrors, "", "")
 	if err != nil {
 		t.Fatalf("createCertsDirStructure failed: %v", err)
 	}
@@ -253,8 +309,8 @@ func TestKindConstants(t *testing.T) {
 		t.Errorf("expected KindClusterName 'kinder', got '%s'", KindClusterName)
 	}
 
-	if KindNodeImage != "kindest/node:v1.32.2" {
-		t.Errorf("expected KindNodeImage 'kindest/node:v1.32.2', got '%s'", KindNodeImage)
+	if !strings.HasPrefix(KindNodeImage, "kindest/node:v1.35.0@sha256:") {
+		t.Errorf("expected KindNodeImage pinned to kindest/node:v1.35.0 by digest, got '%s'", KindNodeImage)
 	}
 }
 
@@ -400,3 +456,480 @@ func TestBuildKindConfig_NoMirrors(t *testing.T) {
 		t.Error("expected at least CA cert mount")
 	}
 }
+
+func TestBuildKindConfig_CNI(t *testing.T) {
+	tests := []struct {
+		cni         string
+		wantDisable bool
+		wantErr     bool
+	}{
+		{"", false, false},
+		{CNIKindnet, false, false},
+		{CNICalico, true, false},
+		{CNICilium, true, false},
+		{CNINone, true, false},
+		{"flannel", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cni, func(t *testing.T) {
+			kindCfg, err := buildKindConfig(KindConfig{ClusterName: "test-cluster", CNI: tt.cni})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildKindConfig error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if kindCfg.Networking.DisableDefaultCNI != tt.wantDisable {
+				t.Errorf("DisableDefaultCNI = %v, want %v", kindCfg.Networking.DisableDefaultCNI, tt.wantDisable)
+			}
+		})
+	}
+}
+
+func TestBuildKindConfig_CustomCNI(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          KindConfig
+		wantErr      bool
+		wantWaitsCNI bool
+	}{
+		{"disable", KindConfig{DisableDefaultCNI: true}, false, true},
+		{"manifest", KindConfig{CNIManifestURL: "https://example.com/cni.yaml"}, false, false},
+		{"manifest with kindnet", KindConfig{CNI: CNIKindnet, CNIManifestURL: "https://example.com/cni.yaml"}, false, false},
+		{"manifest with calico", KindConfig{CNI: CNICalico, CNIManifestURL: "https://example.com/cni.yaml"}, true, false},
+		{"manifest not http", KindConfig{CNIManifestURL: "file:///tmp/cni.yaml"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.ClusterName = "test-cluster"
+			kindCfg, err := buildKindConfig(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildKindConfig error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !kindCfg.Networking.DisableDefaultCNI {
+				t.Error("expected default CNI to be disabled")
+			}
+			if WaitsForCNI(tt.cfg) != tt.wantWaitsCNI {
+				t.Errorf("WaitsForCNI = %v, want %v", WaitsForCNI(tt.cfg), tt.wantWaitsCNI)
+			}
+		})
+	}
+}
+
+func TestCNIManifestURL(t *testing.T) {
+	if cniManifestURL(CNICalico) != CalicoManifestURL {
+		t.Errorf("expected Calico manifest URL for %q", CNICalico)
+	}
+	if cniManifestURL(CNICilium) != CiliumManifestURL {
+		t.Errorf("expected Cilium manifest URL for %q", CNICilium)
+	}
+	for _, cni := range []string{"", CNIKindnet, CNINone} {
+		if url := cniManifestURL(cni); url != "" {
+			t.Errorf("expected no manifest for %q, got %q", cni, url)
+		}
+	}
+}
+
+func TestBuildKindConfig_Networking(t *testing.T) {
+	cfg := KindConfig{
+		ClusterName:   "test-cluster",
+		PodSubnet:     "10.100.0.0/16",
+		ServiceSubnet: "10.200.0.0/16",
+		NetworkCIDR:   "172.28.28.0/24",
+		CNI:           CNICalico,
+	}
+
+	kindCfg, err := buildKindConfig(cfg)
+	if err != nil {
+		t.Fatalf("buildKindConfig failed: %v", err)
+	}
+
+	got, err := yaml.Marshal(kindCfg.Networking)
+	if err != nil {
+		t.Fatalf("failed to marshal networking: %v", err)
+	}
+
+	goldenPath := filepath.Join("testdata", "networking.golden.yaml")
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("networking section mismatch\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuildKindConfig_DualStack(t *testing.T) {
+	kindCfg, err := buildKindConfig(KindConfig{ClusterName: "test-cluster", IPv6: true})
+	if err != nil {
+		t.Fatalf("buildKindConfig failed: %v", err)
+	}
+	if kindCfg.Networking.IPFamily != v1alpha4.DualStackFamily {
+		t.Errorf("expected dual-stack IP family, got %q", kindCfg.Networking.IPFamily)
+	}
+
+	kindCfg, err = buildKindConfig(KindConfig{ClusterName: "test-cluster"})
+	if err != nil {
+		t.Fatalf("buildKindConfig failed: %v", err)
+	}
+	if kindCfg.Networking.IPFamily != "" {
+		t.Errorf("expected Kind's default IP family, got %q", kindCfg.Networking.IPFamily)
+	}
+}
+
+func TestBuildKindConfig_WorkerLabelsAndTaints(t *testing.T) {
+	cfg := KindConfig{
+		ClusterName:  "test-cluster",
+		WorkerNodes:  2,
+		WorkerLabels: map[string]string{"tier": "batch"},
+		WorkerTaints: []string{"dedicated=batch:NoSchedule", "example.com/gpu:NoExecute"},
+	}
+
+	kindCfg, err := buildKindConfig(cfg)
+	if err != nil {
+		t.Fatalf("buildKindConfig failed: %v", err)
+	}
+
+	if len(kindCfg.Nodes[0].Labels) != 0 || len(kindCfg.Nodes[0].KubeadmConfigPatches) != 0 {
+		t.Error("expected control-plane to have no worker labels or taints")
+	}
+
+	want := `kind: JoinConfiguration
+nodeRegistration:
+  taints:
+  - effect: NoSchedule
+    key: dedicated
+    value: batch
+  - effect: NoExecute
+    key: example.com/gpu
+`
+	for _, worker := range kindCfg.Nodes[1:] {
+		if worker.Labels["tier"] != "batch" {
+			t.Errorf("expected worker label tier=batch, got %v", worker.Labels)
+		}
+		if len(worker.KubeadmConfigPatches) != 1 || worker.KubeadmConfigPatches[0] != want {
+			t.Errorf("unexpected worker patches %q, want %q", worker.KubeadmConfigPatches, want)
+		}
+	}
+}
+
+func TestNodeImageForVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		wantImage  string
+		wantPinned bool
+		wantErr    bool
+	}{
+		{"v1.35", defaults.Image, true, false},
+		{"1.35.0", defaults.Image, true, false},
+		{"v1.30", "kindest/node:v1.30", false, false},
+		{"1.20.7", "kindest/node:v1.20.7", false, false},
+		{"latest", "", false, true},
+		{"v1", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			image, pinned, err := NodeImageForVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NodeImageForVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			}
+			if image != tt.wantImage || pinned != tt.wantPinned {
+				t.Errorf("NodeImageForVersion(%q) = %q, %v; want %q, %v", tt.version, image, pinned, tt.wantImage, tt.wantPinned)
+			}
+		})
+	}
+
+	if got := strings.Join(PinnedK8sVersions(), ","); got != "v1.35,v1.35.0" {
+		t.Errorf("PinnedK8sVersions() = %s", got)
+	}
+}
+
+func TestCompareK8sVersions(t *testing.T) {
+	versions := []string{"v1.35", "v1.9", "v1.34.2", "v1.34", "v1.35.0"}
+	slices.SortFunc(versions, compareK8sVersions)
+	if got := strings.Join(versions, ","); got != "v1.9,v1.34,v1.34.2,v1.35,v1.35.0" {
+		t.Errorf("unexpected order %s", got)
+	}
+}
+
+func TestParsePortMapping(t *testing.T) {
+	tests := []struct {
+		mapping string
+		want    PortMapping
+		wantErr bool
+	}{
+		{"30080:8080/tcp", PortMapping{ContainerPort: 30080, HostPort: 8080, Protocol: "TCP"}, false},
+		{"30053:5353/udp", PortMapping{ContainerPort: 30053, HostPort: 5353, Protocol: "UDP"}, false},
+		{"30080:8080", PortMapping{ContainerPort: 30080, HostPort: 8080, Protocol: "TCP"}, false},
+		{"30080", PortMapping{}, true},
+		{"30080:0", PortMapping{}, true},
+		{"70000:8080", PortMapping{}, true},
+		{"30080:8080/icmp", PortMapping{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mapping, func(t *testing.T) {
+			got, err := ParsePortMapping(tt.mapping)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePortMapping(%q) error = %v, wantErr %v", tt.mapping, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePortMapping(%q) = %+v, want %+v", tt.mapping, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildKindConfig_PortMappings(t *testing.T) {
+	kindCfg, err := buildKindConfig(KindConfig{
+		ClusterName:       "test-cluster",
+		WorkerNodes:       1,
+		ExtraPortMappings: []PortMapping{{ContainerPort: 30080, HostPort: 8080}},
+	})
+	if err != nil {
+		t.Fatalf("buildKindConfig failed: %v", err)
+	}
+
+	mappings := kindCfg.Nodes[0].ExtraPortMappings
+	if len(mappings) != 1 || mappings[0].ContainerPort != 30080 || mappings[0].HostPort != 8080 || mappings[0].Protocol != "TCP" {
+		t.Errorf("unexpected control-plane port mappings %+v", mappings)
+	}
+	if len(kindCfg.Nodes[1].ExtraPortMappings) != 0 {
+		t.Error("expected workers to have no port mappings")
+	}
+}
+
+func TestLoadKubeadmPatches(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	valid := "kind: ClusterConfiguration\napiServer:\n  extraArgs:\n    v: \"4\"\n"
+	patches, err := LoadKubeadmPatches([]string{write("valid.yaml", valid)})
+	if err != nil {
+		t.Fatalf("LoadKubeadmPatches failed: %v", err)
+	}
+	if len(patches) != 1 || patches[0] != valid {
+		t.Errorf("unexpected patches %q", patches)
+	}
+
+	kindCfg, err := buildKindConfig(KindConfig{ClusterName: "test-cluster", KubeadmPatches: patches})
+	if err != nil {
+		t.Fatalf("buildKindConfig failed: %v", err)
+	}
+	if len(kindCfg.KubeadmConfigPatches) != 1 || kindCfg.KubeadmConfigPatches[0] != valid {
+		t.Errorf("expected patch in cluster config, got %q", kindCfg.KubeadmConfigPatches)
+	}
+
+	for name, content := range map[string]string{
+		"invalid.yaml": "apiServer: [",
+		"nokind.yaml":  "apiServer:\n  extraArgs: {}\n",
+	} {
+		if _, err := LoadKubeadmPatches([]string{write(name, content)}); err == nil {
+			t.Errorf("expected %s to be rejected", name)
+		}
+	}
+	if _, err := LoadKubeadmPatches([]string{filepath.Join(dir, "missing.yaml")}); err == nil {
+		t.Error("expected missing file to be rejected")
+	}
+}
+
+func TestParseTaint(t *testing.T) {
+	tests := []struct {
+		taint   string
+		wantErr bool
+	}{
+		{"dedicated=batch:NoSchedule", false},
+		{"dedicated:PreferNoSchedule", false},
+		{"example.com/gpu=true:NoExecute", false},
+		{"dedicated=batch", true},
+		{"dedicated=batch:Sometimes", true},
+		{"=batch:NoSchedule", true},
+		{"dedicated=bad value:NoSchedule", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taint, func(t *testing.T) {
+			_, _, _, err := ParseTaint(tt.taint)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTaint(%q) error = %v, wantErr %v", tt.taint, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSubnets(t *testing.T) {
+	tests := []struct {
+		name      string
+		pod       string
+		service   string
+		network   string
+		dualStack bool
+		wantErr   bool
+	}{
+		{"all empty", "", "", "", false, false},
+		{"distinct ranges", "10.244.0.0/16", "10.96.0.0/12", "172.28.28.0/24", false, false},
+		{"invalid pod subnet", "10.244.0.0/33", "", "", false, true},
+		{"invalid service subnet", "", "not-a-cidr", "", false, true},
+		{"pod overlaps service", "10.0.0.0/8", "10.96.0.0/12", "", false, true},
+		{"service overlaps network", "", "172.28.0.0/16", "172.28.28.0/24", false, true},
+		{"pod overlaps network", "172.28.28.0/25", "", "172.28.28.0/24", false, true},
+		{"dual-stack", "10.244.0.0/16,fd00:10:244::/56", "10.96.0.0/16,fd00:10:96::/112", "172.28.28.0/24", true, false},
+		{"dual-stack defaults", "", "", "", true, false},
+		{"dual-stack single CIDR", "10.244.0.0/16", "", "", true, true},
+		{"dual-stack two IPv4 CIDRs", "10.244.0.0/16,10.245.0.0/16", "", "", true, true},
+		{"dual-stack two IPv6 CIDRs", "", "fd00:10:96::/112,fd00:10:97::/112", "", true, true},
+		{"dual-stack overlap", "10.244.0.0/16,fd00:10::/32", "10.96.0.0/16,fd00:10:96::/112", "", true, true},
+		{"two CIDRs without dual-stack", "10.244.0.0/16,fd00:10:244::/56", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSubnets(tt.pod, tt.service, tt.network, tt.dualStack)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSubnets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNodesToConnect(t *testing.T) {
+	node := func(id, cluster string, networks ...string) container.Summary {
+		settings := &container.NetworkSettingsSummary{Networks: map[string]*network.EndpointSettings{}}
+		for _, n := range networks {
+			settings.Networks[n] = &network.EndpointSettings{}
+		}
+		return container.Summary{
+			ID:              id,
+			Labels:          map[string]string{KindClusterLabel: cluster},
+			NetworkSettings: settings,
+		}
+	}
+
+	containers := []container.Summary{
+		node("control-plane", "kinder", "kind"),
+		node("worker", "kinder", "kind", "kinder"),
+		node("other-cluster", "other", "kind"),
+		{ID: "unrelated"},
+	}
+
+	var ids []string
+	for _, ctr := range nodesToConnect(containers, "kinder", "kinder") {
+		ids = append(ids, ctr.ID)
+	}
+	if strings.Join(ids, ",") != "control-plane" {
+		t.Errorf("expected only control-plane to need connecting, got %v", ids)
+	}
+}
+
+func TestHasKinderMarker(t *testing.T) {
+	node := func(cluster string, mounts ...string) container.Summary {
+		ctr := container.Summary{Labels: map[string]string{KindClusterLabel: cluster}}
+		for _, m := range mounts {
+			ctr.Mounts = append(ctr.Mounts, container.MountPoint{Destination: m})
+		}
+		return ctr
+	}
+
+	containers := []container.Summary{
+		node("kinder", "/lib/modules", KinderCAContainerPath),
+		node("plain", "/lib/modules"),
+		node("other", KinderCAContainerPath),
+		{Mounts: []container.MountPoint{{Destination: KinderCAContainerPath}}},
+	}
+
+	tests := []struct {
+		cluster  string
+		expected bool
+	}{
+		{"kinder", true},
+		{"plain", false},
+		{"other", true},
+		{"missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cluster, func(t *testing.T) {
+			if got := hasKinderMarker(containers, tt.cluster); got != tt.expected {
+				t.Errorf("hasKinderMarker(%q) = %v, want %v", tt.cluster, got, tt.expected)
+			}
+		})
+	}
+}
+
+// recordingLogger is a Kind logger used to check which logger is chosen
+type recordingLogger struct{ nullLogger }
+
+func TestKindLogger(t *testing.T) {
+	custom := recordingLogger{}
+
+	if _, ok := kindLogger(KindConfig{Logger: custom}).(nullLogger); !ok {
+		t.Error("expected no output without Verbose")
+	}
+	if got := kindLogger(KindConfig{Verbose: true, Logger: custom}); got != log.Logger(custom) {
+		t.Errorf("expected the configured logger, got %T", got)
+	}
+	if got := kindLogger(KindConfig{Verbose: true}); got == nil {
+		t.Error("expected Kind's default logger")
+	}
+}
+
+func TestReadyWait(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  KindConfig
+		want time.Duration
+	}{
+		{"default CNI", KindConfig{WaitForReady: DefaultKindWaitTimeout}, DefaultKindWaitTimeout},
+		{"zero means no wait", KindConfig{}, 0},
+		{"custom", KindConfig{WaitForReady: 90 * time.Second}, 90 * time.Second},
+		{"CNI disabled", KindConfig{WaitForReady: time.Minute, DisableDefaultCNI: true}, 0},
+		{"calico", KindConfig{WaitForReady: time.Minute, CNI: CNICalico}, 0},
+		{"CNI manifest", KindConfig{WaitForReady: time.Minute, CNIManifestURL: "https://example.com/cni.yaml"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReadyWait(tt.cfg); got != tt.want {
+				t.Errorf("ReadyWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameNodeImage(t *testing.T) {
+	const digest = "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"kindest/node:v1.32.2", "kindest/node:v1.32.2", true},
+		{"docker.io/kindest/node:v1.32.2", "kindest/node:v1.32.2", true},
+		{"kindest/node:v1.32.2", "kindest/node:v1.35.0", false},
+		{"kindest/node:v1.35.0" + digest, "kindest/node:v1.35.0" + digest, true},
+		{"kindest/node:v1.35.0" + digest, "kindest/node:v1.35.0@sha256:fedcba", false},
+		{"kindest/node:v1.35.0" + digest, "kindest/node:v1.35.0", true},
+		{"kindest/node:v1.32.2", "kindest/node:v1.35.0" + digest, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := SameNodeImage(tt.a, tt.b); got != tt.want {
+				t.Errorf("SameNodeImage(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
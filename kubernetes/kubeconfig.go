@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// MinifyKubeconfig removes all clusters, users and contexts not referenced by
+// the given context. If contextName is empty, the current-context is used.
+func MinifyKubeconfig(kubeconfig, contextName string) (string, error) {
+	cfg, err := parseKubeconfig(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+
+	if contextName == "" {
+		contextName = cfg.CurrentContext
+	}
+	if contextName == "" {
+		return "", fmt.Errorf("kubeconfig has no current-context and no context was specified")
+	}
+	if _, ok := cfg.Contexts[contextName]; !ok {
+		return "", fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+
+	cfg.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(cfg); err != nil {
+		return "", fmt.Errorf("failed to minify kubeconfig: %w", err)
+	}
+
+	return marshalKubeconfig(cfg)
+}
+
+// FlattenKubeconfig replaces certificate and key file references with inline
+// data so the kubeconfig can be used on another machine. The kubeconfig has
+// no file of its own, so relative paths are resolved against the working
+// directory.
+func FlattenKubeconfig(kubeconfig string) (string, error) {
+	cfg, err := parseKubeconfig(kubeconfig)
+	if err != nil {
+		return "", err
+	}
+
+	if err := clientcmdapi.FlattenConfig(cfg); err != nil {
+		return "", fmt.Errorf("failed to inline kubeconfig files: %w", err)
+	}
+
+	return marshalKubeconfig(cfg)
+}
+
+// parseKubeconfig decodes a kubeconfig
+func parseKubeconfig(kubeconfig string) (*clientcmdapi.Config, error) {
+	cfg, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	return cfg, nil
+}
+
+// marshalKubeconfig encodes a kubeconfig back to YAML
+func marshalKubeconfig(cfg *clientcmdapi.Config) (string, error) {
+	out, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal kubeconfig: %w", err)
+	}
+	return string(out), nil
+}
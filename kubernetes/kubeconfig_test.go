@@ -0,0 +1,182 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: kind-kinder
+clusters:
+- name: kind-kinder
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority-data: Y2E=
+- name: other
+  cluster:
+    server: https://other.example:6443
+users:
+- name: kind-kinder
+  user:
+    client-certificate-data: Y2VydA==
+    client-key-data: a2V5
+- name: other
+  user:
+    token: secret
+contexts:
+- name: kind-kinder
+  context:
+    cluster: kind-kinder
+    user: kind-kinder
+- name: other
+  context:
+    cluster: other
+    user: other
+`
+
+func namesIn(t *testing.T, kubeconfig, list string) []string {
+	t.Helper()
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(kubeconfig), &doc); err != nil {
+		t.Fatalf("failed to parse kubeconfig: %v", err)
+	}
+	var names []string
+	entries, _ := doc[list].([]interface{})
+	for _, e := range entries {
+		names = append(names, e.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestMinifyKubeconfig(t *testing.T) {
+	t.Run("current context", func(t *testing.T) {
+		out, err := MinifyKubeconfig(testKubeconfig, "")
+		if err != nil {
+			t.Fatalf("MinifyKubeconfig failed: %v", err)
+		}
+		for _, list := range []string{"clusters", "users", "contexts"} {
+			names := namesIn(t, out, list)
+			if len(names) != 1 || names[0] != "kind-kinder" {
+				t.Errorf("expected only kind-kinder in %s, got %v", list, names)
+			}
+		}
+		if strings.Contains(out, "secret") {
+			t.Error("minified kubeconfig should not contain unrelated user credentials")
+		}
+	})
+
+	t.Run("explicit context", func(t *testing.T) {
+		out, err := MinifyKubeconfig(testKubeconfig, "other")
+		if err != nil {
+			t.Fatalf("MinifyKubeconfig failed: %v", err)
+		}
+		if names := namesIn(t, out, "clusters"); len(names) != 1 || names[0] != "other" {
+			t.Errorf("expected only other cluster, got %v", names)
+		}
+		if !strings.Contains(out, "current-context: other") {
+			t.Error("expected current-context to be set to the selected context")
+		}
+	})
+
+	t.Run("unknown context", func(t *testing.T) {
+		if _, err := MinifyKubeconfig(testKubeconfig, "missing"); err == nil {
+			t.Error("expected error for unknown context")
+		}
+	})
+}
+
+func TestFlattenKubeconfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	caPath := filepath.Join(tmpDir, "ca.crt")
+	if err := os.WriteFile(caPath, []byte("ca-bytes"), 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority: ` + caPath + `
+`
+
+	out, err := FlattenKubeconfig(kubeconfig)
+	if err != nil {
+		t.Fatalf("FlattenKubeconfig failed: %v", err)
+	}
+
+	if strings.Contains(out, "certificate-authority: ") {
+		t.Error("expected file reference to be removed")
+	}
+	expected := "certificate-authority-data: " + base64.StdEncoding.EncodeToString([]byte("ca-bytes"))
+	if !strings.Contains(out, expected) {
+		t.Errorf("expected inlined CA data %q, got:\n%s", expected, out)
+	}
+
+	// Already-flat kubeconfigs pass through unchanged
+	if _, err := FlattenKubeconfig(testKubeconfig); err != nil {
+		t.Errorf("FlattenKubeconfig on inline data failed: %v", err)
+	}
+}
+
+func TestFlattenKubeconfig_RelativePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "certs"), 0755); err != nil {
+		t.Fatalf("failed to create certs dir: %v", err)
+	}
+	for name, content := range map[string]string{"ca.crt": "ca-bytes", "client.crt": "cert-bytes", "client.key": "key-bytes"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, "certs", name), []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	t.Chdir(tmpDir)
+
+	kubeconfig := `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+    certificate-authority: certs/ca.crt
+users:
+- name: test
+  user:
+    client-certificate: ./certs/client.crt
+    client-key: certs/client.key
+`
+
+	out, err := FlattenKubeconfig(kubeconfig)
+	if err != nil {
+		t.Fatalf("FlattenKubeconfig failed: %v", err)
+	}
+	for _, want := range []string{
+		"certificate-authority-data: " + base64.StdEncoding.EncodeToString([]byte("ca-bytes")),
+		"client-certificate-data: " + base64.StdEncoding.EncodeToString([]byte("cert-bytes")),
+		"client-key-data: " + base64.StdEncoding.EncodeToString([]byte("key-bytes")),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in flattened kubeconfig, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "certs/") {
+		t.Errorf("expected relative file references to be removed, got:\n%s", out)
+	}
+}
+
+func TestFlattenKubeconfig_MissingFile(t *testing.T) {
+	kubeconfig := `clusters:
+- name: test
+  cluster:
+    certificate-authority: /nonexistent/ca.crt
+`
+	if _, err := FlattenKubeconfig(kubeconfig); err == nil {
+		t.Error("expected error for missing certificate file")
+	}
+}
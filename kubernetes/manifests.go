@@ -0,0 +1,252 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Structured types for the manifests kinder generates. They cover only the
+// fields kinder sets and are marshaled with sigs.k8s.io/yaml, so values are
+// always quoted and indented correctly whatever they contain.
+
+// objectMeta is the metadata common to all Kubernetes resources
+type objectMeta struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Finalizers  []string          `json:"finalizers,omitempty"`
+}
+
+// secretResource is a core/v1 Secret. Data values are base64-encoded when marshaled.
+type secretResource struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   objectMeta        `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data,omitempty"`
+}
+
+// kustomizationResource is a kustomize Kustomization listing resource files
+type kustomizationResource struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resources  []string `json:"resources"`
+}
+
+// clusterIssuerResource is a cert-manager ClusterIssuer using an ACME server
+type clusterIssuerResource struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   objectMeta        `json:"metadata"`
+	Spec       clusterIssuerSpec `json:"spec"`
+}
+
+type clusterIssuerSpec struct {
+	ACME *acmeIssuer `json:"acme,omitempty"`
+	CA   *caIssuer   `json:"ca,omitempty"`
+}
+
+// caIssuer signs certificates with the key pair in SecretName
+type caIssuer struct {
+	SecretName string `json:"secretName"`
+}
+
+type acmeIssuer struct {
+	// Server is the ACME directory URL
+	Server string `json:"server"`
+	// Email is used for ACME registration
+	Email string `json:"email"`
+	// PrivateKeySecretRef names the Secret storing the ACME account key
+	PrivateKeySecretRef secretReference `json:"privateKeySecretRef"`
+	// CABundle is the base64-encoded CA used to trust the ACME server
+	CABundle string       `json:"caBundle"`
+	Solvers  []acmeSolver `json:"solvers"`
+}
+
+type secretReference struct {
+	Name string `json:"name"`
+}
+
+type secretKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+type acmeSolver struct {
+	HTTP01 *http01Solver `json:"http01,omitempty"`
+	DNS01  *dns01Solver  `json:"dns01,omitempty"`
+}
+
+type http01Solver struct {
+	Ingress http01Ingress `json:"ingress"`
+}
+
+type http01Ingress struct {
+	IngressClassName string `json:"ingressClassName"`
+}
+
+type dns01Solver struct {
+	Cloudflare *cloudflareSolver `json:"cloudflare,omitempty"`
+	Route53    *route53Solver    `json:"route53,omitempty"`
+}
+
+type cloudflareSolver struct {
+	APITokenSecretRef secretKeySelector `json:"apiTokenSecretRef"`
+}
+
+type route53Solver struct {
+	Region                   string            `json:"region"`
+	AccessKeyID              string            `json:"accessKeyID"`
+	SecretAccessKeySecretRef secretKeySelector `json:"secretAccessKeySecretRef"`
+}
+
+// certificateResource is a cert-manager Certificate
+type certificateResource struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Metadata   objectMeta      `json:"metadata"`
+	Spec       certificateSpec `json:"spec"`
+}
+
+type certificateSpec struct {
+	SecretName  string          `json:"secretName"`
+	Duration    string          `json:"duration,omitempty"`
+	RenewBefore string          `json:"renewBefore,omitempty"`
+	CommonName  string          `json:"commonName,omitempty"`
+	DNSNames    []string        `json:"dnsNames"`
+	IssuerRef   issuerReference `json:"issuerRef"`
+}
+
+type issuerReference struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Group string `json:"group"`
+}
+
+// applicationResource is an ArgoCD Application
+type applicationResource struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Metadata   objectMeta      `json:"metadata"`
+	Spec       applicationSpec `json:"spec"`
+}
+
+type applicationSpec struct {
+	Project     string                 `json:"project"`
+	Source      applicationSource      `json:"source"`
+	Destination applicationDestination `json:"destination"`
+	SyncPolicy  *syncPolicy            `json:"syncPolicy,omitempty"`
+}
+
+type applicationSource struct {
+	RepoURL        string `json:"repoURL"`
+	TargetRevision string `json:"targetRevision"`
+	Path           string `json:"path,omitempty"`
+}
+
+type applicationDestination struct {
+	Server    string `json:"server"`
+	Namespace string `json:"namespace"`
+}
+
+// appProjectResource is an ArgoCD AppProject restricting where Applications
+// may pull from and deploy to
+type appProjectResource struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   objectMeta     `json:"metadata"`
+	Spec       appProjectSpec `json:"spec"`
+}
+
+type appProjectSpec struct {
+	Description              string                   `json:"description,omitempty"`
+	SourceRepos              []string                 `json:"sourceRepos"`
+	Destinations             []applicationDestination `json:"destinations"`
+	ClusterResourceWhitelist []groupKind              `json:"clusterResourceWhitelist,omitempty"`
+}
+
+type groupKind struct {
+	Group string `json:"group"`
+	Kind  string `json:"kind"`
+}
+
+type syncPolicy struct {
+	Automated   *automatedSync `json:"automated,omitempty"`
+	SyncOptions []string       `json:"syncOptions,omitempty"`
+}
+
+type automatedSync struct {
+	Prune    bool `json:"prune"`
+	SelfHeal bool `json:"selfHeal"`
+}
+
+// bundleResource is a trust-manager Bundle
+type bundleResource struct {
+	APIVersion string     `json:"apiVersion"`
+	Kind       string     `json:"kind"`
+	Metadata   objectMeta `json:"metadata"`
+	Spec       bundleSpec `json:"spec"`
+}
+
+type bundleSpec struct {
+	Sources []bundleSource `json:"sources"`
+	Target  bundleTarget   `json:"target"`
+}
+
+// bundleSource is one Bundle source; exactly one field is set
+type bundleSource struct {
+	ConfigMap     *sourceObjectKeySelector `json:"configMap,omitempty"`
+	Secret        *sourceObjectKeySelector `json:"secret,omitempty"`
+	UseDefaultCAs *bool                    `json:"useDefaultCAs,omitempty"`
+}
+
+type sourceObjectKeySelector struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// bundleTarget is where trust-manager writes the bundle. ConfigMap or
+// Secret is set, depending on the target kind.
+type bundleTarget struct {
+	ConfigMap         *targetKeySelector `json:"configMap,omitempty"`
+	Secret            *targetKeySelector `json:"secret,omitempty"`
+	AdditionalFormats *additionalFormats `json:"additionalFormats,omitempty"`
+	NamespaceSelector *labelSelector     `json:"namespaceSelector,omitempty"`
+}
+
+type targetKeySelector struct {
+	Key string `json:"key"`
+}
+
+type additionalFormats struct {
+	JKS    *targetKeySelector `json:"jks,omitempty"`
+	PKCS12 *targetKeySelector `json:"pkcs12,omitempty"`
+}
+
+// labelSelector is a meta/v1 LabelSelector
+type labelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty"`
+	MatchExpressions []labelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+type labelSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"`
+	Values   []string `json:"values"`
+}
+
+// marshalManifests encodes resources as YAML documents separated by "---"
+func marshalManifests(resources ...any) (string, error) {
+	docs := make([]string, 0, len(resources))
+	for _, r := range resources {
+		data, err := yaml.Marshal(r)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		docs = append(docs, string(data))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
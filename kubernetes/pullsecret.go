@@ -0,0 +1,140 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// ImagePullSecretName is the default name of the Zot image pull secret
+	ImagePullSecretName = "kinder-zot"
+)
+
+// ZotRegistryHosts are the registry addresses workloads use to pull from Zot:
+// the Docker network hostname and the localhost alias mapped on Kind nodes
+var ZotRegistryHosts = []string{"zot:5000", "localhost:5000"}
+
+// ImagePullSecretConfig holds configuration for creating Zot image pull secrets
+type ImagePullSecretConfig struct {
+	// ClusterName is the Kind cluster to create the secret in
+	ClusterName string
+	// Name is the secret name (default: kinder-zot)
+	Name string
+	// Namespaces are the namespaces to create the secret in
+	Namespaces []string
+	// AllNamespaces creates the secret in every namespace, ignoring Namespaces
+	AllNamespaces bool
+	// Username is the Zot username
+	Username string
+	// Password is the Zot password
+	Password string
+	// PatchServiceAccount adds the secret to each namespace's default ServiceAccount
+	PatchServiceAccount bool
+}
+
+// dockerConfigJSON is the content of a kubernetes.io/dockerconfigjson secret
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigAuth `json:"auths"`
+}
+
+type dockerConfigAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// GenerateDockerConfigJSON builds a .dockerconfigjson document granting the
+// credentials access to each registry host
+func GenerateDockerConfigJSON(hosts []string, username, password string) ([]byte, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+
+	cfg := dockerConfigJSON{Auths: make(map[string]dockerConfigAuth, len(hosts))}
+	for _, host := range hosts {
+		cfg.Auths[host] = dockerConfigAuth{Username: username, Password: password, Auth: auth}
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode docker config: %w", err)
+	}
+	return data, nil
+}
+
+// generateImagePullSecretYAML generates a dockerconfigjson Secret manifest
+func generateImagePullSecretYAML(name, namespace string, dockerConfig []byte) string {
+	return fmt.Sprintf(`apiVersion: v1
+kind: Secret
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    app.kubernetes.io/managed-by: kinder
+type: kubernetes.io/dockerconfigjson
+data:
+  .dockerconfigjson: %s
+`, name, namespace, base64.StdEncoding.EncodeToString(dockerConfig))
+}
+
+// serviceAccountPullSecretPatch returns a patch that sets the image pull secret
+// on a ServiceAccount
+func serviceAccountPullSecretPatch(name string) string {
+	return fmt.Sprintf(`{"imagePullSecrets":[{"name":%q}]}`, name)
+}
+
+// CreateImagePullSecrets creates the Zot image pull secret in the configured
+// namespaces and optionally patches their default ServiceAccounts. Returns the
+// namespaces the secret was created in.
+func CreateImagePullSecrets(ctx context.Context, cfg ImagePullSecretConfig) ([]string, error) {
+	if cfg.Name == "" {
+		cfg.Name = ImagePullSecretName
+	}
+	if cfg.Username == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("Zot credentials are not configured")
+	}
+
+	client, err := newKindRESTClient(cfg.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := cfg.Namespaces
+	if cfg.AllNamespaces {
+		namespaces, err = client.namespaces(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil, fmt.Errorf("no namespaces given")
+	}
+
+	dockerConfig, err := GenerateDockerConfigJSON(ZotRegistryHosts, cfg.Username, cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := createImagePullSecrets(ctx, client, cfg, namespaces, dockerConfig); err != nil {
+		return nil, err
+	}
+	return namespaces, nil
+}
+
+// createImagePullSecrets applies the pull secret to each namespace and, if
+// configured, sets it on the namespace's default ServiceAccount
+func createImagePullSecrets(ctx context.Context, client *restClient, cfg ImagePullSecretConfig, namespaces []string, dockerConfig []byte) error {
+	for _, ns := range namespaces {
+		manifest := generateImagePullSecretYAML(cfg.Name, ns, dockerConfig)
+		if err := client.apply(ctx, manifest); err != nil {
+			return fmt.Errorf("failed to create secret in namespace %s: %w", ns, err)
+		}
+
+		if cfg.PatchServiceAccount {
+			if err := client.patchIn(ctx, ns, "serviceaccount", "default", serviceAccountPullSecretPatch(cfg.Name)); err != nil {
+				return fmt.Errorf("failed to patch default ServiceAccount in namespace %s: %w", ns, err)
+			}
+		}
+	}
+	return nil
+}
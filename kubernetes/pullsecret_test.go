@@ -0,0 +1,75 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDockerConfigJSON(t *testing.T) {
+	data, err := GenerateDockerConfigJSON(ZotRegistryHosts, "admin", "s3cret")
+	if err != nil {
+		t.Fatalf("GenerateDockerConfigJSON failed: %v", err)
+	}
+
+	var cfg dockerConfigJSON
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("failed to parse docker config: %v", err)
+	}
+
+	expectedAuth := base64.StdEncoding.EncodeToString([]byte("admin:s3cret"))
+	for _, host := range ZotRegistryHosts {
+		auth, ok := cfg.Auths[host]
+		if !ok {
+			t.Errorf("expected credentials for %s", host)
+			continue
+		}
+		if auth.Username != "admin" || auth.Password != "s3cret" || auth.Auth != expectedAuth {
+			t.Errorf("unexpected credentials for %s: %+v", host, auth)
+		}
+	}
+}
+
+func TestGenerateImagePullSecretYAML(t *testing.T) {
+	yaml := generateImagePullSecretYAML("kinder-zot", "apps", []byte(`{"auths":{}}`))
+
+	expected := []string{
+		"kind: Secret",
+		"name: kinder-zot",
+		"namespace: apps",
+		"type: kubernetes.io/dockerconfigjson",
+		".dockerconfigjson: " + base64.StdEncoding.EncodeToString([]byte(`{"auths":{}}`)),
+	}
+	for _, want := range expected {
+		if !strings.Contains(yaml, want) {
+			t.Errorf("expected secret to contain %q:\n%s", want, yaml)
+		}
+	}
+
+	if patch := serviceAccountPullSecretPatch("kinder-zot"); patch != `{"imagePullSecrets":[{"name":"kinder-zot"}]}` {
+		t.Errorf("unexpected ServiceAccount patch: %s", patch)
+	}
+}
+
+func TestCreateImagePullSecrets_Requests(t *testing.T) {
+	server := newFakeAPIServer(t)
+	server.objects["/api/v1/namespaces/apps/serviceaccounts/default"] = `{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "default"}}`
+	c := server.client(t)
+
+	cfg := ImagePullSecretConfig{Name: "kinder-zot", PatchServiceAccount: true}
+	if err := createImagePullSecrets(t.Context(), c, cfg, []string{"apps"}, []byte(`{"auths":{}}`)); err != nil {
+		t.Fatalf("createImagePullSecrets failed: %v", err)
+	}
+
+	want := []string{
+		"PATCH /api/v1/namespaces/apps/secrets/kinder-zot?fieldManager=kinder&force=true&timeout=1m0s application/apply-patch+yaml",
+		"PATCH /api/v1/namespaces/apps/serviceaccounts/default?timeout=1m0s application/strategic-merge-patch+json",
+	}
+	if strings.Join(server.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected requests:\n%s\nwant:\n%s", strings.Join(server.requests, "\n"), strings.Join(want, "\n"))
+	}
+	if body := server.bodies["/api/v1/namespaces/apps/serviceaccounts/default"]; body != serviceAccountPullSecretPatch("kinder-zot") {
+		t.Errorf("unexpected ServiceAccount patch: %s", body)
+	}
+}
@@ -0,0 +1,639 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	_ "k8s.io/client-go/plugin/pkg/client/auth" // auth-provider plugins such as oidc
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// fieldManager identifies kinder as the owner of fields it applies
+const fieldManager = "kinder"
+
+// restPollInterval is how often the REST client polls while waiting
+const restPollInterval = 2 * time.Second
+
+var (
+	namespaceResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	nodeResource      = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+	crdResource       = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+)
+
+// restClient talks to the Kubernetes API server in-process with client-go's
+// dynamic client and server-side apply, so kinder does not need kubectl
+// installed. Authentication is whatever the kubeconfig context uses,
+// including exec credential plugins.
+type restClient struct {
+	namespace string
+	dynamic   dynamic.Interface
+	mapper    *restmapper.DeferredDiscoveryRESTMapper
+}
+
+// isNotFound reports whether err is an API "not found" error
+func isNotFound(err error) bool {
+	return apierrors.IsNotFound(err)
+}
+
+// kubeClientConfig returns the client config for a kubeconfig path and
+// context. Kind contexts ("kind-<cluster>") are read from Kind itself when no
+// path is given; otherwise $KUBECONFIG or ~/.kube/config is used.
+func kubeClientConfig(kubeconfigPath, kubeContext string) (clientcmd.ClientConfig, error) {
+	if kubeconfigPath == "" && strings.HasPrefix(kubeContext, "kind-") {
+		kubeconfig, err := GetKindKubeconfig(strings.TrimPrefix(kubeContext, "kind-"))
+		if err != nil {
+			return nil, err
+		}
+		raw, err := clientcmd.Load([]byte(kubeconfig))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+		}
+		return clientcmd.NewNonInteractiveClientConfig(*raw, kubeContext, &clientcmd.ConfigOverrides{}, nil), nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfigPath
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext}), nil
+}
+
+// newRESTClient creates a client for a kubeconfig context. Objects without a
+// namespace are placed in namespace, or the context's namespace if empty.
+func newRESTClient(clientConfig clientcmd.ClientConfig, namespace string) (*restClient, error) {
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	config.Timeout = 60 * time.Second
+	// Manifests such as ArgoCD's have dozens of objects to apply
+	config.QPS = 50
+	config.Burst = 100
+	config.WarningHandler = rest.NoWarnings{}
+
+	if namespace == "" {
+		namespace, _, err = clientConfig.Namespace()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+	}
+
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	disco, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	return &restClient{
+		namespace: namespace,
+		dynamic:   dyn,
+		mapper:    restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(disco)),
+	}, nil
+}
+
+// newKindRESTClient creates a client for a Kind cluster's context, read from
+// Kind itself
+func newKindRESTClient(clusterName string) (*restClient, error) {
+	clientConfig, err := kubeClientConfig("", KindContext(clusterName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %w", err)
+	}
+	return newRESTClient(clientConfig, "")
+}
+
+// resource returns the client for a kind using API discovery. Discovery is
+// refreshed on a miss, as CRDs applied earlier may have just been registered.
+func (c *restClient) resource(apiVersion, kind, namespace string) (dynamic.ResourceInterface, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid apiVersion %q: %w", apiVersion, err)
+	}
+	gk := schema.GroupKind{Group: gv.Group, Kind: kind}
+
+	mapping, err := c.mapper.RESTMapping(gk, gv.Version)
+	if meta.IsNoMatchError(err) {
+		c.mapper.Reset()
+		mapping, err = c.mapper.RESTMapping(gk, gv.Version)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no resource found for %s %s: %w", apiVersion, kind, err)
+	}
+
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.dynamic.Resource(mapping.Resource), nil
+	}
+	if namespace == "" {
+		namespace = c.namespace
+	}
+	return c.dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+// resourceNamed returns the client for a resource name such as "deployments",
+// as used on the kubectl command line, in namespace
+func (c *restClient) resourceNamed(resource, namespace string) (dynamic.ResourceInterface, error) {
+	gvr, err := c.mapper.ResourceFor(schema.GroupVersionResource{Resource: resource})
+	if err != nil {
+		return nil, fmt.Errorf("no resource found for %s: %w", resource, err)
+	}
+	return c.dynamic.Resource(gvr).Namespace(namespace), nil
+}
+
+// decodeObjects splits a multi-document YAML manifest into objects, expanding
+// List kinds and skipping empty documents
+func decodeObjects(manifest []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	dec := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(string(manifest)), 4096)
+	for {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: doc}
+
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", obj.GetKind(), err)
+			}
+			for i := range list.Items {
+				objects = append(objects, &list.Items[i])
+			}
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// fetchManifest downloads a manifest from a URL
+func fetchManifest(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: HTTP %d", url, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// applyObjects server-side applies each object in order
+func (c *restClient) applyObjects(ctx context.Context, objects []*unstructured.Unstructured) error {
+	for _, obj := range objects {
+		ri, err := c.resource(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace())
+		if err != nil {
+			return err
+		}
+		opts := metav1.ApplyOptions{FieldManager: fieldManager, Force: true}
+		if _, err := ri.Apply(ctx, obj.GetName(), obj, opts); err != nil {
+			return fmt.Errorf("failed to apply %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func (c *restClient) apply(ctx context.Context, manifest string) error {
+	objects, err := decodeObjects([]byte(manifest))
+	if err != nil {
+		return err
+	}
+	return c.applyObjects(ctx, objects)
+}
+
+func (c *restClient) applyURL(ctx context.Context, url string) error {
+	manifest, err := fetchManifest(ctx, url)
+	if err != nil {
+		return err
+	}
+	objects, err := decodeObjects(manifest)
+	if err != nil {
+		return err
+	}
+	return c.applyObjects(ctx, objects)
+}
+
+// patchKinds maps the kubectl resource names used with patch to their API types
+var patchKinds = map[string]struct{ apiVersion, kind string }{
+	"configmap":      {"v1", "ConfigMap"},
+	"deployment":     {"apps/v1", "Deployment"},
+	"serviceaccount": {"v1", "ServiceAccount"},
+}
+
+func (c *restClient) patch(ctx context.Context, kind, name, patch string) error {
+	return c.patchIn(ctx, "", kind, name, patch)
+}
+
+// patchIn applies a strategic merge patch to an object in namespace, or the
+// client's namespace if empty
+func (c *restClient) patchIn(ctx context.Context, namespace, kind, name, patch string) error {
+	t, ok := patchKinds[kind]
+	if !ok {
+		return fmt.Errorf("unsupported resource %q", kind)
+	}
+	ri, err := c.resource(t.apiVersion, t.kind, namespace)
+	if err != nil {
+		return err
+	}
+	_, err = ri.Patch(ctx, name, types.StrategicMergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// get fetches an object and converts it into a typed API object
+func get(ctx context.Context, ri dynamic.ResourceInterface, name string, into interface{}) error {
+	u, err := ri.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, into); err != nil {
+		return fmt.Errorf("failed to parse %s %s: %w", u.GetKind(), name, err)
+	}
+	return nil
+}
+
+// waitRollout waits for a Deployment's rollout to complete, using the same
+// conditions as "kubectl rollout status". Missing deployments are skipped.
+func (c *restClient) waitRollout(ctx context.Context, deployment string, timeout time.Duration) error {
+	ri, err := c.resource("apps/v1", "Deployment", "")
+	if err != nil {
+		return err
+	}
+
+	return c.poll(ctx, timeout, func() (bool, error) {
+		var d appsv1.Deployment
+		err := get(ctx, ri, deployment, &d)
+		if isNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		for _, cond := range d.Status.Conditions {
+			if cond.Type == appsv1.DeploymentProgressing && cond.Reason == "ProgressDeadlineExceeded" {
+				return false, fmt.Errorf("deployment %s exceeded its progress deadline", deployment)
+			}
+		}
+
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		return d.Status.ObservedGeneration >= d.Generation &&
+			d.Status.UpdatedReplicas == replicas &&
+			d.Status.Replicas == d.Status.UpdatedReplicas &&
+			d.Status.AvailableReplicas == d.Status.UpdatedReplicas, nil
+	})
+}
+
+// namespaces returns the names of all namespaces
+func (c *restClient) namespaces(ctx context.Context) ([]string, error) {
+	list, err := c.dynamic.Resource(namespaceResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		names = append(names, ns.GetName())
+	}
+	return names, nil
+}
+
+// waitNodesReady waits until every node reports the Ready condition, like
+// "kubectl wait --for=condition=Ready nodes --all". Nodes are watched rather
+// than polled; an expired watch starts over with a fresh list.
+func (c *restClient) waitNodesReady(ctx context.Context, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ri := c.dynamic.Resource(nodeResource)
+
+	for {
+		list, err := ri.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out after %s", timeout)
+			}
+			return err
+		}
+		ready := make(map[string]bool, len(list.Items))
+		for i := range list.Items {
+			ready[list.Items[i].GetName()] = nodeReady(&list.Items[i])
+		}
+		if allNodesReady(ready) {
+			return nil
+		}
+
+		w, err := ri.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("timed out after %s", timeout)
+			}
+			return err
+		}
+		done, err := watchNodesReady(ctx, w, ready)
+		w.Stop()
+		if done || err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s", timeout)
+		case <-time.After(restPollInterval):
+		}
+	}
+}
+
+// watchNodesReady updates ready from node events until every node is Ready.
+// It returns false without an error when the watch ends first.
+func watchNodesReady(ctx context.Context, w watch.Interface, ready map[string]bool) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			switch event.Type {
+			case watch.Error:
+				if err := apierrors.FromObject(event.Object); !apierrors.IsResourceExpired(err) && !apierrors.IsGone(err) {
+					return false, err
+				}
+				return false, nil
+			case watch.Deleted:
+				if node, ok := event.Object.(*unstructured.Unstructured); ok {
+					delete(ready, node.GetName())
+				}
+			case watch.Added, watch.Modified:
+				if node, ok := event.Object.(*unstructured.Unstructured); ok {
+					ready[node.GetName()] = nodeReady(node)
+				}
+			}
+			if allNodesReady(ready) {
+				return true, nil
+			}
+		}
+	}
+}
+
+// nodeReady reports whether a node's Ready condition is True
+func nodeReady(u *unstructured.Unstructured) bool {
+	var node corev1.Node
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &node); err != nil {
+		return false
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// allNodesReady reports whether there are nodes and all of them are Ready
+func allNodesReady(ready map[string]bool) bool {
+	for _, ok := range ready {
+		if !ok {
+			return false
+		}
+	}
+	return len(ready) > 0
+}
+
+func (c *restClient) hasCRD(ctx context.Context, name string) (bool, error) {
+	_, err := c.dynamic.Resource(crdResource).Get(ctx, name, metav1.GetOptions{})
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *restClient) deleteAll(ctx context.Context, apiVersion, kind string, timeout time.Duration) error {
+	ri, err := c.resource(apiVersion, kind, "")
+	if err != nil {
+		return err
+	}
+	if err := ri.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{}); err != nil && !isNotFound(err) {
+		return err
+	}
+
+	// Wait for finalizers to run
+	return c.poll(ctx, timeout, func() (bool, error) {
+		list, err := ri.List(ctx, metav1.ListOptions{})
+		if isNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return len(list.Items) == 0, nil
+	})
+}
+
+func (c *restClient) deleteURL(ctx context.Context, url string, timeout time.Duration) error {
+	manifest, err := fetchManifest(ctx, url)
+	if err != nil {
+		return err
+	}
+	objects, err := decodeObjects(manifest)
+	if err != nil {
+		return err
+	}
+
+	type deleted struct {
+		ri   dynamic.ResourceInterface
+		kind string
+		name string
+	}
+	var pending []deleted
+
+	// Delete in reverse order so CRDs outlive their custom resources
+	for i := len(objects) - 1; i >= 0; i-- {
+		obj := objects[i]
+		ri, err := c.resource(obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace())
+		if err != nil {
+			// The API for a CRD-backed kind disappears once the CRD is gone
+			continue
+		}
+		if err := ri.Delete(ctx, obj.GetName(), metav1.DeleteOptions{}); err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to delete %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		pending = append(pending, deleted{ri: ri, kind: obj.GetKind(), name: obj.GetName()})
+	}
+
+	// Wait for finalizers, like "kubectl delete --timeout"
+	err = c.poll(ctx, timeout, func() (bool, error) {
+		remaining := pending[:0]
+		for _, d := range pending {
+			_, err := d.ri.Get(ctx, d.name, metav1.GetOptions{})
+			if isNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			remaining = append(remaining, d)
+		}
+		pending = remaining
+		return len(pending) == 0, nil
+	})
+	if err != nil && len(pending) > 0 {
+		return fmt.Errorf("%w waiting for %s %s to be deleted", err, pending[0].kind, pending[0].name)
+	}
+	return err
+}
+
+func (c *restClient) deleteNamespace(ctx context.Context, name string, timeout time.Duration) error {
+	ri := c.dynamic.Resource(namespaceResource)
+	if err := ri.Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	return c.poll(ctx, timeout, func() (bool, error) {
+		_, err := ri.Get(ctx, name, metav1.GetOptions{})
+		if isNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// runPod creates a bare pod with a single container running command in image,
+// waits for it to be running and deletes it again. Errors carry the
+// container's waiting reason, such as ErrImagePull, when it has one.
+func (c *restClient) runPod(ctx context.Context, name, image string, command []string, timeout time.Duration) error {
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels:    map[string]string{"app": name},
+		},
+		Spec: corev1.PodSpec{
+			Containers:    []corev1.Container{{Name: "test", Image: image, Command: command}},
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	if err != nil {
+		return fmt.Errorf("failed to encode pod %s: %w", name, err)
+	}
+	if err := c.applyObjects(ctx, []*unstructured.Unstructured{{Object: u}}); err != nil {
+		return err
+	}
+
+	ri, err := c.resource("v1", "Pod", "")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Clean up even when ctx has been cancelled
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		_ = ri.Delete(cleanupCtx, name, metav1.DeleteOptions{})
+	}()
+
+	var last string
+	err = c.poll(ctx, timeout, func() (bool, error) {
+		var p corev1.Pod
+		if err := get(ctx, ri, name, &p); err != nil {
+			return false, err
+		}
+
+		last = "phase " + string(p.Status.Phase)
+		for _, cs := range p.Status.ContainerStatuses {
+			if w := cs.State.Waiting; w != nil && w.Reason != "" {
+				last += ", " + w.Reason
+				if w.Message != "" {
+					last += ": " + w.Message
+				}
+			}
+		}
+
+		switch p.Status.Phase {
+		case corev1.PodRunning, corev1.PodSucceeded:
+			return true, nil
+		case corev1.PodFailed:
+			return false, fmt.Errorf("pod %s failed (%s)", name, last)
+		}
+		return false, nil
+	})
+	if err != nil && last != "" && !strings.Contains(err.Error(), last) {
+		return fmt.Errorf("%w (%s)", err, last)
+	}
+	return err
+}
+
+func (c *restClient) applicationStatus(ctx context.Context, name string) (appStatus, error) {
+	ri, err := c.resource("argoproj.io/v1alpha1", "Application", "")
+	if err != nil {
+		return appStatus{}, err
+	}
+	app, err := ri.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return appStatus{}, err
+	}
+
+	health, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	sync, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	return appStatus{Health: health, Sync: sync}, nil
+}
+
+// poll calls done until it reports true, returns an error, or timeout passes
+func (c *restClient) poll(ctx context.Context, timeout time.Duration, done func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		ok, err := done()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s", timeout)
+		case <-time.After(restPollInterval):
+		}
+	}
+}
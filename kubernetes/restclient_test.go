@@ -0,0 +1,373 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// fakeAPIServer serves discovery for a few core and apps kinds and records
+// the other requests it receives. Watches stream the events in watches for
+// their path, one JSON event per line, and then end.
+type fakeAPIServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string]string
+	objects  map[string]string
+	watches  map[string]string
+}
+
+func newFakeAPIServer(t *testing.T) *fakeAPIServer {
+	t.Helper()
+	f := &fakeAPIServer{bodies: map[string]string{}, objects: map[string]string{}, watches: map[string]string{}}
+
+	discovery := map[string]string{
+		"/api":                       `{"kind":"APIVersions","versions":["v1"]}`,
+		"/apis":                      `{"kind":"APIGroupList","apiVersion":"v1","groups":[{"name":"apps","versions":[{"groupVersion":"apps/v1","version":"v1"}],"preferredVersion":{"groupVersion":"apps/v1","version":"v1"}},{"name":"argoproj.io","versions":[{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}],"preferredVersion":{"groupVersion":"argoproj.io/v1alpha1","version":"v1alpha1"}}]}`,
+		"/api/v1":                    `{"resources":[{"name":"namespaces","kind":"Namespace","namespaced":false},{"name":"nodes","kind":"Node","namespaced":false},{"name":"configmaps","kind":"ConfigMap","namespaced":true},{"name":"pods","kind":"Pod","namespaced":true},{"name":"secrets","kind":"Secret","namespaced":true},{"name":"serviceaccounts","kind":"ServiceAccount","namespaced":true}]}`,
+		"/apis/argoproj.io/v1alpha1": `{"resources":[{"name":"applications","kind":"Application","namespaced":true}]}`,
+		"/apis/apps/v1":              `{"resources":[{"name":"deployments","kind":"Deployment","namespaced":true},{"name":"deployments/status","kind":"Deployment","namespaced":true}]}`,
+	}
+
+	f.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if doc, ok := discovery[r.URL.Path]; ok {
+			fmt.Fprint(w, doc)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		f.mu.Lock()
+		f.requests = append(f.requests, r.Method+" "+r.URL.RequestURI()+" "+r.Header.Get("Content-Type"))
+		f.bodies[r.URL.Path] = string(body)
+		object, ok := f.objects[r.URL.Path]
+		events := f.watches[r.URL.Path]
+		f.mu.Unlock()
+
+		if r.URL.Query().Get("watch") == "true" {
+			fmt.Fprint(w, events)
+			return
+		}
+
+		if r.Method == http.MethodGet && !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404,"message":"not found"}`)
+			return
+		}
+		switch {
+		case ok:
+			fmt.Fprint(w, object)
+		case r.Method == http.MethodDelete:
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Success"}`)
+		default:
+			// Applied and patched objects are returned as sent
+			w.Write(body)
+		}
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeAPIServer) client(t *testing.T) *restClient {
+	t.Helper()
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: test
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+clusters:
+- name: test
+  cluster:
+    server: %s
+    insecure-skip-tls-verify: true
+users:
+- name: test
+  user:
+    token: test-token
+`, f.URL)
+
+	clientConfig, err := clientcmd.NewClientConfigFromBytes([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("failed to load kubeconfig: %v", err)
+	}
+	c, err := newRESTClient(clientConfig, "argocd")
+	if err != nil {
+		t.Fatalf("newRESTClient failed: %v", err)
+	}
+	return c
+}
+
+func TestRESTClientApply(t *testing.T) {
+	server := newFakeAPIServer(t)
+	c := server.client(t)
+
+	manifest := `apiVersion: v1
+kind: Namespace
+metadata:
+  name: apps
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  key: value
+`
+	if err := c.apply(t.Context(), manifest); err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+
+	want := []string{
+		"PATCH /api/v1/namespaces/apps?fieldManager=kinder&force=true&timeout=1m0s application/apply-patch+yaml",
+		"PATCH /api/v1/namespaces/argocd/configmaps/settings?fieldManager=kinder&force=true&timeout=1m0s application/apply-patch+yaml",
+	}
+	if strings.Join(server.requests, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected requests:\n%s\nwant:\n%s", strings.Join(server.requests, "\n"), strings.Join(want, "\n"))
+	}
+
+	var applied map[string]any
+	if err := json.Unmarshal([]byte(server.bodies["/api/v1/namespaces/argocd/configmaps/settings"]), &applied); err != nil {
+		t.Fatalf("applied body is not JSON: %v", err)
+	}
+	if data, _ := applied["data"].(map[string]any); data["key"] != "value" {
+		t.Errorf("unexpected applied object %v", applied)
+	}
+}
+
+func TestRESTClientApply_UnknownKind(t *testing.T) {
+	c := newFakeAPIServer(t).client(t)
+
+	err := c.apply(t.Context(), "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: w\n")
+	if err == nil || !strings.Contains(err.Error(), "Widget") {
+		t.Errorf("expected unknown kind error, got %v", err)
+	}
+}
+
+func TestRESTClientWaitRollout(t *testing.T) {
+	server := newFakeAPIServer(t)
+	server.objects["/apis/apps/v1/namespaces/argocd/deployments/ready"] = `{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": {"generation": 2},
+		"spec": {"replicas": 1},
+		"status": {"observedGeneration": 2, "replicas": 1, "updatedReplicas": 1, "availableReplicas": 1}
+	}`
+	server.objects["/apis/apps/v1/namespaces/argocd/deployments/stuck"] = `{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": {"generation": 1},
+		"spec": {"replicas": 1},
+		"status": {"conditions": [{"type": "Progressing", "reason": "ProgressDeadlineExceeded"}]}
+	}`
+	c := server.client(t)
+
+	if err := c.waitRollout(t.Context(), "ready", time.Second); err != nil {
+		t.Errorf("expected ready deployment to pass, got %v", err)
+	}
+	if err := c.waitRollout(t.Context(), "missing", time.Second); err != nil {
+		t.Errorf("expected missing deployment to be skipped, got %v", err)
+	}
+	if err := c.waitRollout(t.Context(), "stuck", time.Second); err == nil {
+		t.Error("expected stuck deployment to fail")
+	}
+}
+
+func TestRESTClientRunPod(t *testing.T) {
+	server := newFakeAPIServer(t)
+	server.objects["/api/v1/namespaces/argocd/pods/running"] = `{"apiVersion": "v1", "kind": "Pod", "status": {"phase": "Running"}}`
+	server.objects["/api/v1/namespaces/argocd/pods/failed"] = `{"apiVersion": "v1", "kind": "Pod", "status": {"phase": "Failed"}}`
+	server.objects["/api/v1/namespaces/argocd/pods/pulling"] = `{
+		"apiVersion": "v1", "kind": "Pod",
+		"status": {"phase": "Pending", "containerStatuses": [{"state": {"waiting": {"reason": "ErrImagePull", "message": "not found"}}}]}
+	}`
+	c := server.client(t)
+
+	if err := c.runPod(t.Context(), "running", "busybox", []string{"sleep", "300"}, time.Second); err != nil {
+		t.Errorf("expected running pod to pass, got %v", err)
+	}
+	for _, want := range []string{
+		"PATCH /api/v1/namespaces/argocd/pods/running?fieldManager=kinder&force=true&timeout=1m0s application/apply-patch+yaml",
+		"DELETE /api/v1/namespaces/argocd/pods/running?timeout=1m0s application/json",
+	} {
+		if !slices.Contains(server.requests, want) {
+			t.Errorf("expected request %q, got:\n%s", want, strings.Join(server.requests, "\n"))
+		}
+	}
+
+	if err := c.runPod(t.Context(), "failed", "busybox", nil, time.Second); err == nil {
+		t.Error("expected failed pod to fail")
+	}
+	err := c.runPod(t.Context(), "pulling", "busybox", nil, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "ErrImagePull: not found") {
+		t.Errorf("expected timeout to report the waiting reason, got %v", err)
+	}
+}
+
+func TestRESTClientDeleteURL(t *testing.T) {
+	manifest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: gone\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: finalizing\n")
+	}))
+	t.Cleanup(manifest.Close)
+
+	server := newFakeAPIServer(t)
+	c := server.client(t)
+	if err := c.deleteURL(t.Context(), manifest.URL, time.Second); err != nil {
+		t.Errorf("expected deleted objects to pass, got %v", err)
+	}
+
+	// An object whose finalizers never finish is still there after the timeout
+	server.objects["/api/v1/namespaces/argocd/configmaps/finalizing"] = `{"apiVersion": "v1", "kind": "ConfigMap"}`
+	err := c.deleteURL(t.Context(), manifest.URL, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "ConfigMap finalizing") {
+		t.Errorf("expected timeout naming the remaining object, got %v", err)
+	}
+}
+
+func TestRESTClientWaitNodesReady(t *testing.T) {
+	server := newFakeAPIServer(t)
+	server.objects["/api/v1/nodes"] = `{
+		"apiVersion": "v1", "kind": "NodeList", "metadata": {"resourceVersion": "1"},
+		"items": [{"metadata": {"name": "control-plane"}, "status": {"conditions": [{"type": "Ready", "status": "False"}]}}]
+	}`
+	server.watches["/api/v1/nodes"] = `{"type": "MODIFIED", "object": {"apiVersion": "v1", "kind": "Node", "metadata": {"name": "control-plane", "resourceVersion": "2"}, "status": {"conditions": [{"type": "Ready", "status": "True"}]}}}` + "\n"
+	c := server.client(t)
+
+	if err := c.waitNodesReady(t.Context(), time.Second); err != nil {
+		t.Errorf("expected the watched node to become ready, got %v", err)
+	}
+	watched := slices.ContainsFunc(server.requests, func(r string) bool {
+		return strings.HasPrefix(r, "GET /api/v1/nodes?") && strings.Contains(r, "resourceVersion=1") && strings.Contains(r, "watch=true")
+	})
+	if !watched {
+		t.Errorf("expected a watch on nodes, got:\n%s", strings.Join(server.requests, "\n"))
+	}
+
+	// Without a Ready event the wait times out
+	server.watches["/api/v1/nodes"] = ""
+	err := c.waitNodesReady(t.Context(), 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a timeout for a node that stays NotReady, got %v", err)
+	}
+}
+
+func TestNodeReady(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []any
+		want       bool
+	}{
+		{"ready", []any{map[string]any{"type": "Ready", "status": "True"}}, true},
+		{"not ready", []any{map[string]any{"type": "Ready", "status": "False"}}, false},
+		{"no conditions", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &unstructured.Unstructured{Object: map[string]any{
+				"apiVersion": "v1", "kind": "Node",
+				"status": map[string]any{"conditions": tt.conditions},
+			}}
+			if got := nodeReady(node); got != tt.want {
+				t.Errorf("nodeReady = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if allNodesReady(map[string]bool{}) {
+		t.Error("expected no nodes not to count as ready")
+	}
+}
+
+func TestNewRESTClient_UnknownContext(t *testing.T) {
+	kubeconfig := "apiVersion: v1\nkind: Config\ncontexts: []\n"
+	raw, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		t.Fatalf("failed to load kubeconfig: %v", err)
+	}
+	clientConfig := clientcmd.NewNonInteractiveClientConfig(*raw, "missing", &clientcmd.ConfigOverrides{}, nil)
+	if _, err := newRESTClient(clientConfig, ""); err == nil {
+		t.Error("expected error for unknown context")
+	}
+}
+
+func TestDecodeObjects(t *testing.T) {
+	manifest := `---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: a
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: b
+---
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: apps
+`
+	objects, err := decodeObjects([]byte(manifest))
+	if err != nil {
+		t.Fatalf("decodeObjects failed: %v", err)
+	}
+
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+	}
+	if got := strings.Join(names, ","); got != "ConfigMap/a,ConfigMap/b,Namespace/apps" {
+		t.Errorf("unexpected objects %s", got)
+	}
+}
+
+func TestWaitForApplications(t *testing.T) {
+	server := newFakeAPIServer(t)
+	server.objects["/apis/argoproj.io/v1alpha1/namespaces/argocd/applications/ready"] = `{
+		"apiVersion": "argoproj.io/v1alpha1", "kind": "Application",
+		"status": {"health": {"status": "Healthy"}, "sync": {"status": "Synced"}}
+	}`
+	server.objects["/apis/argoproj.io/v1alpha1/namespaces/argocd/applications/degraded"] = `{
+		"apiVersion": "argoproj.io/v1alpha1", "kind": "Application",
+		"status": {"health": {"status": "Degraded"}, "sync": {"status": "OutOfSync"}}
+	}`
+	c := server.client(t)
+
+	if err := waitForApplications(t.Context(), c, []string{"ready"}, time.Second); err != nil {
+		t.Errorf("expected ready application to pass, got %v", err)
+	}
+
+	err := waitForApplications(t.Context(), c, []string{"ready", "degraded", "missing"}, 10*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+	for _, want := range []string{"degraded (health Degraded, sync OutOfSync)", "missing (not found)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "ready (") {
+		t.Errorf("expected ready application to be omitted, got %v", err)
+	}
+}
@@ -1,24 +1,18 @@
 package kubernetes
 
 import (
-	"archive/tar"
 	"bytes"
 	"context"
 	"fmt"
-	"net/http"
+	"maps"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/google/go-containerregistry/pkg/authn"
-	"github.com/google/go-containerregistry/pkg/name"
+	"codeberg.org/hipkoi/kinder/ociartifact"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/google/go-containerregistry/pkg/v1/empty"
-	"github.com/google/go-containerregistry/pkg/v1/mutate"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
-	"github.com/google/go-containerregistry/pkg/v1/tarball"
-	"github.com/google/go-containerregistry/pkg/v1/types"
 )
 
 const (
@@ -32,10 +26,66 @@ const (
 	TrustManagerBundleName = "kinder-ca-bundle"
 	// TrustManagerConfigMapName is the name of the source ConfigMap
 	TrustManagerConfigMapName = "kinder-ca-source"
-	// TrustManagerTargetConfigMapKey is the key in the target ConfigMap
+	// TrustManagerTargetConfigMapKey is the default key in the target
 	TrustManagerTargetConfigMapKey = "ca-certificates.crt"
 )
 
+// Kinds of trust-manager Bundle sources and targets
+const (
+	// TrustManagerSourceConfigMap reads certificates from a ConfigMap
+	TrustManagerSourceConfigMap = "configMap"
+	// TrustManagerSourceSecret reads certificates from a Secret
+	TrustManagerSourceSecret = "secret"
+)
+
+// Additional trust-manager target formats
+const (
+	// TrustManagerFormatJKS writes a Java KeyStore alongside the PEM bundle
+	TrustManagerFormatJKS = "jks"
+	// TrustManagerFormatPKCS12 writes a PKCS#12 truststore alongside the PEM bundle
+	TrustManagerFormatPKCS12 = "pkcs12"
+)
+
+// defaultFormatKeys are the target keys used for additional formats when no
+// key is given
+var defaultFormatKeys = map[string]string{
+	TrustManagerFormatJKS:    "bundle.jks",
+	TrustManagerFormatPKCS12: "bundle.p12",
+}
+
+// sourceNamePattern matches a Kubernetes object name (RFC 1123 subdomain)
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+// sourceKeyPattern matches a ConfigMap or Secret data key
+var sourceKeyPattern = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+// labelNamePattern matches the name part of a label key and a non-empty
+// label value
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([-_.a-zA-Z0-9]{0,61}[a-zA-Z0-9])?$`)
+
+// namespaceNameLabel is set by Kubernetes on every namespace to its name
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// TrustManagerSource is an additional ConfigMap or Secret the Bundle reads
+// certificates from. trust-manager looks it up in its trust namespace.
+type TrustManagerSource struct {
+	// Kind is configMap or secret
+	Kind string
+	// Name is the name of the ConfigMap or Secret
+	Name string
+	// Key is the data key holding the PEM certificates
+	Key string
+}
+
+// TrustManagerFormat is an additional format trust-manager writes to the
+// target next to the PEM bundle
+type TrustManagerFormat struct {
+	// Format is jks or pkcs12
+	Format string
+	// Key is the target key for the format (default: bundle.jks or bundle.p12)
+	Key string
+}
+
 // TrustManagerBundleConfig holds configuration for building trust-manager manifests
 type TrustManagerBundleConfig struct {
 	// RootCACertPath is the path to the kinder root CA certificate
@@ -50,15 +100,53 @@ type TrustManagerBundleConfig struct {
 	Namespace string
 	// BundleName is the name of the Bundle resource (default: kinder-ca-bundle)
 	BundleName string
-	// TargetNamespace restricts the bundle to a specific namespace (empty = all namespaces)
-	TargetNamespace string
+	// TargetNamespaces restricts the bundle to these namespaces (empty = all namespaces)
+	TargetNamespaces []string
+	// TargetSelector restricts the bundle to namespaces with these labels
+	TargetSelector map[string]string
+	// TargetKind is configMap or secret (default: configMap). Secret targets
+	// must be enabled in trust-manager.
+	TargetKind string
+	// TargetKey is the key holding the PEM bundle in the target
+	// (default: ca-certificates.crt)
+	TargetKey string
+	// AdditionalFormats are written to the target next to the PEM bundle
+	AdditionalFormats []TrustManagerFormat
 	// IncludeMozillaCAs includes Mozilla CA bundle alongside kinder CA
 	IncludeMozillaCAs bool
+	// MozillaCAPath is a local Mozilla CA bundle to use instead of downloading
+	MozillaCAPath string
+	// MozillaCACacheDir is the directory used to cache the downloaded Mozilla CA bundle
+	MozillaCACacheDir string
+	// MozillaCACacheTTL is how long the cached Mozilla CA bundle is reused
+	MozillaCACacheTTL time.Duration
+	// MozillaCASHA256 is the expected hex SHA-256 of the Mozilla CA bundle (optional)
+	MozillaCASHA256 string
+	// ExtraSources are appended to the Bundle's sources after the kinder CA
+	ExtraSources []TrustManagerSource
+	// UseDefaultCAs adds trust-manager's default CA package as a source
+	UseDefaultCAs bool
+	// Platforms are the platforms to build the image for; more than one
+	// pushes an image index (default: ociartifact.DefaultPlatform)
+	Platforms []v1.Platform
+	// Force pushes the image even if the registry already has identical content
+	Force bool
+}
+
+// MozillaCASource returns where the Mozilla CA bundle should be loaded from
+func (cfg TrustManagerBundleConfig) MozillaCASource() MozillaCASource {
+	return MozillaCASource{
+		Path:     cfg.MozillaCAPath,
+		CacheDir: cfg.MozillaCACacheDir,
+		CacheTTL: cfg.MozillaCACacheTTL,
+	}
 }
 
 // BuildAndPushTrustManagerBundle creates an OCI image containing trust-manager
-// Kubernetes manifests and pushes it to the registry
-func BuildAndPushTrustManagerBundle(ctx context.Context, cfg TrustManagerBundleConfig) error {
+// Kubernetes manifests and pushes it to the registry. The push is skipped if the
+// registry already holds an image with the same digest, unless cfg.Force is set.
+// Returns whether the image was pushed.
+func BuildAndPushTrustManagerBundle(ctx context.Context, cfg TrustManagerBundleConfig) (bool, error) {
 	// Set defaults
 	if cfg.RegistryURL == "" {
 		cfg.RegistryURL = "localhost:5000"
@@ -79,37 +167,41 @@ func BuildAndPushTrustManagerBundle(ctx context.Context, cfg TrustManagerBundleC
 	// Read the kinder root CA
 	kinderCA, err := os.ReadFile(cfg.RootCACertPath)
 	if err != nil {
-		return fmt.Errorf("failed to read kinder CA certificate: %w", err)
+		return false, fmt.Errorf("failed to read kinder CA certificate: %w", err)
 	}
 
-	// Optionally download and include Mozilla CAs
+	// Optionally load and include Mozilla CAs
 	var mozillaCA []byte
 	if cfg.IncludeMozillaCAs {
-		mozillaCA, err = DownloadMozillaCACerts(ctx)
+		mozillaCA, err = LoadMozillaCACerts(ctx, cfg.MozillaCASource())
 		if err != nil {
-			return fmt.Errorf("failed to download Mozilla CA certificates: %w", err)
+			return false, fmt.Errorf("failed to load Mozilla CA certificates: %w", err)
+		}
+		if err := VerifyMozillaCADigest(mozillaCA, cfg.MozillaCASHA256); err != nil {
+			return false, err
 		}
 	}
 
 	// Generate manifests
 	manifests, err := GenerateTrustManagerManifests(cfg, kinderCA, mozillaCA)
 	if err != nil {
-		return fmt.Errorf("failed to generate trust-manager manifests: %w", err)
+		return false, fmt.Errorf("failed to generate trust-manager manifests: %w", err)
 	}
 
 	// Create OCI image with the manifests
-	img, err := createTrustManagerImage(manifests)
+	img, err := createTrustManagerImage(manifests, cfg.Platforms)
 	if err != nil {
-		return fmt.Errorf("failed to create trust-manager bundle image: %w", err)
+		return false, fmt.Errorf("failed to create trust-manager bundle image: %w", err)
 	}
 
-	// Push to registry
+	// Push to registry, unless it already has this exact image
 	imageRef := fmt.Sprintf("%s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag)
-	if err := pushImage(ctx, img, imageRef); err != nil {
-		return fmt.Errorf("failed to push trust-manager bundle image: %w", err)
+	pushed, err := ociartifact.PushIfChanged(ctx, img, imageRef, cfg.Force)
+	if err != nil {
+		return false, fmt.Errorf("failed to push trust-manager bundle image: %w", err)
 	}
 
-	return nil
+	return pushed, nil
 }
 
 // TrustManagerManifests holds the generated Kubernetes manifests
@@ -132,6 +224,43 @@ func GenerateTrustManagerManifests(cfg TrustManagerBundleConfig, kinderCA, mozil
 		cfg.BundleName = TrustManagerBundleName
 	}
 
+	if cfg.TargetKind == "" {
+		cfg.TargetKind = TrustManagerSourceConfigMap
+	}
+	if cfg.TargetKey == "" {
+		cfg.TargetKey = TrustManagerTargetConfigMapKey
+	}
+	formats := make([]TrustManagerFormat, len(cfg.AdditionalFormats))
+	for i, f := range cfg.AdditionalFormats {
+		if f.Key == "" {
+			f.Key = defaultFormatKeys[f.Format]
+		}
+		formats[i] = f
+	}
+	cfg.AdditionalFormats = formats
+
+	for _, src := range cfg.ExtraSources {
+		if err := validateTrustManagerSource(src); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateTrustManagerTarget(cfg); err != nil {
+		return nil, err
+	}
+	for _, ns := range cfg.TargetNamespaces {
+		if !k8sNameRegex.MatchString(ns) || len(ns) > 63 {
+			return nil, fmt.Errorf("invalid target namespace %q", ns)
+		}
+	}
+	for key, value := range cfg.TargetSelector {
+		if err := validateLabel(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := cfg.TargetSelector[namespaceNameLabel]; ok && len(cfg.TargetNamespaces) > 0 {
+		return nil, fmt.Errorf("target selector label %s cannot be combined with target namespaces; use one or the other", namespaceNameLabel)
+	}
+
 	// Combine certificates if Mozilla CAs are included
 	var combinedCert string
 	if len(mozillaCA) > 0 {
@@ -144,7 +273,10 @@ func GenerateTrustManagerManifests(cfg TrustManagerBundleConfig, kinderCA, mozil
 	configMap := generateConfigMapYAML(cfg.Namespace, combinedCert)
 
 	// Generate Bundle YAML
-	bundle := generateBundleYAML(cfg)
+	bundle, err := generateBundleYAML(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate Kustomization YAML
 	kustomization := generateKustomizationYAML()
@@ -172,36 +304,156 @@ data:
 %s`, TrustManagerConfigMapName, namespace, indentPEMForConfigMap(certPEM, 4))
 }
 
+// validateTrustManagerSource checks an additional Bundle source
+func validateTrustManagerSource(src TrustManagerSource) error {
+	if src.Kind != TrustManagerSourceConfigMap && src.Kind != TrustManagerSourceSecret {
+		return fmt.Errorf("invalid source kind %q: must be %s or %s", src.Kind, TrustManagerSourceConfigMap, TrustManagerSourceSecret)
+	}
+	if len(src.Name) > 253 || !sourceNamePattern.MatchString(src.Name) {
+		return fmt.Errorf("invalid %s source name %q", src.Kind, src.Name)
+	}
+	if len(src.Key) > 253 || !sourceKeyPattern.MatchString(src.Key) {
+		return fmt.Errorf("invalid %s source key %q", src.Kind, src.Key)
+	}
+	return nil
+}
+
+// validateTrustManagerTarget checks the target kind, key and additional
+// formats. trust-manager allows each format once, and every format needs its
+// own key.
+func validateTrustManagerTarget(cfg TrustManagerBundleConfig) error {
+	if cfg.TargetKind != TrustManagerSourceConfigMap && cfg.TargetKind != TrustManagerSourceSecret {
+		return fmt.Errorf("invalid target kind %q: must be %s or %s", cfg.TargetKind, TrustManagerSourceConfigMap, TrustManagerSourceSecret)
+	}
+	if len(cfg.TargetKey) > 253 || !sourceKeyPattern.MatchString(cfg.TargetKey) {
+		return fmt.Errorf("invalid target key %q", cfg.TargetKey)
+	}
+
+	keys := map[string]bool{cfg.TargetKey: true}
+	formats := make(map[string]bool)
+	for _, f := range cfg.AdditionalFormats {
+		if _, ok := defaultFormatKeys[f.Format]; !ok {
+			return fmt.Errorf("invalid additional format %q: must be %s or %s", f.Format, TrustManagerFormatJKS, TrustManagerFormatPKCS12)
+		}
+		if formats[f.Format] {
+			return fmt.Errorf("additional format %s given more than once", f.Format)
+		}
+		formats[f.Format] = true
+		if len(f.Key) > 253 || !sourceKeyPattern.MatchString(f.Key) {
+			return fmt.Errorf("invalid %s key %q", f.Format, f.Key)
+		}
+		if keys[f.Key] {
+			return fmt.Errorf("%s key %q is already used in the target", f.Format, f.Key)
+		}
+		keys[f.Key] = true
+	}
+	return nil
+}
+
+// validateLabel checks a namespace selector label against the Kubernetes
+// label syntax
+func validateLabel(key, value string) error {
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) > 253 || !sourceNamePattern.MatchString(prefix) {
+			return fmt.Errorf("invalid target selector label %q: bad prefix", key)
+		}
+		name = rest
+	}
+	if !labelNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid target selector label %q", key)
+	}
+	if value != "" && !labelNamePattern.MatchString(value) {
+		return fmt.Errorf("invalid target selector value %q for label %q", value, key)
+	}
+	return nil
+}
+
+// bundleNamespaceSelector returns the target namespaceSelector. A single
+// namespace and any labels become matchLabels; several namespaces become a
+// matchExpressions In clause. Returns nil to target all namespaces.
+func bundleNamespaceSelector(cfg TrustManagerBundleConfig) *labelSelector {
+	if len(cfg.TargetSelector) == 0 && len(cfg.TargetNamespaces) == 0 {
+		return nil
+	}
+
+	selector := &labelSelector{}
+	if len(cfg.TargetSelector) > 0 || len(cfg.TargetNamespaces) == 1 {
+		selector.MatchLabels = maps.Clone(cfg.TargetSelector)
+		if selector.MatchLabels == nil {
+			selector.MatchLabels = make(map[string]string, 1)
+		}
+	}
+	switch {
+	case len(cfg.TargetNamespaces) == 1:
+		selector.MatchLabels[namespaceNameLabel] = cfg.TargetNamespaces[0]
+	case len(cfg.TargetNamespaces) > 1:
+		selector.MatchExpressions = []labelSelectorRequirement{{
+			Key:      namespaceNameLabel,
+			Operator: "In",
+			Values:   cfg.TargetNamespaces,
+		}}
+	}
+	return selector
+}
+
+// bundleTargetFor returns the Bundle target: the PEM bundle under the target
+// key, any additional formats and the namespace selector
+func bundleTargetFor(cfg TrustManagerBundleConfig) bundleTarget {
+	target := bundleTarget{NamespaceSelector: bundleNamespaceSelector(cfg)}
+	key := &targetKeySelector{Key: cfg.TargetKey}
+	if cfg.TargetKind == TrustManagerSourceSecret {
+		target.Secret = key
+	} else {
+		target.ConfigMap = key
+	}
+
+	if len(cfg.AdditionalFormats) > 0 {
+		target.AdditionalFormats = &additionalFormats{}
+		for _, f := range cfg.AdditionalFormats {
+			switch f.Format {
+			case TrustManagerFormatJKS:
+				target.AdditionalFormats.JKS = &targetKeySelector{Key: f.Key}
+			case TrustManagerFormatPKCS12:
+				target.AdditionalFormats.PKCS12 = &targetKeySelector{Key: f.Key}
+			}
+		}
+	}
+	return target
+}
+
 // generateBundleYAML creates a trust-manager Bundle resource
-func generateBundleYAML(cfg TrustManagerBundleConfig) string {
-	// Build the target section
-	targetSection := fmt.Sprintf(`  target:
-    configMap:
-      key: %s`, TrustManagerTargetConfigMapKey)
-
-	// Add namespace selector if restricted to a specific namespace
-	if cfg.TargetNamespace != "" {
-		targetSection += fmt.Sprintf(`
-    namespaceSelector:
-      matchLabels:
-        kubernetes.io/metadata.name: %s`, cfg.TargetNamespace)
-	}
-
-	return fmt.Sprintf(`apiVersion: trust.cert-manager.io/v1alpha1
-kind: Bundle
-metadata:
-  name: %s
-  labels:
-    app.kubernetes.io/name: kinder-ca
-    app.kubernetes.io/component: trust-bundle
-    app.kubernetes.io/managed-by: kinder
-spec:
-  sources:
-    - configMap:
-        name: %s
-        key: ca.crt
-%s
-`, cfg.BundleName, TrustManagerConfigMapName, targetSection)
+func generateBundleYAML(cfg TrustManagerBundleConfig) (string, error) {
+	// The kinder CA always comes first, followed by any additional sources
+	sources := []bundleSource{{
+		ConfigMap: &sourceObjectKeySelector{Name: TrustManagerConfigMapName, Key: "ca.crt"},
+	}}
+	for _, src := range cfg.ExtraSources {
+		selector := &sourceObjectKeySelector{Name: src.Name, Key: src.Key}
+		if src.Kind == TrustManagerSourceSecret {
+			sources = append(sources, bundleSource{Secret: selector})
+		} else {
+			sources = append(sources, bundleSource{ConfigMap: selector})
+		}
+	}
+	if cfg.UseDefaultCAs {
+		useDefaultCAs := true
+		sources = append(sources, bundleSource{UseDefaultCAs: &useDefaultCAs})
+	}
+
+	return marshalManifests(bundleResource{
+		APIVersion: "trust.cert-manager.io/v1alpha1",
+		Kind:       "Bundle",
+		Metadata: objectMeta{
+			Name: cfg.BundleName,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "kinder-ca",
+				"app.kubernetes.io/component":  "trust-bundle",
+				"app.kubernetes.io/managed-by": "kinder",
+			},
+		},
+		Spec: bundleSpec{Sources: sources, Target: bundleTargetFor(cfg)},
+	})
 }
 
 // generateKustomizationYAML creates the kustomization.yaml
@@ -236,75 +488,17 @@ func indentPEMForConfigMap(pem string, spaces int) string {
 }
 
 // createTrustManagerImage creates an OCI image containing the manifests
-func createTrustManagerImage(manifests *TrustManagerManifests) (v1.Image, error) {
-	// Create a tar archive with the manifest files
-	var buf bytes.Buffer
-	tw := tar.NewWriter(&buf)
-
-	files := map[string][]byte{
+func createTrustManagerImage(manifests *TrustManagerManifests, platforms []v1.Platform) (ociartifact.Artifact, error) {
+	return ociartifact.BuildForPlatforms(map[string][]byte{
 		"kustomization.yaml": manifests.Kustomization,
 		"configmap.yaml":     manifests.ConfigMap,
 		"bundle.yaml":        manifests.Bundle,
-	}
-
-	for name, content := range files {
-		header := &tar.Header{
-			Name:    name,
-			Mode:    0644,
-			Size:    int64(len(content)),
-			ModTime: time.Now(),
-		}
-
-		if err := tw.WriteHeader(header); err != nil {
-			return nil, fmt.Errorf("failed to write tar header for %s: %w", name, err)
-		}
-
-		if _, err := tw.Write(content); err != nil {
-			return nil, fmt.Errorf("failed to write %s to tar: %w", name, err)
-		}
-	}
-
-	if err := tw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close tar writer: %w", err)
-	}
-
-	// Create a layer from the tar archive
-	layer, err := tarball.LayerFromReader(&buf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create layer: %w", err)
-	}
-
-	// Start with an empty image and add our layer
-	img := empty.Image
-	img, err = mutate.AppendLayers(img, layer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to append layer: %w", err)
-	}
-
-	// Set image config with annotations
-	imgCfg, err := img.ConfigFile()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get config file: %w", err)
-	}
-
-	imgCfg.Author = "kinder"
-	imgCfg.Created = v1.Time{Time: time.Now()}
-	imgCfg.Config.Labels = map[string]string{
+	}, map[string]string{
 		"org.opencontainers.image.title":       "Trust Manager Bundle",
 		"org.opencontainers.image.description": "Kustomization bundle with trust-manager resources for kinder CA",
 		"org.opencontainers.image.source":      "https://codeberg.org/hipkoi/kinder",
 		"argocd.argoproj.io/manifest-type":     "kustomize",
-	}
-
-	img, err = mutate.ConfigFile(img, imgCfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to set config: %w", err)
-	}
-
-	// Set the media type to OCI
-	img = mutate.MediaType(img, types.OCIManifestSchema1)
-
-	return img, nil
+	}, platforms)
 }
 
 // GetTrustManagerBundleDigest returns the digest of the trust-manager bundle in the registry
@@ -316,24 +510,7 @@ func GetTrustManagerBundleDigest(ctx context.Context, registryURL, imageName str
 		imageName = TrustManagerBundleImageName
 	}
 
-	imageRef := fmt.Sprintf("%s/%s:latest", registryURL, imageName)
-	ref, err := name.ParseReference(imageRef, name.Insecure)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse image reference: %w", err)
-	}
-
-	// Create transport for insecure registry
-	tr, err := transport.NewWithContext(ctx, ref.Context().Registry, authn.Anonymous, http.DefaultTransport, []string{ref.Scope(transport.PullScope)})
-	if err != nil {
-		return "", fmt.Errorf("failed to create transport: %w", err)
-	}
-
-	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(authn.Anonymous), remote.WithTransport(tr))
-	if err != nil {
-		return "", fmt.Errorf("failed to get image digest: %w", err)
-	}
-
-	return desc.Digest.String(), nil
+	return ociartifact.Digest(ctx, fmt.Sprintf("%s/%s:latest", registryURL, imageName))
 }
 
 // ExtractTrustManagerManifests extracts manifests from a local path for verification
@@ -0,0 +1,267 @@
+package kubernetes
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerateTrustManagerManifests_Sources(t *testing.T) {
+	kinderSource := map[string]any{"configMap": map[string]any{"name": TrustManagerConfigMapName, "key": "ca.crt"}}
+
+	tests := []struct {
+		name    string
+		cfg     TrustManagerBundleConfig
+		want    []any
+		wantErr bool
+	}{
+		{
+			name: "kinder CA only",
+			cfg:  TrustManagerBundleConfig{},
+			want: []any{kinderSource},
+		},
+		{
+			name: "extra sources and default CAs",
+			cfg: TrustManagerBundleConfig{
+				ExtraSources: []TrustManagerSource{
+					{Kind: TrustManagerSourceConfigMap, Name: "corp-ca", Key: "ca.pem"},
+					{Kind: TrustManagerSourceSecret, Name: "partner.ca", Key: "tls.crt"},
+				},
+				UseDefaultCAs: true,
+			},
+			want: []any{
+				kinderSource,
+				map[string]any{"configMap": map[string]any{"name": "corp-ca", "key": "ca.pem"}},
+				map[string]any{"secret": map[string]any{"name": "partner.ca", "key": "tls.crt"}},
+				map[string]any{"useDefaultCAs": true},
+			},
+		},
+		{
+			name:    "invalid kind",
+			cfg:     TrustManagerBundleConfig{ExtraSources: []TrustManagerSource{{Kind: "configmap", Name: "corp-ca", Key: "ca.pem"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			cfg:     TrustManagerBundleConfig{ExtraSources: []TrustManagerSource{{Kind: TrustManagerSourceSecret, Name: "Corp CA", Key: "ca.pem"}}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			cfg:     TrustManagerBundleConfig{ExtraSources: []TrustManagerSource{{Kind: TrustManagerSourceSecret, Name: "corp-ca", Key: "ca\n  injected: true"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := GenerateTrustManagerManifests(tt.cfg, []byte("kinder-ca\n"), nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateTrustManagerManifests failed: %v", err)
+			}
+
+			var bundle struct {
+				Spec struct {
+					Sources []any `json:"sources"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal(manifests.Bundle, &bundle); err != nil {
+				t.Fatalf("bundle is not valid YAML: %v\n%s", err, manifests.Bundle)
+			}
+			if !reflect.DeepEqual(bundle.Spec.Sources, tt.want) {
+				t.Errorf("unexpected sources:\ngot:  %v\nwant: %v", bundle.Spec.Sources, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTrustManagerManifests_Target(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TrustManagerBundleConfig
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "all namespaces",
+			cfg:  TrustManagerBundleConfig{},
+		},
+		{
+			name: "single namespace",
+			cfg:  TrustManagerBundleConfig{TargetNamespaces: []string{"2024"}},
+			want: map[string]any{
+				"matchLabels": map[string]any{"kubernetes.io/metadata.name": "2024"},
+			},
+		},
+		{
+			name: "multiple namespaces",
+			cfg:  TrustManagerBundleConfig{TargetNamespaces: []string{"dev", "staging"}},
+			want: map[string]any{
+				"matchExpressions": []any{map[string]any{
+					"key":      "kubernetes.io/metadata.name",
+					"operator": "In",
+					"values":   []any{"dev", "staging"},
+				}},
+			},
+		},
+		{
+			name: "label selector",
+			cfg:  TrustManagerBundleConfig{TargetSelector: map[string]string{"env": "dev", "example.com/team": ""}},
+			want: map[string]any{
+				"matchLabels": map[string]any{"env": "dev", "example.com/team": ""},
+			},
+		},
+		{
+			name: "label selector with namespace",
+			cfg:  TrustManagerBundleConfig{TargetNamespaces: []string{"dev"}, TargetSelector: map[string]string{"env": "dev"}},
+			want: map[string]any{
+				"matchLabels": map[string]any{"env": "dev", "kubernetes.io/metadata.name": "dev"},
+			},
+		},
+		{
+			name:    "invalid namespace",
+			cfg:     TrustManagerBundleConfig{TargetNamespaces: []string{"Dev"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid label key",
+			cfg:     TrustManagerBundleConfig{TargetSelector: map[string]string{"env\n  injected": "dev"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid label value",
+			cfg:     TrustManagerBundleConfig{TargetSelector: map[string]string{"env": "dev test"}},
+			wantErr: true,
+		},
+		{
+			name:    "namespace name label with namespace",
+			cfg:     TrustManagerBundleConfig{TargetNamespaces: []string{"dev"}, TargetSelector: map[string]string{"kubernetes.io/metadata.name": "prod"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := GenerateTrustManagerManifests(tt.cfg, []byte("kinder-ca\n"), nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateTrustManagerManifests failed: %v", err)
+			}
+
+			var bundle struct {
+				Spec struct {
+					Target struct {
+						NamespaceSelector map[string]any `json:"namespaceSelector"`
+					} `json:"target"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal(manifests.Bundle, &bundle); err != nil {
+				t.Fatalf("bundle is not valid YAML: %v\n%s", err, manifests.Bundle)
+			}
+			if !reflect.DeepEqual(bundle.Spec.Target.NamespaceSelector, tt.want) {
+				t.Errorf("unexpected namespaceSelector:\ngot:  %v\nwant: %v", bundle.Spec.Target.NamespaceSelector, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTrustManagerManifests_TargetFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TrustManagerBundleConfig
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name: "default configmap",
+			cfg:  TrustManagerBundleConfig{},
+			want: map[string]any{"configMap": map[string]any{"key": TrustManagerTargetConfigMapKey}},
+		},
+		{
+			name: "secret with custom key",
+			cfg:  TrustManagerBundleConfig{TargetKind: TrustManagerSourceSecret, TargetKey: "root-certs.pem"},
+			want: map[string]any{"secret": map[string]any{"key": "root-certs.pem"}},
+		},
+		{
+			name: "additional formats",
+			cfg: TrustManagerBundleConfig{AdditionalFormats: []TrustManagerFormat{
+				{Format: TrustManagerFormatJKS},
+				{Format: TrustManagerFormatPKCS12, Key: "truststore.p12"},
+			}},
+			want: map[string]any{
+				"configMap": map[string]any{"key": TrustManagerTargetConfigMapKey},
+				"additionalFormats": map[string]any{
+					"jks":    map[string]any{"key": "bundle.jks"},
+					"pkcs12": map[string]any{"key": "truststore.p12"},
+				},
+			},
+		},
+		{
+			name:    "invalid kind",
+			cfg:     TrustManagerBundleConfig{TargetKind: "pod"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			cfg:     TrustManagerBundleConfig{TargetKey: "ca certs"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			cfg:     TrustManagerBundleConfig{AdditionalFormats: []TrustManagerFormat{{Format: "der"}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate format",
+			cfg: TrustManagerBundleConfig{AdditionalFormats: []TrustManagerFormat{
+				{Format: TrustManagerFormatJKS},
+				{Format: TrustManagerFormatJKS, Key: "other.jks"},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "format key clashes with target key",
+			cfg:     TrustManagerBundleConfig{AdditionalFormats: []TrustManagerFormat{{Format: TrustManagerFormatJKS, Key: TrustManagerTargetConfigMapKey}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifests, err := GenerateTrustManagerManifests(tt.cfg, []byte("kinder-ca\n"), nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateTrustManagerManifests failed: %v", err)
+			}
+
+			var bundle struct {
+				Spec struct {
+					Target map[string]any `json:"target"`
+				} `json:"spec"`
+			}
+			if err := yaml.Unmarshal(manifests.Bundle, &bundle); err != nil {
+				t.Fatalf("bundle is not valid YAML: %v\n%s", err, manifests.Bundle)
+			}
+			if !reflect.DeepEqual(bundle.Spec.Target, tt.want) {
+				t.Errorf("unexpected target:\ngot:  %v\nwant: %v", bundle.Spec.Target, tt.want)
+			}
+		})
+	}
+}
@@ -1,25 +1,23 @@
 package kubernetes
 
 import (
-	"archive/tar"
 	"bytes"
 	"context"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
-	"github.com/google/go-containerregistry/pkg/authn"
-	"github.com/google/go-containerregistry/pkg/name"
+	"codeberg.org/hipkoi/kinder/ociartifact"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
-	"github.com/google/go-containerregistry/pkg/v1/empty"
-	"github.com/google/go-containerregistry/pkg/v1/mutate"
-	"github.com/google/go-containerregistry/pkg/v1/remote"
-	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
-	"github.com/google/go-containerregistry/pkg/v1/tarball"
-	"github.com/google/go-containerregistry/pkg/v1/types"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -31,8 +29,20 @@ const (
 	MozillaCACertURL = "https://curl.se/ca/cacert.pem"
 	// BundleFilePath is the path inside the OCI image where the bundle is stored
 	BundleFilePath = "trust-bundle.pem"
+	// MozillaCACacheFile is the file name of the cached Mozilla CA bundle
+	MozillaCACacheFile = "cacert.pem"
 )
 
+// MozillaCASource describes where to load the Mozilla CA bundle from
+type MozillaCASource struct {
+	// Path is a local cacert.pem to use instead of downloading (optional)
+	Path string
+	// CacheDir is the directory used to cache downloaded bundles (optional)
+	CacheDir string
+	// CacheTTL is how long a cached bundle is used before downloading again
+	CacheTTL time.Duration
+}
+
 // TrustBundleConfig holds configuration for building the trust bundle
 type TrustBundleConfig struct {
 	// RootCACertPath is the path to the kinder root CA certificate
@@ -43,11 +53,35 @@ type TrustBundleConfig struct {
 	ImageName string
 	// ImageTag is the image tag (default: latest)
 	ImageTag string
+	// MozillaCAPath is a local Mozilla CA bundle to use instead of downloading
+	MozillaCAPath string
+	// MozillaCACacheDir is the directory used to cache the downloaded Mozilla CA bundle
+	MozillaCACacheDir string
+	// MozillaCACacheTTL is how long the cached Mozilla CA bundle is reused
+	MozillaCACacheTTL time.Duration
+	// MozillaCASHA256 is the expected hex SHA-256 of the Mozilla CA bundle (optional)
+	MozillaCASHA256 string
+	// Platforms are the platforms to build the image for; more than one
+	// pushes an image index (default: ociartifact.DefaultPlatform)
+	Platforms []v1.Platform
+	// Force pushes the image even if the registry already has identical content
+	Force bool
+}
+
+// MozillaCASource returns where the Mozilla CA bundle should be loaded from
+func (cfg TrustBundleConfig) MozillaCASource() MozillaCASource {
+	return MozillaCASource{
+		Path:     cfg.MozillaCAPath,
+		CacheDir: cfg.MozillaCACacheDir,
+		CacheTTL: cfg.MozillaCACacheTTL,
+	}
 }
 
 // BuildAndPushTrustBundle creates an OCI image containing the combined trust bundle
-// and pushes it to the local registry
-func BuildAndPushTrustBundle(ctx context.Context, cfg TrustBundleConfig) error {
+// and pushes it to the local registry. The push is skipped when the registry
+// already holds an image with the same digest, unless cfg.Force is set.
+// Returns whether the image was pushed.
+func BuildAndPushTrustBundle(ctx context.Context, cfg TrustBundleConfig) (bool, error) {
 	// Set defaults
 	if cfg.RegistryURL == "" {
 		cfg.RegistryURL = "localhost:5000"
@@ -62,31 +96,79 @@ func BuildAndPushTrustBundle(ctx context.Context, cfg TrustBundleConfig) error {
 	// Read the kinder root CA
 	kinderCA, err := os.ReadFile(cfg.RootCACertPath)
 	if err != nil {
-		return fmt.Errorf("failed to read kinder CA certificate: %w", err)
+		return false, fmt.Errorf("failed to read kinder CA certificate: %w", err)
 	}
 
-	// Download Mozilla CA bundle
-	mozillaCA, err := DownloadMozillaCACerts(ctx)
+	// Load Mozilla CA bundle from a local file, the cache or the network
+	mozillaCA, err := LoadMozillaCACerts(ctx, cfg.MozillaCASource())
 	if err != nil {
-		return fmt.Errorf("failed to download Mozilla CA certificates: %w", err)
+		return false, fmt.Errorf("failed to load Mozilla CA certificates: %w", err)
+	}
+	if err := VerifyMozillaCADigest(mozillaCA, cfg.MozillaCASHA256); err != nil {
+		return false, err
 	}
 
 	// Combine the certificates: kinder CA first, then Mozilla CAs
 	combinedBundle := combineCABundles(kinderCA, mozillaCA)
 
 	// Create OCI image with the bundle
-	img, err := createTrustBundleImage(combinedBundle)
+	img, err := createTrustBundleImage(combinedBundle, cfg.Platforms)
 	if err != nil {
-		return fmt.Errorf("failed to create trust bundle image: %w", err)
+		return false, fmt.Errorf("failed to create trust bundle image: %w", err)
 	}
 
-	// Push to registry
 	imageRef := fmt.Sprintf("%s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag)
-	if err := pushImage(ctx, img, imageRef); err != nil {
-		return fmt.Errorf("failed to push trust bundle image: %w", err)
+
+	// Push to registry, unless it already has this exact image
+	pushed, err := ociartifact.PushIfChanged(ctx, img, imageRef, cfg.Force)
+	if err != nil {
+		return false, fmt.Errorf("failed to push trust bundle image: %w", err)
 	}
 
-	return nil
+	return pushed, nil
+}
+
+// LoadMozillaCACerts returns the Mozilla CA bundle. A local file takes
+// precedence, then a cached copy younger than the TTL. Otherwise the bundle is
+// downloaded and written to the cache. If the download fails, a stale cached
+// copy is used rather than failing.
+func LoadMozillaCACerts(ctx context.Context, src MozillaCASource) ([]byte, error) {
+	if src.Path != "" {
+		data, err := os.ReadFile(src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Mozilla CA file: %w", err)
+		}
+		return data, nil
+	}
+
+	var cachePath string
+	var cached []byte
+	if src.CacheDir != "" {
+		cachePath = filepath.Join(src.CacheDir, MozillaCACacheFile)
+		if info, err := os.Stat(cachePath); err == nil {
+			cached, err = os.ReadFile(cachePath)
+			if err == nil && len(cached) > 0 && time.Since(info.ModTime()) < src.CacheTTL {
+				return cached, nil
+			}
+		}
+	}
+
+	data, err := DownloadMozillaCACerts(ctx)
+	if err != nil {
+		if len(cached) > 0 {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if cachePath != "" {
+		// Caching is best effort; a failure only means the next run downloads again
+		if err := os.MkdirAll(src.CacheDir, 0755); err == nil {
+			_ = os.WriteFile(cachePath, data, 0644)
+		}
+	}
+
+	return data, nil
 }
 
 // DownloadMozillaCACerts downloads the Mozilla CA certificate bundle from curl.se
@@ -135,113 +217,115 @@ func combineCABundles(bundles ...[]byte) []byte {
 }
 
 // createTrustBundleImage creates an OCI image containing the trust bundle
-func createTrustBundleImage(bundle []byte) (v1.Image, error) {
-	// Create a tar archive with the bundle file
-	var buf bytes.Buffer
-	tw := tar.NewWriter(&buf)
+func createTrustBundleImage(bundle []byte, platforms []v1.Platform) (ociartifact.Artifact, error) {
+	return ociartifact.BuildForPlatforms(map[string][]byte{
+		BundleFilePath: bundle,
+	}, map[string]string{
+		"org.opencontainers.image.title":       "Trust Bundle",
+		"org.opencontainers.image.description": "Combined CA certificate bundle with kinder root CA and Mozilla CAs",
+		"org.opencontainers.image.source":      "https://codeberg.org/hipkoi/kinder",
+		"trust-manager.io/bundle":              "true",
+	}, platforms)
+}
 
-	// Add the bundle file to the tar archive
-	header := &tar.Header{
-		Name:    BundleFilePath,
-		Mode:    0644,
-		Size:    int64(len(bundle)),
-		ModTime: time.Now(),
+// GetTrustBundleDigest returns the digest of the trust bundle in the registry
+func GetTrustBundleDigest(ctx context.Context, registryURL string) (string, error) {
+	if registryURL == "" {
+		registryURL = "localhost:5000"
 	}
 
-	if err := tw.WriteHeader(header); err != nil {
-		return nil, fmt.Errorf("failed to write tar header: %w", err)
-	}
+	return ociartifact.Digest(ctx, fmt.Sprintf("%s/trust-bundle:latest", registryURL))
+}
 
-	if _, err := tw.Write(bundle); err != nil {
-		return nil, fmt.Errorf("failed to write bundle to tar: %w", err)
-	}
+// ExtractOCIArtifact pulls an artifact from the registry and returns the files
+// it contains, keyed by path
+func ExtractOCIArtifact(ctx context.Context, ref string) (map[string][]byte, error) {
+	return ociartifact.Pull(ctx, ref)
+}
 
-	if err := tw.Close(); err != nil {
-		return nil, fmt.Errorf("failed to close tar writer: %w", err)
+// ArtifactCABundle returns the PEM certificate bundle embedded in a trust
+// bundle artifact (trust-bundle.pem) or a trust-manager bundle artifact (the
+// ca.crt key of configmap.yaml)
+func ArtifactCABundle(files map[string][]byte) ([]byte, error) {
+	if bundle, ok := files[BundleFilePath]; ok {
+		return bundle, nil
 	}
 
-	// Create a layer from the tar archive
-	layer, err := tarball.LayerFromReader(&buf)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create layer: %w", err)
+	configMap, ok := files["configmap.yaml"]
+	if !ok {
+		return nil, fmt.Errorf("artifact contains neither %s nor configmap.yaml", BundleFilePath)
 	}
 
-	// Start with an empty image and add our layer
-	img := empty.Image
-	img, err = mutate.AppendLayers(img, layer)
-	if err != nil {
-		return nil, fmt.Errorf("failed to append layer: %w", err)
+	var doc struct {
+		Data map[string]string `yaml:"data"`
 	}
-
-	// Set image config with annotations
-	cfg, err := img.ConfigFile()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get config file: %w", err)
+	if err := yaml.Unmarshal(configMap, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse configmap.yaml: %w", err)
 	}
-
-	cfg.Author = "kinder"
-	cfg.Created = v1.Time{Time: time.Now()}
-	cfg.Config.Labels = map[string]string{
-		"org.opencontainers.image.title":       "Trust Bundle",
-		"org.opencontainers.image.description": "Combined CA certificate bundle with kinder root CA and Mozilla CAs",
-		"org.opencontainers.image.source":      "https://codeberg.org/hipkoi/kinder",
-		"trust-manager.io/bundle":              "true",
+	bundle, ok := doc.Data["ca.crt"]
+	if !ok {
+		return nil, fmt.Errorf("configmap.yaml has no ca.crt key")
 	}
 
-	img, err = mutate.ConfigFile(img, cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to set config: %w", err)
-	}
+	return []byte(bundle), nil
+}
 
-	// Set the media type to OCI
-	img = mutate.MediaType(img, types.OCIManifestSchema1)
+// ParseCertificates parses every PEM certificate in a bundle, skipping any
+// text between blocks
+func ParseCertificates(bundle []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
 
-	return img, nil
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate %d: %w", len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
 }
 
-// pushImage pushes an image to a registry
-func pushImage(ctx context.Context, img v1.Image, imageRef string) error {
-	ref, err := name.ParseReference(imageRef, name.Insecure)
+// VerifyBundleCA checks that the first certificate of a bundle is the kinder
+// root CA, as kinder always places it first
+func VerifyBundleCA(certs []*x509.Certificate, kinderCA []byte) error {
+	local, err := ParseCertificates(kinderCA)
 	if err != nil {
-		return fmt.Errorf("failed to parse image reference: %w", err)
+		return fmt.Errorf("failed to parse local CA certificate: %w", err)
 	}
-
-	// Create a transport that uses HTTP for insecure registries
-	tr, err := transport.NewWithContext(ctx, ref.Context().Registry, authn.Anonymous, http.DefaultTransport, []string{ref.Scope(transport.PushScope)})
-	if err != nil {
-		return fmt.Errorf("failed to create transport: %w", err)
+	if len(local) == 0 {
+		return fmt.Errorf("local CA certificate file contains no certificates")
 	}
-
-	// Push with the custom transport for HTTP registry
-	if err := remote.Write(ref, img,
-		remote.WithContext(ctx),
-		remote.WithAuth(authn.Anonymous),
-		remote.WithTransport(tr),
-	); err != nil {
-		return fmt.Errorf("failed to push image: %w", err)
+	if len(certs) == 0 {
+		return fmt.Errorf("bundle contains no certificates")
+	}
+	if !bytes.Equal(certs[0].Raw, local[0].Raw) {
+		return fmt.Errorf("bundle CA %q does not match the local CA %q", certs[0].Subject.CommonName, local[0].Subject.CommonName)
 	}
-
 	return nil
 }
 
-// GetTrustBundleDigest returns the digest of the trust bundle in the registry
-func GetTrustBundleDigest(ctx context.Context, registryURL string) (string, error) {
-	if registryURL == "" {
-		registryURL = "localhost:5000"
-	}
-
-	imageRef := fmt.Sprintf("%s/trust-bundle:latest", registryURL)
-	ref, err := name.ParseReference(imageRef, name.Insecure)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse image reference: %w", err)
+// VerifyMozillaCADigest checks the Mozilla CA bundle against an expected
+// hex-encoded SHA-256 digest. An empty expected digest skips the check.
+func VerifyMozillaCADigest(mozillaCA []byte, expected string) error {
+	if expected == "" {
+		return nil
 	}
 
-	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(authn.Anonymous))
-	if err != nil {
-		return "", fmt.Errorf("failed to get image digest: %w", err)
+	hash := sha256.Sum256(mozillaCA)
+	actual := hex.EncodeToString(hash[:])
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("mozilla CA bundle SHA-256 mismatch: expected %s, got %s", strings.ToLower(expected), actual)
 	}
 
-	return desc.Digest.String(), nil
+	return nil
 }
 
 // ComputeBundleHash computes a SHA256 hash of the combined bundle for change detection
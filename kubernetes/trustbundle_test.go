@@ -0,0 +1,306 @@
+package kubernetes
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"codeberg.org/hipkoi/kinder/cacert"
+	"github.com/google/go-containerregistry/pkg/registry"
+)
+
+func TestLoadMozillaCACerts(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("local file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cacert.pem")
+		if err := os.WriteFile(path, []byte("local-bundle"), 0644); err != nil {
+			t.Fatalf("failed to write bundle: %v", err)
+		}
+
+		data, err := LoadMozillaCACerts(ctx, MozillaCASource{Path: path})
+		if err != nil {
+			t.Fatalf("LoadMozillaCACerts failed: %v", err)
+		}
+		if string(data) != "local-bundle" {
+			t.Errorf("expected local bundle, got %q", data)
+		}
+	})
+
+	t.Run("missing local file", func(t *testing.T) {
+		_, err := LoadMozillaCACerts(ctx, MozillaCASource{Path: filepath.Join(t.TempDir(), "missing.pem")})
+		if err == nil {
+			t.Error("expected error for missing local file")
+		}
+	})
+
+	t.Run("fresh cache", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(cacheDir, MozillaCACacheFile), []byte("cached-bundle"), 0644); err != nil {
+			t.Fatalf("failed to write cache: %v", err)
+		}
+
+		data, err := LoadMozillaCACerts(ctx, MozillaCASource{CacheDir: cacheDir, CacheTTL: time.Hour})
+		if err != nil {
+			t.Fatalf("LoadMozillaCACerts failed: %v", err)
+		}
+		if string(data) != "cached-bundle" {
+			t.Errorf("expected cached bundle, got %q", data)
+		}
+	})
+
+	t.Run("stale cache used when download fails", func(t *testing.T) {
+		cacheDir := t.TempDir()
+		cachePath := filepath.Join(cacheDir, MozillaCACacheFile)
+		if err := os.WriteFile(cachePath, []byte("stale-bundle"), 0644); err != nil {
+			t.Fatalf("failed to write cache: %v", err)
+		}
+		old := time.Now().Add(-48 * time.Hour)
+		if err := os.Chtimes(cachePath, old, old); err != nil {
+			t.Fatalf("failed to age cache: %v", err)
+		}
+
+		// A cancelled context makes the download fail without network access
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		data, err := LoadMozillaCACerts(cancelled, MozillaCASource{CacheDir: cacheDir, CacheTTL: time.Hour})
+		if err != nil {
+			t.Fatalf("LoadMozillaCACerts failed: %v", err)
+		}
+		if string(data) != "stale-bundle" {
+			t.Errorf("expected stale cached bundle, got %q", data)
+		}
+	})
+}
+
+func TestVerifyMozillaCADigest(t *testing.T) {
+	bundle := []byte("bundle")
+	// sha256("bundle")
+	digest := "1e6ed65d77d6364eeaed5a745ba5c4985ae2b700dd85d7cf7f027bdf294a33fc"
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"no pin", "", false},
+		{"match", digest, false},
+		{"match uppercase", strings.ToUpper(digest), false},
+		{"mismatch", strings.Repeat("0", 64), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyMozillaCADigest(bundle, tt.expected)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyMozillaCADigest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateTrustBundleImage_Reproducible(t *testing.T) {
+	bundle := combineCABundles([]byte("kinder-ca\n"), []byte("mozilla-ca\n"))
+
+	first, err := createTrustBundleImage(bundle, nil)
+	if err != nil {
+		t.Fatalf("createTrustBundleImage failed: %v", err)
+	}
+	second, err := createTrustBundleImage(bundle, nil)
+	if err != nil {
+		t.Fatalf("createTrustBundleImage failed: %v", err)
+	}
+
+	firstDigest, err := first.Digest()
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	secondDigest, err := second.Digest()
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	if firstDigest != secondDigest {
+		t.Errorf("expected identical bundles to produce identical digests, got %s and %s", firstDigest, secondDigest)
+	}
+
+	changed, err := createTrustBundleImage(combineCABundles([]byte("other-ca\n"), []byte("mozilla-ca\n")), nil)
+	if err != nil {
+		t.Fatalf("createTrustBundleImage failed: %v", err)
+	}
+	changedDigest, err := changed.Digest()
+	if err != nil {
+		t.Fatalf("failed to get digest: %v", err)
+	}
+	if changedDigest == firstDigest {
+		t.Error("expected different bundle content to produce a different digest")
+	}
+}
+
+// newTestRegistry starts an in-memory OCI registry and returns its host:port
+func newTestRegistry(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(registry.New())
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestBuildAndPushSkipsUnchanged(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	caPath := filepath.Join(dir, "ca.crt")
+	if err := os.WriteFile(caPath, []byte("kinder-ca\n"), 0644); err != nil {
+		t.Fatalf("failed to write CA: %v", err)
+	}
+	mozillaPath := filepath.Join(dir, "cacert.pem")
+	if err := os.WriteFile(mozillaPath, []byte("mozilla-ca\n"), 0644); err != nil {
+		t.Fatalf("failed to write Mozilla bundle: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		push func(registryURL string, force bool) (bool, error)
+	}{
+		{"trust bundle", func(registryURL string, force bool) (bool, error) {
+			return BuildAndPushTrustBundle(ctx, TrustBundleConfig{
+				RootCACertPath: caPath,
+				RegistryURL:    registryURL,
+				MozillaCAPath:  mozillaPath,
+				Force:          force,
+			})
+		}},
+		{"trust-manager bundle", func(registryURL string, force bool) (bool, error) {
+			return BuildAndPushTrustManagerBundle(ctx, TrustManagerBundleConfig{
+				RootCACertPath:    caPath,
+				RegistryURL:       registryURL,
+				IncludeMozillaCAs: true,
+				MozillaCAPath:     mozillaPath,
+				Force:             force,
+			})
+		}},
+		{"cert-manager issuer", func(registryURL string, force bool) (bool, error) {
+			return BuildAndPushCertManagerIssuer(ctx, CertManagerIssuerConfig{
+				RootCACertPath: caPath,
+				RegistryURL:    registryURL,
+				Force:          force,
+			})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryURL := newTestRegistry(t)
+
+			steps := []struct {
+				desc   string
+				force  bool
+				pushed bool
+			}{
+				{"first push", false, true},
+				{"unchanged", false, false},
+				{"forced", true, true},
+			}
+
+			for _, step := range steps {
+				pushed, err := tt.push(registryURL, step.force)
+				if err != nil {
+					t.Fatalf("%s: push failed: %v", step.desc, err)
+				}
+				if pushed != step.pushed {
+					t.Errorf("%s: pushed = %v, want %v", step.desc, pushed, step.pushed)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractAndVerifyArtifact(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	caPath := filepath.Join(dir, "ca.crt")
+	if err := cacert.GenerateCA(caPath, filepath.Join(dir, "ca.key")); err != nil {
+		t.Fatalf("failed to generate CA: %v", err)
+	}
+	otherCAPath := filepath.Join(dir, "other.crt")
+	if err := cacert.GenerateCA(otherCAPath, filepath.Join(dir, "other.key")); err != nil {
+		t.Fatalf("failed to generate CA: %v", err)
+	}
+	kinderCA, err := os.ReadFile(caPath)
+	if err != nil {
+		t.Fatalf("failed to read CA: %v", err)
+	}
+	otherCA, err := os.ReadFile(otherCAPath)
+	if err != nil {
+		t.Fatalf("failed to read CA: %v", err)
+	}
+
+	// The "Mozilla" bundle holds another CA with text between blocks, as cacert.pem does
+	mozillaPath := filepath.Join(dir, "cacert.pem")
+	if err := os.WriteFile(mozillaPath, append([]byte("Other CA\n========\n"), otherCA...), 0644); err != nil {
+		t.Fatalf("failed to write Mozilla bundle: %v", err)
+	}
+
+	registryURL := newTestRegistry(t)
+
+	tests := []struct {
+		name  string
+		image string
+		push  func() error
+	}{
+		{"trust bundle", "trust-bundle", func() error {
+			_, err := BuildAndPushTrustBundle(ctx, TrustBundleConfig{
+				RootCACertPath: caPath,
+				RegistryURL:    registryURL,
+				MozillaCAPath:  mozillaPath,
+			})
+			return err
+		}},
+		{"trust-manager bundle", TrustManagerBundleImageName, func() error {
+			_, err := BuildAndPushTrustManagerBundle(ctx, TrustManagerBundleConfig{
+				RootCACertPath:    caPath,
+				RegistryURL:       registryURL,
+				IncludeMozillaCAs: true,
+				MozillaCAPath:     mozillaPath,
+			})
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.push(); err != nil {
+				t.Fatalf("push failed: %v", err)
+			}
+
+			files, err := ExtractOCIArtifact(ctx, registryURL+"/"+tt.image+":latest")
+			if err != nil {
+				t.Fatalf("ExtractOCIArtifact failed: %v", err)
+			}
+
+			bundle, err := ArtifactCABundle(files)
+			if err != nil {
+				t.Fatalf("ArtifactCABundle failed: %v", err)
+			}
+			certs, err := ParseCertificates(bundle)
+			if err != nil {
+				t.Fatalf("ParseCertificates failed: %v", err)
+			}
+			if len(certs) != 2 {
+				t.Errorf("expected 2 certificates, got %d", len(certs))
+			}
+
+			if err := VerifyBundleCA(certs, kinderCA); err != nil {
+				t.Errorf("expected bundle to match the kinder CA: %v", err)
+			}
+			if err := VerifyBundleCA(certs, otherCA); err == nil {
+				t.Error("expected a different local CA to fail verification")
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadKinds are the namespaced resource kinds saved by SaveWorkloads.
+// Cluster-scoped resources, custom resources, Jobs, bare Pods and the
+// contents of persistent volumes are not preserved.
+var WorkloadKinds = []string{
+	"serviceaccounts",
+	"configmaps",
+	"secrets",
+	"persistentvolumeclaims",
+	"roles",
+	"rolebindings",
+	"networkpolicies",
+	"services",
+	"deployments",
+	"statefulsets",
+	"daemonsets",
+	"cronjobs",
+	"ingresses",
+}
+
+// namespaceDeleteTimeout is how long DeleteNamespaces waits for each
+// namespace to finish terminating
+const namespaceDeleteTimeout = 2 * time.Minute
+
+// systemNamespaces are created by Kubernetes or Kind and are never saved
+var systemNamespaces = map[string]bool{
+	"kube-system":        true,
+	"kube-public":        true,
+	"kube-node-lease":    true,
+	"local-path-storage": true,
+}
+
+// isSystemNamespace reports whether a namespace belongs to the cluster itself
+func isSystemNamespace(name string) bool {
+	return systemNamespaces[name]
+}
+
+// SaveWorkloads writes the workloads in all non-system namespaces of a Kind
+// cluster to path as a single List manifest that can be re-applied to a new
+// cluster. Returns the namespaces saved.
+func SaveWorkloads(ctx context.Context, clusterName, path string) ([]string, error) {
+	client, err := newKindRESTClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, items, err := collectWorkloads(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode workloads: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write workloads: %w", err)
+	}
+
+	return namespaces, nil
+}
+
+// collectWorkloads returns the non-system namespaces and their cleaned
+// workloads, each namespace other than default preceded by its Namespace
+func collectWorkloads(ctx context.Context, client *restClient) ([]string, []map[string]any, error) {
+	all, err := client.namespaces(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	var namespaces []string
+	var items []map[string]any
+	for _, ns := range all {
+		if isSystemNamespace(ns) {
+			continue
+		}
+		namespaces = append(namespaces, ns)
+
+		if ns != "default" {
+			items = append(items, map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata":   map[string]any{"name": ns},
+			})
+		}
+
+		for _, kind := range WorkloadKinds {
+			ri, err := client.resourceNamed(kind, ns)
+			if err != nil {
+				return nil, nil, err
+			}
+			list, err := ri.List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get %s in namespace %s: %w", kind, ns, err)
+			}
+			for _, obj := range list.Items {
+				if cleanWorkload(obj.Object) {
+					items = append(items, obj.Object)
+				}
+			}
+		}
+	}
+
+	return namespaces, items, nil
+}
+
+// RestoreWorkloads applies workloads saved by SaveWorkloads to a Kind cluster
+func RestoreWorkloads(ctx context.Context, clusterName, path string) error {
+	manifest, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read workloads: %w", err)
+	}
+	client, err := newKindRESTClient(clusterName)
+	if err != nil {
+		return err
+	}
+	if err := client.apply(ctx, string(manifest)); err != nil {
+		return fmt.Errorf("failed to apply workloads: %w", err)
+	}
+	return nil
+}
+
+// DeleteNamespaces deletes namespaces from a Kind cluster, ignoring any that
+// do not exist. The default namespace is emptied of the saved kinds instead.
+func DeleteNamespaces(ctx context.Context, clusterName string, namespaces []string) error {
+	client, err := newKindRESTClient(clusterName)
+	if err != nil {
+		return err
+	}
+	for _, ns := range namespaces {
+		if err := deleteWorkloadNamespace(ctx, client, ns); err != nil {
+			return fmt.Errorf("failed to clean up namespace %s: %w", ns, err)
+		}
+	}
+	return nil
+}
+
+// deleteWorkloadNamespace deletes a namespace and waits for it to be gone, or
+// deletes the saved kinds from the default namespace
+func deleteWorkloadNamespace(ctx context.Context, client *restClient, ns string) error {
+	if ns != "default" {
+		return client.deleteNamespace(ctx, ns, namespaceDeleteTimeout)
+	}
+	for _, kind := range WorkloadKinds {
+		ri, err := client.resourceNamed(kind, ns)
+		if err != nil {
+			return err
+		}
+		if err := ri.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{}); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete %s: %w", kind, err)
+		}
+	}
+	return nil
+}
+
+// cleanWorkload strips cluster-assigned fields from a resource so it can be
+// applied to a new cluster. Returns false for resources that the cluster
+// creates itself or that are owned by another resource.
+func cleanWorkload(obj map[string]any) bool {
+	kind, _ := obj["kind"].(string)
+	metadata, _ := obj["metadata"].(map[string]any)
+	if metadata == nil {
+		return false
+	}
+	name, _ := metadata["name"].(string)
+
+	// Owned resources are recreated by their owner
+	if _, owned := metadata["ownerReferences"]; owned {
+		return false
+	}
+
+	switch {
+	case kind == "ConfigMap" && name == "kube-root-ca.crt":
+		return false
+	case kind == "ServiceAccount" && name == "default":
+		return false
+	case kind == "Service" && name == "kubernetes":
+		return false
+	case kind == "Secret" && obj["type"] == "kubernetes.io/service-account-token":
+		return false
+	}
+
+	for _, field := range []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "selfLink"} {
+		delete(metadata, field)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]any); ok {
+		for key := range annotations {
+			if key == "kubectl.kubernetes.io/last-applied-configuration" ||
+				key == "deployment.kubernetes.io/revision" ||
+				strings.HasPrefix(key, "pv.kubernetes.io/") ||
+				strings.HasPrefix(key, "volume.kubernetes.io/") ||
+				strings.HasPrefix(key, "volume.beta.kubernetes.io/") {
+				delete(annotations, key)
+			}
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+	delete(obj, "status")
+
+	// Cluster-assigned addresses and volumes differ in the new cluster
+	if spec, ok := obj["spec"].(map[string]any); ok {
+		switch kind {
+		case "Service":
+			delete(spec, "clusterIP")
+			delete(spec, "clusterIPs")
+		case "PersistentVolumeClaim":
+			delete(spec, "volumeName")
+		}
+	}
+
+	return true
+}
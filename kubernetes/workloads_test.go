@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCleanWorkload(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  map[string]any
+		keep bool
+	}{
+		{"root CA configmap", resource("ConfigMap", "kube-root-ca.crt"), false},
+		{"default service account", resource("ServiceAccount", "default"), false},
+		{"kubernetes service", resource("Service", "kubernetes"), false},
+		{"token secret", func() map[string]any {
+			obj := resource("Secret", "token")
+			obj["type"] = "kubernetes.io/service-account-token"
+			return obj
+		}(), false},
+		{"owned resource", func() map[string]any {
+			obj := resource("ConfigMap", "owned")
+			obj["metadata"].(map[string]any)["ownerReferences"] = []any{map[string]any{"name": "app"}}
+			return obj
+		}(), false},
+		{"deployment", resource("Deployment", "app"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanWorkload(tt.obj); got != tt.keep {
+				t.Errorf("cleanWorkload() = %v, want %v", got, tt.keep)
+			}
+		})
+	}
+}
+
+func TestCleanWorkload_StripsClusterFields(t *testing.T) {
+	obj := resource("Service", "web")
+	metadata := obj["metadata"].(map[string]any)
+	metadata["uid"] = "1234"
+	metadata["resourceVersion"] = "42"
+	metadata["creationTimestamp"] = "2024-01-01T00:00:00Z"
+	metadata["annotations"] = map[string]any{
+		"kubectl.kubernetes.io/last-applied-configuration": "{}",
+		"example.com/keep": "yes",
+	}
+	obj["status"] = map[string]any{"loadBalancer": map[string]any{}}
+	obj["spec"] = map[string]any{"clusterIP": "10.96.0.10", "clusterIPs": []any{"10.96.0.10"}, "type": "ClusterIP"}
+
+	if !cleanWorkload(obj) {
+		t.Fatal("expected service to be kept")
+	}
+
+	for _, field := range []string{"uid", "resourceVersion", "creationTimestamp"} {
+		if _, ok := metadata[field]; ok {
+			t.Errorf("expected metadata.%s to be removed", field)
+		}
+	}
+	annotations := metadata["annotations"].(map[string]any)
+	if _, ok := annotations["kubectl.kubernetes.io/last-applied-configuration"]; ok {
+		t.Error("expected last-applied-configuration to be removed")
+	}
+	if annotations["example.com/keep"] != "yes" {
+		t.Error("expected user annotations to be kept")
+	}
+	if _, ok := obj["status"]; ok {
+		t.Error("expected status to be removed")
+	}
+	spec := obj["spec"].(map[string]any)
+	if _, ok := spec["clusterIP"]; ok {
+		t.Error("expected clusterIP to be removed")
+	}
+	if spec["type"] != "ClusterIP" {
+		t.Error("expected service type to be kept")
+	}
+}
+
+func TestIsSystemNamespace(t *testing.T) {
+	for _, ns := range []string{"kube-system", "kube-public", "kube-node-lease", "local-path-storage"} {
+		if !isSystemNamespace(ns) {
+			t.Errorf("expected %s to be a system namespace", ns)
+		}
+	}
+	for _, ns := range []string{"default", "apps", "argocd"} {
+		if isSystemNamespace(ns) {
+			t.Errorf("expected %s not to be a system namespace", ns)
+		}
+	}
+}
+
+// resource returns a minimal Kubernetes object
+func resource(kind, name string) map[string]any {
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   map[string]any{"name": name, "namespace": "apps"},
+	}
+}
+
+func TestCollectWorkloads(t *testing.T) {
+	orig := WorkloadKinds
+	t.Cleanup(func() { WorkloadKinds = orig })
+	WorkloadKinds = []string{"configmaps"}
+
+	server := newFakeAPIServer(t)
+	server.objects["/api/v1/namespaces"] = `{"apiVersion": "v1", "kind": "NamespaceList", "items": [
+		{"metadata": {"name": "default"}}, {"metadata": {"name": "kube-system"}}, {"metadata": {"name": "apps"}}
+	]}`
+	server.objects["/api/v1/namespaces/default/configmaps"] = `{"apiVersion": "v1", "kind": "ConfigMapList", "items": [
+		{"metadata": {"name": "kube-root-ca.crt", "namespace": "default"}},
+		{"metadata": {"name": "settings", "namespace": "default"}}
+	]}`
+	server.objects["/api/v1/namespaces/apps/configmaps"] = `{"apiVersion": "v1", "kind": "ConfigMapList", "items": [
+		{"metadata": {"name": "app-config", "namespace": "apps", "resourceVersion": "42"}}
+	]}`
+	c := server.client(t)
+
+	namespaces, items, err := collectWorkloads(t.Context(), c)
+	if err != nil {
+		t.Fatalf("collectWorkloads failed: %v", err)
+	}
+	if strings.Join(namespaces, ",") != "default,apps" {
+		t.Errorf("expected namespaces default,apps, got %v", namespaces)
+	}
+
+	var got []string
+	for _, item := range items {
+		metadata := item["metadata"].(map[string]any)
+		got = append(got, item["kind"].(string)+"/"+metadata["name"].(string))
+		if _, ok := metadata["resourceVersion"]; ok {
+			t.Errorf("expected resourceVersion to be stripped from %v", metadata["name"])
+		}
+	}
+	if strings.Join(got, ",") != "ConfigMap/settings,Namespace/apps,ConfigMap/app-config" {
+		t.Errorf("unexpected workloads %v", got)
+	}
+
+	// Cleaning up empties the default namespace and deletes the others
+	for _, ns := range namespaces {
+		if err := deleteWorkloadNamespace(t.Context(), c, ns); err != nil {
+			t.Fatalf("deleteWorkloadNamespace(%s) failed: %v", ns, err)
+		}
+	}
+	for _, want := range []string{
+		"DELETE /api/v1/namespaces/default/configmaps?timeout=1m0s application/json",
+		"DELETE /api/v1/namespaces/apps?timeout=1m0s application/json",
+	} {
+		if !slices.Contains(server.requests, want) {
+			t.Errorf("expected request %q, got:\n%s", want, strings.Join(server.requests, "\n"))
+		}
+	}
+}
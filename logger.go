@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+
+	kindlog "sigs.k8s.io/kind/pkg/log"
+)
+
+// Log formats accepted by --log-format
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// logLevels maps --log-level values to slog levels
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+var (
+	// logLevel is the minimum level logged; the output helpers log through it
+	logLevel = new(slog.LevelVar)
+	// logger receives everything printed by the output helpers
+	logger = slog.New(newPlainHandler(os.Stdout, os.Stderr, logLevel))
+	// jsonLogs is set when --log-format=json replaces the terminal output
+	// with one JSON record per message
+	jsonLogs bool
+)
+
+// configureLogging sets the log level and format and the matching verbosity
+// for the progress output: debug is verbose, warn and error are quiet
+func configureLogging(level, format string) error {
+	lvl, ok := logLevels[strings.ToLower(level)]
+	if !ok {
+		return fmt.Errorf("invalid log level %q (valid: debug, info, warn, error)", level)
+	}
+	logLevel.Set(lvl)
+
+	switch {
+	case lvl <= slog.LevelDebug:
+		SetVerbosity(VerbosityVerbose)
+	case lvl >= slog.LevelWarn:
+		SetVerbosity(VerbosityQuiet)
+	default:
+		SetVerbosity(VerbosityDefault)
+	}
+
+	switch format {
+	case LogFormatText:
+		jsonLogs = false
+		logger = slog.New(newPlainHandler(os.Stdout, os.Stderr, logLevel))
+	case LogFormatJSON:
+		// Logs go to stderr so commands that print data keep stdout clean
+		jsonLogs = true
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel}))
+	default:
+		return fmt.Errorf("invalid log format %q (valid: %s, %s)", format, LogFormatText, LogFormatJSON)
+	}
+	return nil
+}
+
+// effectiveLogLevel returns the log level from --log-level, --verbose and
+// --quiet. The shorthands can't be combined with each other or with an
+// explicit --log-level.
+func effectiveLogLevel(level string, levelSet, verbose, quiet bool) (string, error) {
+	switch {
+	case verbose && quiet:
+		return "", fmt.Errorf("--quiet and --verbose cannot be used together")
+	case quiet && levelSet:
+		return "", fmt.Errorf("--quiet and --log-level cannot be used together")
+	case quiet:
+		return "warn", nil
+	case verbose && !levelSet:
+		return "debug", nil
+	}
+	return level, nil
+}
+
+// logf formats and logs a message. The terminal output keeps the message
+// exactly as formatted; JSON records get it without surrounding whitespace.
+func logf(level slog.Level, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if jsonLogs {
+		msg = strings.TrimSpace(msg)
+		if msg == "" {
+			return
+		}
+	}
+	logger.Log(context.Background(), level, msg)
+}
+
+// plainHandler is the terminal output: it writes messages verbatim, info and
+// below to stdout and warnings and errors to stderr, with any attributes
+// appended as key=value
+type plainHandler struct {
+	mu     *sync.Mutex
+	stdout io.Writer
+	stderr io.Writer
+	level  slog.Leveler
+	attrs  []slog.Attr
+}
+
+func newPlainHandler(stdout, stderr io.Writer, level slog.Leveler) *plainHandler {
+	return &plainHandler{mu: &sync.Mutex{}, stdout: stdout, stderr: stderr, level: level}
+}
+
+func (h *plainHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	if r.Level == slog.LevelWarn {
+		b.WriteString("Warning: ")
+	}
+	b.WriteString(plainText(r.Message))
+
+	appendAttr := func(a slog.Attr) bool {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+		return true
+	}
+	for _, a := range h.attrs {
+		appendAttr(a)
+	}
+	r.Attrs(appendAttr)
+	if (len(h.attrs) > 0 || r.NumAttrs() > 0) && !strings.HasSuffix(r.Message, "\n") {
+		b.WriteString("\n")
+	}
+
+	w := h.stdout
+	if r.Level >= slog.LevelWarn {
+		w = h.stderr
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup is not needed for terminal output, so groups are flattened
+func (h *plainHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// kindLog routes Kind's output through the output helpers, so cluster
+// creation logs in verbose mode are prefixed and follow --log-format rather
+// than going straight to stderr
+type kindLog struct{}
+
+func (kindLog) Warn(message string) { Warn("kind: %s\n", kindMessage(message)) }
+func (l kindLog) Warnf(format string, args ...interface{}) {
+	l.Warn(fmt.Sprintf(format, args...))
+}
+func (kindLog) Error(message string) { Error("kind: %s\n", kindMessage(message)) }
+func (l kindLog) Errorf(format string, args ...interface{}) {
+	l.Error(fmt.Sprintf(format, args...))
+}
+
+// V returns an info logger for Kind's user facing messages (level 0) in
+// verbose mode; Kind's debug levels are dropped
+func (kindLog) V(level kindlog.Level) kindlog.InfoLogger {
+	return kindInfoLog{enabled: level <= 0 && IsVerbose()}
+}
+
+type kindInfoLog struct {
+	enabled bool
+}
+
+func (l kindInfoLog) Info(message string) {
+	if l.enabled {
+		Verbose("  kind: %s\n", kindMessage(message))
+	}
+}
+func (l kindInfoLog) Infof(format string, args ...interface{}) {
+	l.Info(fmt.Sprintf(format, args...))
+}
+func (l kindInfoLog) Enabled() bool { return l.enabled }
+
+// kindMessage trims the padding and trailing newlines Kind adds for its own
+// terminal output
+func kindMessage(message string) string {
+	return strings.TrimSpace(message)
+}
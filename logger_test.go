@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestPlainHandler(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	level := new(slog.LevelVar)
+	log := slog.New(newPlainHandler(&stdout, &stderr, level))
+
+	log.Debug("hidden\n")
+	log.Info("  ✓ done\n")
+	log.Warn("disk almost full\n")
+	log.Error("failed\n")
+	log.Info("endpoint", "name", "Gatus")
+
+	if got, want := stdout.String(), "  ✓ done\nendpoint name=Gatus\n"; got != want {
+		t.Errorf("unexpected stdout %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "Warning: disk almost full\nfailed\n"; got != want {
+		t.Errorf("unexpected stderr %q, want %q", got, want)
+	}
+}
+
+func TestConfigureLogging(t *testing.T) {
+	t.Cleanup(func() {
+		_ = configureLogging("info", LogFormatText)
+	})
+
+	tests := []struct {
+		level     string
+		verbosity int
+	}{
+		{"debug", VerbosityVerbose},
+		{"INFO", VerbosityDefault},
+		{"warn", VerbosityQuiet},
+		{"error", VerbosityQuiet},
+	}
+	for _, tt := range tests {
+		if err := configureLogging(tt.level, LogFormatText); err != nil {
+			t.Fatalf("configureLogging(%s) failed: %v", tt.level, err)
+		}
+		if GetVerbosity() != tt.verbosity {
+			t.Errorf("expected verbosity %d for %s, got %d", tt.verbosity, tt.level, GetVerbosity())
+		}
+	}
+
+	if err := configureLogging("trace", LogFormatText); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if err := configureLogging("info", "xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestJSONLogs(t *testing.T) {
+	t.Cleanup(func() {
+		_ = configureLogging("info", LogFormatText)
+	})
+	if err := configureLogging("info", LogFormatJSON); err != nil {
+		t.Fatalf("configureLogging failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: logLevel}))
+
+	ProgressStart("🌐", "Network")
+	ProgressDone(true, "Created")
+	BlankLine()
+	Verbose("not logged at info\n")
+
+	var records []map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r map[string]any
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("invalid JSON log: %v", err)
+		}
+		records = append(records, r)
+	}
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d: %v", len(records), records)
+	}
+	if records[0]["msg"] != "starting" || records[0]["step"] != "Network" {
+		t.Errorf("unexpected start record %v", records[0])
+	}
+	if records[1]["msg"] != "done" || records[1]["step"] != "Network" || records[1]["details"] != "Created" {
+		t.Errorf("unexpected done record %v", records[1])
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	t.Cleanup(func() {
+		configureOutput(false, false)
+	})
+
+	tests := []struct {
+		name    string
+		plain   bool
+		noColor bool
+		input   string
+		want    string
+	}{
+		{"unchanged", false, false, "✅ \x1b[32mready\x1b[0m", "✅ \x1b[32mready\x1b[0m"},
+		{"no color", false, true, "✅ \x1b[32mready\x1b[0m", "✅ ready"},
+		{"plain symbols", true, false, "  ✓ done\n  ⚠️  slow\n  ✗ gone\n", "  OK done\n  WARN  slow\n  FAIL gone\n"},
+		{"plain emoji", true, false, "🚀 Starting kinder...\n", "Starting kinder...\n"},
+		{"plain failed", true, false, "Result: ❌ FAILED", "Result: FAILED"},
+		{"plain rules", true, false, "━━━ \x1b[1mStatus\x1b[0m", "--- Status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configureOutput(tt.plain, tt.noColor)
+			if got := plainText(tt.input); got != tt.want {
+				t.Errorf("plainText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainHandlerStripsEmoji(t *testing.T) {
+	t.Cleanup(func() {
+		configureOutput(false, false)
+	})
+	configureOutput(true, false)
+
+	var stdout bytes.Buffer
+	log := slog.New(newPlainHandler(&stdout, &stdout, new(slog.LevelVar)))
+	log.Info("✅ Backup written\n")
+
+	if got, want := stdout.String(), "OK Backup written\n"; got != want {
+		t.Errorf("unexpected stdout %q, want %q", got, want)
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{42*time.Second + 900*time.Millisecond, "42s"},
+		{time.Minute, "1m00s"},
+		{3*time.Minute + 5*time.Second, "3m05s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := formatElapsed(tt.d); got != tt.want {
+				t.Errorf("formatElapsed(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressStartLongWithoutTerminal(t *testing.T) {
+	// Test output is not a terminal, so no spinner is started
+	ProgressStartLong("☸️", "Kind cluster")
+	if progressSpinner != nil {
+		stopSpinner()
+		t.Fatal("expected no spinner when stdout is not a terminal")
+	}
+}
+
+func TestKindLog(t *testing.T) {
+	savedLogger, savedVerbosity := logger, verbosity
+	t.Cleanup(func() {
+		logger, verbosity = savedLogger, savedVerbosity
+	})
+
+	var stdout, stderr bytes.Buffer
+	level := new(slog.LevelVar)
+	level.Set(slog.LevelDebug)
+	logger = slog.New(newPlainHandler(&stdout, &stderr, level))
+	verbosity = VerbosityVerbose
+
+	var l kindLog
+	l.V(0).Infof(" • Ensuring node image (%s) 🖼\n", "kindest/node")
+	l.V(1).Info("debug detail")
+	l.Warn("port mapping overlaps")
+
+	if got, want := stdout.String(), "  kind: • Ensuring node image (kindest/node) 🖼\n"; got != want {
+		t.Errorf("unexpected stdout %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "Warning: kind: port mapping overlaps\n"; got != want {
+		t.Errorf("unexpected stderr %q, want %q", got, want)
+	}
+
+	verbosity = VerbosityDefault
+	if l.V(0).Enabled() {
+		t.Error("expected Kind info logs to be disabled outside verbose mode")
+	}
+}
+
+func TestEffectiveLogLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		levelSet bool
+		verbose  bool
+		quiet    bool
+		want     string
+		wantErr  bool
+	}{
+		{"default", "info", false, false, false, "info", false},
+		{"verbose", "info", false, true, false, "debug", false},
+		{"verbose with log level", "warn", true, true, false, "warn", false},
+		{"quiet", "info", false, false, true, "warn", false},
+		{"quiet and verbose", "info", false, true, true, "", true},
+		{"quiet with log level", "debug", true, false, true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := effectiveLogLevel(tt.level, tt.levelSet, tt.verbose, tt.quiet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("effectiveLogLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("effectiveLogLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultIgnoresLogLevel(t *testing.T) {
+	origOut, origLogger := reportOut, logger
+	t.Cleanup(func() {
+		reportOut, logger = origOut, origLogger
+		_ = configureLogging("info", LogFormatText)
+	})
+	if err := configureLogging("warn", LogFormatText); err != nil {
+		t.Fatalf("configureLogging failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	reportOut = &stdout
+	logger = slog.New(newPlainHandler(&stdout, &stderr, logLevel))
+
+	Output("Pushing image...\n")
+	Result("Digest: %s\n", "sha256:abc")
+
+	if got, want := stdout.String(), "Digest: sha256:abc\n"; got != want {
+		t.Errorf("unexpected stdout %q, want %q", got, want)
+	}
+}
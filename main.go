@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"codeberg.org/hipkoi/kinder/cacert"
@@ -25,27 +27,111 @@ var (
 	dataDir string
 	// Verbose flag for increased output
 	verbose bool
+	// Quiet flag to print only errors and requested data
+	quiet bool
+	// Log level and format (--log-level, --log-format)
+	logLevelFlag  string
+	logFormatFlag string
+	// Plain output without emoji or ANSI codes (--plain, --no-emoji)
+	plainFlag bool
+	// Named environment (can be set with --profile flag)
+	profile string
+	// Base data directory the active profile was derived from
+	profileBaseDir string
 
 	// CLI flag variables (these get bound to Viper)
 	certPath             string
 	keyPath              string
+	caOrganization       string
+	caCommonName         string
 	networkCIDR          string
 	networkName          string
 	stepCAContainerName  string
 	stepCAImage          string
+	stepCANoACME         bool
+	stepCAJWKPassword    string
+	stepCAJWKPassFile    string
+	stepCACRLURL         string
+	stepCACertDuration   time.Duration
+	stepCAMaxDuration    time.Duration
 	zotImage             string
 	zotContainerName     string
+	zotPort              string
 	gatusImage           string
 	gatusContainerName   string
+	gatusExtraEndpoints  []string
+	gatusAlertWebhook    string
 	traefikImage         string
 	traefikContainerName string
 	traefikPort          string
 	traefikDomain        string
+	traefikDashboardPort string
+	traefikHTTPPort      string
+	traefikHTTPRedirect  bool
+	traefikNoDashboard   bool
+	traefikLogLevel      string
+	traefikAccessLog     bool
+	skipTrustBundle      bool
+	skipCertIssuer       bool
+	mozillaCAFile        string
+	mozillaCASHA256      string
+	forcePush            bool
+	artifactPlatforms    []string
+	rollbackOnFailure    bool
+	skipPreflight        bool
+	skipDomainCheck      bool
+	serviceIP            string
+	exposeArgoCD         bool
+
+	// ArgoCD bootstrap flags for start and restart
+	startArgocdRepoURL       string
+	startArgocdRepoPath      string
+	startArgocdRepoBranch    string
+	startArgocdIncludeKinder bool
+	startArgocdGitUsername   string
+	startArgocdGitPassword   string
+	startArgocdGitSSHKey     string
+	pullPolicy               string
+	registryAuthFlags        []string
+	networkIPv6              bool
+	networkIPv6CIDR          string
+	networkOutput            string
+	diagnosticsFix           bool
+	diagnosticsYes           bool
+	diagnosticsTools         bool
+	diagnosticsTimeout       time.Duration
+	clusterKubeconfig        string
+	clusterContext           string
+	waitReady                = true
+	waitTimeout              time.Duration
+	noWait                   bool
+	endpointsOutput          string
+	migrateDataTo            string
+	configDiffAll            bool
+	statusWatch              bool
+	statusInterval           time.Duration
+	statusOutput             string
+	cleanOnly                string
+	backupOut                string
+	backupIn                 string
+	backupSkipRegistry       bool
 )
 
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	// Commands stop at the next cancellation point on Ctrl-C or SIGTERM and
+	// clean up after themselves
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		// Restore the default handling so a second Ctrl-C exits immediately
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if ctx.Err() != nil {
+			os.Exit(130)
+		}
 		os.Exit(1)
 	}
 }
@@ -60,9 +146,15 @@ dashboard, and Traefik reverse proxy.`,
 		DisableDefaultCmd: false,
 	},
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Set verbosity level based on flag
-		if verbose {
-			SetVerbosity(VerbosityVerbose)
+		// Configure logging; --verbose is shorthand for --log-level=debug and
+		// --quiet for --log-level=warn
+		level, err := effectiveLogLevel(logLevelFlag, cmd.Flags().Changed("log-level"), verbose, quiet)
+		if err != nil {
+			return err
+		}
+		configureOutput(plainFlag, os.Getenv("NO_COLOR") != "")
+		if err := configureLogging(level, logFormatFlag); err != nil {
+			return err
 		}
 
 		// Initialize Viper with config file and environment variables
@@ -72,8 +164,30 @@ dashboard, and Traefik reverse proxy.`,
 
 		// Bind CLI flags to Viper (flags take highest precedence)
 		bindFlagsToViper(cmd)
+		if err := applyServiceIP(cmd); err != nil {
+			return err
+		}
 
-		return nil
+		// Apply profile overrides after flags so the profile data dir nests under --data-dir
+		if profile != "" {
+			if err := applyProfile(profile); err != nil {
+				return err
+			}
+		}
+
+		// Validate the merged configuration early. Config commands are exempt
+		// so a broken config file can still be inspected and repaired.
+		if cmd.Parent() != configCmd {
+			cfg, err := config.Get()
+			if err != nil {
+				return fmt.Errorf("failed to get configuration: %w", err)
+			}
+			if err := config.Validate(cfg); err != nil {
+				return err
+			}
+		}
+
+		return applyRegistryAuth()
 	},
 	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 		// Clean up shared Docker client to prevent connection leaks
@@ -145,9 +259,25 @@ PowerShell:
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Start all kinder services",
-	Long:  `Start the network and all kinder service containers in the correct order.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+	Long: `Start the network and all kinder service containers in the correct order.
+
+If interrupted with Ctrl-C, or if a step fails and --rollback-on-failure is
+set, the network, containers and cluster created by this run are removed
+again; ones that already existed are left alone.`,
+	RunE: func(cmd *cobra.Command, args []string) (retErr error) {
+		ctx := cmd.Context()
+
+		tracker := &startupTracker{}
+		defer func() {
+			switch {
+			case retErr == nil:
+			case ctx.Err() != nil:
+				rollbackStart(tracker, "interrupted")
+				retErr = errInterrupted
+			case rollbackOnFailure:
+				rollbackStart(tracker, "start failed")
+			}
+		}()
 
 		// Set defaults for Traefik configuration
 		if traefikPort == "" {
@@ -157,18 +287,42 @@ var startCmd = &cobra.Command{
 			traefikDomain = docker.DefaultTraefikDomain
 		}
 
-		// Get data directory
-		dataDir, err := getDataDir()
+		policy, err := applyPullPolicy()
 		if err != nil {
-			return fmt.Errorf("failed to get data directory: %w", err)
+			return err
+		}
+		if _, err := startGitCredentialType(); err != nil {
+			return err
+		}
+
+		// Fail early with a clear message rather than a Docker bind error
+		if !skipPreflight {
+			ports, err := startHostPorts(ctx)
+			if err != nil {
+				return err
+			}
+			if err := preflightPorts(ports); err != nil {
+				return err
+			}
+		}
+		if err := preflightDomain(ctx); err != nil {
+			return err
 		}
 
 		// Set default cert paths if not provided
 		if certPath == "" {
-			certPath = filepath.Join(dataDir, CACertFilename)
+			path, err := config.CACertPath()
+			if err != nil {
+				return err
+			}
+			certPath = path
 		}
 		if keyPath == "" {
-			keyPath = filepath.Join(dataDir, CAKeyFilename)
+			path, err := config.CAKeyPath()
+			if err != nil {
+				return err
+			}
+			keyPath = path
 		}
 
 		Header("Starting kinder...")
@@ -176,9 +330,26 @@ var startCmd = &cobra.Command{
 			BlankLine()
 		}
 
+		// Pull images up front so downloads show progress rather than
+		// stalling a create step
+		images, err := startImages()
+		if err != nil {
+			return err
+		}
+		if err := prePullImages(ctx, policy, images); err != nil {
+			return fmt.Errorf("failed to pull images: %w", err)
+		}
+
 		// Check if CA certificate exists, generate if not
 		ProgressStart("🔐", "CA certificate")
-		if _, err := os.Stat(certPath); os.IsNotExist(err) {
+		if caURL := externalCAURL(); caURL != "" {
+			// The root of an external Step CA is imported, never generated
+			if _, err := os.Stat(certPath); err != nil {
+				ProgressDone(false, "not imported")
+				return fmt.Errorf("external CA %s is configured but %s is missing. Run 'kinder ca import-step' first", caURL, certPath)
+			}
+			ProgressDone(true, "imported")
+		} else if _, err := os.Stat(certPath); os.IsNotExist(err) {
 			// Ensure the directory exists
 			certDir := filepath.Dir(certPath)
 			if err := os.MkdirAll(certDir, 0755); err != nil {
@@ -193,18 +364,27 @@ var startCmd = &cobra.Command{
 			}
 
 			// Generate the CA certificate with domain constraints
-			if err := cacert.GenerateCAWithDomain(certPath, keyPath, traefikDomain); err != nil {
+			if err := cacert.GenerateCAWithOptions(certPath, keyPath, traefikDomain, caOptions()); err != nil {
 				ProgressDone(false, err.Error())
 				return fmt.Errorf("failed to generate CA certificate: %w", err)
 			}
 			ProgressDone(true, "generated")
 		} else {
+			// Ensure the existing CA can issue certificates for the configured domain
+			if err := cacert.ValidateDomain(certPath, traefikDomain); err != nil {
+				ProgressDone(false, "domain mismatch")
+				return err
+			}
 			ProgressDone(true, "exists")
 		}
 		Verbose("\n")
 
 		// Step 1: Create network
 		ProgressStart("📡", "Network")
+		if err := resolveNetworkCIDR(ctx); err != nil {
+			ProgressDone(false, err.Error())
+			return err
+		}
 		networkExists, err := docker.NetworkExists(ctx, networkName)
 		if err != nil {
 			ProgressDone(false, err.Error())
@@ -212,40 +392,41 @@ var startCmd = &cobra.Command{
 		}
 
 		if !networkExists {
-			netConfig := docker.NetworkConfig{
-				Name:       networkName,
-				CIDR:       networkCIDR,
-				Driver:     "bridge",
-				BridgeName: networkName + "br0",
-			}
-			networkID, err := docker.CreateNetwork(ctx, netConfig)
+			networkID, err := docker.CreateNetwork(ctx, kinderNetworkConfig())
 			if err != nil {
 				ProgressDone(false, err.Error())
 				return fmt.Errorf("failed to create network: %w", err)
 			}
+			tracker.record("network "+networkName, func(ctx context.Context) error {
+				return docker.RemoveNetwork(ctx, networkName)
+			})
 			ProgressDone(true, fmt.Sprintf("Created '%s' (ID: %s, CIDR: %s)", networkName, networkID[:12], networkCIDR))
 		} else {
 			ProgressDone(true, fmt.Sprintf("'%s' exists", networkName))
 		}
 		Verbose("\n")
 
-		// Step 2: Start Step CA
+		// Step 2: Start Step CA, unless an external one is used
 		ProgressStart("🔐", "Step CA")
-		if err := startStepCA(ctx); err != nil {
-			ProgressDone(false, err.Error())
-			return fmt.Errorf("failed to start Step CA: %w", err)
+		if externalCAURL() != "" {
+			ProgressSkip("External CA")
+		} else {
+			if err := tracker.container(ctx, stepCAContainerName, startStepCA, docker.RemoveStepCAContainer); err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to start Step CA: %w", err)
+			}
+			ProgressDone(true, "Running")
 		}
-		ProgressDone(true, "Running")
 		Verbose("\n")
 
 		// Step 3: Start Zot Registry
 		ProgressStart("📦", "Zot Registry")
-		if err := startZot(ctx); err != nil {
+		if err := tracker.container(ctx, zotContainerName, startZot, docker.RemoveZotContainer); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to start Zot: %w", err)
 		}
 		// Wait for Zot to be ready before pushing images
-		if err := docker.WaitForZot(ctx, 30*time.Second); err != nil {
+		if err := docker.WaitForZot(ctx, zotHostPort(), 30*time.Second); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("Zot registry not ready: %w", err)
 		}
@@ -254,25 +435,48 @@ var startCmd = &cobra.Command{
 
 		// Step 3.5: Push trust bundle to registry
 		ProgressStart("🔐", "Trust Bundle")
-		if err := pushTrustBundle(ctx, certPath); err != nil {
-			ProgressDone(false, err.Error())
-			return fmt.Errorf("failed to push trust bundle: %w", err)
+		if config.GetBool(config.KeySkipTrustBundle) {
+			ProgressSkip("Skipped")
+		} else {
+			pushed, err := pushTrustBundle(ctx, certPath)
+			if err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to push trust bundle: %w", err)
+			}
+			ProgressDone(true, pushResult(pushed))
+		}
+		Verbose("\n")
+
+		ProgressStart("🧩", "Trust Manager")
+		if config.GetBool(config.KeySkipTrustBundle) {
+			ProgressSkip("Skipped")
+		} else {
+			pushed, err := pushTrustManagerBundle(ctx, certPath)
+			if err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to push trust-manager bundle: %w", err)
+			}
+			ProgressDone(true, pushResult(pushed))
 		}
-		ProgressDone(true, "Pushed")
 		Verbose("\n")
 
 		// Step 3.6: Push cert-manager issuer to registry
 		ProgressStart("📜", "Cert Issuer")
-		if err := pushCertManagerIssuer(ctx, certPath); err != nil {
-			ProgressDone(false, err.Error())
-			return fmt.Errorf("failed to push cert-manager issuer: %w", err)
+		if config.GetBool(config.KeySkipCertIssuer) {
+			ProgressSkip("Skipped")
+		} else {
+			pushed, err := pushCertManagerIssuer(ctx, certPath)
+			if err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to push cert-manager issuer: %w", err)
+			}
+			ProgressDone(true, pushResult(pushed))
 		}
-		ProgressDone(true, "Pushed")
 		Verbose("\n")
 
 		// Step 4: Start Gatus
 		ProgressStart("📊", "Gatus")
-		if err := startGatus(ctx); err != nil {
+		if err := tracker.container(ctx, gatusContainerName, startGatus, docker.RemoveGatusContainer); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to start Gatus: %w", err)
 		}
@@ -281,16 +485,21 @@ var startCmd = &cobra.Command{
 
 		// Step 5: Start Traefik
 		ProgressStart("🔀", "Traefik")
-		if err := startTraefik(ctx); err != nil {
+		if err := tracker.container(ctx, traefikContainerName, startTraefik, docker.RemoveTraefikContainer); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to start Traefik: %w", err)
 		}
 		ProgressDone(true, "Running")
 		Verbose("\n")
 
+		appName := config.GetString(config.KeyAppName)
+		if appName == "" {
+			appName = config.DefaultAppName
+		}
+
 		// Step 6: Start Kind cluster
-		ProgressStart("☸️", "Kind cluster")
-		if err := startKind(ctx); err != nil {
+		ProgressStartLong("☸️", "Kind cluster")
+		if err := tracker.cluster(ctx, appName, startKind); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to start Kind: %w", err)
 		}
@@ -298,7 +507,7 @@ var startCmd = &cobra.Command{
 		Verbose("\n")
 
 		// Step 7: Bootstrap ArgoCD
-		ProgressStart("🐙", "ArgoCD")
+		ProgressStartLong("🐙", "ArgoCD")
 		if err := bootstrapArgoCD(ctx); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to bootstrap ArgoCD: %w", err)
@@ -307,20 +516,9 @@ var startCmd = &cobra.Command{
 
 		BlankLine()
 
-		appName := config.GetString(config.KeyAppName)
-		if appName == "" {
-			appName = config.DefaultAppName
-		}
-
 		Success("All services started")
 		BlankLine()
-		Header("Endpoints:")
-		ServiceInfo("Traefik", fmt.Sprintf("https://traefik.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Step CA", fmt.Sprintf("https://ca.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Registry", fmt.Sprintf("https://registry.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Gatus", fmt.Sprintf("https://gatus.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Zot (direct)", "http://localhost:5000")
-		ServiceInfo("ArgoCD", "kubectl port-forward svc/argocd-server -n argocd 8080:443")
+		printEndpoints()
 		BlankLine()
 		Output("kubectl cluster-info --context kind-%s\n", appName)
 
@@ -333,7 +531,7 @@ var stopCmd = &cobra.Command{
 	Short: "Stop all kinder services",
 	Long:  `Stop and remove all kinder service containers and network.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		Header("Stopping kinder...")
 		if !IsVerbose() {
@@ -428,13 +626,34 @@ var restartCmd = &cobra.Command{
 	Short: "Restart all kinder services",
 	Long:  `Restart all kinder service containers to apply configuration changes.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		policy, err := applyPullPolicy()
+		if err != nil {
+			return err
+		}
+		if _, err := startGitCredentialType(); err != nil {
+			return err
+		}
+		if err := preflightDomain(ctx); err != nil {
+			return err
+		}
 
 		Header("Restarting kinder...")
 		if !IsVerbose() {
 			BlankLine()
 		}
 
+		// Pull images before stopping anything, so a failed pull doesn't
+		// leave kinder down
+		images, err := startImages()
+		if err != nil {
+			return err
+		}
+		if err := prePullImages(ctx, policy, images); err != nil {
+			return fmt.Errorf("failed to pull images: %w", err)
+		}
+
 		// Stop containers (but not the network)
 		Verbose("Stopping services...\n")
 
@@ -486,13 +705,17 @@ var restartCmd = &cobra.Command{
 		Verbose("Starting services...\n")
 
 		// Start containers with updated configs
-		// Step 1: Start Step CA
+		// Step 1: Start Step CA, unless an external one is used
 		ProgressStart("🔐", "Step CA")
-		if err := startStepCA(ctx); err != nil {
-			ProgressDone(false, err.Error())
-			return fmt.Errorf("failed to start Step CA: %w", err)
+		if externalCAURL() != "" {
+			ProgressSkip("External CA")
+		} else {
+			if err := startStepCA(ctx); err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to start Step CA: %w", err)
+			}
+			ProgressDone(true, "Running")
 		}
-		ProgressDone(true, "Running")
 		Verbose("\n")
 
 		// Step 2: Start Zot Registry
@@ -502,7 +725,7 @@ var restartCmd = &cobra.Command{
 			return fmt.Errorf("failed to start Zot: %w", err)
 		}
 		// Wait for Zot to be ready before pushing images
-		if err := docker.WaitForZot(ctx, 30*time.Second); err != nil {
+		if err := docker.WaitForZot(ctx, zotHostPort(), 30*time.Second); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("Zot registry not ready: %w", err)
 		}
@@ -511,20 +734,43 @@ var restartCmd = &cobra.Command{
 
 		// Step 2.5: Push trust bundle
 		ProgressStart("🔐", "Trust Bundle")
-		if err := pushTrustBundle(ctx, certPath); err != nil {
-			ProgressDone(false, err.Error())
-			return fmt.Errorf("failed to push trust bundle: %w", err)
+		if config.GetBool(config.KeySkipTrustBundle) {
+			ProgressSkip("Skipped")
+		} else {
+			pushed, err := pushTrustBundle(ctx, certPath)
+			if err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to push trust bundle: %w", err)
+			}
+			ProgressDone(true, pushResult(pushed))
+		}
+		Verbose("\n")
+
+		ProgressStart("🧩", "Trust Manager")
+		if config.GetBool(config.KeySkipTrustBundle) {
+			ProgressSkip("Skipped")
+		} else {
+			pushed, err := pushTrustManagerBundle(ctx, certPath)
+			if err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to push trust-manager bundle: %w", err)
+			}
+			ProgressDone(true, pushResult(pushed))
 		}
-		ProgressDone(true, "Pushed")
 		Verbose("\n")
 
 		// Step 2.6: Push cert-manager issuer
 		ProgressStart("📜", "Cert Issuer")
-		if err := pushCertManagerIssuer(ctx, certPath); err != nil {
-			ProgressDone(false, err.Error())
-			return fmt.Errorf("failed to push cert-manager issuer: %w", err)
+		if config.GetBool(config.KeySkipCertIssuer) {
+			ProgressSkip("Skipped")
+		} else {
+			pushed, err := pushCertManagerIssuer(ctx, certPath)
+			if err != nil {
+				ProgressDone(false, err.Error())
+				return fmt.Errorf("failed to push cert-manager issuer: %w", err)
+			}
+			ProgressDone(true, pushResult(pushed))
 		}
-		ProgressDone(true, "Pushed")
 		Verbose("\n")
 
 		// Step 3: Start Gatus
@@ -546,7 +792,7 @@ var restartCmd = &cobra.Command{
 		Verbose("\n")
 
 		// Step 5: Start Kind cluster
-		ProgressStart("☸️", "Kind cluster")
+		ProgressStartLong("☸️", "Kind cluster")
 		if err := startKind(ctx); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to start Kind: %w", err)
@@ -555,7 +801,7 @@ var restartCmd = &cobra.Command{
 		Verbose("\n")
 
 		// Step 6: Bootstrap ArgoCD
-		ProgressStart("🐙", "ArgoCD")
+		ProgressStartLong("🐙", "ArgoCD")
 		if err := bootstrapArgoCD(ctx); err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to bootstrap ArgoCD: %w", err)
@@ -571,13 +817,7 @@ var restartCmd = &cobra.Command{
 
 		Success("All services restarted")
 		BlankLine()
-		Header("Endpoints:")
-		ServiceInfo("Traefik", fmt.Sprintf("https://traefik.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Step CA", fmt.Sprintf("https://ca.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Registry", fmt.Sprintf("https://registry.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Gatus", fmt.Sprintf("https://gatus.%s:%s", traefikDomain, traefikPort))
-		ServiceInfo("Zot (direct)", "http://localhost:5000")
-		ServiceInfo("ArgoCD", "kubectl port-forward svc/argocd-server -n argocd 8080:443")
+		printEndpoints()
 		BlankLine()
 		Output("kubectl cluster-info --context kind-%s\n", appName)
 
@@ -588,9 +828,20 @@ var restartCmd = &cobra.Command{
 var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Remove all kinder data",
-	Long:  `Remove all kinder configuration and data files. This will delete the CA certificate, container data, and all generated configurations.`,
+	Long: `Remove all kinder configuration and data files. This will delete the CA certificate, container data, and all generated configurations.
+
+Use --only to remove part of the data and keep the CA:
+  registry   the Zot registry storage, including the pull-through cache
+  manifests  the saved trust-manager and cert-manager issuer manifests
+  certs-d    the containerd registry configuration for the Kind cluster
+  all        everything (the default)`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
+
+		subdirs, err := cleanSubdirs(cleanOnly)
+		if err != nil {
+			return err
+		}
 
 		cfg, err := buildConfigFromFlags()
 		if err != nil {
@@ -598,13 +849,7 @@ var cleanCmd = &cobra.Command{
 		}
 
 		// Check if any containers are running
-		runningContainers := []string{}
-		for _, name := range cfg.AllContainerNames() {
-			exists, err := docker.ContainerExists(ctx, name)
-			if err == nil && exists {
-				runningContainers = append(runningContainers, name)
-			}
-		}
+		runningContainers := existingContainers(ctx, cfg.AllContainerNames())
 
 		if len(runningContainers) > 0 {
 			Error("❌ Cannot clean while containers are running\n")
@@ -624,6 +869,19 @@ var cleanCmd = &cobra.Command{
 			return nil
 		}
 
+		if cleanOnly != "all" {
+			Section("🧹", "Cleaning kinder "+cleanOnly+" data")
+			for _, subdir := range subdirs {
+				path := filepath.Join(cfg.DataDir, subdir)
+				Verbose("Directory: %s\n", path)
+				if err := os.RemoveAll(path); err != nil {
+					return fmt.Errorf("failed to remove %s: %w", path, err)
+				}
+			}
+			Success("Kinder " + cleanOnly + " data removed successfully!")
+			return nil
+		}
+
 		Section("🧹", "Cleaning kinder data")
 		Verbose("Directory: %s\n", cfg.DataDir)
 		BlankLine()
@@ -639,15 +897,57 @@ var cleanCmd = &cobra.Command{
 	},
 }
 
+// cleanTargets maps clean --only targets to the data subdirectories they
+// remove
+var cleanTargets = map[string][]string{
+	"registry":  {filepath.Join("zot", "data")},
+	"manifests": {"trust-manager-manifests", "cert-manager-issuer"},
+	"certs-d":   {"certs.d"},
+}
+
+// cleanSubdirs returns the data subdirectories removed by clean --only target,
+// or nil for all, which removes the whole data directory
+func cleanSubdirs(target string) ([]string, error) {
+	if target == "all" {
+		return nil, nil
+	}
+	subdirs, ok := cleanTargets[target]
+	if !ok {
+		return nil, fmt.Errorf("invalid clean target %q (valid: registry, manifests, certs-d, all)", target)
+	}
+	return subdirs, nil
+}
+
+// existingContainers returns the named containers that exist. Their bind
+// mounts keep the data directory in use.
+func existingContainers(ctx context.Context, names []string) []string {
+	existing := []string{}
+	for _, name := range names {
+		exists, err := docker.ContainerExists(ctx, name)
+		if err == nil && exists {
+			existing = append(existing, name)
+		}
+	}
+	return existing
+}
+
 func init() {
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Path to config file (default: $XDG_CONFIG_HOME/kinder/config.yaml)")
 	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "Path to data directory (default: $XDG_DATA_HOME/kinder)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Print only errors and requested data, not progress")
+	rootCmd.PersistentFlags().StringVar(&logLevelFlag, "log-level", "info", "Log level (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&logFormatFlag, "log-format", LogFormatText, "Log format (text, json)")
+	rootCmd.PersistentFlags().BoolVar(&plainFlag, "plain", false, "Plain output without emoji or ANSI colours (also honours NO_COLOR for colours)")
+	rootCmd.PersistentFlags().BoolVar(&plainFlag, "no-emoji", false, "Alias for --plain")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Named environment to use (isolates app name, data directory, network and containers)")
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfileNames)
 
 	// Setup flags for generate command
 	generateCmd.Flags().StringVar(&certPath, "cert", "", "Path to save the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
 	generateCmd.Flags().StringVar(&keyPath, "key", "", "Path to save the CA private key (default: $XDG_DATA_HOME/kinder/ca.key)")
+	caSubjectFlags(generateCmd)
 	generateCmd.Flags().StringVar(&traefikDomain, "domain", docker.DefaultTraefikDomain, "Domain for name constraints")
 
 	// Setup flags for print command
@@ -655,18 +955,42 @@ func init() {
 	printCmd.Flags().StringVar(&keyPath, "key", "", "Path to the CA private key (default: $XDG_DATA_HOME/kinder/ca.key)")
 
 	// Add commands to ca
+	caImportStepCmd.Flags().StringVar(&caImportURL, "ca-url", "", "URL of the external Step CA")
+	caImportStepCmd.Flags().StringVar(&caImportFingerprint, "fingerprint", "", "SHA-256 fingerprint of the Step CA root certificate")
+	caImportStepCmd.Flags().BoolVar(&caImportForce, "force", false, "Replace an existing CA certificate (the old certificate and key are kept as .bak)")
+	caImportStepCmd.Flags().StringVar(&certPath, "cert", "", "Path to save the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
+	_ = caImportStepCmd.MarkFlagRequired("ca-url")
+	_ = caImportStepCmd.MarkFlagRequired("fingerprint")
+
+	caTrustCmd.Flags().StringVar(&certPath, "cert", "", "Path to the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
+	caTrustCmd.Flags().BoolVar(&caTrustYes, "yes", false, "Run the privileged commands instead of only printing them")
+	caUntrustCmd.Flags().StringVar(&certPath, "cert", "", "Path to the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
+	caUntrustCmd.Flags().BoolVar(&caTrustYes, "yes", false, "Run the privileged commands instead of only printing them")
+	caCRLCmd.Flags().StringArrayVar(&caCRLRevoke, "revoke", nil, "Serial number (hex) of a certificate to revoke (repeatable)")
+	caCRLCmd.Flags().DurationVar(&caCRLValidity, "validity", 7*24*time.Hour, "How long the generated CRL is valid for")
+
 	caCmd.AddCommand(generateCmd)
 	caCmd.AddCommand(printCmd)
+	caCmd.AddCommand(caImportStepCmd)
+	caCmd.AddCommand(caTrustCmd)
+	caCmd.AddCommand(caUntrustCmd)
+	caCmd.AddCommand(caCRLCmd)
 
 	// Setup flags for network commands
-	networkCreateCmd.Flags().StringVar(&networkCIDR, "cidr", docker.DefaultNetworkCIDR, "CIDR for the network")
+	networkCreateCmd.Flags().StringVar(&networkCIDR, "cidr", docker.DefaultNetworkCIDR, "CIDR for the network, or auto to pick a free private /24")
+	networkCreateCmd.Flags().BoolVar(&networkIPv6, "ipv6", false, "Enable IPv6 on the network")
+	networkCreateCmd.Flags().StringVar(&networkIPv6CIDR, "ipv6-cidr", docker.DefaultNetworkIPv6CIDR, "IPv6 CIDR for the network when --ipv6 is set")
 	networkCreateCmd.Flags().StringVar(&networkName, "name", docker.DefaultNetworkName, "Name of the network")
 
 	networkRemoveCmd.Flags().StringVar(&networkName, "name", docker.DefaultNetworkName, "Name of the network to remove")
 
+	networkInspectCmd.Flags().StringVar(&networkName, "name", docker.DefaultNetworkName, "Name of the network to inspect")
+	networkInspectCmd.Flags().StringVarP(&networkOutput, "output", "o", "table", "Output format: table or json")
+
 	// Add commands to network
 	networkCmd.AddCommand(networkCreateCmd)
 	networkCmd.AddCommand(networkRemoveCmd)
+	networkCmd.AddCommand(networkInspectCmd)
 
 	// Setup flags for Step CA commands
 	stepCAStartCmd.Flags().StringVar(&certPath, "cert", "", "Path to the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
@@ -674,6 +998,13 @@ func init() {
 	stepCAStartCmd.Flags().StringVar(&networkName, "network", docker.DefaultNetworkName, "Docker network name")
 	stepCAStartCmd.Flags().StringVar(&stepCAContainerName, "name", docker.StepCAContainerName, "Container name")
 	stepCAStartCmd.Flags().StringVar(&stepCAImage, "image", docker.StepCAImage, "Step CA Docker image")
+	stepCAStartCmd.Flags().BoolVar(&stepCANoACME, "no-acme", false, "Disable the ACME provisioner (Traefik and the cert-manager issuer need it)")
+	stepCAStartCmd.Flags().StringVar(&stepCAJWKPassword, "jwk-password", "", "Add a kinder-admin JWK provisioner encrypted with this password")
+	stepCAStartCmd.Flags().StringVar(&stepCAJWKPassFile, "jwk-password-file", "", "Read the JWK provisioner password from this file (- for stdin)")
+	stepCAStartCmd.Flags().StringVar(&stepCACRLURL, "crl-url", "", "CRL distribution point URL to embed in the intermediate certificate (see \"kinder ca crl\")")
+	stepCAStartCmd.Flags().DurationVar(&stepCACertDuration, "cert-duration", 0, "Default lifetime of certificates issued by Step CA (default: 24h)")
+	stepCAStartCmd.Flags().DurationVar(&stepCAMaxDuration, "max-cert-duration", 0, "Maximum lifetime of certificates issued by Step CA (default: 24h)")
+	addWaitFlags(stepCAStartCmd)
 
 	stepCAStopCmd.Flags().StringVar(&stepCAContainerName, "name", docker.StepCAContainerName, "Container name")
 
@@ -685,6 +1016,8 @@ func init() {
 	zotStartCmd.Flags().StringVar(&networkName, "network", docker.DefaultNetworkName, "Docker network name")
 	zotStartCmd.Flags().StringVar(&zotContainerName, "name", docker.ZotContainerName, "Container name")
 	zotStartCmd.Flags().StringVar(&zotImage, "image", docker.ZotImage, "Zot Docker image")
+	zotStartCmd.Flags().StringVar(&zotPort, "zot-port", docker.DefaultZotPort, "Zot registry localhost port")
+	addWaitFlags(zotStartCmd)
 
 	zotStopCmd.Flags().StringVar(&zotContainerName, "name", docker.ZotContainerName, "Container name")
 
@@ -696,6 +1029,8 @@ func init() {
 	gatusStartCmd.Flags().StringVar(&networkName, "network", docker.DefaultNetworkName, "Docker network name")
 	gatusStartCmd.Flags().StringVar(&gatusContainerName, "name", docker.GatusContainerName, "Container name")
 	gatusStartCmd.Flags().StringVar(&gatusImage, "image", docker.GatusImage, "Gatus Docker image")
+	gatusStartCmd.Flags().StringArrayVar(&gatusExtraEndpoints, "extra-endpoint", nil, "Extra endpoint for Gatus to monitor as name=url (repeatable)")
+	gatusStartCmd.Flags().StringVar(&gatusAlertWebhook, "alert-webhook", "", "URL Gatus POSTs alerts to")
 
 	gatusStopCmd.Flags().StringVar(&gatusContainerName, "name", docker.GatusContainerName, "Container name")
 
@@ -709,6 +1044,13 @@ func init() {
 	traefikStartCmd.Flags().StringVar(&traefikImage, "image", docker.TraefikImage, "Traefik Docker image")
 	traefikStartCmd.Flags().StringVar(&traefikPort, "port", docker.DefaultTraefikPort, "Localhost HTTPS port")
 	traefikStartCmd.Flags().StringVar(&traefikDomain, "domain", docker.DefaultTraefikDomain, "Base domain for services")
+	traefikStartCmd.Flags().StringVar(&traefikDashboardPort, "dashboard-port", "", "Also publish the dashboard on this localhost port")
+	traefikStartCmd.Flags().StringVar(&traefikHTTPPort, "http-port", docker.DefaultTraefikHTTPPort, "Localhost plain HTTP port (\"\" to not publish it)")
+	traefikStartCmd.Flags().BoolVar(&traefikHTTPRedirect, "http-redirect", true, "Redirect plain HTTP requests to HTTPS")
+	traefikStartCmd.Flags().BoolVar(&traefikNoDashboard, "no-dashboard", false, "Disable the Traefik dashboard and API")
+	traefikStartCmd.Flags().StringVar(&traefikLogLevel, "log-level", docker.DefaultTraefikLogLevel, "Traefik log level (TRACE, DEBUG, INFO, WARN, ERROR)")
+	traefikStartCmd.Flags().BoolVar(&traefikAccessLog, "access-log", false, "Write Traefik access logs to the container output")
+	addWaitFlags(traefikStartCmd)
 
 	traefikStopCmd.Flags().StringVar(&traefikContainerName, "name", docker.TraefikContainerName, "Container name")
 
@@ -725,14 +1067,35 @@ func init() {
 	containerStartCmd.Flags().StringVar(&gatusContainerName, "gatus-name", docker.GatusContainerName, "Gatus container name")
 	containerStartCmd.Flags().StringVar(&traefikContainerName, "traefik-name", docker.TraefikContainerName, "Traefik container name")
 	containerStartCmd.Flags().StringVar(&stepCAImage, "stepca-image", docker.StepCAImage, "Step CA Docker image")
+	containerStartCmd.Flags().BoolVar(&stepCANoACME, "stepca-no-acme", false, "Disable the Step CA ACME provisioner")
+	containerStartCmd.Flags().StringVar(&stepCAJWKPassword, "stepca-jwk-password", "", "Add a kinder-admin JWK provisioner encrypted with this password")
+	containerStartCmd.Flags().StringVar(&stepCAJWKPassFile, "stepca-jwk-password-file", "", "Read the Step CA JWK provisioner password from this file (- for stdin)")
+	containerStartCmd.Flags().StringVar(&stepCACRLURL, "stepca-crl-url", "", "CRL distribution point URL to embed in the Step CA intermediate certificate")
+	containerStartCmd.Flags().DurationVar(&stepCACertDuration, "cert-duration", 0, "Default lifetime of certificates issued by Step CA (default: 24h)")
+	containerStartCmd.Flags().DurationVar(&stepCAMaxDuration, "max-cert-duration", 0, "Maximum lifetime of certificates issued by Step CA (default: 24h)")
 	containerStartCmd.Flags().StringVar(&zotImage, "zot-image", docker.ZotImage, "Zot Docker image")
 	containerStartCmd.Flags().StringVar(&gatusImage, "gatus-image", docker.GatusImage, "Gatus Docker image")
 	containerStartCmd.Flags().StringVar(&traefikImage, "traefik-image", docker.TraefikImage, "Traefik Docker image")
+	containerStartCmd.Flags().StringVar(&traefikDashboardPort, "traefik-dashboard-port", "", "Also publish the Traefik dashboard on this localhost port")
+	containerStartCmd.Flags().StringVar(&zotPort, "zot-port", docker.DefaultZotPort, "Zot registry localhost port")
+	containerStartCmd.Flags().StringVar(&traefikHTTPPort, "traefik-http-port", docker.DefaultTraefikHTTPPort, "Localhost plain HTTP port for Traefik (\"\" to not publish it)")
+	containerStartCmd.Flags().BoolVar(&traefikHTTPRedirect, "traefik-http-redirect", true, "Redirect plain HTTP requests to HTTPS")
+	containerStartCmd.Flags().BoolVar(&traefikNoDashboard, "no-dashboard", false, "Disable the Traefik dashboard and API")
+	containerStartCmd.Flags().StringVar(&traefikLogLevel, "traefik-log-level", docker.DefaultTraefikLogLevel, "Traefik log level (TRACE, DEBUG, INFO, WARN, ERROR)")
+	containerStartCmd.Flags().BoolVar(&traefikAccessLog, "traefik-access-log", false, "Write Traefik access logs to the container output")
+	containerStartCmd.Flags().StringArrayVar(&gatusExtraEndpoints, "gatus-extra-endpoint", nil, "Extra endpoint for Gatus to monitor as name=url (repeatable)")
+	containerStartCmd.Flags().StringVar(&gatusAlertWebhook, "gatus-alert-webhook", "", "URL Gatus POSTs alerts to")
+	addKindFlags(containerStartCmd)
+	containerStartCmd.Flags().BoolVar(&kindRecreateOnImage, "recreate-on-image-change", false, "Recreate an existing Kind cluster whose node image differs from --node-image")
+	containerStartCmd.Flags().StringVar(&pullPolicy, "pull-policy", string(docker.PullMissing), "When to pull service and node images (always, missing, never)")
+	containerStartCmd.Flags().StringArrayVar(&registryAuthFlags, "registry-auth", nil, "Credentials for images under a prefix as prefix=username:password (repeatable; default: ~/.docker/config.json)")
+	addWaitFlags(containerStartCmd)
 
 	containerStopCmd.Flags().StringVar(&stepCAContainerName, "stepca-name", docker.StepCAContainerName, "Step CA container name")
 	containerStopCmd.Flags().StringVar(&zotContainerName, "zot-name", docker.ZotContainerName, "Zot container name")
 	containerStopCmd.Flags().StringVar(&gatusContainerName, "gatus-name", docker.GatusContainerName, "Gatus container name")
 	containerStopCmd.Flags().StringVar(&traefikContainerName, "traefik-name", docker.TraefikContainerName, "Traefik container name")
+	containerStopCmd.Flags().BoolVar(&kindForceDelete, "force", false, "Delete the Kind cluster even if it was not created by kinder")
 
 	// Add commands to container
 	containerCmd.AddCommand(containerStartCmd)
@@ -741,40 +1104,155 @@ func init() {
 	// Setup flags for start command
 	startCmd.Flags().StringVar(&certPath, "cert", "", "Path to the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
 	startCmd.Flags().StringVar(&keyPath, "key", "", "Path to the CA private key (default: $XDG_DATA_HOME/kinder/ca.key)")
+	caSubjectFlags(startCmd)
 	startCmd.Flags().StringVar(&networkName, "network", docker.DefaultNetworkName, "Docker network name")
-	startCmd.Flags().StringVar(&networkCIDR, "cidr", docker.DefaultNetworkCIDR, "Network CIDR")
+	startCmd.Flags().StringVar(&networkCIDR, "cidr", docker.DefaultNetworkCIDR, "Network CIDR, or auto to pick a free private /24")
+	startCmd.Flags().BoolVar(&networkIPv6, "ipv6", false, "Enable IPv6 on the network and make the Kind cluster dual-stack")
+	startCmd.Flags().StringVar(&networkIPv6CIDR, "ipv6-cidr", docker.DefaultNetworkIPv6CIDR, "Network IPv6 CIDR when --ipv6 is set")
 	startCmd.Flags().StringVar(&traefikPort, "traefik-port", docker.DefaultTraefikPort, "Traefik localhost HTTPS port")
 	startCmd.Flags().StringVar(&traefikDomain, "traefik-domain", docker.DefaultTraefikDomain, "Traefik base domain for services")
-	startCmd.Flags().IntVar(&kindWorkerNodes, "workers", 0, "Number of Kind worker nodes (0 = control-plane only)")
-	startCmd.Flags().StringVar(&kindNodeImage, "node-image", kubernetes.KindNodeImage, "Kind node image to use")
+	startCmd.Flags().StringVar(&stepCAJWKPassword, "stepca-jwk-password", "", "Add a kinder-admin JWK provisioner to Step CA encrypted with this password")
+	startCmd.Flags().StringVar(&stepCAJWKPassFile, "stepca-jwk-password-file", "", "Read the Step CA JWK provisioner password from this file (- for stdin)")
+	startCmd.Flags().StringVar(&stepCACRLURL, "stepca-crl-url", "", "CRL distribution point URL to embed in the Step CA intermediate certificate")
+	startCmd.Flags().DurationVar(&stepCACertDuration, "cert-duration", 0, "Default lifetime of certificates issued by Step CA (default: 24h)")
+	startCmd.Flags().DurationVar(&stepCAMaxDuration, "max-cert-duration", 0, "Maximum lifetime of certificates issued by Step CA (default: 24h)")
+	startCmd.Flags().StringVar(&traefikDashboardPort, "traefik-dashboard-port", "", "Also publish the Traefik dashboard on this localhost port")
+	startCmd.Flags().StringVar(&zotPort, "zot-port", docker.DefaultZotPort, "Zot registry localhost port")
+	startCmd.Flags().StringVar(&traefikHTTPPort, "traefik-http-port", docker.DefaultTraefikHTTPPort, "Localhost plain HTTP port for Traefik (\"\" to not publish it)")
+	startCmd.Flags().BoolVar(&traefikHTTPRedirect, "traefik-http-redirect", true, "Redirect plain HTTP requests to HTTPS")
+	startCmd.Flags().BoolVar(&traefikNoDashboard, "no-dashboard", false, "Disable the Traefik dashboard and API")
+	startCmd.Flags().StringVar(&traefikLogLevel, "traefik-log-level", docker.DefaultTraefikLogLevel, "Traefik log level (TRACE, DEBUG, INFO, WARN, ERROR)")
+	startCmd.Flags().BoolVar(&traefikAccessLog, "traefik-access-log", false, "Write Traefik access logs to the container output")
+	addKindFlags(startCmd)
+	startCmd.Flags().BoolVar(&kindRecreateOnImage, "recreate-on-image-change", false, "Recreate an existing Kind cluster whose node image differs from --node-image")
+	startCmd.Flags().BoolVar(&skipTrustBundle, "skip-trust-bundle", false, "Skip pushing the trust bundle to the registry (for offline use)")
+	startCmd.Flags().BoolVar(&skipCertIssuer, "skip-cert-issuer", false, "Skip pushing the cert-manager issuer to the registry")
+	startCmd.Flags().StringVar(&mozillaCAFile, "mozilla-ca-file", "", "Local Mozilla CA bundle (cacert.pem) to use instead of downloading")
+	startCmd.Flags().StringVar(&mozillaCASHA256, "mozilla-ca-sha256", "", "Expected SHA-256 of the Mozilla CA bundle")
+	startCmd.Flags().BoolVar(&forcePush, "force-push", false, "Push bundles even if the registry already has identical content")
+	startCmd.Flags().StringArrayVar(&artifactPlatforms, "artifact-platform", nil, "Platform to build bundle images for as os/arch[/variant] (repeatable; several push an image index)")
+	startCmd.Flags().StringArrayVar(&gatusExtraEndpoints, "gatus-extra-endpoint", nil, "Extra endpoint for Gatus to monitor as name=url (repeatable)")
+	startCmd.Flags().StringVar(&gatusAlertWebhook, "gatus-alert-webhook", "", "URL Gatus POSTs alerts to")
+	startCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Remove the network, containers and cluster created by this run if a step fails")
+	startCmd.Flags().BoolVar(&skipPreflight, "skip-preflight", false, "Skip checking that the host ports kinder binds are free")
+	startCmd.Flags().BoolVar(&skipDomainCheck, "skip-domain-check", false, "Skip checking that the domain resolves to a routable address")
+	startCmd.Flags().StringVar(&serviceIP, "service-ip", "", "IPv4 address services are reached on; sets the domain to its sslip.io name")
+	startCmd.Flags().BoolVar(&exposeArgoCD, "expose-argocd", false, "Serve the ArgoCD UI at https://argocd.<domain> through Traefik")
+	argocdStartFlags(startCmd)
+	startCmd.Flags().StringVar(&pullPolicy, "pull-policy", string(docker.PullMissing), "When to pull service and node images (always, missing, never)")
+	startCmd.Flags().StringArrayVar(&registryAuthFlags, "registry-auth", nil, "Credentials for images under a prefix as prefix=username:password (repeatable; default: ~/.docker/config.json)")
 
 	// Setup flags for stop command
 	stopCmd.Flags().StringVar(&networkName, "network", docker.DefaultNetworkName, "Docker network name")
+	stopCmd.Flags().BoolVar(&kindForceDelete, "force", false, "Delete the Kind cluster even if it was not created by kinder")
 
 	// Setup flags for restart command
 	restartCmd.Flags().StringVar(&certPath, "cert", "", "Path to the CA certificate (default: $XDG_DATA_HOME/kinder/ca.crt)")
 	restartCmd.Flags().StringVar(&keyPath, "key", "", "Path to the CA private key (default: $XDG_DATA_HOME/kinder/ca.key)")
+	caSubjectFlags(restartCmd)
 	restartCmd.Flags().StringVar(&networkName, "network", docker.DefaultNetworkName, "Docker network name")
-	restartCmd.Flags().StringVar(&networkCIDR, "cidr", docker.DefaultNetworkCIDR, "Network CIDR")
+	restartCmd.Flags().StringVar(&networkCIDR, "cidr", docker.DefaultNetworkCIDR, "Network CIDR, or auto to pick a free private /24")
 	restartCmd.Flags().StringVar(&traefikPort, "traefik-port", docker.DefaultTraefikPort, "Traefik localhost HTTPS port")
 	restartCmd.Flags().StringVar(&traefikDomain, "traefik-domain", docker.DefaultTraefikDomain, "Traefik base domain for services")
-	restartCmd.Flags().IntVar(&kindWorkerNodes, "workers", 0, "Number of Kind worker nodes (0 = control-plane only)")
-	restartCmd.Flags().StringVar(&kindNodeImage, "node-image", kubernetes.KindNodeImage, "Kind node image to use")
+	restartCmd.Flags().BoolVar(&skipDomainCheck, "skip-domain-check", false, "Skip checking that the domain resolves to a routable address")
+	restartCmd.Flags().StringVar(&serviceIP, "service-ip", "", "IPv4 address services are reached on; sets the domain to its sslip.io name")
+	restartCmd.Flags().BoolVar(&exposeArgoCD, "expose-argocd", false, "Serve the ArgoCD UI at https://argocd.<domain> through Traefik")
+	argocdStartFlags(restartCmd)
+	restartCmd.Flags().StringVar(&stepCAJWKPassword, "stepca-jwk-password", "", "Add a kinder-admin JWK provisioner to Step CA encrypted with this password")
+	restartCmd.Flags().StringVar(&stepCAJWKPassFile, "stepca-jwk-password-file", "", "Read the Step CA JWK provisioner password from this file (- for stdin)")
+	restartCmd.Flags().StringVar(&stepCACRLURL, "stepca-crl-url", "", "CRL distribution point URL to embed in the Step CA intermediate certificate")
+	restartCmd.Flags().DurationVar(&stepCACertDuration, "cert-duration", 0, "Default lifetime of certificates issued by Step CA (default: 24h)")
+	restartCmd.Flags().DurationVar(&stepCAMaxDuration, "max-cert-duration", 0, "Maximum lifetime of certificates issued by Step CA (default: 24h)")
+	restartCmd.Flags().StringVar(&traefikDashboardPort, "traefik-dashboard-port", "", "Also publish the Traefik dashboard on this localhost port")
+	restartCmd.Flags().StringVar(&zotPort, "zot-port", docker.DefaultZotPort, "Zot registry localhost port")
+	restartCmd.Flags().StringVar(&traefikHTTPPort, "traefik-http-port", docker.DefaultTraefikHTTPPort, "Localhost plain HTTP port for Traefik (\"\" to not publish it)")
+	restartCmd.Flags().BoolVar(&traefikHTTPRedirect, "traefik-http-redirect", true, "Redirect plain HTTP requests to HTTPS")
+	restartCmd.Flags().BoolVar(&traefikNoDashboard, "no-dashboard", false, "Disable the Traefik dashboard and API")
+	restartCmd.Flags().StringVar(&traefikLogLevel, "traefik-log-level", docker.DefaultTraefikLogLevel, "Traefik log level (TRACE, DEBUG, INFO, WARN, ERROR)")
+	restartCmd.Flags().BoolVar(&traefikAccessLog, "traefik-access-log", false, "Write Traefik access logs to the container output")
+	addKindFlags(restartCmd)
+	restartCmd.Flags().BoolVar(&skipTrustBundle, "skip-trust-bundle", false, "Skip pushing the trust bundle to the registry (for offline use)")
+	restartCmd.Flags().BoolVar(&skipCertIssuer, "skip-cert-issuer", false, "Skip pushing the cert-manager issuer to the registry")
+	restartCmd.Flags().StringVar(&mozillaCAFile, "mozilla-ca-file", "", "Local Mozilla CA bundle (cacert.pem) to use instead of downloading")
+	restartCmd.Flags().StringVar(&mozillaCASHA256, "mozilla-ca-sha256", "", "Expected SHA-256 of the Mozilla CA bundle")
+	restartCmd.Flags().BoolVar(&forcePush, "force-push", false, "Push bundles even if the registry already has identical content")
+	restartCmd.Flags().StringArrayVar(&artifactPlatforms, "artifact-platform", nil, "Platform to build bundle images for as os/arch[/variant] (repeatable; several push an image index)")
+	restartCmd.Flags().BoolVar(&kindForceDelete, "force", false, "Delete the Kind cluster even if it was not created by kinder")
+	restartCmd.Flags().StringArrayVar(&gatusExtraEndpoints, "gatus-extra-endpoint", nil, "Extra endpoint for Gatus to monitor as name=url (repeatable)")
+	restartCmd.Flags().StringVar(&gatusAlertWebhook, "gatus-alert-webhook", "", "URL Gatus POSTs alerts to")
+	restartCmd.Flags().StringVar(&pullPolicy, "pull-policy", string(docker.PullMissing), "When to pull service and node images (always, missing, never)")
+	restartCmd.Flags().StringArrayVar(&registryAuthFlags, "registry-auth", nil, "Credentials for images under a prefix as prefix=username:password (repeatable; default: ~/.docker/config.json)")
+
+	// Setup flags for config migrate-data command
+	configMigrateDataCmd.Flags().StringVar(&migrateDataTo, "to", "", "New data directory (must not exist or be empty)")
+	_ = configMigrateDataCmd.MarkFlagRequired("to")
+
+	// Setup flags for config diff command
+	configDiffCmd.Flags().BoolVar(&configDiffAll, "all", false, "List every key, including those left at their defaults")
 
 	// Add commands to config
 	configCmd.AddCommand(configShowCmd)
 	configCmd.AddCommand(configPathCmd)
 	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configDiffCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateDataCmd)
 
 	// Setup flags for Kind commands
-	kindStartCmd.Flags().IntVar(&kindWorkerNodes, "workers", 0, "Number of worker nodes (0 = control-plane only)")
-	kindStartCmd.Flags().StringVar(&kindNodeImage, "node-image", kubernetes.KindNodeImage, "Kind node image to use")
+	addKindFlags(kindStartCmd)
+	kindStartCmd.Flags().BoolVar(&kindRecreateOnImage, "recreate-on-image-change", false, "Recreate an existing Kind cluster whose node image differs from --node-image")
+	kindStopCmd.Flags().BoolVar(&kindForceDelete, "force", false, "Delete the Kind cluster even if it was not created by kinder")
+	addKindFlags(kindRecreateCmd)
+	kindRecreateCmd.Flags().BoolVar(&kindPreserveWorkload, "preserve-workloads", false, "Save workloads before deleting the cluster and re-apply them afterwards")
+	kindRecreateCmd.Flags().BoolVar(&kindForceDelete, "force", false, "Recreate the Kind cluster even if it was not created by kinder")
+	kindKubeconfigCmd.Flags().BoolVar(&kindKubeconfigMinify, "minify", false, "Only include the Kind cluster's context, cluster and user")
+	kindKubeconfigCmd.Flags().BoolVar(&kindKubeconfigFlat, "flatten", false, "Inline referenced certificate and key files")
+	kindPullSecretCmd.Flags().StringSliceVarP(&pullSecretNamespaces, "namespace", "n", nil, "Namespace to create the secret in (repeatable)")
+	kindPullSecretCmd.Flags().BoolVar(&pullSecretAllNS, "all-namespaces", false, "Create the secret in every namespace")
+	kindPullSecretCmd.Flags().StringVar(&pullSecretName, "name", kubernetes.ImagePullSecretName, "Name of the secret")
+	kindPullSecretCmd.Flags().BoolVar(&pullSecretPatchSA, "patch-service-account", false, "Add the secret to each namespace's default ServiceAccount")
 
 	// Add commands to kind
 	kindCmd.AddCommand(kindStartCmd)
 	kindCmd.AddCommand(kindStopCmd)
+	kindCmd.AddCommand(kindRecreateCmd)
 	kindCmd.AddCommand(kindStatusCmd)
 	kindCmd.AddCommand(kindKubeconfigCmd)
+	kindCmd.AddCommand(kindDumpCmd)
+	kindCmd.AddCommand(kindPullSecretCmd)
+
+	// Add commands to profile
+	profileCmd.AddCommand(profileListCmd)
+
+	// Setup flags for status command
+	statusCmd.Flags().BoolVarP(&statusWatch, "watch", "w", false, "Refresh the status report until Ctrl-C")
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", "text", "Output format: text or json (container details)")
+	statusCmd.Flags().DurationVar(&statusInterval, "interval", 5*time.Second, "Refresh interval for --watch")
+	statusCmd.Flags().StringVar(&clusterKubeconfig, "kubeconfig", "", "Path to kubeconfig file for the ArgoCD status")
+	statusCmd.Flags().StringVar(&clusterContext, "context", "", "Kubernetes context for the ArgoCD status (default: kind-<cluster>)")
+
+	// Setup flags for endpoints command
+	endpointsCmd.Flags().StringVarP(&endpointsOutput, "output", "o", "text", "Output format: text, json or env")
+
+	// Setup flags for clean command
+	cleanCmd.Flags().StringVar(&cleanOnly, "only", "all", "Remove only part of the data: registry, manifests, certs-d or all")
+
+	// Setup flags for backup and restore commands
+	backupCmd.Flags().StringVar(&backupOut, "out", "kinder-backup.tar.gz", "Backup archive to write")
+	backupCmd.Flags().BoolVar(&backupSkipRegistry, "skip-registry", false, "Leave out the Zot registry storage")
+	restoreCmd.Flags().StringVar(&backupIn, "in", "", "Backup archive to restore")
+	_ = restoreCmd.MarkFlagRequired("in")
+
+	// Setup flags for diagnostics command
+	diagnosticsCmd.Flags().BoolVar(&diagnosticsFix, "fix", false, "Fix failed checks that kinder can remediate")
+	diagnosticsCmd.Flags().BoolVar(&diagnosticsYes, "yes", false, "Apply fixes without asking for confirmation")
+	diagnosticsCmd.Flags().BoolVar(&diagnosticsTools, "tools", false, "List the external tools kinder uses and whether each is installed")
+	diagnosticsCmd.Flags().DurationVar(&diagnosticsTimeout, "timeout", 60*time.Second, "Maximum time for the whole diagnostics run (0 for no limit; no limit by default with --fix)")
+	diagnosticsCmd.Flags().StringVar(&clusterKubeconfig, "kubeconfig", "", "Path to kubeconfig file for the Kubernetes checks")
+	diagnosticsCmd.Flags().StringVar(&clusterContext, "context", "", "Kubernetes context for the Kubernetes checks (default: kind-<cluster>)")
 
 	// Add all commands to root
 	rootCmd.AddCommand(startCmd)
@@ -783,13 +1261,18 @@ func init() {
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(cleanCmd)
 	rootCmd.AddCommand(diagnosticsCmd)
+	rootCmd.AddCommand(endpointsCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
 	rootCmd.AddCommand(caCmd)
 	rootCmd.AddCommand(networkCmd)
 	rootCmd.AddCommand(containerCmd)
 	rootCmd.AddCommand(kindCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(profileCmd)
 	rootCmd.AddCommand(trustBundleCmd)
 	rootCmd.AddCommand(certIssuerCmd)
+	rootCmd.AddCommand(registryCmd)
 	rootCmd.AddCommand(argocdCmd)
 	rootCmd.AddCommand(completionCmd)
 }
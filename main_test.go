@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"github.com/spf13/cobra"
 )
 
 // TestMain sets up a separate data directory for tests to avoid
@@ -83,3 +88,276 @@ func TestGetDataDirForAppWithXDG(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, dataDir)
 	}
 }
+
+func TestValidateProfileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		wantErr bool
+	}{
+		{"simple", "dev", false},
+		{"with hyphen and digits", "team-2", false},
+		{"uppercase", "Dev", true},
+		{"leading hyphen", "-dev", true},
+		{"path separator", "dev/test", true},
+		{"too long for bridge name", "abcdefghijklm", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProfileName(tt.profile)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateProfileName(%q) error = %v, wantErr %v", tt.profile, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	baseDir := t.TempDir()
+
+	// No profiles yet
+	profiles, err := listProfiles(baseDir)
+	if err != nil {
+		t.Fatalf("listProfiles failed: %v", err)
+	}
+	if len(profiles) != 0 {
+		t.Errorf("expected no profiles, got %v", profiles)
+	}
+
+	for _, name := range []string{"staging", "dev"} {
+		if err := ensureProfileDataDir(profileDataDir(baseDir, name)); err != nil {
+			t.Fatalf("failed to create profile dir: %v", err)
+		}
+	}
+	// Files and the default environment's own directories are ignored
+	if err := os.MkdirAll(filepath.Join(baseDir, "snapshots"), 0755); err != nil {
+		t.Fatalf("failed to create snapshots dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(baseDir, "ca.crt"), nil, 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	profiles, err = listProfiles(baseDir)
+	if err != nil {
+		t.Fatalf("listProfiles failed: %v", err)
+	}
+
+	expected := []string{"dev", "staging"}
+	if len(profiles) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, profiles)
+	}
+	for i := range expected {
+		if profiles[i] != expected[i] {
+			t.Errorf("expected profile %q at index %d, got %q", expected[i], i, profiles[i])
+		}
+	}
+}
+
+func TestEnsureProfileDataDir(t *testing.T) {
+	baseDir := t.TempDir()
+
+	dir := profileDataDir(baseDir, "dev")
+	if err := ensureProfileDataDir(dir); err != nil {
+		t.Fatalf("ensureProfileDataDir failed: %v", err)
+	}
+	if !isProfileDir(dir) {
+		t.Errorf("expected %s to be marked as a profile", dir)
+	}
+	// An existing profile is reused
+	if err := ensureProfileDataDir(dir); err != nil {
+		t.Errorf("ensureProfileDataDir on an existing profile failed: %v", err)
+	}
+
+	// A directory of the default environment is not taken over
+	snapshots := profileDataDir(baseDir, "snapshots")
+	if err := os.MkdirAll(snapshots, 0755); err != nil {
+		t.Fatalf("failed to create snapshots dir: %v", err)
+	}
+	if err := ensureProfileDataDir(snapshots); err == nil {
+		t.Error("expected an error for a directory that is not a profile")
+	}
+}
+
+func TestProfileUsesAutoCIDR(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	if err := config.Initialize(""); err != nil {
+		t.Fatalf("failed to initialize config: %v", err)
+	}
+	saved := networkCIDR
+	t.Cleanup(func() { networkCIDR = saved })
+
+	networkCIDR = docker.DefaultNetworkCIDR
+	if !profileUsesAutoCIDR() {
+		t.Error("expected the default CIDR to be replaced with auto")
+	}
+
+	networkCIDR = "10.10.0.0/24"
+	if profileUsesAutoCIDR() {
+		t.Error("expected an explicit --cidr to be kept")
+	}
+
+	networkCIDR = docker.DefaultNetworkCIDR
+	t.Setenv("KINDER_NETWORK_CIDR", "10.20.0.0/24")
+	if profileUsesAutoCIDR() {
+		t.Error("expected a CIDR from the environment to be kept")
+	}
+}
+
+func TestKubeTarget(t *testing.T) {
+	t.Cleanup(func() { clusterKubeconfig, clusterContext = "", "" })
+
+	tests := []struct {
+		name       string
+		kubeconfig string
+		context    string
+		want       []string
+	}{
+		{name: "defaults to the kind context", want: []string{"kubectl", "--context", "kind-kinder", "get", "pods"}},
+		{name: "context", context: "dev", want: []string{"kubectl", "--context", "dev", "get", "pods"}},
+		{
+			name:       "kubeconfig and context",
+			kubeconfig: "/tmp/kubeconfig",
+			context:    "dev",
+			want:       []string{"kubectl", "--kubeconfig", "/tmp/kubeconfig", "--context", "dev", "get", "pods"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterKubeconfig, clusterContext = tt.kubeconfig, tt.context
+			cmd := clusterTarget("kinder").kubectl(context.Background(), "get", "pods")
+			if !slices.Equal(cmd.Args, tt.want) {
+				t.Errorf("got %v, want %v", cmd.Args, tt.want)
+			}
+		})
+	}
+}
+
+func TestKindFlags(t *testing.T) {
+	for _, cmd := range []*cobra.Command{startCmd, restartCmd, containerStartCmd, kindStartCmd, kindRecreateCmd} {
+		t.Run(cmd.CommandPath(), func(t *testing.T) {
+			for _, name := range []string{"kind-timeout", "node-image", "k8s-version", "workers", "cni"} {
+				if cmd.Flags().Lookup(name) == nil {
+					t.Errorf("expected --%s to be registered", name)
+				}
+			}
+		})
+	}
+}
+
+func TestStepCAJWKProvisionerPassword(t *testing.T) {
+	t.Cleanup(func() { stepCAJWKPassword, stepCAJWKPassFile = "", "" })
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(emptyFile, nil, 0600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		file     string
+		stdin    string
+		want     string
+		wantErr  bool
+	}{
+		{name: "flag", password: "from-flag", want: "from-flag"},
+		{name: "file", file: passwordFile, want: "from-file"},
+		{name: "stdin", file: "-", stdin: "from-stdin\r\n", want: "from-stdin"},
+		{name: "none", want: ""},
+		{name: "flag and file", password: "from-flag", file: passwordFile, wantErr: true},
+		{name: "empty file", file: emptyFile, wantErr: true},
+		{name: "missing file", file: filepath.Join(t.TempDir(), "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stepCAJWKPassword, stepCAJWKPassFile = tt.password, tt.file
+			got, err := stepCAJWKProvisionerPassword(strings.NewReader(tt.stdin))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWaitFlags(t *testing.T) {
+	t.Cleanup(func() { waitReady, noWait, waitTimeout = true, false, defaultWaitTimeout })
+
+	tests := []struct {
+		args     []string
+		wantWait bool
+		wantErr  bool
+	}{
+		{nil, true, false},
+		{[]string{"--no-wait"}, false, false},
+		{[]string{"--wait=false"}, false, false},
+		{[]string{"--wait", "--no-wait"}, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := &cobra.Command{Use: "start"}
+			addWaitFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("ParseFlags failed: %v", err)
+			}
+			err := cmd.ValidateFlagGroups()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateFlagGroups error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && waitForReady() != tt.wantWait {
+				t.Errorf("waitForReady() = %v, want %v", waitForReady(), tt.wantWait)
+			}
+			if waitTimeout != defaultWaitTimeout {
+				t.Errorf("expected default wait timeout %s, got %s", defaultWaitTimeout, waitTimeout)
+			}
+		})
+	}
+}
+
+func TestCleanSubdirs(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    []string
+		wantErr bool
+	}{
+		{"registry", []string{filepath.Join("zot", "data")}, false},
+		{"manifests", []string{"trust-manager-manifests", "cert-manager-issuer"}, false},
+		{"certs-d", []string{"certs.d"}, false},
+		{"all", nil, false},
+		{"cache", nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			got, err := cleanSubdirs(tt.target)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanSubdirs(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("cleanSubdirs(%q) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanRejectsUnknownTarget(t *testing.T) {
+	saved := cleanOnly
+	t.Cleanup(func() { cleanOnly = saved })
+
+	// The target is checked before any container or data directory is touched
+	cleanOnly = "everything"
+	err := cleanCmd.RunE(cleanCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), `invalid clean target "everything"`) {
+		t.Errorf("expected an invalid clean target error, got %v", err)
+	}
+}
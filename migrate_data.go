@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// runSubcommand runs another command's RunE with the current context
+func runSubcommand(ctx context.Context, c *cobra.Command) error {
+	c.SetContext(ctx)
+	return c.RunE(c, nil)
+}
+
+// checkMigrateTarget checks that src exists and dst is a new or empty
+// directory outside src
+func checkMigrateTarget(src, dst string) error {
+	if _, err := os.Stat(src); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("data directory %s does not exist", src)
+		}
+		return fmt.Errorf("failed to access data directory: %w", err)
+	}
+	if isWithin(src, dst) {
+		return fmt.Errorf("%s is inside the data directory %s", dst, src)
+	}
+
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read %s: %w", dst, err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dst)
+	}
+	return nil
+}
+
+// isWithin reports whether path is dir or inside it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// moveDir renames src to dst, falling back to copying and removing src
+// when they are on different filesystems
+func moveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dst), err)
+	}
+	// An empty target directory is replaced
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace %s: %w", dst, err)
+	}
+
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return fmt.Errorf("failed to move data directory: %w", err)
+	}
+
+	if err := copyDir(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return fmt.Errorf("failed to copy data directory: %w", err)
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("data copied, but failed to remove %s: %w", src, err)
+	}
+	return nil
+}
+
+// copyDir copies a directory tree, preserving file modes and symlinks
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFileMode(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// copyFileMode copies a regular file, creating dst with mode
+func copyFileMode(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckMigrateTarget(t *testing.T) {
+	src := t.TempDir()
+	parent := t.TempDir()
+	nonEmpty := filepath.Join(parent, "full")
+	if err := os.MkdirAll(filepath.Join(nonEmpty, "zot"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		src     string
+		dst     string
+		wantErr bool
+	}{
+		{"new directory", src, filepath.Join(parent, "new"), false},
+		{"empty directory", src, t.TempDir(), false},
+		{"non-empty directory", src, nonEmpty, true},
+		{"inside source", src, filepath.Join(src, "nested"), true},
+		{"missing source", filepath.Join(parent, "missing"), filepath.Join(parent, "new"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMigrateTarget(tt.src, tt.dst)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMigrateTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCopyDir(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "zot", "cache"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, CAKeyFilename), []byte("key"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := os.Symlink(CAKeyFilename, filepath.Join(src, "link")); err != nil {
+		t.Fatalf("failed to create symlink: %v", err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "moved")
+	if err := copyDir(src, dst); err != nil {
+		t.Fatalf("copyDir failed: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(dst, CAKeyFilename))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected CA key copied with mode 0600, got %v (%v)", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "zot", "cache")); err != nil {
+		t.Errorf("expected nested directory to be copied: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != CAKeyFilename {
+		t.Errorf("expected symlink to be preserved, got %q (%v)", link, err)
+	}
+}
@@ -2,8 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
 
+	"codeberg.org/hipkoi/kinder/config"
 	"codeberg.org/hipkoi/kinder/docker"
 	"github.com/spf13/cobra"
 )
@@ -19,7 +25,7 @@ var networkCreateCmd = &cobra.Command{
 	Short: "Create Docker network",
 	Long:  `Create a Docker network for kinder services with configurable CIDR.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Check if network already exists
 		exists, err := docker.NetworkExists(ctx, networkName)
@@ -28,27 +34,29 @@ var networkCreateCmd = &cobra.Command{
 		}
 
 		if exists {
-			fmt.Printf("Network '%s' already exists\n", networkName)
+			Output("Network '%s' already exists\n", networkName)
 			return nil
 		}
 
-		// Create network
-		config := docker.NetworkConfig{
-			Name:       networkName,
-			CIDR:       networkCIDR,
-			Driver:     "bridge",
-			BridgeName: networkName + "br0",
+		if err := resolveNetworkCIDR(ctx); err != nil {
+			return err
 		}
 
-		networkID, err := docker.CreateNetwork(ctx, config)
+		// Create network
+		netConfig := kinderNetworkConfig()
+
+		networkID, err := docker.CreateNetwork(ctx, netConfig)
 		if err != nil {
 			return fmt.Errorf("failed to create network: %w", err)
 		}
 
-		fmt.Printf("Network created successfully:\n")
-		fmt.Printf("  Name: %s\n", networkName)
-		fmt.Printf("  CIDR: %s\n", networkCIDR)
-		fmt.Printf("  ID: %s\n", networkID)
+		Output("Network created successfully:\n")
+		Output("  Name: %s\n", networkName)
+		Output("  CIDR: %s\n", networkCIDR)
+		if netConfig.EnableIPv6 {
+			Output("  IPv6 CIDR: %s\n", netConfig.IPv6CIDR)
+		}
+		Output("  ID: %s\n", networkID)
 
 		return nil
 	},
@@ -59,7 +67,7 @@ var networkRemoveCmd = &cobra.Command{
 	Short: "Remove Docker network",
 	Long:  `Remove the Docker network used by kinder.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Check if network exists
 		exists, err := docker.NetworkExists(ctx, networkName)
@@ -68,7 +76,7 @@ var networkRemoveCmd = &cobra.Command{
 		}
 
 		if !exists {
-			fmt.Printf("Network '%s' does not exist\n", networkName)
+			Output("Network '%s' does not exist\n", networkName)
 			return nil
 		}
 
@@ -77,8 +85,118 @@ var networkRemoveCmd = &cobra.Command{
 			return fmt.Errorf("failed to remove network: %w", err)
 		}
 
-		fmt.Printf("Network '%s' removed successfully\n", networkName)
+		Output("Network '%s' removed successfully\n", networkName)
 
 		return nil
 	},
 }
+
+var networkInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Show Docker network details",
+	Long: `Show the network's ID, driver and subnets, and every connected container
+with its addresses and aliases. Use --output json for scripting.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if networkOutput != "table" && networkOutput != "json" {
+			return fmt.Errorf("invalid output format %q (valid: table, json)", networkOutput)
+		}
+
+		details, err := docker.InspectNetwork(cmd.Context(), networkName)
+		if err != nil {
+			return err
+		}
+
+		if networkOutput == "json" {
+			data, err := json.MarshalIndent(details, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal network details: %w", err)
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+
+		printNetworkDetails(os.Stdout, details)
+		return nil
+	},
+}
+
+// printNetworkDetails writes a network's details followed by a table of its
+// containers
+func printNetworkDetails(w io.Writer, details *docker.NetworkDetails) {
+	fmt.Fprintf(w, "Name:   %s\n", details.Name)
+	fmt.Fprintf(w, "ID:     %s\n", details.ID)
+	fmt.Fprintf(w, "Driver: %s\n", details.Driver)
+	for _, subnet := range details.Subnets {
+		fmt.Fprintf(w, "Subnet: %s", subnet.Subnet)
+		if subnet.Gateway != "" {
+			fmt.Fprintf(w, " (gateway %s)", subnet.Gateway)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w)
+
+	if len(details.Containers) == 0 {
+		fmt.Fprintln(w, "No containers connected")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTAINER\tIPV4\tIPV6\tALIASES")
+	for _, ctr := range details.Containers {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+			ctr.Name, orDash(ctr.IPv4Address), orDash(ctr.IPv6Address), orDash(strings.Join(ctr.Aliases, ",")))
+	}
+	tw.Flush()
+}
+
+// orDash returns "-" for empty table cells
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// kinderNetworkConfig returns the configuration for the kinder network from
+// the network flags and config. Call resolveNetworkCIDR first.
+func kinderNetworkConfig() docker.NetworkConfig {
+	return docker.NetworkConfig{
+		Name:       networkName,
+		CIDR:       networkCIDR,
+		Driver:     "bridge",
+		BridgeName: networkName + "br0",
+		EnableIPv6: config.GetBool(config.KeyNetworkIPv6),
+		IPv6CIDR:   config.GetString(config.KeyNetworkIPv6CIDR),
+	}
+}
+
+// resolveNetworkCIDR replaces --cidr auto with a concrete CIDR: the subnet
+// of the existing network, or a free private /24 for a new one. The result
+// is also stored in the config so the Kind subnet checks see it.
+func resolveNetworkCIDR(ctx context.Context) error {
+	if networkCIDR != config.AutoNetworkCIDR {
+		return nil
+	}
+
+	exists, err := docker.NetworkExists(ctx, networkName)
+	if err != nil {
+		return fmt.Errorf("failed to check if network exists: %w", err)
+	}
+
+	var cidr string
+	if exists {
+		cidr, err = docker.NetworkSubnet(ctx, networkName)
+	} else {
+		cidr, err = docker.PickFreeCIDR(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to pick a network CIDR: %w", err)
+	}
+	if !exists {
+		Verbose("Picked free network CIDR %s\n", cidr)
+	}
+
+	networkCIDR = cidr
+	config.Set(config.KeyNetworkCIDR, cidr)
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"codeberg.org/hipkoi/kinder/docker"
+)
+
+func TestPrintNetworkDetails(t *testing.T) {
+	details := &docker.NetworkDetails{
+		ID:      "abc123",
+		Name:    "kinder",
+		Driver:  "bridge",
+		Subnets: []docker.SubnetDetails{{Subnet: "172.28.28.0/24", Gateway: "172.28.28.1"}},
+		Containers: []docker.NetworkContainer{
+			{Name: "kinder-zot", IPv4Address: "172.28.28.3/24", Aliases: []string{"zot"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	printNetworkDetails(&buf, details)
+	out := buf.String()
+
+	for _, want := range []string{
+		"Subnet: 172.28.28.0/24 (gateway 172.28.28.1)",
+		"CONTAINER   IPV4",
+		"kinder-zot  172.28.28.3/24  -     zot",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printNetworkDetails(&buf, &docker.NetworkDetails{Name: "empty"})
+	if !strings.Contains(buf.String(), "No containers connected") {
+		t.Errorf("expected empty network message, got:\n%s", buf.String())
+	}
+}
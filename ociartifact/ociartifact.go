@@ -0,0 +1,408 @@
+// Package ociartifact builds small single-layer OCI images (or image indexes,
+// for several platforms) from in-memory files and pushes them to the local
+// registry. It is shared by the trust bundle, trust-manager bundle and
+// cert-manager issuer images, and copies upstream images into the registry
+// mirror.
+package ociartifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+// Epoch is the fixed timestamp used in artifacts so that identical content
+// always produces the same image digest
+var Epoch = time.Unix(0, 0).UTC()
+
+// DefaultPlatform is the platform artifacts are built for unless others are
+// requested: Linux on the architecture kinder was built for. The Kind nodes
+// run on the Docker daemon's architecture, which can differ, e.g. for an
+// amd64 binary emulated on an arm64 host, so callers that can reach Docker
+// should request DaemonPlatform instead.
+var DefaultPlatform = v1.Platform{OS: "linux", Architecture: runtime.GOARCH}
+
+// DaemonPlatform returns the Linux platform for an architecture reported by
+// the Docker daemon, which uses kernel names such as "x86_64" and "aarch64"
+func DaemonPlatform(arch string) v1.Platform {
+	platform := v1.Platform{OS: "linux", Architecture: arch}
+	switch arch {
+	case "x86_64":
+		platform.Architecture = "amd64"
+	case "aarch64":
+		platform.Architecture = "arm64"
+	case "armv7l", "armhf":
+		platform.Architecture, platform.Variant = "arm", "v7"
+	case "armv6l", "armel":
+		platform.Architecture, platform.Variant = "arm", "v6"
+	case "i386", "i686":
+		platform.Architecture = "386"
+	}
+	return platform
+}
+
+// Artifact is a built image or image index
+type Artifact interface {
+	Digest() (v1.Hash, error)
+	MediaType() (types.MediaType, error)
+}
+
+// ParsePlatforms parses platforms such as "linux/arm64" or "linux/arm/v7"
+func ParsePlatforms(specs []string) ([]v1.Platform, error) {
+	platforms := make([]v1.Platform, 0, len(specs))
+	for _, spec := range specs {
+		p, err := v1.ParsePlatform(spec)
+		if err != nil || p.OS == "" || p.Architecture == "" {
+			return nil, fmt.Errorf("invalid platform %q (expected os/arch[/variant])", spec)
+		}
+		platforms = append(platforms, *p)
+	}
+	return platforms, nil
+}
+
+// Build creates a single-layer OCI image for DefaultPlatform containing the
+// given files, with the given config labels
+func Build(files map[string][]byte, labels map[string]string) (v1.Image, error) {
+	return BuildPlatform(files, labels, DefaultPlatform)
+}
+
+// BuildForPlatforms builds the files for each platform. No platforms means
+// DefaultPlatform; with more than one the images are wrapped in an OCI image
+// index.
+func BuildForPlatforms(files map[string][]byte, labels map[string]string, platforms []v1.Platform) (Artifact, error) {
+	if len(platforms) <= 1 {
+		platform := DefaultPlatform
+		if len(platforms) == 1 {
+			platform = platforms[0]
+		}
+		img, err := BuildPlatform(files, labels, platform)
+		if err != nil {
+			return nil, err
+		}
+		return img, nil
+	}
+
+	index := mutate.IndexMediaType(empty.Index, types.OCIImageIndex)
+	for _, platform := range platforms {
+		img, err := BuildPlatform(files, labels, platform)
+		if err != nil {
+			return nil, err
+		}
+		index = mutate.AppendManifests(index, mutate.IndexAddendum{
+			Add:        img,
+			Descriptor: v1.Descriptor{Platform: &platform},
+		})
+	}
+	return index, nil
+}
+
+// BuildPlatform creates a single-layer OCI image containing the given files,
+// with the given config labels and platform. Files are written in name order
+// and all timestamps are fixed, so identical content always produces the same
+// digest.
+func BuildPlatform(files map[string][]byte, labels map[string]string, platform v1.Platform) (v1.Image, error) {
+	// Create a tar archive with the files in a stable order
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content := files[name]
+		header := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(content)),
+			ModTime: Epoch,
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, fmt.Errorf("failed to write tar header for %s: %w", name, err)
+		}
+
+		if _, err := tw.Write(content); err != nil {
+			return nil, fmt.Errorf("failed to write %s to tar: %w", name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close tar writer: %w", err)
+	}
+
+	// Create a layer from the tar archive
+	layer, err := tarball.LayerFromReader(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create layer: %w", err)
+	}
+
+	// Start with an empty image and add our layer
+	img, err := mutate.AppendLayers(empty.Image, layer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to append layer: %w", err)
+	}
+
+	// Set image config with labels
+	imgCfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config file: %w", err)
+	}
+
+	imgCfg.Author = "kinder"
+	imgCfg.Created = v1.Time{Time: Epoch}
+	imgCfg.Config.Labels = labels
+	// Record the platform so tools don't warn about a mismatch with the nodes
+	imgCfg.OS = platform.OS
+	imgCfg.Architecture = platform.Architecture
+	imgCfg.Variant = platform.Variant
+	imgCfg.OSVersion = platform.OSVersion
+
+	img, err = mutate.ConfigFile(img, imgCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set config: %w", err)
+	}
+
+	// Set the media type to OCI
+	img = mutate.MediaType(img, types.OCIManifestSchema1)
+
+	return img, nil
+}
+
+// Push pushes an image or image index to a registry. References are treated
+// as insecure, so plain HTTP is used for the local registry.
+func Push(ctx context.Context, artifact Artifact, imageRef string) error {
+	ref, err := name.ParseReference(imageRef, name.Insecure)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	// Create a transport that uses HTTP for insecure registries
+	tr, err := transport.NewWithContext(ctx, ref.Context().Registry, authn.Anonymous, http.DefaultTransport, []string{ref.Scope(transport.PushScope)})
+	if err != nil {
+		return fmt.Errorf("failed to create transport: %w", err)
+	}
+
+	// Push with the custom transport for HTTP registry
+	opts := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuth(authn.Anonymous),
+		remote.WithTransport(tr),
+	}
+	switch a := artifact.(type) {
+	case v1.ImageIndex:
+		err = remote.WriteIndex(ref, a, opts...)
+	case v1.Image:
+		err = remote.Write(ref, a, opts...)
+	default:
+		return fmt.Errorf("unsupported artifact type %T", artifact)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to push image: %w", err)
+	}
+
+	return nil
+}
+
+// Copy copies an image, or an image index with all its platforms, from an
+// upstream registry to the local registry
+func Copy(ctx context.Context, srcRef, dstRef string) error {
+	src, err := name.ParseReference(srcRef)
+	if err != nil {
+		return fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	artifact, err := fetchRemote(ctx, src)
+	if err != nil {
+		return err
+	}
+	return Push(ctx, artifact, dstRef)
+}
+
+// PushImage copies an image to dstRef in the local registry and returns the
+// pushed digest. The image is taken from the Docker daemon behind
+// dockerClient when it has it, for locally built images, and otherwise from
+// its registry. A nil dockerClient skips the daemon.
+func PushImage(ctx context.Context, dockerClient daemon.Client, srcRef, dstRef string) (string, error) {
+	src, err := name.ParseReference(srcRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	var artifact Artifact
+	if dockerClient != nil {
+		if img, err := daemon.Image(src, daemon.WithContext(ctx), daemon.WithClient(dockerClient)); err == nil {
+			artifact = img
+		}
+	}
+	if artifact == nil {
+		if artifact, err = fetchRemote(ctx, src); err != nil {
+			return "", err
+		}
+	}
+
+	if err := Push(ctx, artifact, dstRef); err != nil {
+		return "", err
+	}
+	digest, err := artifact.Digest()
+	if err != nil {
+		return "", fmt.Errorf("failed to compute image digest: %w", err)
+	}
+	return digest.String(), nil
+}
+
+// fetchRemote returns the image or image index for ref from its registry,
+// using the credentials from the Docker config, if any
+func fetchRemote(ctx context.Context, ref name.Reference) (Artifact, error) {
+	desc, err := remote.Get(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch image: %w", err)
+	}
+
+	if desc.MediaType.IsIndex() {
+		index, err := desc.ImageIndex()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		return index, nil
+	}
+	img, err := desc.Image()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	return img, nil
+}
+
+// Digest returns the digest of an image in the registry
+func Digest(ctx context.Context, imageRef string) (string, error) {
+	ref, err := name.ParseReference(imageRef, name.Insecure)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	desc, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuth(authn.Anonymous))
+	if err != nil {
+		return "", fmt.Errorf("failed to get image digest: %w", err)
+	}
+
+	return desc.Digest.String(), nil
+}
+
+// PushIfChanged pushes img to imageRef unless the registry already holds an
+// image with the same digest, or force is set. Returns whether it pushed.
+func PushIfChanged(ctx context.Context, img Artifact, imageRef string, force bool) (bool, error) {
+	if !force {
+		upToDate, err := UpToDate(ctx, img, imageRef)
+		if err != nil {
+			return false, err
+		}
+		if upToDate {
+			return false, nil
+		}
+	}
+
+	if err := Push(ctx, img, imageRef); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// UpToDate reports whether the registry already holds img under imageRef.
+// An image missing from the registry is not an error.
+func UpToDate(ctx context.Context, img Artifact, imageRef string) (bool, error) {
+	localDigest, err := img.Digest()
+	if err != nil {
+		return false, fmt.Errorf("failed to compute image digest: %w", err)
+	}
+
+	remoteDigest, err := Digest(ctx, imageRef)
+	if err != nil {
+		// Not pushed yet, or the registry cannot be queried; pushing decides
+		return false, nil
+	}
+
+	return remoteDigest == localDigest.String(), nil
+}
+
+// Pull fetches the image stored under imageRef and returns the regular files
+// in its layers, keyed by path. Later layers override earlier ones. For an
+// image index, the DefaultPlatform image is used.
+func Pull(ctx context.Context, imageRef string) (map[string][]byte, error) {
+	ref, err := name.ParseReference(imageRef, name.Insecure)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	img, err := remote.Image(ref, remote.WithContext(ctx), remote.WithAuth(authn.Anonymous), remote.WithPlatform(DefaultPlatform))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull image: %w", err)
+	}
+
+	return Files(img)
+}
+
+// Files returns the regular files in an image's layers, keyed by path
+func Files(img v1.Image) (map[string][]byte, error) {
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get layers: %w", err)
+	}
+
+	files := make(map[string][]byte)
+	for _, layer := range layers {
+		if err := readLayer(layer, files); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// readLayer adds the regular files in a layer's tar archive to files
+func readLayer(layer v1.Layer, files map[string][]byte) error {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return fmt.Errorf("failed to read layer: %w", err)
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read layer archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", header.Name, err)
+		}
+		files[header.Name] = content
+	}
+}
@@ -0,0 +1,237 @@
+package ociartifact
+
+import (
+	"archive/tar"
+	"io"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+)
+
+func TestBuild(t *testing.T) {
+	files := map[string][]byte{
+		"kustomization.yaml": []byte("resources: []\n"),
+		"clusterissuer.yaml": []byte("kind: ClusterIssuer\n"),
+		"bundle.yaml":        []byte("kind: Bundle\n"),
+	}
+	labels := map[string]string{"org.opencontainers.image.title": "Test"}
+
+	first, err := Build(files, labels)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	second, err := Build(files, labels)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	t.Run("reproducible digest", func(t *testing.T) {
+		firstDigest, err := first.Digest()
+		if err != nil {
+			t.Fatalf("failed to get digest: %v", err)
+		}
+		secondDigest, err := second.Digest()
+		if err != nil {
+			t.Fatalf("failed to get digest: %v", err)
+		}
+		if firstDigest != secondDigest {
+			t.Errorf("expected identical files to produce identical digests, got %s and %s", firstDigest, secondDigest)
+		}
+	})
+
+	t.Run("sorted entries with fixed timestamps", func(t *testing.T) {
+		layers, err := first.Layers()
+		if err != nil || len(layers) != 1 {
+			t.Fatalf("expected a single layer, got %d (%v)", len(layers), err)
+		}
+		rc, err := layers[0].Uncompressed()
+		if err != nil {
+			t.Fatalf("failed to read layer: %v", err)
+		}
+		defer rc.Close()
+
+		var names []string
+		tr := tar.NewReader(rc)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("failed to read tar: %v", err)
+			}
+			if !header.ModTime.Equal(Epoch) {
+				t.Errorf("expected %s to have a fixed timestamp, got %s", header.Name, header.ModTime)
+			}
+			names = append(names, header.Name)
+		}
+
+		expected := []string{"bundle.yaml", "clusterissuer.yaml", "kustomization.yaml"}
+		if len(names) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+		for i := range expected {
+			if names[i] != expected[i] {
+				t.Errorf("expected %v, got %v", expected, names)
+				break
+			}
+		}
+	})
+
+	t.Run("config", func(t *testing.T) {
+		cfg, err := first.ConfigFile()
+		if err != nil {
+			t.Fatalf("failed to get config: %v", err)
+		}
+		if !cfg.Created.Time.Equal(Epoch) {
+			t.Errorf("expected fixed created time, got %s", cfg.Created.Time)
+		}
+		if cfg.Config.Labels["org.opencontainers.image.title"] != "Test" {
+			t.Errorf("expected labels to be set, got %v", cfg.Config.Labels)
+		}
+	})
+}
+
+func TestFiles(t *testing.T) {
+	files := map[string][]byte{
+		"kustomization.yaml": []byte("resources: []\n"),
+		"trust-bundle.pem":   []byte("bundle\n"),
+	}
+
+	img, err := Build(files, nil)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	got, err := Files(img)
+	if err != nil {
+		t.Fatalf("Files failed: %v", err)
+	}
+	if len(got) != len(files) {
+		t.Fatalf("expected %d files, got %d", len(files), len(got))
+	}
+	for name, content := range files {
+		if string(got[name]) != string(content) {
+			t.Errorf("%s: expected %q, got %q", name, content, got[name])
+		}
+	}
+}
+
+func TestBuildPlatform(t *testing.T) {
+	files := map[string][]byte{"bundle.pem": []byte("ca\n")}
+
+	img, err := Build(files, nil)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if cfg.OS != DefaultPlatform.OS || cfg.Architecture != DefaultPlatform.Architecture {
+		t.Errorf("expected platform %s, got %s/%s", DefaultPlatform, cfg.OS, cfg.Architecture)
+	}
+
+	arm, err := BuildPlatform(files, nil, v1.Platform{OS: "linux", Architecture: "arm", Variant: "v7"})
+	if err != nil {
+		t.Fatalf("BuildPlatform failed: %v", err)
+	}
+	cfg, err = arm.ConfigFile()
+	if err != nil {
+		t.Fatalf("failed to get config: %v", err)
+	}
+	if got := cfg.Platform().String(); got != "linux/arm/v7" {
+		t.Errorf("expected platform linux/arm/v7, got %s", got)
+	}
+}
+
+func TestBuildForPlatforms(t *testing.T) {
+	files := map[string][]byte{"bundle.pem": []byte("ca\n")}
+	platforms, err := ParsePlatforms([]string{"linux/amd64", "linux/arm64"})
+	if err != nil {
+		t.Fatalf("ParsePlatforms failed: %v", err)
+	}
+
+	t.Run("single platform is an image", func(t *testing.T) {
+		artifact, err := BuildForPlatforms(files, nil, platforms[1:])
+		if err != nil {
+			t.Fatalf("BuildForPlatforms failed: %v", err)
+		}
+		if _, ok := artifact.(v1.Image); !ok {
+			t.Fatalf("expected an image, got %T", artifact)
+		}
+	})
+
+	t.Run("several platforms are an index", func(t *testing.T) {
+		artifact, err := BuildForPlatforms(files, nil, platforms)
+		if err != nil {
+			t.Fatalf("BuildForPlatforms failed: %v", err)
+		}
+		index, ok := artifact.(v1.ImageIndex)
+		if !ok {
+			t.Fatalf("expected an image index, got %T", artifact)
+		}
+		if mt, _ := index.MediaType(); mt != types.OCIImageIndex {
+			t.Errorf("expected media type %s, got %s", types.OCIImageIndex, mt)
+		}
+		manifest, err := index.IndexManifest()
+		if err != nil {
+			t.Fatalf("failed to get index manifest: %v", err)
+		}
+		if len(manifest.Manifests) != 2 {
+			t.Fatalf("expected 2 manifests, got %d", len(manifest.Manifests))
+		}
+		for i, desc := range manifest.Manifests {
+			if desc.Platform == nil || desc.Platform.String() != platforms[i].String() {
+				t.Errorf("manifest %d: expected platform %s, got %v", i, platforms[i], desc.Platform)
+			}
+		}
+	})
+}
+
+func TestParsePlatforms(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{"linux/amd64", "linux/amd64", false},
+		{"linux/arm/v7", "linux/arm/v7", false},
+		{"linux", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			platforms, err := ParsePlatforms([]string{tt.spec})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePlatforms(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if err == nil && platforms[0].String() != tt.want {
+				t.Errorf("ParsePlatforms(%q) = %s, want %s", tt.spec, platforms[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestDaemonPlatform(t *testing.T) {
+	tests := []struct {
+		arch string
+		want string
+	}{
+		{"x86_64", "linux/amd64"},
+		{"aarch64", "linux/arm64"},
+		{"armv7l", "linux/arm/v7"},
+		{"i686", "linux/386"},
+		{"s390x", "linux/s390x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arch, func(t *testing.T) {
+			if got := DaemonPlatform(tt.arch); got.String() != tt.want {
+				t.Errorf("DaemonPlatform(%q) = %s, want %s", tt.arch, got, tt.want)
+			}
+		})
+	}
+}
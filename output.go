@@ -2,7 +2,12 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 )
 
 // Verbosity levels
@@ -15,6 +20,103 @@ const (
 // Global verbosity level
 var verbosity = VerbosityDefault
 
+// progressStep is the step announced by the last ProgressStart, so JSON
+// records from ProgressDone and ProgressSkip can name it
+var progressStep string
+
+var (
+	// stripEmoji is set by --plain (or --no-emoji) to replace emoji and
+	// symbols with plain text
+	stripEmoji bool
+	// stripANSI is set by --plain or NO_COLOR to drop ANSI escape codes
+	stripANSI bool
+	// reportOut is where reports such as status and diagnostics are printed,
+	// filtered like the output helpers
+	reportOut io.Writer = os.Stdout
+)
+
+// ansiPattern matches ANSI escape sequences
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// plainSymbols replaces status symbols with words in plain output. Longer
+// sequences come first as the replacer tries them in order.
+var plainSymbols = strings.NewReplacer(
+	"❌ FAILED", "FAILED",
+	"✅", "OK",
+	"✓", "OK",
+	"❌", "FAIL",
+	"✗", "FAIL",
+	"⚠️", "WARN",
+	"⚠", "WARN",
+	"○", "-",
+	"●", "*",
+	"━", "-",
+	"─", "-",
+)
+
+// configureOutput sets how output is decorated: plain strips emoji and ANSI
+// codes, noColor (NO_COLOR) only ANSI codes
+func configureOutput(plain, noColor bool) {
+	stripEmoji = plain
+	stripANSI = plain || noColor
+	reportOut = os.Stdout
+	if stripEmoji || stripANSI {
+		reportOut = plainWriter{os.Stdout}
+	}
+}
+
+// plainText removes the decorations disabled by configureOutput from s
+func plainText(s string) string {
+	if stripANSI {
+		s = ansiPattern.ReplaceAllString(s, "")
+	}
+	if !stripEmoji {
+		return s
+	}
+
+	s = plainSymbols.Replace(s)
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\uFE0F' || r == '\u20E3' || r == '\u200D':
+			// Variation selectors, keycaps and joiners only modify emoji
+		case isEmoji(r):
+			// Drop the space that separated the emoji from the text
+			for i+1 < len(runes) && runes[i+1] == '\uFE0F' {
+				i++
+			}
+			if i+1 < len(runes) && runes[i+1] == ' ' {
+				i++
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isEmoji reports whether r is a pictograph or symbol kinder uses as an icon
+func isEmoji(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) ||
+		(r >= 0x2300 && r <= 0x23FF) ||
+		(r >= 0x25A0 && r <= 0x25FF) ||
+		(r >= 0x2600 && r <= 0x27BF) ||
+		(r >= 0x2B00 && r <= 0x2BFF)
+}
+
+// plainWriter filters everything written through plainText
+type plainWriter struct {
+	w io.Writer
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := io.WriteString(p.w, plainText(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
 // SetVerbosity sets the global verbosity level
 func SetVerbosity(v int) {
 	verbosity = v
@@ -35,42 +137,52 @@ func IsQuiet() bool {
 	return verbosity <= VerbosityQuiet
 }
 
+// isTerminal reports whether f is a terminal, where output can redraw lines
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
 // Output prints a message at the default verbosity level
 func Output(format string, args ...interface{}) {
-	if verbosity >= VerbosityDefault {
-		fmt.Printf(format, args...)
-	}
+	logf(slog.LevelInfo, format, args...)
 }
 
 // OutputLn prints a message with newline at the default verbosity level
 func OutputLn(args ...interface{}) {
-	if verbosity >= VerbosityDefault {
-		fmt.Println(args...)
-	}
+	logf(slog.LevelInfo, "%s", fmt.Sprintln(args...))
+}
+
+// Result prints data a command was asked for, such as a listing or a digest,
+// to stdout. Unlike Output it ignores the log level, so --quiet only hides
+// progress messages.
+func Result(format string, args ...interface{}) {
+	fmt.Fprintf(reportOut, format, args...)
 }
 
 // Verbose prints a message only when verbose mode is enabled
 func Verbose(format string, args ...interface{}) {
-	if verbosity >= VerbosityVerbose {
-		fmt.Printf(format, args...)
-	}
+	logf(slog.LevelDebug, format, args...)
 }
 
 // VerboseLn prints a message with newline only when verbose mode is enabled
 func VerboseLn(args ...interface{}) {
-	if verbosity >= VerbosityVerbose {
-		fmt.Println(args...)
-	}
+	logf(slog.LevelDebug, "%s", fmt.Sprintln(args...))
+}
+
+// Warn prints a warning to stderr, prefixed with "Warning: "
+func Warn(format string, args ...interface{}) {
+	logf(slog.LevelWarn, format, args...)
 }
 
 // Error prints an error message (always shown unless quiet)
 func Error(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format, args...)
+	logf(slog.LevelError, format, args...)
 }
 
 // ErrorLn prints an error message with newline (always shown)
 func ErrorLn(args ...interface{}) {
-	fmt.Fprintln(os.Stderr, args...)
+	logf(slog.LevelError, "%s", fmt.Sprintln(args...))
 }
 
 // Status prints a status line with emoji and message
@@ -80,11 +192,15 @@ func Status(emoji, message, details string) {
 	if verbosity < VerbosityDefault {
 		return
 	}
+	if jsonLogs {
+		logger.Info(message, "details", details)
+		return
+	}
 
 	if verbosity >= VerbosityVerbose && details != "" {
-		fmt.Printf("%s %s (%s)\n", emoji, message, details)
+		Output("%s %s (%s)\n", emoji, message, details)
 	} else {
-		fmt.Printf("%s %s\n", emoji, message)
+		Output("%s %s\n", emoji, message)
 	}
 }
 
@@ -95,6 +211,10 @@ func StatusResult(success bool, message, details string) {
 	if verbosity < VerbosityDefault {
 		return
 	}
+	if jsonLogs {
+		logger.Info(message, "ok", success, "details", details)
+		return
+	}
 
 	icon := "✓"
 	if !success {
@@ -102,9 +222,9 @@ func StatusResult(success bool, message, details string) {
 	}
 
 	if verbosity >= VerbosityVerbose && details != "" {
-		fmt.Printf("  %s %s (%s)\n", icon, message, details)
+		Output("  %s %s (%s)\n", icon, message, details)
 	} else {
-		fmt.Printf("  %s %s\n", icon, message)
+		Output("  %s %s\n", icon, message)
 	}
 }
 
@@ -113,114 +233,189 @@ func Section(emoji, title string) {
 	if verbosity < VerbosityDefault {
 		return
 	}
-	fmt.Printf("%s %s...\n", emoji, title)
+	if jsonLogs {
+		logger.Info(title)
+		return
+	}
+	Output("%s %s...\n", emoji, title)
 }
 
 // SectionDone prints completion of a section (only in verbose mode adds newline)
 func SectionDone() {
-	if verbosity >= VerbosityVerbose {
-		fmt.Println()
-	}
+	Verbose("\n")
 }
 
 // BlankLine prints a blank line (only in default+ mode)
 func BlankLine() {
-	if verbosity >= VerbosityDefault {
-		fmt.Println()
-	}
+	Output("\n")
 }
 
 // Header prints a header message
 func Header(message string) {
-	if verbosity >= VerbosityDefault {
-		fmt.Println(message)
-	}
+	Output("%s\n", message)
 }
 
 // Success prints a success message with checkmark emoji
 func Success(message string) {
-	if verbosity >= VerbosityDefault {
-		fmt.Printf("✅ %s\n", message)
+	if jsonLogs {
+		logger.Info(message)
+		return
 	}
+	Output("✅ %s\n", message)
 }
 
 // Info prints an informational message (only in verbose mode)
 func Info(format string, args ...interface{}) {
-	if verbosity >= VerbosityVerbose {
-		fmt.Printf("   "+format+"\n", args...)
-	}
+	Verbose("   "+format+"\n", args...)
 }
 
 // ServiceInfo prints service availability info
 func ServiceInfo(name, url string) {
-	if verbosity >= VerbosityDefault {
-		fmt.Printf("  - %s: %s\n", name, url)
+	if jsonLogs {
+		logger.Info("endpoint", "name", name, "url", url)
+		return
 	}
+	Output("  - %s: %s\n", name, url)
 }
 
 // ProgressStart prints a compact progress line for starting an action.
 // In default mode: "  emoji name... " (no newline, waiting for ProgressDone)
 // In verbose mode: uses Section format with newline
 func ProgressStart(emoji, name string) {
+	stopSpinner()
+	progressStep = name
 	if verbosity < VerbosityDefault {
 		return
 	}
+	if jsonLogs {
+		logger.Info("starting", "step", name)
+		return
+	}
 
 	if verbosity >= VerbosityVerbose {
 		// Verbose mode: use existing Section format
-		fmt.Printf("%s %s...\n", emoji, name)
+		Output("%s %s...\n", emoji, name)
 	} else {
 		// Default mode: compact inline format
-		fmt.Printf("  %s %-16s", emoji, name)
+		Output("  %s %-16s", emoji, name)
+	}
+}
+
+// spinnerFrames are drawn in turn while a long step runs
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is how often the spinner is redrawn
+const spinnerInterval = 100 * time.Millisecond
+
+// progressSpinner is the spinner of the current long step, if any
+var progressSpinner *spinner
+
+// spinner redraws a progress line with an animation and the elapsed time
+type spinner struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// ProgressStartLong starts a progress line like ProgressStart for a step
+// that may take minutes, animating it with a spinner and the elapsed time
+// until ProgressDone or ProgressSkip. The spinner relies on carriage returns,
+// so it only runs in default verbosity on a terminal and not with --plain.
+func ProgressStartLong(emoji, name string) {
+	ProgressStart(emoji, name)
+	if verbosity != VerbosityDefault || jsonLogs || stripEmoji || stripANSI || !isTerminal(os.Stdout) {
+		return
+	}
+
+	prefix := fmt.Sprintf("  %s %-16s", emoji, name)
+	s := &spinner{stop: make(chan struct{}), done: make(chan struct{})}
+	progressSpinner = s
+	start := time.Now()
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			Output("\r%s%s %s", prefix, spinnerFrames[i%len(spinnerFrames)], formatElapsed(time.Since(start)))
+			select {
+			case <-s.stop:
+				Output("\r\x1b[K%s", prefix)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// stopSpinner stops the current spinner, leaving the line as ProgressStart
+// printed it
+func stopSpinner() {
+	if progressSpinner == nil {
+		return
 	}
+	close(progressSpinner.stop)
+	<-progressSpinner.done
+	progressSpinner = nil
+}
+
+// formatElapsed formats a duration as "42s" or "3m05s"
+func formatElapsed(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs < 60 {
+		return fmt.Sprintf("%ds", secs)
+	}
+	return fmt.Sprintf("%dm%02ds", secs/60, secs%60)
 }
 
 // ProgressDone completes a progress line with success/failure indicator.
 // In default mode: prints "✓" or "✗" on the same line, with optional brief status
 // In verbose mode: prints full status result
 func ProgressDone(success bool, details string) {
+	stopSpinner()
 	if verbosity < VerbosityDefault {
 		return
 	}
+	if jsonLogs {
+		if success {
+			logger.Info("done", "step", progressStep, "details", details)
+		} else {
+			logger.Error("failed", "step", progressStep, "details", details)
+		}
+		return
+	}
+
+	icon := "✓"
+	if !success {
+		icon = "✗"
+	}
 
 	if verbosity >= VerbosityVerbose {
 		// Verbose mode: use existing StatusResult format
-		icon := "✓"
-		if !success {
-			icon = "✗"
+		if details == "" {
+			details = "Done"
 		}
-		if details != "" {
-			fmt.Printf("  %s %s\n", icon, details)
-		} else {
-			fmt.Printf("  %s Done\n", icon)
-		}
-	} else {
+		Output("  %s %s\n", icon, details)
+	} else if details != "" {
 		// Default mode: compact inline completion with optional brief status
-		if success {
-			if details != "" {
-				fmt.Printf("✓ %s\n", details)
-			} else {
-				fmt.Printf("✓\n")
-			}
-		} else {
-			if details != "" {
-				fmt.Printf("✗ %s\n", details)
-			} else {
-				fmt.Printf("✗\n")
-			}
-		}
+		Output("%s %s\n", icon, details)
+	} else {
+		Output("%s\n", icon)
 	}
 }
 
 // ProgressSkip indicates an action was skipped (e.g., already exists)
 func ProgressSkip(reason string) {
+	stopSpinner()
 	if verbosity < VerbosityDefault {
 		return
 	}
+	if jsonLogs {
+		logger.Info("skipped", "step", progressStep, "reason", reason)
+		return
+	}
 
 	if verbosity >= VerbosityVerbose {
-		fmt.Printf("  ○ %s\n", reason)
+		Output("  ○ %s\n", reason)
 	} else {
-		fmt.Printf("○ %s\n", reason)
+		Output("○ %s\n", reason)
 	}
 }
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"codeberg.org/hipkoi/kinder/kubernetes"
+)
+
+// hostPort is a port a kinder service publishes on the host
+type hostPort struct {
+	Service  string
+	Address  string // host address bound, empty for all interfaces
+	Port     string
+	Protocol string // tcp or udp
+}
+
+// startHostPorts returns the host ports start will bind. Services that
+// already exist are skipped: their ports are kinder's own and are reused.
+func startHostPorts(ctx context.Context) ([]hostPort, error) {
+	var ports []hostPort
+
+	exists, err := docker.ContainerExists(ctx, zotContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if container exists: %w", err)
+	}
+	if !exists {
+		ports = append(ports, hostPort{Service: "Zot registry", Port: zotHostPort(), Protocol: "tcp"})
+	}
+
+	exists, err = docker.ContainerExists(ctx, traefikContainerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if container exists: %w", err)
+	}
+	if !exists {
+		ports = append(ports, hostPort{Service: "Traefik HTTPS", Port: traefikPort, Protocol: "tcp"})
+		if traefikHTTPPort != "" {
+			ports = append(ports, hostPort{Service: "Traefik HTTP", Port: traefikHTTPPort, Protocol: "tcp"})
+		}
+		if traefikDashboardPort != "" && !traefikNoDashboard {
+			ports = append(ports, hostPort{Service: "Traefik dashboard", Address: "127.0.0.1", Port: traefikDashboardPort, Protocol: "tcp"})
+		}
+	}
+
+	appName := config.GetString(config.KeyAppName)
+	if appName == "" {
+		appName = config.DefaultAppName
+	}
+	exists, err = kubernetes.KindExists(appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check cluster status: %w", err)
+	}
+	if !exists {
+		portMappings, err := parsePortMaps(kindPortMaps)
+		if err != nil {
+			return nil, err
+		}
+		for _, pm := range portMappings {
+			ports = append(ports, hostPort{
+				Service:  fmt.Sprintf("Kind port map %d", pm.ContainerPort),
+				Port:     strconv.Itoa(int(pm.HostPort)),
+				Protocol: strings.ToLower(pm.Protocol),
+			})
+		}
+	}
+
+	return ports, nil
+}
+
+// preflightDomain checks the service domain unless --skip-domain-check is set
+func preflightDomain(ctx context.Context) error {
+	if skipDomainCheck {
+		return nil
+	}
+	domain := traefikDomain
+	if domain == "" {
+		domain = docker.DefaultTraefikDomain
+	}
+	return checkDomain(ctx, domain, net.DefaultResolver.LookupHost, checkIPReachability)
+}
+
+// checkDomain resolves the service domain and checks that at least one of its
+// addresses is routable from this host, as every service URL depends on it.
+// sslip.io domains carry their address, so they are not looked up and the
+// check works offline.
+func checkDomain(ctx context.Context, domain string, lookup func(ctx context.Context, host string) ([]string, error), reachable func(ctx context.Context, ip string) error) error {
+	var addrs []string
+	if ip, ok := config.SslipAddress(domain); ok {
+		addrs = []string{ip}
+	} else if resolved, err := lookup(ctx, domain); err == nil {
+		addrs = resolved
+	}
+	if len(addrs) == 0 {
+		return fmt.Errorf("domain %s does not resolve; use an sslip.io domain such as %s, add a DNS record, or rerun with --skip-domain-check", domain, docker.DefaultTraefikDomain)
+	}
+
+	for _, addr := range addrs {
+		if reachable(ctx, addr) == nil {
+			Verbose("Domain %s resolves to %s\n", domain, addr)
+			return nil
+		}
+	}
+	return fmt.Errorf("domain %s resolves to %s, which is not routable from this host; add a route or address for it, or rerun with --skip-domain-check", domain, strings.Join(addrs, ", "))
+}
+
+// preflightPorts checks that each host port is free by briefly listening on
+// it, and reports every port already in use together with the process
+// holding it, where that can be found
+func preflightPorts(ports []hostPort) error {
+	var conflicts []string
+	for _, p := range ports {
+		if err := checkPort(p); err != nil {
+			conflict := fmt.Sprintf("port %s/%s (%s) is already in use", p.Port, p.Protocol, p.Service)
+			if owner := portOwner(p); owner != "" {
+				conflict += " by " + owner
+			}
+			conflicts = append(conflicts, conflict)
+		}
+	}
+	if len(conflicts) > 0 {
+		// Profiles share the default host ports, so the owner is often another profile
+		if profile != "" {
+			return fmt.Errorf("%s; free the ports, give profile %q its own --traefik-port, --traefik-http-port and --zot-port, or rerun with --skip-preflight", strings.Join(conflicts, "; "), profile)
+		}
+		return fmt.Errorf("%s; free the ports or rerun with --skip-preflight", strings.Join(conflicts, "; "))
+	}
+	return nil
+}
+
+// checkPort returns an error if the port is taken. Other failures, such as
+// lacking permission to bind a privileged port that Docker binds as root,
+// are not conflicts.
+func checkPort(p hostPort) error {
+	addr := net.JoinHostPort(p.Address, p.Port)
+
+	var err error
+	switch p.Protocol {
+	case "tcp":
+		var l net.Listener
+		if l, err = net.Listen("tcp", addr); err == nil {
+			return l.Close()
+		}
+	case "udp":
+		var c net.PacketConn
+		if c, err = net.ListenPacket("udp", addr); err == nil {
+			return c.Close()
+		}
+	default:
+		return nil
+	}
+
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return err
+	}
+	return nil
+}
+
+// portOwner names the process listening on a port using lsof, or returns ""
+// if it cannot be found
+func portOwner(p hostPort) string {
+	lsof, err := exec.LookPath("lsof")
+	if err != nil {
+		return ""
+	}
+	args := []string{"-nP", "-i" + p.Protocol + ":" + p.Port, "-Fpc"}
+	if p.Protocol == "tcp" {
+		args = append(args, "-sTCP:LISTEN")
+	}
+	out, err := exec.Command(lsof, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return parseLsofOwner(string(out))
+}
+
+// parseLsofOwner returns "command (pid N)" for the first process in lsof -F
+// output
+func parseLsofOwner(out string) string {
+	var pid, command string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if pid != "" {
+				return fmt.Sprintf("%s (pid %s)", command, pid)
+			}
+			pid = line[1:]
+		case 'c':
+			command = line[1:]
+		}
+	}
+	if pid == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (pid %s)", command, pid)
+}
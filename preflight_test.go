@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPreflightPorts(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+	_, taken, _ := net.SplitHostPort(l.Addr().String())
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	_, freePort, _ := net.SplitHostPort(free.Addr().String())
+	free.Close()
+
+	if err := preflightPorts([]hostPort{{Service: "Zot registry", Address: "127.0.0.1", Port: freePort, Protocol: "tcp"}}); err != nil {
+		t.Errorf("expected free port to pass, got %v", err)
+	}
+
+	err = preflightPorts([]hostPort{
+		{Service: "Zot registry", Address: "127.0.0.1", Port: freePort, Protocol: "tcp"},
+		{Service: "Traefik HTTPS", Address: "127.0.0.1", Port: taken, Protocol: "tcp"},
+	})
+	if err == nil {
+		t.Fatal("expected error for port in use")
+	}
+	if !strings.Contains(err.Error(), "port "+taken+"/tcp (Traefik HTTPS) is already in use") {
+		t.Errorf("error does not name the conflicting port: %v", err)
+	}
+	if strings.Contains(err.Error(), freePort) {
+		t.Errorf("error names a free port: %v", err)
+	}
+
+	// With a profile the error says how to give it its own ports
+	saved := profile
+	t.Cleanup(func() { profile = saved })
+	profile = "dev"
+	err = preflightPorts([]hostPort{{Service: "Traefik HTTPS", Address: "127.0.0.1", Port: taken, Protocol: "tcp"}})
+	if err == nil || !strings.Contains(err.Error(), `give profile "dev" its own --traefik-port`) {
+		t.Errorf("expected a profile port hint, got %v", err)
+	}
+}
+
+func TestParseLsofOwner(t *testing.T) {
+	tests := []struct {
+		out      string
+		expected string
+	}{
+		{"p4242\ncControlCenter\nf12\n", "ControlCenter (pid 4242)"},
+		{"p10\ncdocker-proxy\nf4\np11\ncdocker-proxy\n", "docker-proxy (pid 10)"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := parseLsofOwner(tt.out); got != tt.expected {
+			t.Errorf("parseLsofOwner(%q) = %q, want %q", tt.out, got, tt.expected)
+		}
+	}
+}
+
+func TestCheckDomain(t *testing.T) {
+	lookup := func(addrs []string, err error) func(context.Context, string) ([]string, error) {
+		return func(context.Context, string) ([]string, error) { return addrs, err }
+	}
+	reachable := func(routable ...string) func(context.Context, string) error {
+		return func(_ context.Context, ip string) error {
+			if slices.Contains(routable, ip) {
+				return nil
+			}
+			return errors.New("no route")
+		}
+	}
+
+	tests := []struct {
+		name      string
+		domain    string
+		lookup    func(context.Context, string) ([]string, error)
+		reachable func(context.Context, string) error
+		wantErr   string
+	}{
+		{"routable", "example.test", lookup([]string{"192.0.2.1"}, nil), reachable("192.0.2.1"), ""},
+		{"one of several routable", "example.test", lookup([]string{"203.0.113.1", "192.0.2.1"}, nil), reachable("192.0.2.1"), ""},
+		{"does not resolve", "example.test", lookup(nil, errors.New("no such host")), reachable(), "does not resolve"},
+		{"not routable", "example.test", lookup([]string{"203.0.113.1"}, nil), reachable(), "resolves to 203.0.113.1, which is not routable"},
+		{"sslip.io offline", "c0000201.sslip.io", lookup(nil, errors.New("no such host")), reachable("192.0.2.1"), ""},
+		{"sslip.io not routable", "0a000005.sslip.io", lookup(nil, errors.New("no such host")), reachable(), "resolves to 10.0.0.5, which is not routable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDomain(context.Background(), tt.domain, tt.lookup, tt.reachable)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "--skip-domain-check") {
+				t.Errorf("expected error to mention --skip-domain-check, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"github.com/spf13/cobra"
+)
+
+// profileMarkerFile marks a subdirectory of the base data directory as the
+// data directory of a profile, telling it apart from the default
+// environment's own subdirectories such as snapshots and dumps
+const profileMarkerFile = ".kinder-profile"
+
+// maxProfileNameLength keeps the derived bridge name ("<profile>br0") within
+// the 15 character Linux interface name limit
+const maxProfileNameLength = 12
+
+// profileNamePattern restricts profile names to values that are valid as
+// container, network and Kind cluster names
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named kinder environments",
+	Long: `Commands for managing named kinder environments.
+
+Use the global --profile flag to run an isolated stack. A profile overrides the
+app name, data directory, network name and container name prefixes, and picks
+a free network CIDR unless --cidr is set.
+
+Profiles share the host ports. To run several at once, give each its own
+--traefik-port, --traefik-http-port and --zot-port on start.`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List existing profiles",
+	Long:  `List profiles that have data in the base data directory.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		baseDir, err := profileBaseDataDir()
+		if err != nil {
+			return fmt.Errorf("failed to get data directory: %w", err)
+		}
+
+		profiles, err := listProfiles(baseDir)
+		if err != nil {
+			return err
+		}
+
+		if len(profiles) == 0 {
+			Output("No profiles found\n")
+			return nil
+		}
+
+		for _, name := range profiles {
+			marker := " "
+			if name == profile {
+				marker = "*"
+			}
+			Result("%s %s\n", marker, name)
+		}
+
+		return nil
+	},
+}
+
+// validateProfileName checks that a profile name can be used to derive
+// resource names
+func validateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: must contain only lowercase letters, digits and '-', and start with a letter or digit", name)
+	}
+	if len(name) > maxProfileNameLength {
+		return fmt.Errorf("invalid profile name %q: must be at most %d characters", name, maxProfileNameLength)
+	}
+	return nil
+}
+
+// profileBaseDataDir returns the directory that contains the profile data directories.
+// When a profile is active this is the data directory it was derived from.
+func profileBaseDataDir() (string, error) {
+	if profile != "" && profileBaseDir != "" {
+		return profileBaseDir, nil
+	}
+	return config.GetDataDir()
+}
+
+// profileDataDir returns the data directory for the named profile
+func profileDataDir(baseDir, name string) string {
+	return filepath.Join(baseDir, name)
+}
+
+// isProfileDir reports whether dir holds the profile marker file
+func isProfileDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, profileMarkerFile))
+	return err == nil
+}
+
+// ensureProfileDataDir creates the data directory of a profile and marks it
+// as one. A directory of the same name that is not a profile, such as the
+// default environment's snapshots directory, is an error.
+func ensureProfileDataDir(dir string) error {
+	if isProfileDir(dir) {
+		return nil
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("cannot use %s as a profile data directory: it already exists and is not a profile", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create profile data directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, profileMarkerFile), nil, 0644); err != nil {
+		return fmt.Errorf("failed to mark profile data directory: %w", err)
+	}
+	return nil
+}
+
+// listProfiles returns the sorted names of profiles found under baseDir
+func listProfiles(baseDir string) ([]string, error) {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	var profiles []string
+	for _, entry := range entries {
+		if entry.IsDir() && validateProfileName(entry.Name()) == nil && isProfileDir(profileDataDir(baseDir, entry.Name())) {
+			profiles = append(profiles, entry.Name())
+		}
+	}
+	sort.Strings(profiles)
+
+	return profiles, nil
+}
+
+// applyProfile overrides the app name, data directory, network and container
+// names so that every command operates on the named profile. The default
+// network CIDR is replaced with auto so each profile gets its own subnet.
+// Must be called after config.Initialize and bindFlagsToViper.
+func applyProfile(name string) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+
+	baseDir, err := config.GetDataDir()
+	if err != nil {
+		return fmt.Errorf("failed to get data directory: %w", err)
+	}
+	profileBaseDir = baseDir
+
+	dir := profileDataDir(baseDir, name)
+	if err := ensureProfileDataDir(dir); err != nil {
+		return err
+	}
+
+	config.Set(config.KeyAppName, name)
+	config.Set(config.KeyDataDir, dir)
+	config.Set(config.KeyNetworkName, name)
+	config.Set(config.KeyNetworkBridge, name+"br0")
+
+	// Override flag variables still holding their defaults so that explicit
+	// flags continue to take precedence
+	if networkName == "" || networkName == docker.DefaultNetworkName {
+		networkName = name
+	}
+	if profileUsesAutoCIDR() {
+		networkCIDR = config.AutoNetworkCIDR
+		config.Set(config.KeyNetworkCIDR, config.AutoNetworkCIDR)
+	}
+	if stepCAContainerName == "" || stepCAContainerName == docker.StepCAContainerName {
+		stepCAContainerName = name + "-step-ca"
+	}
+	if zotContainerName == "" || zotContainerName == docker.ZotContainerName {
+		zotContainerName = name + "-zot"
+	}
+	if gatusContainerName == "" || gatusContainerName == docker.GatusContainerName {
+		gatusContainerName = name + "-gatus"
+	}
+	if traefikContainerName == "" || traefikContainerName == docker.TraefikContainerName {
+		traefikContainerName = name + "-traefik"
+	}
+
+	return nil
+}
+
+// profileUsesAutoCIDR reports whether the network CIDR is left at its default,
+// which a profile replaces with auto so it does not clash with other profiles
+func profileUsesAutoCIDR() bool {
+	return (networkCIDR == "" || networkCIDR == docker.DefaultNetworkCIDR) &&
+		config.ValueSource(config.KeyNetworkCIDR) == config.SourceDefault &&
+		config.GetString(config.KeyNetworkCIDR) == config.DefaultNetworkCIDR
+}
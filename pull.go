@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"codeberg.org/hipkoi/kinder/docker"
+)
+
+// pullBarWidth is the width of the pull progress bar in characters
+const pullBarWidth = 20
+
+// parsePullPolicy validates a --pull-policy value
+func parsePullPolicy(value string) (docker.PullPolicy, error) {
+	policy := docker.PullPolicy(strings.ToLower(value))
+	if !slices.Contains(docker.PullPolicies, policy) {
+		return "", fmt.Errorf("invalid pull policy %q (valid: always, missing, never)", value)
+	}
+	return policy, nil
+}
+
+// applyPullPolicy validates --pull-policy and sets it for the images pulled
+// as containers are created
+func applyPullPolicy() (docker.PullPolicy, error) {
+	policy, err := parsePullPolicy(pullPolicy)
+	if err != nil {
+		return "", err
+	}
+	docker.SetPullPolicy(policy)
+	return policy, nil
+}
+
+// applyRegistryAuth passes --registry-auth credentials to the docker
+// package for image pulls
+func applyRegistryAuth() error {
+	creds, err := parseKeyValues("--registry-auth", registryAuthFlags)
+	if err != nil {
+		return err
+	}
+	if err := docker.SetRegistryAuth(creds); err != nil {
+		return fmt.Errorf("--registry-auth: %w", err)
+	}
+	return nil
+}
+
+// startImages returns the images start runs: the service images and the
+// Kind node image. Step CA's is left out when an external CA is used.
+func startImages() ([]string, error) {
+	var images []string
+	if externalCAURL() == "" {
+		images = append(images, stepCAImage)
+	}
+	images = append(images, zotImage, gatusImage, traefikImage)
+
+	nodeImage, _, err := resolveNodeImage()
+	if err != nil {
+		return nil, err
+	}
+	return append(images, nodeImage), nil
+}
+
+// prePullImages pulls images according to policy before anything is
+// created, showing download progress so a cold start does not appear to
+// hang
+func prePullImages(ctx context.Context, policy docker.PullPolicy, images []string) error {
+	for _, ref := range images {
+		exists, err := docker.ImageExists(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if policy == docker.PullNever && !exists {
+			return fmt.Errorf("image %s is not present locally and the pull policy is never", ref)
+		}
+		if policy == docker.PullNever || (policy == docker.PullMissing && exists) {
+			Verbose("Image %s is present\n", ref)
+			continue
+		}
+
+		label := imageLabel(ref)
+		ProgressStart("📥", label)
+		bar := newPullProgressBar(label)
+		if err := docker.PullImage(ctx, ref, bar.update); err != nil {
+			bar.clear()
+			ProgressDone(false, err.Error())
+			return err
+		}
+		bar.clear()
+		ProgressDone(true, "Pulled")
+	}
+	return nil
+}
+
+// imageLabel shortens an image reference to its last path element, without
+// a digest, for progress output
+func imageLabel(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		ref = ref[i+1:]
+	}
+	return ref
+}
+
+// pullProgressBar redraws a progress line while an image downloads. It only
+// draws in default verbosity on a terminal, as it relies on carriage returns.
+type pullProgressBar struct {
+	label   string
+	enabled bool
+	drawn   bool
+}
+
+func newPullProgressBar(label string) *pullProgressBar {
+	return &pullProgressBar{
+		label:   label,
+		enabled: GetVerbosity() == VerbosityDefault && !jsonLogs && !stripANSI && isTerminal(os.Stdout),
+	}
+}
+
+func (b *pullProgressBar) update(p docker.PullProgress) {
+	if !b.enabled || p.Total == 0 {
+		return
+	}
+	b.drawn = true
+	Output("\r  📥 %-16s%s", b.label, renderPullBar(p, pullBarWidth))
+}
+
+// clear removes the bar so the line can be completed by ProgressDone
+func (b *pullProgressBar) clear() {
+	if b.drawn {
+		Output("\r\x1b[K  📥 %-16s", b.label)
+	}
+}
+
+// renderPullBar draws "[=====>    ]  45%" for the downloaded share of an
+// image
+func renderPullBar(p docker.PullProgress, width int) string {
+	percent := 0
+	if p.Total > 0 {
+		percent = int(min(p.Current*100/p.Total, 100))
+	}
+	filled := percent * width / 100
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	return fmt.Sprintf("[%s] %3d%%", bar, percent)
+}
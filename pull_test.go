@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"codeberg.org/hipkoi/kinder/docker"
+	"github.com/spf13/cobra"
+)
+
+func TestParsePullPolicy(t *testing.T) {
+	if policy, err := parsePullPolicy("Always"); err != nil || policy != docker.PullAlways {
+		t.Errorf("expected always, got %q (%v)", policy, err)
+	}
+	if _, err := parsePullPolicy("sometimes"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
+
+func TestPullPolicyFlag(t *testing.T) {
+	// Every command that creates service containers honours --pull-policy
+	for _, cmd := range []*cobra.Command{startCmd, restartCmd, containerStartCmd} {
+		flag := cmd.Flags().Lookup("pull-policy")
+		if flag == nil {
+			t.Errorf("%s has no --pull-policy flag", cmd.CommandPath())
+			continue
+		}
+		if flag.DefValue != string(docker.PullMissing) {
+			t.Errorf("%s: expected default %s, got %s", cmd.CommandPath(), docker.PullMissing, flag.DefValue)
+		}
+	}
+}
+
+func TestImageLabel(t *testing.T) {
+	tests := map[string]string{
+		"traefik:v3.6":                           "traefik:v3.6",
+		"ghcr.io/project-zot/zot-linux-amd64:v2": "zot-linux-amd64:v2",
+		"kindest/node:v1.35.0@sha256:abc":        "node:v1.35.0",
+	}
+	for ref, expected := range tests {
+		if got := imageLabel(ref); got != expected {
+			t.Errorf("imageLabel(%s) = %s, want %s", ref, got, expected)
+		}
+	}
+}
+
+func TestRenderPullBar(t *testing.T) {
+	tests := []struct {
+		progress docker.PullProgress
+		expected string
+	}{
+		{docker.PullProgress{Current: 0, Total: 100}, "[>         ]   0%"},
+		{docker.PullProgress{Current: 45, Total: 100}, "[====>     ]  45%"},
+		{docker.PullProgress{Current: 100, Total: 100}, "[==========] 100%"},
+	}
+	for _, tt := range tests {
+		if got := renderPullBar(tt.progress, 10); got != tt.expected {
+			t.Errorf("renderPullBar(%+v) = %q, want %q", tt.progress, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
+	"codeberg.org/hipkoi/kinder/kubernetes"
+	"codeberg.org/hipkoi/kinder/ociartifact"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/daemon"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Registry flags
+	registryWarmupConcurrency int
+	registryOutput            string
+)
+
+// zotHostPort returns the host port Zot is published on
+func zotHostPort() string {
+	if port := config.GetString(config.KeyZotPort); port != "" {
+		return port
+	}
+	return docker.DefaultZotPort
+}
+
+// localRegistry returns the Zot registry as reached from the host
+func localRegistry() string {
+	return "localhost:" + zotHostPort()
+}
+
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the local Zot registry",
+	Long: `Commands for the local Zot registry, which mirrors the configured upstream
+registries for the Kind cluster.`,
+}
+
+var registryWarmupCmd = &cobra.Command{
+	Use:   "warmup [image...]",
+	Short: "Pre-pull images into the registry mirror",
+	Long: `Copy images from their upstream registries into Zot, under the path the
+Kind nodes pull them from, so the first workload using them starts quickly.
+
+Without arguments the kinder node and service images are copied. Images must
+come from a mirrored registry (see 'registryMirrors' in the config). Several
+images are copied at once; use --concurrency to change how many.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		images := args
+		if len(images) == 0 {
+			images = defaultWarmupImages()
+		}
+		if registryWarmupConcurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+
+		mirrors := buildRegistryMirrorMap()
+		results := warmupImages(ctx, images, registryWarmupConcurrency, func(ctx context.Context, image string) (string, error) {
+			target, err := mirrorRef(image, mirrors)
+			if err != nil {
+				return "", err
+			}
+			return target, ociartifact.Copy(ctx, image, target)
+		})
+
+		failed := 0
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to warm up %d of %d images", failed, len(results))
+		}
+		Success(fmt.Sprintf("Warmed up %d images", len(results)))
+		return nil
+	},
+}
+
+var registryLsCmd = &cobra.Command{
+	Use:   "ls [repo]",
+	Short: "List repositories and tags in the registry",
+	Long: `List the repositories cached in Zot with their tags, or only the tags of
+the given repository. Use --output json for scripting.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		registryURL := "http://" + localRegistry()
+
+		repos := args
+		if len(repos) == 0 {
+			var err error
+			if repos, err = docker.ListRepositories(ctx, registryURL); err != nil {
+				return err
+			}
+		}
+
+		listing := make([]repositoryTags, 0, len(repos))
+		for _, repo := range repos {
+			tags, err := docker.ListTags(ctx, registryURL, repo)
+			if err != nil {
+				return err
+			}
+			listing = append(listing, repositoryTags{Repository: repo, Tags: tags})
+		}
+
+		return writeRepositories(os.Stdout, registryOutput, listing)
+	},
+}
+
+var registryPushCmd = &cobra.Command{
+	Use:   "push <src-ref> <dest-repo:tag>",
+	Short: "Copy an image into the registry",
+	Long: `Copy an image into Zot so the Kind cluster can pull it, for example an app
+image built locally:
+
+  kinder registry push myapp:dev myapp:dev
+
+The image is taken from the local Docker daemon if it has it, otherwise from
+its registry. The destination is a repository and tag in the kinder registry;
+the command prints the reference to use in manifests.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		dest, err := localImageRef(args[1])
+		if err != nil {
+			return err
+		}
+
+		var dockerClient daemon.Client
+		if c, err := docker.GetSharedClient(); err == nil {
+			dockerClient = c.Raw()
+		} else {
+			Verbose("Docker is not available, pulling %s from its registry: %v\n", args[0], err)
+		}
+
+		digest, err := ociartifact.PushImage(ctx, dockerClient, args[0], localRegistry()+"/"+dest)
+		if err != nil {
+			return fmt.Errorf("failed to push %s: %w", args[0], err)
+		}
+
+		Success(fmt.Sprintf("Pushed %s/%s", localRegistry(), dest))
+		Result("Digest: %s\n", digest)
+		Result("Use in manifests: %s:5000/%s\n", docker.ZotHostname, dest)
+		return nil
+	},
+}
+
+// localImageRef validates a destination such as "myapp:dev" for the kinder
+// registry and returns it with a tag, defaulting to latest. It must not name
+// a registry.
+func localImageRef(dest string) (string, error) {
+	ref, err := name.NewTag(localRegistry()+"/"+dest, name.Insecure)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination %q: %w", dest, err)
+	}
+	if ref.RegistryStr() != localRegistry() {
+		return "", fmt.Errorf("invalid destination %q: expected a repository and tag such as myapp:dev", dest)
+	}
+	return ref.RepositoryStr() + ":" + ref.TagStr(), nil
+}
+
+// repositoryTags is a registry repository and its tags
+type repositoryTags struct {
+	Repository string   `json:"repository"`
+	Tags       []string `json:"tags"`
+}
+
+// writeRepositories writes a registry listing as text or json
+func writeRepositories(w io.Writer, format string, listing []repositoryTags) error {
+	switch format {
+	case "text":
+		for _, r := range listing {
+			fmt.Fprintf(w, "%s: %s\n", r.Repository, orDash(strings.Join(r.Tags, ", ")))
+		}
+	case "json":
+		data, err := json.MarshalIndent(listing, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal repositories: %w", err)
+		}
+		fmt.Fprintln(w, string(data))
+	default:
+		return fmt.Errorf("invalid output format %q (valid: text, json)", format)
+	}
+	return nil
+}
+
+// defaultWarmupImages returns the kinder node and service images
+func defaultWarmupImages() []string {
+	return []string{
+		kubernetes.KindNodeImage,
+		docker.StepCAImage,
+		docker.TraefikImage,
+		docker.GatusImage,
+	}
+}
+
+// warmupResult is the outcome of copying one image
+type warmupResult struct {
+	Image  string
+	Target string
+	Err    error
+}
+
+// warmupImages copies images with up to concurrency copies at once,
+// reporting each as it finishes. Results are returned in input order.
+func warmupImages(ctx context.Context, images []string, concurrency int, copyImage func(ctx context.Context, image string) (string, error)) []warmupResult {
+	results := make([]warmupResult, len(images))
+	jobs := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(concurrency, len(images)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				target, err := copyImage(ctx, images[i])
+				results[i] = warmupResult{Image: images[i], Target: target, Err: err}
+				done <- i
+			}
+		}()
+	}
+
+	go func() {
+		for i := range images {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+		close(done)
+	}()
+
+	for i := range done {
+		if r := results[i]; r.Err != nil {
+			Output("  ✗ %s: %v\n", r.Image, r.Err)
+		} else {
+			Output("  ✓ %s\n", r.Image)
+			Verbose("    → %s\n", r.Target)
+		}
+	}
+	return results
+}
+
+// mirrorRef returns where an image is stored in Zot: the local registry with
+// the image's repository path, which is what containerd requests from the
+// mirror. The image's registry must be one of the mirrors.
+func mirrorRef(image string, mirrors map[string]string) (string, error) {
+	ref, err := name.ParseReference(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference: %w", err)
+	}
+
+	registry := registryKey(ref.Context().RegistryStr())
+	mirrored := false
+	for m := range mirrors {
+		if registryKey(m) == registry {
+			mirrored = true
+			break
+		}
+	}
+	if !mirrored {
+		return "", fmt.Errorf("registry %s is not mirrored", registry)
+	}
+
+	target := localRegistry() + "/" + ref.Context().RepositoryStr()
+	switch r := ref.(type) {
+	case name.Digest:
+		return target + "@" + r.DigestStr(), nil
+	case name.Tag:
+		return target + ":" + r.TagStr(), nil
+	}
+	return target, nil
+}
+
+// registryKey maps the names Docker Hub goes by to docker.io, so configured
+// mirrors and parsed references compare equal
+func registryKey(registry string) string {
+	switch registry {
+	case name.DefaultRegistry, "registry-1.docker.io":
+		return "docker.io"
+	}
+	return registry
+}
+
+func init() {
+	// Setup flags for registry warmup command
+	registryWarmupCmd.Flags().IntVar(&registryWarmupConcurrency, "concurrency", 4, "Number of images to copy at once")
+
+	// Setup flags for registry ls command
+	registryLsCmd.Flags().StringVarP(&registryOutput, "output", "o", "text", "Output format: text or json")
+
+	// Add commands to registry
+	registryCmd.AddCommand(registryWarmupCmd)
+	registryCmd.AddCommand(registryLsCmd)
+	registryCmd.AddCommand(registryPushCmd)
+}
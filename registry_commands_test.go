@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMirrorRef(t *testing.T) {
+	mirrors := map[string]string{
+		"registry-1.docker.io": "http://zot:5000",
+		"ghcr.io":              "http://zot:5000",
+	}
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		image   string
+		want    string
+		wantErr bool
+	}{
+		{"nginx", "localhost:5000/library/nginx:latest", false},
+		{"docker.io/kindest/node:v1.32.2", "localhost:5000/kindest/node:v1.32.2", false},
+		{"ghcr.io/project-zot/zot:v2@" + digest, "localhost:5000/project-zot/zot@" + digest, false},
+		{"quay.io/jetstack/cert-manager-controller:v1.16.0", "", true},
+		{"Not A Ref", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := mirrorRef(tt.image, mirrors)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mirrorRef(%q) error = %v, wantErr %v", tt.image, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("mirrorRef(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarmupImages(t *testing.T) {
+	images := []string{"a", "b", "c", "d", "e"}
+
+	var running, peak atomic.Int32
+	results := warmupImages(context.Background(), images, 2, func(ctx context.Context, image string) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if image == "c" {
+			return "", errors.New("not found")
+		}
+		return "localhost:5000/" + image, nil
+	})
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent copies, got %d", got)
+	}
+	if len(results) != len(images) {
+		t.Fatalf("expected %d results, got %d", len(images), len(results))
+	}
+	for i, r := range results {
+		if r.Image != images[i] {
+			t.Errorf("result %d: expected image %s, got %s", i, images[i], r.Image)
+		}
+		if (r.Err != nil) != (r.Image == "c") {
+			t.Errorf("result %d: unexpected error %v", i, r.Err)
+		}
+	}
+}
+
+func TestWriteRepositories(t *testing.T) {
+	listing := []repositoryTags{
+		{Repository: "library/nginx", Tags: []string{"1.27", "latest"}},
+		{Repository: "empty"},
+	}
+
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{"text", "library/nginx: 1.27, latest\nempty: -\n", false},
+		{"json", `[
+  {
+    "repository": "library/nginx",
+    "tags": [
+      "1.27",
+      "latest"
+    ]
+  },
+  {
+    "repository": "empty",
+    "tags": null
+  }
+]
+`, false},
+		{"yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeRepositories(&buf, tt.format, listing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeRepositories() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("unexpected output:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalImageRef(t *testing.T) {
+	tests := []struct {
+		dest    string
+		want    string
+		wantErr bool
+	}{
+		{"myapp:dev", "myapp:dev", false},
+		{"team/myapp", "team/myapp:latest", false},
+		{"MyApp:dev", "", true},
+		{"myapp@sha256:abc", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			got, err := localImageRef(tt.dest)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("localImageRef(%q) error = %v, wantErr %v", tt.dest, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("localImageRef(%q) = %q, want %q", tt.dest, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"context"
-
 	"github.com/spf13/cobra"
 )
 
@@ -18,7 +16,7 @@ var stepCAStartCmd = &cobra.Command{
 	Short: "Start Step CA container",
 	Long:  `Start the Step CA container using the generated root CA certificate.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return startStepCA(context.Background())
+		return startStepCA(cmd.Context())
 	},
 }
 
@@ -27,7 +25,7 @@ var stepCAStopCmd = &cobra.Command{
 	Short: "Stop and remove Step CA container",
 	Long:  `Stop and remove the Step CA container.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stopStepCA(context.Background())
+		return stopStepCA(cmd.Context())
 	},
 }
 
@@ -43,7 +41,7 @@ var zotStartCmd = &cobra.Command{
 	Short: "Start Zot registry container",
 	Long:  `Start the Zot container registry.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return startZot(context.Background())
+		return startZot(cmd.Context())
 	},
 }
 
@@ -52,7 +50,7 @@ var zotStopCmd = &cobra.Command{
 	Short: "Stop and remove Zot container",
 	Long:  `Stop and remove the Zot registry container.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stopZot(context.Background())
+		return stopZot(cmd.Context())
 	},
 }
 
@@ -68,7 +66,7 @@ var gatusStartCmd = &cobra.Command{
 	Short: "Start Gatus health dashboard container",
 	Long:  `Start the Gatus health dashboard container.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return startGatus(context.Background())
+		return startGatus(cmd.Context())
 	},
 }
 
@@ -77,7 +75,7 @@ var gatusStopCmd = &cobra.Command{
 	Short: "Stop and remove Gatus container",
 	Long:  `Stop and remove the Gatus health dashboard container.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stopGatus(context.Background())
+		return stopGatus(cmd.Context())
 	},
 }
 
@@ -93,7 +91,7 @@ var traefikStartCmd = &cobra.Command{
 	Short: "Start Traefik reverse proxy container",
 	Long:  `Start the Traefik reverse proxy container.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return startTraefik(context.Background())
+		return startTraefik(cmd.Context())
 	},
 }
 
@@ -102,6 +100,6 @@ var traefikStopCmd = &cobra.Command{
 	Short: "Stop and remove Traefik container",
 	Long:  `Stop and remove the Traefik reverse proxy container.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return stopTraefik(context.Background())
+		return stopTraefik(cmd.Context())
 	},
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"codeberg.org/hipkoi/kinder/docker"
+	"codeberg.org/hipkoi/kinder/kubernetes"
+)
+
+// cleanupTimeout bounds how long removing partially created resources may
+// take once the command's own context has been cancelled
+const cleanupTimeout = 2 * time.Minute
+
+// errInterrupted is returned by a start that was stopped with Ctrl-C or
+// SIGTERM
+var errInterrupted = errors.New("interrupted")
+
+// trackedResource is a resource created by the current invocation
+type trackedResource struct {
+	Name   string
+	remove func(context.Context) error
+}
+
+// startupTracker records the networks, containers and clusters a start
+// creates, so they can be removed again if it does not finish. Resources
+// that existed before the start are never recorded.
+type startupTracker struct {
+	created []trackedResource
+}
+
+// record adds a resource created by this invocation and how to remove it
+func (t *startupTracker) record(name string, remove func(context.Context) error) {
+	t.created = append(t.created, trackedResource{Name: name, remove: remove})
+}
+
+// container runs start and records containerName if it did not exist
+// before. It is recorded even when start fails, as the container may have
+// been created but not started.
+func (t *startupTracker) container(ctx context.Context, containerName string, start func(context.Context) error, remove func(context.Context, string) error) error {
+	exists, err := docker.ContainerExists(ctx, containerName)
+	if err != nil {
+		return fmt.Errorf("failed to check if container exists: %w", err)
+	}
+	err = start(ctx)
+	if !exists {
+		t.record("container "+containerName, func(ctx context.Context) error {
+			exists, err := docker.ContainerExists(ctx, containerName)
+			if err != nil || !exists {
+				return err
+			}
+			return remove(ctx, containerName)
+		})
+	}
+	return err
+}
+
+// cluster runs start and records the Kind cluster if it did not exist before
+func (t *startupTracker) cluster(ctx context.Context, clusterName string, start func(context.Context) error) error {
+	exists, err := kubernetes.KindExists(clusterName)
+	if err != nil {
+		return fmt.Errorf("failed to check cluster status: %w", err)
+	}
+	err = start(ctx)
+	if !exists {
+		t.record("Kind cluster "+clusterName, func(context.Context) error {
+			exists, err := kubernetes.KindExists(clusterName)
+			if err != nil || !exists {
+				return err
+			}
+			return kubernetes.StopKind(kubernetes.KindConfig{ClusterName: clusterName, Verbose: IsVerbose(), Logger: kindLog{}})
+		})
+	}
+	return err
+}
+
+// rollback removes the recorded resources in reverse order of creation. It
+// is best effort: every resource is attempted and the failures returned.
+func (t *startupTracker) rollback(ctx context.Context) []error {
+	var errs []error
+	for i := len(t.created) - 1; i >= 0; i-- {
+		res := t.created[i]
+		Verbose("Removing %s\n", res.Name)
+		if err := res.remove(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Name, err))
+		}
+	}
+	t.created = nil
+	return errs
+}
+
+// rollbackStart removes what a failed or interrupted start created, saying
+// why. The command's context may already be cancelled, so a fresh one is
+// used.
+func rollbackStart(tracker *startupTracker, reason string) {
+	if len(tracker.created) == 0 {
+		return
+	}
+	BlankLine()
+	Warn("%s, removing resources created by this run\n", reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	for _, err := range tracker.rollback(ctx) {
+		Error("failed to remove %v\n", err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStartupTrackerRollback(t *testing.T) {
+	var removed []string
+	remove := func(name string, err error) func(context.Context) error {
+		return func(context.Context) error {
+			removed = append(removed, name)
+			return err
+		}
+	}
+
+	tracker := &startupTracker{}
+	tracker.record("network kinder", remove("network", nil))
+	tracker.record("container kinder-zot", remove("zot", errors.New("busy")))
+	tracker.record("Kind cluster kinder", remove("cluster", nil))
+
+	errs := tracker.rollback(context.Background())
+
+	if got := strings.Join(removed, ","); got != "cluster,zot,network" {
+		t.Errorf("expected removal in reverse order, got %s", got)
+	}
+	if len(errs) != 1 || errs[0].Error() != "container kinder-zot: busy" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if len(tracker.created) != 0 {
+		t.Errorf("expected tracker to be empty after rollback, got %d", len(tracker.created))
+	}
+}
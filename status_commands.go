@@ -1,73 +1,300 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
 	"codeberg.org/hipkoi/kinder/docker"
 	"codeberg.org/hipkoi/kinder/kubernetes"
+	"github.com/docker/docker/api/types/container"
 	"github.com/spf13/cobra"
 )
 
+// containerLister is the subset of the Docker API used to build a status snapshot
+type containerLister interface {
+	ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error)
+}
+
+// statusSnapshot holds the result of a single container list so that all
+// status sections report a consistent view. It is not modified after
+// creation and is safe for concurrent reads.
+type statusSnapshot struct {
+	containers map[string]container.Summary
+}
+
+// takeStatusSnapshot lists all containers once and indexes them by name
+func takeStatusSnapshot(ctx context.Context, lister containerLister) (*statusSnapshot, error) {
+	containers, err := lister.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	snapshot := &statusSnapshot{containers: make(map[string]container.Summary, len(containers))}
+	for _, ctr := range containers {
+		for _, name := range ctr.Names {
+			// Docker prefixes container names with "/"
+			snapshot.containers[strings.TrimPrefix(name, "/")] = ctr
+		}
+	}
+
+	return snapshot, nil
+}
+
+// newStatusSnapshotFromDocker takes a status snapshot using the shared Docker client
+func newStatusSnapshotFromDocker(ctx context.Context) (*statusSnapshot, error) {
+	c, err := docker.GetSharedClient()
+	if err != nil {
+		return nil, err
+	}
+	return takeStatusSnapshot(ctx, c.Raw())
+}
+
+// container returns the named container, if present
+func (s *statusSnapshot) container(name string) (container.Summary, bool) {
+	if s == nil {
+		return container.Summary{}, false
+	}
+	ctr, ok := s.containers[name]
+	return ctr, ok
+}
+
+// kindNodes returns the node containers of the named Kind cluster, sorted by name
+func (s *statusSnapshot) kindNodes(cluster string) []container.Summary {
+	if s == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var nodes []container.Summary
+	for _, ctr := range s.containers {
+		if ctr.Labels[kubernetes.KindClusterLabel] != cluster || seen[ctr.ID] {
+			continue
+		}
+		seen[ctr.ID] = true
+		nodes = append(nodes, ctr)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return containerName(nodes[i]) < containerName(nodes[j])
+	})
+
+	return nodes
+}
+
+// networkContainers returns the number of containers attached to the named network
+func (s *statusSnapshot) networkContainers(network string) int {
+	if s == nil {
+		return 0
+	}
+
+	seen := make(map[string]bool)
+	for _, ctr := range s.containers {
+		if ctr.NetworkSettings == nil || seen[ctr.ID] {
+			continue
+		}
+		if _, ok := ctr.NetworkSettings.Networks[network]; ok {
+			seen[ctr.ID] = true
+		}
+	}
+
+	return len(seen)
+}
+
+// containerName returns the primary name of a container without the "/" prefix
+func containerName(ctr container.Summary) string {
+	if len(ctr.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(ctr.Names[0], "/")
+}
+
+// containerStateText describes a container's state, including uptime when running
+func containerStateText(ctr container.Summary) string {
+	switch ctr.State {
+	case container.StateRunning:
+		// Status is e.g. "Up 3 hours (healthy)"
+		if uptime := strings.TrimPrefix(ctr.Status, "Up "); uptime != ctr.Status && uptime != "" {
+			return fmt.Sprintf("running (%s)", uptime)
+		}
+		return "running"
+	case container.StatePaused:
+		return "paused"
+	case container.StateRestarting:
+		return "restarting"
+	case "":
+		return "unknown"
+	}
+	return string(ctr.State)
+}
+
+// statusReport holds the rendered sections of a status report, gathered
+// before anything is written so a report can be printed in one go
+type statusReport struct {
+	CA          string `json:"-"`
+	Network     string `json:"-"`
+	Containers  string `json:"-"`
+	KindCluster string `json:"-"`
+	ArgoCD      string `json:"-"`
+	Endpoints   string `json:"-"`
+
+	// Services holds the structured state of the service containers, the
+	// part of the report printed by --output json
+	Services []containerStatus `json:"containers"`
+}
+
+// gatherStatus collects the status of all kinder components
+func gatherStatus(ctx context.Context) (*statusReport, error) {
+	caCertPath, err := config.CACertPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch the container list once and derive all container, node and
+	// cluster states from it
+	snapshot, snapErr := newStatusSnapshotFromDocker(ctx)
+
+	report := &statusReport{
+		CA:          checkCAStatus(caCertPath),
+		Network:     checkNetworkStatus(ctx, snapshot),
+		KindCluster: checkKindClusterStatus(snapshot),
+		ArgoCD:      checkArgoCDStatus(ctx, snapshot),
+		Endpoints:   checkEndpointsStatus(snapshot),
+	}
+	services, err := statusServices()
+	switch {
+	case snapErr != nil:
+		report.Containers = fmt.Sprintf("   ✗ Error listing containers: %v\n", snapErr)
+	case err != nil:
+		report.Containers = fmt.Sprintf("   ✗ Error: %v\n", err)
+	default:
+		var inspector containerInspector
+		if c, err := docker.GetSharedClient(); err == nil {
+			inspector = c.Raw()
+		}
+		report.Services = collectContainerStatus(ctx, snapshot, inspector, services)
+		report.Containers = formatContainerStatus(services, report.Services)
+	}
+
+	return report, nil
+}
+
+// writeStatusReport renders a status report
+func writeStatusReport(w io.Writer, report *statusReport) {
+	fmt.Fprintln(w, "kinder status")
+	fmt.Fprintln(w, "─────────────────────────────────────────")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "📜 CA Certificate")
+	fmt.Fprintln(w, report.CA)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "🌐 Network")
+	fmt.Fprintln(w, report.Network)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "📦 Containers")
+	fmt.Fprintln(w, report.Containers)
+
+	fmt.Fprintln(w, "☸️ Kind Cluster")
+	fmt.Fprintln(w, report.KindCluster)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "🔄 ArgoCD")
+	fmt.Fprintln(w, report.ArgoCD)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "🔗 Endpoints")
+	fmt.Fprintln(w, report.Endpoints)
+}
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchStatus re-renders the report from gather every interval until ctx is
+// cancelled. Each frame is built in memory and written at once to avoid
+// flicker. On a terminal with ANSI codes enabled the screen is cleared
+// between frames; otherwise frames are appended.
+func watchStatus(ctx context.Context, w io.Writer, interval time.Duration, clear bool, gather func(context.Context) (*statusReport, error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := gather(ctx)
+		if err != nil {
+			return err
+		}
+
+		var frame bytes.Buffer
+		if clear {
+			frame.WriteString(clearScreen)
+		}
+		writeStatusReport(&frame, report)
+		fmt.Fprintf(&frame, "\nUpdated %s, refreshing every %s (Ctrl-C to exit)\n", time.Now().Format("15:04:05"), interval)
+		if !clear {
+			frame.WriteString("\n")
+		}
+		if _, err := w.Write(frame.Bytes()); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show status of kinder components",
-	Long:  `Display the current status of all kinder components including CA certificate, network, containers, and endpoints.`,
+	Long: `Display the current status of all kinder components including CA certificate, network, containers, and endpoints.
+
+Containers that have restarted or exited show their restart count, exit code
+and last error. Use --output json for these container details in JSON, or
+--watch to refresh the report every --interval until Ctrl-C.
+
+The ArgoCD status uses the kind-<cluster> context; use --kubeconfig and
+--context to point it at a different kubeconfig or context.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
-		fmt.Println("kinder status")
-		fmt.Println("─────────────────────────────────────────")
-		fmt.Println()
+		if statusOutput != "text" && statusOutput != "json" {
+			return fmt.Errorf("invalid output format %q (valid: text, json)", statusOutput)
+		}
 
-		// Get data directory
-		dataDir, err := config.GetDataDir()
-		if err != nil {
-			return fmt.Errorf("failed to get data directory: %w", err)
+		if statusWatch {
+			if statusOutput == "json" {
+				return fmt.Errorf("--watch only supports text output")
+			}
+			if statusInterval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			return watchStatus(ctx, reportOut, statusInterval, !stripANSI && isTerminal(os.Stdout), gatherStatus)
 		}
 
-		// CA Certificate status
-		fmt.Println("📜 CA Certificate")
-		caCertPath := filepath.Join(dataDir, CACertFilename)
-		caStatus := checkCAStatus(caCertPath)
-		fmt.Println(caStatus)
-		fmt.Println()
-
-		// Network status
-		fmt.Println("🌐 Network")
-		netStatus := checkNetworkStatus(ctx)
-		fmt.Println(netStatus)
-		fmt.Println()
-
-		// Container status
-		fmt.Println("📦 Containers")
-		containerStatus := checkContainerStatus(ctx)
-		fmt.Println(containerStatus)
-
-		// Kind cluster status
-		fmt.Println("☸️ Kind Cluster")
-		kindStatus := checkKindClusterStatus(ctx)
-		fmt.Println(kindStatus)
-		fmt.Println()
-
-		// ArgoCD status (only if Kind cluster exists)
-		fmt.Println("🔄 ArgoCD")
-		argocdStatus := checkArgoCDStatus(ctx)
-		fmt.Println(argocdStatus)
-		fmt.Println()
-
-		// Endpoints
-		fmt.Println("🔗 Endpoints")
-		endpointsStatus := checkEndpointsStatus(ctx)
-		fmt.Println(endpointsStatus)
+		report, err := gatherStatus(ctx)
+		if err != nil {
+			return err
+		}
+		if statusOutput == "json" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal status: %w", err)
+			}
+			fmt.Fprintln(reportOut, string(data))
+			return nil
+		}
+		writeStatusReport(reportOut, report)
 
 		return nil
 	},
@@ -121,7 +348,7 @@ func checkCAStatus(certPath string) string {
 		info.ModTime().Format("2006-01-02 15:04:05"))
 }
 
-func checkNetworkStatus(ctx context.Context) string {
+func checkNetworkStatus(ctx context.Context, snapshot *statusSnapshot) string {
 	// Get network name from config (apply same derivation as Config.ApplyDefaults)
 	netName := config.GetString(config.KeyNetworkName)
 	if netName == "" || netName == config.DefaultNetworkName {
@@ -145,122 +372,175 @@ func checkNetworkStatus(ctx context.Context) string {
 		if len(shortID) > 12 {
 			shortID = shortID[:12]
 		}
+		if attached := snapshot.networkContainers(netName); attached > 0 {
+			return fmt.Sprintf("   ● %s (ID: %s, %d containers)", netName, shortID, attached)
+		}
 		return fmt.Sprintf("   ● %s (ID: %s)", netName, shortID)
 	}
 
 	return fmt.Sprintf("   ○ %s (not created)", netName)
 }
 
-func checkContainerStatus(ctx context.Context) string {
-	containers := []struct {
-		name    string
-		display string
-	}{
-		{docker.StepCAContainerName, "Step CA"},
-		{docker.ZotContainerName, "Zot Registry"},
-		{docker.GatusContainerName, "Gatus"},
-		{docker.TraefikContainerName, "Traefik"},
-	}
+// containerInspector is the subset of the Docker API used to read restart
+// counts and exit details, which the container list does not include
+type containerInspector interface {
+	ContainerInspect(ctx context.Context, containerID string) (container.InspectResponse, error)
+}
 
-	var result string
-	for _, c := range containers {
-		exists, err := docker.ContainerExists(ctx, c.name)
-		if err != nil {
-			result += fmt.Sprintf("   ✗ %-12s Error: %v\n", c.display, err)
-			continue
-		}
+// containerStatus is the state of one kinder service container
+type containerStatus struct {
+	Service      string `json:"service"`
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	Uptime       string `json:"uptime,omitempty"`
+	RestartCount int    `json:"restartCount"`
+	ExitCode     int    `json:"exitCode"`
+	Error        string `json:"error,omitempty"`
+}
 
-		if exists {
-			// Get more details about the container
-			status := getContainerState(ctx, c.name)
-			result += fmt.Sprintf("   ● %-12s %s\n", c.display, status)
-		} else {
-			result += fmt.Sprintf("   ○ %-12s not running\n", c.display)
+// newContainerStatus builds the status of a container from the snapshot and,
+// when inspect is non-nil, its restart count and last exit
+func newContainerStatus(service string, ctr container.Summary, inspect *container.InspectResponse) containerStatus {
+	status := containerStatus{
+		Service: service,
+		Name:    containerName(ctr),
+		State:   string(ctr.State),
+	}
+	if ctr.State == container.StateRunning {
+		// Status is e.g. "Up 3 hours (healthy)"
+		if uptime := strings.TrimPrefix(ctr.Status, "Up "); uptime != ctr.Status {
+			status.Uptime = uptime
 		}
 	}
-
-	return result
+	if inspect != nil && inspect.ContainerJSONBase != nil {
+		status.RestartCount = inspect.RestartCount
+		if inspect.State != nil {
+			status.ExitCode = inspect.State.ExitCode
+			status.Error = inspect.State.Error
+		}
+	}
+	return status
 }
 
-func getContainerState(ctx context.Context, name string) string {
-	client, err := docker.GetSharedClient()
-	if err != nil {
-		return "unknown"
+// text describes the container state, e.g. "running (2 hours, restarted 4x)"
+// or "exited (code 1): <error>"
+func (s containerStatus) text() string {
+	var details []string
+	if s.Uptime != "" {
+		details = append(details, s.Uptime)
+	}
+	if s.RestartCount > 0 {
+		details = append(details, fmt.Sprintf("restarted %dx", s.RestartCount))
 	}
 
-	info, err := client.Raw().ContainerInspect(ctx, name)
-	if err != nil {
-		return "unknown"
+	state := s.State
+	switch s.State {
+	case "":
+		state = "unknown"
+	case string(container.StateExited), string(container.StateDead):
+		details = append([]string{fmt.Sprintf("code %d", s.ExitCode)}, details...)
 	}
+	if len(details) > 0 {
+		state += " (" + strings.Join(details, ", ") + ")"
+	}
+	if s.Error != "" {
+		state += ": " + s.Error
+	}
+	return state
+}
+
+// healthy reports whether the container is up and has not been restarted
+func (s containerStatus) healthy() bool {
+	return s.State == string(container.StateRunning) && s.RestartCount == 0
+}
 
-	state := info.State
-	if state.Running {
-		// Calculate uptime
-		startedAt, err := time.Parse(time.RFC3339Nano, state.StartedAt)
-		if err == nil {
-			uptime := time.Since(startedAt)
-			return fmt.Sprintf("running (%s)", formatDuration(uptime))
+// collectContainerStatus returns the status of the kinder service containers
+// that exist. Inspect failures leave out the restart count and exit details.
+func collectContainerStatus(ctx context.Context, snapshot *statusSnapshot, inspector containerInspector, services []statusService) []containerStatus {
+	var statuses []containerStatus
+	for _, svc := range services {
+		ctr, ok := snapshot.container(svc.name)
+		if !ok {
+			continue
 		}
-		return "running"
-	}
 
-	if state.Paused {
-		return "paused"
+		var inspect *container.InspectResponse
+		if inspector != nil {
+			if info, err := inspector.ContainerInspect(ctx, ctr.ID); err == nil {
+				inspect = &info
+			}
+		}
+		statuses = append(statuses, newContainerStatus(svc.display, ctr, inspect))
 	}
+	return statuses
+}
 
-	if state.Restarting {
-		return "restarting"
-	}
+// statusService is a kinder service container shown in the status report
+type statusService struct {
+	name    string
+	display string
+}
 
-	return state.Status
+// statusServices returns the kinder service containers for the current config
+func statusServices() ([]statusService, error) {
+	cfg, err := buildConfigFromFlags()
+	if err != nil {
+		return nil, err
+	}
+	return []statusService{
+		{cfg.StepCAContainerName, "Step CA"},
+		{cfg.ZotContainerName, "Zot Registry"},
+		{cfg.GatusContainerName, "Gatus"},
+		{cfg.TraefikContainerName, "Traefik"},
+	}, nil
 }
 
-func formatDuration(d time.Duration) string {
-	if d < time.Minute {
-		return fmt.Sprintf("%ds", int(d.Seconds()))
+// formatContainerStatus renders the Containers section, listing services
+// without a container as not running
+func formatContainerStatus(services []statusService, statuses []containerStatus) string {
+	byService := make(map[string]containerStatus, len(statuses))
+	for _, s := range statuses {
+		byService[s.Service] = s
 	}
-	if d < time.Hour {
-		return fmt.Sprintf("%dm", int(d.Minutes()))
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+
+	var result string
+	for _, svc := range services {
+		s, ok := byService[svc.display]
+		switch {
+		case !ok:
+			result += fmt.Sprintf("   ○ %-12s not running\n", svc.display)
+		case s.healthy():
+			result += fmt.Sprintf("   ● %-12s %s\n", svc.display, s.text())
+		default:
+			result += fmt.Sprintf("   ⚠ %-12s %s\n", svc.display, s.text())
+		}
 	}
-	days := int(d.Hours()) / 24
-	hours := int(d.Hours()) % 24
-	return fmt.Sprintf("%dd %dh", days, hours)
+
+	return result
 }
 
-func checkKindClusterStatus(ctx context.Context) string {
+func checkKindClusterStatus(snapshot *statusSnapshot) string {
 	clusterName := config.GetString(config.KeyAppName)
 	if clusterName == "" {
 		clusterName = config.DefaultAppName
 	}
 
-	exists, err := kubernetes.KindExists(clusterName)
-	if err != nil {
-		return fmt.Sprintf("   ✗ Error checking cluster: %v", err)
-	}
-
-	if !exists {
-		return fmt.Sprintf("   ○ %s (not created)", clusterName)
-	}
-
-	// Get nodes from Kind API
-	nodes, err := kubernetes.GetKindNodes(ctx, clusterName)
-	if err != nil {
-		return fmt.Sprintf("   ● %s (exists, failed to get nodes: %v)", clusterName, err)
+	if snapshot == nil {
+		return fmt.Sprintf("   ✗ %s (container list unavailable)", clusterName)
 	}
 
+	nodes := snapshot.kindNodes(clusterName)
 	if len(nodes) == 0 {
-		return fmt.Sprintf("   ⚠ %s (exists but no nodes found)", clusterName)
+		return fmt.Sprintf("   ○ %s (not created)", clusterName)
 	}
 
 	// Count control-plane vs worker nodes
 	var controlPlanes, workers int
 	for _, node := range nodes {
-		if strings.Contains(node, "control-plane") {
+		switch node.Labels[kubernetes.KindRoleLabel] {
+		case "control-plane":
 			controlPlanes++
-		} else if strings.Contains(node, "worker") {
+		case "worker":
 			workers++
 		}
 	}
@@ -273,16 +553,15 @@ func checkKindClusterStatus(ctx context.Context) string {
 	result = fmt.Sprintf("   ● %s (%s)\n", clusterName, nodeDesc)
 
 	for _, node := range nodes {
-		state := getContainerState(ctx, node)
 		// Extract role from node name (e.g., "kinder-control-plane" -> "control-plane")
-		role := strings.TrimPrefix(node, clusterName+"-")
-		result += fmt.Sprintf("     %-20s %s\n", role, state)
+		role := strings.TrimPrefix(containerName(node), clusterName+"-")
+		result += fmt.Sprintf("     %-20s %s\n", role, containerStateText(node))
 	}
 
 	return result
 }
 
-func checkEndpointsStatus(ctx context.Context) string {
+func checkEndpointsStatus(snapshot *statusSnapshot) string {
 	domain := config.GetString(config.KeyDomain)
 	if domain == "" {
 		domain = config.DefaultDomain
@@ -293,8 +572,11 @@ func checkEndpointsStatus(ctx context.Context) string {
 	}
 
 	// Check if any containers are running to determine if endpoints should be shown
-	traefikRunning, _ := docker.ContainerExists(ctx, docker.TraefikContainerName)
-	if !traefikRunning {
+	traefikName := docker.TraefikContainerName
+	if cfg, err := buildConfigFromFlags(); err == nil {
+		traefikName = cfg.TraefikContainerName
+	}
+	if _, traefikRunning := snapshot.container(traefikName); !traefikRunning {
 		return "   ○ Services not running"
 	}
 
@@ -307,7 +589,7 @@ func checkEndpointsStatus(ctx context.Context) string {
 		{"Step CA", fmt.Sprintf("https://ca.%s:%s", domain, port)},
 		{"Zot Registry", fmt.Sprintf("https://registry.%s:%s", domain, port)},
 		{"Gatus Dashboard", fmt.Sprintf("https://gatus.%s:%s", domain, port)},
-		{"Zot (direct)", "http://localhost:5000"},
+		{"Zot (direct)", "http://" + localRegistry()},
 	}
 
 	for _, ep := range endpoints {
@@ -317,33 +599,31 @@ func checkEndpointsStatus(ctx context.Context) string {
 	return result
 }
 
-func checkArgoCDStatus(ctx context.Context) string {
+func checkArgoCDStatus(ctx context.Context, snapshot *statusSnapshot) string {
 	clusterName := config.GetString(config.KeyAppName)
 	if clusterName == "" {
 		clusterName = config.DefaultAppName
 	}
 
 	// Check if Kind cluster exists first
-	exists, err := kubernetes.KindExists(clusterName)
-	if err != nil {
-		return fmt.Sprintf("   ✗ Error checking cluster: %v", err)
-	}
-	if !exists {
+	if len(snapshot.kindNodes(clusterName)) == 0 {
 		return "   ○ Kind cluster not running"
 	}
 
-	kubectlContext := "kind-" + clusterName
+	if err := checkTool("kubectl"); err != nil {
+		return fmt.Sprintf("   ⚠ Unknown (%v)", err)
+	}
+
+	target := clusterTarget(clusterName)
 
 	// Check if argocd namespace exists
-	nsCmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-		"get", "namespace", "argocd", "-o", "name")
+	nsCmd := target.kubectl(ctx, "get", "namespace", "argocd", "-o", "name")
 	if err := nsCmd.Run(); err != nil {
 		return "   ○ Not installed (namespace 'argocd' not found)"
 	}
 
 	// Get argocd-server deployment status
-	deployCmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-		"get", "deployment", "argocd-server", "-n", "argocd",
+	deployCmd := target.kubectl(ctx, "get", "deployment", "argocd-server", "-n", "argocd",
 		"-o", "jsonpath={.status.availableReplicas}/{.status.replicas}")
 	output, err := deployCmd.Output()
 	if err != nil {
@@ -359,7 +639,7 @@ func checkArgoCDStatus(ctx context.Context) string {
 	parts := strings.Split(replicas, "/")
 	if len(parts) == 2 && parts[0] == parts[1] && parts[0] != "0" {
 		// Get version from argocd-server image tag
-		version := getArgoCDVersion(ctx, kubectlContext)
+		version := getArgoCDVersion(ctx, target)
 		if version != "" {
 			return fmt.Sprintf("   ● Running (%s replicas, %s)", replicas, version)
 		}
@@ -370,9 +650,8 @@ func checkArgoCDStatus(ctx context.Context) string {
 }
 
 // getArgoCDVersion extracts the ArgoCD version from the argocd-server container image
-func getArgoCDVersion(ctx context.Context, kubectlContext string) string {
-	cmd := exec.CommandContext(ctx, "kubectl", "--context", kubectlContext,
-		"get", "deployment", "argocd-server", "-n", "argocd",
+func getArgoCDVersion(ctx context.Context, target kubeTarget) string {
+	cmd := target.kubectl(ctx, "get", "deployment", "argocd-server", "-n", "argocd",
 		"-o", "jsonpath={.spec.template.spec.containers[0].image}")
 	output, err := cmd.Output()
 	if err != nil {
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/kubernetes"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+)
+
+// fakeLister returns a fixed container list and counts calls
+type fakeLister struct {
+	containers []container.Summary
+	err        error
+	calls      int
+}
+
+func (f *fakeLister) ContainerList(ctx context.Context, options container.ListOptions) ([]container.Summary, error) {
+	f.calls++
+	if !options.All {
+		return nil, errors.New("expected all containers to be listed")
+	}
+	return f.containers, f.err
+}
+
+func kindNode(cluster, role, name string, state container.ContainerState) container.Summary {
+	return container.Summary{
+		ID:     name + "-id",
+		Names:  []string{"/" + name},
+		State:  state,
+		Status: "Up 5 minutes",
+		Labels: map[string]string{
+			kubernetes.KindClusterLabel: cluster,
+			kubernetes.KindRoleLabel:    role,
+		},
+	}
+}
+
+func TestStatusSnapshot(t *testing.T) {
+	lister := &fakeLister{containers: []container.Summary{
+		{
+			ID:     "zot-id",
+			Names:  []string{"/kinder-zot"},
+			State:  container.StateRunning,
+			Status: "Up 2 hours",
+			NetworkSettings: &container.NetworkSettingsSummary{
+				Networks: map[string]*network.EndpointSettings{"kinder": {}},
+			},
+		},
+		{
+			ID:     "gatus-id",
+			Names:  []string{"/kinder-gatus"},
+			State:  container.StateExited,
+			Status: "Exited (1) 3 minutes ago",
+		},
+		kindNode("kinder", "worker", "kinder-worker", container.StateRunning),
+		kindNode("kinder", "control-plane", "kinder-control-plane", container.StatePaused),
+		kindNode("other", "control-plane", "other-control-plane", container.StateRunning),
+	}}
+
+	snapshot, err := takeStatusSnapshot(context.Background(), lister)
+	if err != nil {
+		t.Fatalf("takeStatusSnapshot failed: %v", err)
+	}
+	if lister.calls != 1 {
+		t.Errorf("expected a single container list call, got %d", lister.calls)
+	}
+
+	t.Run("container lookup", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expected string
+			found    bool
+		}{
+			{"kinder-zot", "running (2 hours)", true},
+			{"kinder-gatus", "exited", true},
+			{"kinder-traefik", "", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ctr, ok := snapshot.container(tt.name)
+				if ok != tt.found {
+					t.Fatalf("container(%q) found = %v, want %v", tt.name, ok, tt.found)
+				}
+				if ok && containerStateText(ctr) != tt.expected {
+					t.Errorf("containerStateText = %q, want %q", containerStateText(ctr), tt.expected)
+				}
+			})
+		}
+	})
+
+	t.Run("kind nodes", func(t *testing.T) {
+		nodes := snapshot.kindNodes("kinder")
+		if len(nodes) != 2 {
+			t.Fatalf("expected 2 nodes, got %d", len(nodes))
+		}
+		if containerName(nodes[0]) != "kinder-control-plane" || containerName(nodes[1]) != "kinder-worker" {
+			t.Errorf("unexpected node order: %s, %s", containerName(nodes[0]), containerName(nodes[1]))
+		}
+		if got := containerStateText(nodes[0]); got != "paused" {
+			t.Errorf("expected control-plane to be paused, got %q", got)
+		}
+		if nodes := snapshot.kindNodes("missing"); len(nodes) != 0 {
+			t.Errorf("expected no nodes for missing cluster, got %d", len(nodes))
+		}
+	})
+
+	t.Run("network containers", func(t *testing.T) {
+		if got := snapshot.networkContainers("kinder"); got != 1 {
+			t.Errorf("expected 1 container on network, got %d", got)
+		}
+	})
+
+	t.Run("cluster status", func(t *testing.T) {
+		config.Set(config.KeyAppName, "kinder")
+		defer config.Set(config.KeyAppName, config.DefaultAppName)
+
+		status := checkKindClusterStatus(snapshot)
+		if !strings.Contains(status, "1 control-plane, 1 worker") {
+			t.Errorf("unexpected cluster status:\n%s", status)
+		}
+		if lister.calls != 1 {
+			t.Errorf("status checks should not list containers again, got %d calls", lister.calls)
+		}
+	})
+}
+
+func TestStatusSnapshotError(t *testing.T) {
+	lister := &fakeLister{err: errors.New("daemon unavailable")}
+
+	snapshot, err := takeStatusSnapshot(context.Background(), lister)
+	if err == nil {
+		t.Fatal("expected error from failing lister")
+	}
+
+	// A nil snapshot reports nothing rather than panicking
+	if _, ok := snapshot.container("kinder-zot"); ok {
+		t.Error("expected no containers in nil snapshot")
+	}
+	if nodes := snapshot.kindNodes("kinder"); nodes != nil {
+		t.Error("expected no nodes in nil snapshot")
+	}
+}
+
+// frameWriter records each Write as a separate frame
+type frameWriter struct {
+	frames []string
+}
+
+func (f *frameWriter) Write(b []byte) (int, error) {
+	f.frames = append(f.frames, string(b))
+	return len(b), nil
+}
+
+func TestWatchStatus(t *testing.T) {
+	for _, clear := range []bool{true, false} {
+		t.Run(fmt.Sprintf("clear=%v", clear), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			gather := func(context.Context) (*statusReport, error) {
+				calls++
+				if calls == 3 {
+					cancel()
+				}
+				return &statusReport{Network: fmt.Sprintf("   ● frame %d", calls)}, nil
+			}
+
+			w := &frameWriter{}
+			if err := watchStatus(ctx, w, time.Millisecond, clear, gather); err != nil {
+				t.Fatalf("watchStatus failed: %v", err)
+			}
+
+			if len(w.frames) != 3 {
+				t.Fatalf("expected 3 frames, each written at once, got %d", len(w.frames))
+			}
+			for i, frame := range w.frames {
+				if strings.HasPrefix(frame, clearScreen) != clear {
+					t.Errorf("frame %d: expected clear screen prefix %v", i, clear)
+				}
+				if !strings.Contains(frame, fmt.Sprintf("frame %d", i+1)) || !strings.Contains(frame, "kinder status") {
+					t.Errorf("frame %d does not hold a full report:\n%s", i, frame)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchStatusError(t *testing.T) {
+	want := errors.New("no data directory")
+	err := watchStatus(context.Background(), &frameWriter{}, time.Millisecond, false, func(context.Context) (*statusReport, error) {
+		return nil, want
+	})
+	if !errors.Is(err, want) {
+		t.Errorf("expected %v, got %v", want, err)
+	}
+}
+
+// fakeInspector returns canned inspect results by container ID
+type fakeInspector map[string]container.InspectResponse
+
+func (f fakeInspector) ContainerInspect(ctx context.Context, id string) (container.InspectResponse, error) {
+	info, ok := f[id]
+	if !ok {
+		return container.InspectResponse{}, errors.New("no such container")
+	}
+	return info, nil
+}
+
+func inspectResponse(restarts, exitCode int, errMsg string) container.InspectResponse {
+	return container.InspectResponse{ContainerJSONBase: &container.ContainerJSONBase{
+		RestartCount: restarts,
+		State:        &container.State{ExitCode: exitCode, Error: errMsg},
+	}}
+}
+
+// inspected returns a pointer to an inspect response
+func inspected(restarts, exitCode int, errMsg string) *container.InspectResponse {
+	info := inspectResponse(restarts, exitCode, errMsg)
+	return &info
+}
+
+func TestContainerStatusText(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctr         container.Summary
+		inspect     *container.InspectResponse
+		want        string
+		wantHealthy bool
+	}{
+		{
+			name:        "running",
+			ctr:         container.Summary{State: container.StateRunning, Status: "Up 2 hours"},
+			want:        "running (2 hours)",
+			wantHealthy: true,
+		},
+		{
+			name:    "running after restarts",
+			ctr:     container.Summary{State: container.StateRunning, Status: "Up 5 seconds"},
+			inspect: inspected(4, 0, ""),
+			want:    "running (5 seconds, restarted 4x)",
+		},
+		{
+			name:    "exited with error",
+			ctr:     container.Summary{State: container.StateExited, Status: "Exited (1) 3 minutes ago"},
+			inspect: inspected(0, 1, "address already in use"),
+			want:    "exited (code 1): address already in use",
+		},
+		{
+			name: "exited without inspect",
+			ctr:  container.Summary{State: container.StateExited},
+			want: "exited (code 0)",
+		},
+		{
+			name:    "restarting",
+			ctr:     container.Summary{State: container.StateRestarting},
+			inspect: inspected(7, 2, ""),
+			want:    "restarting (restarted 7x)",
+		},
+		{
+			name: "unknown",
+			ctr:  container.Summary{},
+			want: "unknown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := newContainerStatus("Zot Registry", tt.ctr, tt.inspect)
+			if got := status.text(); got != tt.want {
+				t.Errorf("text() = %q, want %q", got, tt.want)
+			}
+			if got := status.healthy(); got != tt.wantHealthy {
+				t.Errorf("healthy() = %v, want %v", got, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestCollectContainerStatus(t *testing.T) {
+	snapshot, err := takeStatusSnapshot(context.Background(), &fakeLister{containers: []container.Summary{
+		{ID: "zot-id", Names: []string{"/zot"}, State: container.StateRunning, Status: "Up 1 minute"},
+		{ID: "step-id", Names: []string{"/step-ca"}, State: container.StateExited},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := []statusService{
+		{"step-ca", "Step CA"},
+		{"zot", "Zot Registry"},
+		{"traefik", "Traefik"},
+	}
+	inspector := fakeInspector{
+		"zot-id":  inspectResponse(3, 0, ""),
+		"step-id": inspectResponse(0, 1, "bad config"),
+	}
+
+	statuses := collectContainerStatus(context.Background(), snapshot, inspector, services)
+	want := []containerStatus{
+		{Service: "Step CA", Name: "step-ca", State: "exited", ExitCode: 1, Error: "bad config"},
+		{Service: "Zot Registry", Name: "zot", State: "running", Uptime: "1 minute", RestartCount: 3},
+	}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Fatalf("collectContainerStatus() = %+v, want %+v", statuses, want)
+	}
+
+	text := formatContainerStatus(services, statuses)
+	for _, line := range []string{
+		"⚠ Step CA      exited (code 1): bad config",
+		"⚠ Zot Registry running (1 minute, restarted 3x)",
+		"○ Traefik      not running",
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("expected %q in:\n%s", line, text)
+		}
+	}
+
+	// Inspect failures fall back to the container list
+	statuses = collectContainerStatus(context.Background(), snapshot, fakeInspector{}, services)
+	if statuses[1].RestartCount != 0 || statuses[1].State != "running" {
+		t.Errorf("expected list-only status without inspect, got %+v", statuses[1])
+	}
+}
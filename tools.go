@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// externalTool is a command-line tool some kinder features shell out to
+type externalTool struct {
+	// name is the executable looked up in PATH
+	name string
+	// feature describes what is unavailable without the tool
+	feature string
+	// versionArgs print the tool's version, if it has a short form
+	versionArgs []string
+}
+
+// externalTools are the tools kinder can use. None is needed to start the
+// environment; each only enables the features listed.
+var externalTools = []externalTool{
+	{
+		name:        "kubectl",
+		feature:     "ArgoCD status and health checks, argocd --use-kubectl and kind dump",
+		versionArgs: []string{"version", "--client"},
+	},
+	{
+		name:    "lsof",
+		feature: "naming the process holding a busy port",
+	},
+}
+
+// lookTool finds a tool in PATH; tests replace it
+var lookTool = exec.LookPath
+
+// checkTool returns an error naming the feature that is unavailable when the
+// named tool is not in PATH
+func checkTool(name string) error {
+	if _, err := lookTool(name); err != nil {
+		for _, tool := range externalTools {
+			if tool.name == name {
+				return fmt.Errorf("%s not found in PATH: install %s for %s", name, name, tool.feature)
+			}
+		}
+		return fmt.Errorf("%s not found in PATH: install %s", name, name)
+	}
+	return nil
+}
+
+// toolVersion returns the first line of the tool's version output, or ""
+func toolVersion(ctx context.Context, path string, args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, path, args...).Output()
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// writeToolReport lists the external tools, whether each is installed and its
+// version. version is called with the tool's path.
+func writeToolReport(ctx context.Context, w io.Writer, version func(ctx context.Context, path string, args []string) string) {
+	for _, tool := range externalTools {
+		path, err := lookTool(tool.name)
+		if err != nil {
+			fmt.Fprintf(w, "   ○ %-8s not installed (needed for %s)\n", tool.name, tool.feature)
+			continue
+		}
+		if v := version(ctx, path, tool.versionArgs); v != "" {
+			fmt.Fprintf(w, "   ● %-8s %s (%s)\n", tool.name, path, v)
+		} else {
+			fmt.Fprintf(w, "   ● %-8s %s\n", tool.name, path)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeLookTool makes only the given tools appear installed
+func fakeLookTool(t *testing.T, installed ...string) {
+	t.Helper()
+	orig := lookTool
+	t.Cleanup(func() { lookTool = orig })
+	lookTool = func(name string) (string, error) {
+		for _, tool := range installed {
+			if tool == name {
+				return "/usr/bin/" + name, nil
+			}
+		}
+		return "", errors.New("executable file not found in $PATH")
+	}
+}
+
+func TestCheckTool(t *testing.T) {
+	fakeLookTool(t, "kubectl")
+
+	tests := []struct {
+		name    string
+		tool    string
+		wantErr string
+	}{
+		{name: "installed", tool: "kubectl"},
+		{name: "missing known tool", tool: "lsof", wantErr: "install lsof for naming the process holding a busy port"},
+		{name: "missing unknown tool", tool: "helm", wantErr: "helm not found in PATH"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTool(tt.tool)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWriteToolReport(t *testing.T) {
+	fakeLookTool(t, "kubectl")
+
+	var buf bytes.Buffer
+	writeToolReport(context.Background(), &buf, func(ctx context.Context, path string, args []string) string {
+		if path == "/usr/bin/kubectl" {
+			return "Client Version: v1.31.0"
+		}
+		return ""
+	})
+
+	for _, line := range []string{
+		"● kubectl  /usr/bin/kubectl (Client Version: v1.31.0)",
+		"○ lsof     not installed (needed for naming the process holding a busy port)",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("expected %q in:\n%s", line, buf.String())
+		}
+	}
+}
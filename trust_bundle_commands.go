@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"codeberg.org/hipkoi/kinder/config"
 	"codeberg.org/hipkoi/kinder/kubernetes"
 	"github.com/spf13/cobra"
 )
@@ -13,10 +16,17 @@ import (
 var (
 	// Trust bundle flags
 	trustBundleIncludeMozilla bool
-	trustBundleTargetNS       string
+	trustBundleTargetNS       []string
+	trustBundleTargetSelector map[string]string
+	trustBundleTargetKind     string
+	trustBundleTargetKey      string
+	trustBundleFormats        []string
 	trustBundleImageName      string
 	trustBundleImageTag       string
 	trustBundleSaveLocal      bool
+	trustBundleVerifyPlain    bool
+	trustBundleExtraSources   []string
+	trustBundleUseDefaultCAs  bool
 )
 
 var trustBundleCmd = &cobra.Command{
@@ -39,6 +49,14 @@ The artifact contains:
   - configmap.yaml: ConfigMap with the CA certificate (source)
   - bundle.yaml: trust-manager Bundle CRD for distribution
 
+Add --extra-source configmap/<name>/<key> or secret/<name>/<key> to append
+certificates from other ConfigMaps or Secrets in the trust namespace, and
+--use-default-cas to include trust-manager's default CA package.
+
+The bundle is written to the ca-certificates.crt key of a ConfigMap in each
+target namespace. Use --target-kind secret and --target-key to change that, and
+--additional-format jks or pkcs12 (optionally =<key>) to add truststores.
+
 ArgoCD can reference this artifact directly:
   apiVersion: argoproj.io/v1alpha1
   kind: Application
@@ -51,7 +69,7 @@ ArgoCD can reference this artifact directly:
       repoURL: $trustBundle
       path: .`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 
 		// Get data directory and cert path
 		dataDir, err := getDataDir()
@@ -61,7 +79,9 @@ ArgoCD can reference this artifact directly:
 
 		caCertPath := certPath
 		if caCertPath == "" {
-			caCertPath = filepath.Join(dataDir, CACertFilename)
+			if caCertPath, err = config.CACertPath(); err != nil {
+				return err
+			}
 		}
 
 		// Check if CA certificate exists
@@ -69,13 +89,44 @@ ArgoCD can reference this artifact directly:
 			return fmt.Errorf("CA certificate not found at %s. Run 'kinder start' or 'kinder ca generate' first", caCertPath)
 		}
 
+		platforms, err := parseArtifactPlatforms(ctx)
+		if err != nil {
+			return err
+		}
+		extraSources, err := parseTrustManagerSources(trustBundleExtraSources)
+		if err != nil {
+			return err
+		}
+
+		targetKind, err := parseTrustManagerTargetKind(trustBundleTargetKind)
+		if err != nil {
+			return err
+		}
+		formats, err := parseTrustManagerFormats(trustBundleFormats)
+		if err != nil {
+			return err
+		}
+
+		mozillaCASrc := mozillaCASource()
 		cfg := kubernetes.TrustManagerBundleConfig{
 			RootCACertPath:    caCertPath,
-			RegistryURL:       "localhost:5000",
+			RegistryURL:       localRegistry(),
 			ImageName:         trustBundleImageName,
 			ImageTag:          trustBundleImageTag,
 			IncludeMozillaCAs: trustBundleIncludeMozilla,
-			TargetNamespace:   trustBundleTargetNS,
+			TargetNamespaces:  trustBundleTargetNS,
+			TargetSelector:    trustBundleTargetSelector,
+			MozillaCAPath:     mozillaCASrc.Path,
+			MozillaCACacheDir: mozillaCASrc.CacheDir,
+			MozillaCACacheTTL: mozillaCASrc.CacheTTL,
+			MozillaCASHA256:   config.GetString(config.KeyMozillaCASHA256),
+			ExtraSources:      extraSources,
+			UseDefaultCAs:     trustBundleUseDefaultCAs,
+			TargetKind:        targetKind,
+			TargetKey:         trustBundleTargetKey,
+			AdditionalFormats: formats,
+			Platforms:         platforms,
+			Force:             forcePush,
 		}
 
 		Header("Building trust-manager bundle...")
@@ -83,11 +134,16 @@ ArgoCD can reference this artifact directly:
 
 		// Build and push the bundle
 		ProgressStart("📦", "Building OCI artifact")
-		if err := kubernetes.BuildAndPushTrustManagerBundle(ctx, cfg); err != nil {
+		pushed, err := kubernetes.BuildAndPushTrustManagerBundle(ctx, cfg)
+		if err != nil {
 			ProgressDone(false, err.Error())
 			return fmt.Errorf("failed to push trust-manager bundle: %w", err)
 		}
-		ProgressDone(true, fmt.Sprintf("Pushed to localhost:5000/%s:%s", cfg.ImageName, cfg.ImageTag))
+		if pushed {
+			ProgressDone(true, fmt.Sprintf("Pushed to %s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag))
+		} else {
+			ProgressDone(true, fmt.Sprintf("Unchanged at %s/%s:%s", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag))
+		}
 
 		// Optionally save manifests locally for inspection
 		if trustBundleSaveLocal {
@@ -105,7 +161,7 @@ ArgoCD can reference this artifact directly:
 			if trustBundleIncludeMozilla {
 				mozillaCA, err = downloadMozillaCACerts(ctx)
 				if err != nil {
-					ProgressDone(false, "Mozilla CA download failed")
+					ProgressDone(false, "Mozilla CA load failed")
 					Verbose("Warning: %v\n", err)
 				}
 			}
@@ -130,7 +186,7 @@ ArgoCD can reference this artifact directly:
 		BlankLine()
 
 		Header("Usage with ArgoCD:")
-		Output("  Image: localhost:5000/%s:%s\n", cfg.ImageName, cfg.ImageTag)
+		Output("  Image: %s/%s:%s\n", cfg.RegistryURL, cfg.ImageName, cfg.ImageTag)
 		Output("  From Kind: zot:5000/%s:%s\n", cfg.ImageName, cfg.ImageTag)
 
 		return nil
@@ -142,17 +198,15 @@ var trustBundleShowCmd = &cobra.Command{
 	Short: "Show the generated trust-manager manifests",
 	Long:  `Display the Kubernetes manifests that would be included in the trust-manager bundle.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-
-		// Get data directory and cert path
-		dataDir, err := getDataDir()
-		if err != nil {
-			return fmt.Errorf("failed to get data directory: %w", err)
-		}
+		ctx := cmd.Context()
 
 		caCertPath := certPath
 		if caCertPath == "" {
-			caCertPath = filepath.Join(dataDir, CACertFilename)
+			path, err := config.CACertPath()
+			if err != nil {
+				return err
+			}
+			caCertPath = path
 		}
 
 		// Check if CA certificate exists
@@ -175,10 +229,30 @@ var trustBundleShowCmd = &cobra.Command{
 			}
 		}
 
+		extraSources, err := parseTrustManagerSources(trustBundleExtraSources)
+		if err != nil {
+			return err
+		}
+
+		targetKind, err := parseTrustManagerTargetKind(trustBundleTargetKind)
+		if err != nil {
+			return err
+		}
+		formats, err := parseTrustManagerFormats(trustBundleFormats)
+		if err != nil {
+			return err
+		}
+
 		cfg := kubernetes.TrustManagerBundleConfig{
 			RootCACertPath:    caCertPath,
 			IncludeMozillaCAs: trustBundleIncludeMozilla,
-			TargetNamespace:   trustBundleTargetNS,
+			TargetNamespaces:  trustBundleTargetNS,
+			TargetSelector:    trustBundleTargetSelector,
+			ExtraSources:      extraSources,
+			UseDefaultCAs:     trustBundleUseDefaultCAs,
+			TargetKind:        targetKind,
+			TargetKey:         trustBundleTargetKey,
+			AdditionalFormats: formats,
 		}
 
 		manifests, err := generateTrustManagerManifests(cfg, kinderCA, mozillaCA)
@@ -207,18 +281,157 @@ var trustBundleShowCmd = &cobra.Command{
 	},
 }
 
+var trustBundleVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Pull the pushed bundle and check it against the local CA",
+	Long: `Pull the trust-manager bundle from the local registry, print the manifests it
+contains and count the certificates in its CA bundle.
+
+Use --plain to verify the plain trust bundle image (trust-bundle:latest, as
+pushed by 'kinder start') instead. Verification fails if the bundle's first
+certificate is not the local kinder CA.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		caCertPath := certPath
+		if caCertPath == "" {
+			path, err := config.CACertPath()
+			if err != nil {
+				return err
+			}
+			caCertPath = path
+		}
+
+		kinderCA, err := os.ReadFile(caCertPath)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+
+		imageName := trustBundleImageName
+		if trustBundleVerifyPlain && !cmd.Flags().Changed("image-name") {
+			imageName = "trust-bundle"
+		}
+		imageRef := fmt.Sprintf("%s/%s:%s", localRegistry(), imageName, trustBundleImageTag)
+
+		ProgressStart("📥", "Pulling artifact")
+		files, err := kubernetes.ExtractOCIArtifact(ctx, imageRef)
+		if err != nil {
+			ProgressDone(false, err.Error())
+			return fmt.Errorf("failed to pull %s: %w", imageRef, err)
+		}
+		ProgressDone(true, imageRef)
+		BlankLine()
+
+		names := make([]string, 0, len(files))
+		for name := range files {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if name == "kustomization.yaml" {
+				continue
+			}
+			Output("# %s\n", name)
+			// Show the first 20 lines to avoid flooding the terminal with certificates
+			lines := splitLines(string(files[name]))
+			for i, line := range lines {
+				if i >= 20 {
+					Output("... (%d more lines)\n", len(lines)-20)
+					break
+				}
+				Output("%s\n", line)
+			}
+			BlankLine()
+		}
+
+		bundle, err := kubernetes.ArtifactCABundle(files)
+		if err != nil {
+			return err
+		}
+		certs, err := kubernetes.ParseCertificates(bundle)
+		if err != nil {
+			return err
+		}
+		Output("Certificates: %d\n", len(certs))
+
+		if err := kubernetes.VerifyBundleCA(certs, kinderCA); err != nil {
+			return fmt.Errorf("verification failed: %w", err)
+		}
+		Success("Bundle contains the local kinder CA")
+
+		return nil
+	},
+}
+
 // Helper functions exposed for the commands
 
 func downloadMozillaCACerts(ctx context.Context) ([]byte, error) {
-	// This delegates to the unexported function in the kubernetes package
-	// We need to make a simple HTTP call here
-	return kubernetes.DownloadMozillaCACerts(ctx)
+	// Prefers a configured local file or a fresh cached copy over the network
+	mozillaCA, err := kubernetes.LoadMozillaCACerts(ctx, mozillaCASource())
+	if err != nil {
+		return nil, err
+	}
+	if err := kubernetes.VerifyMozillaCADigest(mozillaCA, config.GetString(config.KeyMozillaCASHA256)); err != nil {
+		return nil, err
+	}
+	return mozillaCA, nil
 }
 
 func generateTrustManagerManifests(cfg kubernetes.TrustManagerBundleConfig, kinderCA, mozillaCA []byte) (*kubernetes.TrustManagerManifests, error) {
 	return kubernetes.GenerateTrustManagerManifests(cfg, kinderCA, mozillaCA)
 }
 
+// parseTrustManagerSources parses --extra-source values of the form
+// kind/name/key, where kind is configmap or secret
+func parseTrustManagerSources(values []string) ([]kubernetes.TrustManagerSource, error) {
+	var sources []kubernetes.TrustManagerSource
+	for _, value := range values {
+		parts := strings.Split(value, "/")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid --extra-source %q: expected kind/name/key", value)
+		}
+
+		var kind string
+		switch strings.ToLower(parts[0]) {
+		case "configmap":
+			kind = kubernetes.TrustManagerSourceConfigMap
+		case "secret":
+			kind = kubernetes.TrustManagerSourceSecret
+		default:
+			return nil, fmt.Errorf("invalid --extra-source %q: kind must be configmap or secret", value)
+		}
+		sources = append(sources, kubernetes.TrustManagerSource{Kind: kind, Name: parts[1], Key: parts[2]})
+	}
+	return sources, nil
+}
+
+// parseTrustManagerTargetKind maps --target-kind to the Bundle target kind
+func parseTrustManagerTargetKind(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "configmap":
+		return kubernetes.TrustManagerSourceConfigMap, nil
+	case "secret":
+		return kubernetes.TrustManagerSourceSecret, nil
+	default:
+		return "", fmt.Errorf("invalid --target-kind %q: must be configmap or secret", value)
+	}
+}
+
+// parseTrustManagerFormats parses --additional-format values of the form
+// format or format=key
+func parseTrustManagerFormats(values []string) ([]kubernetes.TrustManagerFormat, error) {
+	var formats []kubernetes.TrustManagerFormat
+	for _, value := range values {
+		format, key, _ := strings.Cut(value, "=")
+		if format == "" {
+			return nil, fmt.Errorf("invalid --additional-format %q: expected format or format=key", value)
+		}
+		formats = append(formats, kubernetes.TrustManagerFormat{Format: strings.ToLower(format), Key: key})
+	}
+	return formats, nil
+}
+
 func splitLines(s string) []string {
 	var lines []string
 	start := 0
@@ -237,16 +450,39 @@ func splitLines(s string) []string {
 func init() {
 	// Setup flags for trust-bundle push command
 	trustBundlePushCmd.Flags().BoolVar(&trustBundleIncludeMozilla, "include-mozilla", true, "Include Mozilla CA certificates in the bundle")
-	trustBundlePushCmd.Flags().StringVar(&trustBundleTargetNS, "target-namespace", "", "Restrict bundle to a specific namespace (empty = all namespaces)")
+	trustBundlePushCmd.Flags().StringArrayVar(&trustBundleTargetNS, "target-namespace", nil, "Restrict bundle to a namespace (repeatable; empty = all namespaces)")
+	trustBundlePushCmd.Flags().StringToStringVar(&trustBundleTargetSelector, "target-selector", nil, "Restrict bundle to namespaces with these labels, e.g. env=dev")
 	trustBundlePushCmd.Flags().StringVar(&trustBundleImageName, "image-name", kubernetes.TrustManagerBundleImageName, "Image name for the bundle")
 	trustBundlePushCmd.Flags().StringVar(&trustBundleImageTag, "image-tag", kubernetes.TrustManagerBundleImageTag, "Image tag for the bundle")
 	trustBundlePushCmd.Flags().BoolVar(&trustBundleSaveLocal, "save-local", false, "Also save manifests to local data directory")
+	trustBundlePushCmd.Flags().StringVar(&trustBundleTargetKind, "target-kind", "configmap", "Kind of target to write the bundle to (configmap, secret)")
+	trustBundlePushCmd.Flags().StringVar(&trustBundleTargetKey, "target-key", kubernetes.TrustManagerTargetConfigMapKey, "Key holding the PEM bundle in the target")
+	trustBundlePushCmd.Flags().StringArrayVar(&trustBundleFormats, "additional-format", nil, "Also write the bundle as jks or pkcs12, optionally as format=<key> (repeatable)")
+	trustBundlePushCmd.Flags().StringArrayVar(&trustBundleExtraSources, "extra-source", nil, "Additional Bundle source as configmap/<name>/<key> or secret/<name>/<key> (repeatable)")
+	trustBundlePushCmd.Flags().BoolVar(&trustBundleUseDefaultCAs, "use-default-cas", false, "Add trust-manager's default CA package as a Bundle source")
+	trustBundlePushCmd.Flags().StringVar(&mozillaCAFile, "mozilla-ca-file", "", "Local Mozilla CA bundle (cacert.pem) to use instead of downloading")
+	trustBundlePushCmd.Flags().StringVar(&mozillaCASHA256, "mozilla-ca-sha256", "", "Expected SHA-256 of the Mozilla CA bundle")
+	trustBundlePushCmd.Flags().BoolVar(&forcePush, "force", false, "Push even if the registry already has identical content")
+	trustBundlePushCmd.Flags().StringArrayVar(&artifactPlatforms, "platform", nil, "Platform to build the image for as os/arch[/variant] (repeatable; several push an image index)")
 
 	// Setup flags for trust-bundle show command
 	trustBundleShowCmd.Flags().BoolVar(&trustBundleIncludeMozilla, "include-mozilla", true, "Include Mozilla CA certificates in the bundle")
-	trustBundleShowCmd.Flags().StringVar(&trustBundleTargetNS, "target-namespace", "", "Restrict bundle to a specific namespace")
+	trustBundleShowCmd.Flags().StringArrayVar(&trustBundleTargetNS, "target-namespace", nil, "Restrict bundle to a namespace (repeatable)")
+	trustBundleShowCmd.Flags().StringToStringVar(&trustBundleTargetSelector, "target-selector", nil, "Restrict bundle to namespaces with these labels, e.g. env=dev")
+	trustBundleShowCmd.Flags().StringVar(&trustBundleTargetKind, "target-kind", "configmap", "Kind of target to write the bundle to (configmap, secret)")
+	trustBundleShowCmd.Flags().StringVar(&trustBundleTargetKey, "target-key", kubernetes.TrustManagerTargetConfigMapKey, "Key holding the PEM bundle in the target")
+	trustBundleShowCmd.Flags().StringArrayVar(&trustBundleFormats, "additional-format", nil, "Also write the bundle as jks or pkcs12, optionally as format=<key> (repeatable)")
+	trustBundleShowCmd.Flags().StringArrayVar(&trustBundleExtraSources, "extra-source", nil, "Additional Bundle source as configmap/<name>/<key> or secret/<name>/<key> (repeatable)")
+	trustBundleShowCmd.Flags().BoolVar(&trustBundleUseDefaultCAs, "use-default-cas", false, "Add trust-manager's default CA package as a Bundle source")
+	trustBundleShowCmd.Flags().StringVar(&mozillaCAFile, "mozilla-ca-file", "", "Local Mozilla CA bundle (cacert.pem) to use instead of downloading")
+
+	// Setup flags for trust-bundle verify command
+	trustBundleVerifyCmd.Flags().BoolVar(&trustBundleVerifyPlain, "plain", false, "Verify the plain trust bundle image instead of the trust-manager bundle")
+	trustBundleVerifyCmd.Flags().StringVar(&trustBundleImageName, "image-name", kubernetes.TrustManagerBundleImageName, "Image name to verify")
+	trustBundleVerifyCmd.Flags().StringVar(&trustBundleImageTag, "image-tag", kubernetes.TrustManagerBundleImageTag, "Image tag to verify")
 
 	// Add subcommands
 	trustBundleCmd.AddCommand(trustBundlePushCmd)
 	trustBundleCmd.AddCommand(trustBundleShowCmd)
+	trustBundleCmd.AddCommand(trustBundleVerifyCmd)
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"codeberg.org/hipkoi/kinder/kubernetes"
+)
+
+func TestParseTrustManagerSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []kubernetes.TrustManagerSource
+		wantErr bool
+	}{
+		{name: "none"},
+		{
+			name:   "configmap and secret",
+			values: []string{"configmap/corp-ca/ca.pem", "Secret/partner-ca/tls.crt"},
+			want: []kubernetes.TrustManagerSource{
+				{Kind: kubernetes.TrustManagerSourceConfigMap, Name: "corp-ca", Key: "ca.pem"},
+				{Kind: kubernetes.TrustManagerSourceSecret, Name: "partner-ca", Key: "tls.crt"},
+			},
+		},
+		{name: "missing key", values: []string{"configmap/corp-ca"}, wantErr: true},
+		{name: "unknown kind", values: []string{"pod/corp-ca/ca.pem"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTrustManagerSources(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTrustManagerSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTrustManagerSources() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustManagerTargetKind(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "configmap", want: kubernetes.TrustManagerSourceConfigMap},
+		{value: "ConfigMap", want: kubernetes.TrustManagerSourceConfigMap},
+		{value: "secret", want: kubernetes.TrustManagerSourceSecret},
+		{value: "pod", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTrustManagerTargetKind(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTrustManagerTargetKind() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTrustManagerTargetKind() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustManagerFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []kubernetes.TrustManagerFormat
+		wantErr bool
+	}{
+		{name: "none"},
+		{
+			name:   "default and custom keys",
+			values: []string{"JKS", "pkcs12=truststore.p12"},
+			want: []kubernetes.TrustManagerFormat{
+				{Format: kubernetes.TrustManagerFormatJKS},
+				{Format: kubernetes.TrustManagerFormatPKCS12, Key: "truststore.p12"},
+			},
+		},
+		{name: "missing format", values: []string{"=bundle.jks"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTrustManagerFormats(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTrustManagerFormats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTrustManagerFormats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"codeberg.org/hipkoi/kinder/config"
+	"codeberg.org/hipkoi/kinder/docker"
 	"codeberg.org/hipkoi/kinder/kubernetes"
+	"codeberg.org/hipkoi/kinder/ociartifact"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 )
 
 // joinErrors joins a slice of error strings with ", "
@@ -33,9 +38,11 @@ func buildRegistryMirrorMap() map[string]string {
 
 const (
 	// CACertFilename is the filename for the CA certificate
-	CACertFilename = "ca.crt"
+	CACertFilename = config.CACertFilename
 	// CAKeyFilename is the filename for the CA private key
-	CAKeyFilename = "ca.key"
+	CAKeyFilename = config.CAKeyFilename
+	// CacheDirName is the data subdirectory holding downloaded files
+	CacheDirName = "cache"
 )
 
 // getDataDir returns the data directory, checking config first then XDG standard.
@@ -95,42 +102,168 @@ func cleanContainerData(dataDir string) error {
 	return nil
 }
 
-// pushTrustBundle creates and pushes the trust bundle OCI image to the local registry
-func pushTrustBundle(ctx context.Context, caCertPath string) error {
+// mozillaCASource returns where to load the Mozilla CA bundle from: the
+// configured local file, or the cache in the data directory
+func mozillaCASource() kubernetes.MozillaCASource {
+	src := kubernetes.MozillaCASource{
+		Path: config.GetString(config.KeyMozillaCAFile),
+	}
+
+	if dataDir, err := getDataDir(); err == nil {
+		src.CacheDir = filepath.Join(dataDir, CacheDirName)
+	}
+
+	ttl, err := time.ParseDuration(config.GetString(config.KeyMozillaCACacheTTL))
+	if err != nil {
+		ttl, _ = time.ParseDuration(config.DefaultMozillaCACacheTTL)
+	}
+	src.CacheTTL = ttl
+
+	return src
+}
+
+// parseArtifactPlatforms returns the platforms set with --artifact-platform,
+// defaulting to the Docker daemon's platform, which the Kind nodes share
+func parseArtifactPlatforms(ctx context.Context) ([]v1.Platform, error) {
+	if len(artifactPlatforms) > 0 {
+		return ociartifact.ParsePlatforms(artifactPlatforms)
+	}
+
+	c, err := docker.GetSharedClient()
+	if err != nil {
+		Verbose("Docker is not available, building artifacts for %s: %v\n", ociartifact.DefaultPlatform.Architecture, err)
+		return nil, nil
+	}
+	arch, err := c.Architecture(ctx)
+	if err != nil {
+		Verbose("Docker is not available, building artifacts for %s: %v\n", ociartifact.DefaultPlatform.Architecture, err)
+		return nil, nil
+	}
+	return []v1.Platform{ociartifact.DaemonPlatform(arch)}, nil
+}
+
+// pushTrustBundle creates and pushes the trust bundle OCI image to the local
+// registry. Returns false if the trust bundle was already up to date.
+func pushTrustBundle(ctx context.Context, caCertPath string) (bool, error) {
+	mozillaCA := mozillaCASource()
+
+	platforms, err := parseArtifactPlatforms(ctx)
+	if err != nil {
+		return false, err
+	}
+
 	cfg := kubernetes.TrustBundleConfig{
-		RootCACertPath: caCertPath,
-		RegistryURL:    "localhost:5000",
-		ImageName:      "trust-bundle",
-		ImageTag:       "latest",
+		RootCACertPath:    caCertPath,
+		RegistryURL:       localRegistry(),
+		ImageName:         "trust-bundle",
+		ImageTag:          "latest",
+		MozillaCAPath:     mozillaCA.Path,
+		MozillaCACacheDir: mozillaCA.CacheDir,
+		MozillaCACacheTTL: mozillaCA.CacheTTL,
+		MozillaCASHA256:   config.GetString(config.KeyMozillaCASHA256),
+		Platforms:         platforms,
+		Force:             forcePush,
 	}
 
-	if err := kubernetes.BuildAndPushTrustBundle(ctx, cfg); err != nil {
-		return err
+	return kubernetes.BuildAndPushTrustBundle(ctx, cfg)
+}
+
+// pushTrustManagerBundle creates and pushes the trust-manager manifests OCI
+// image to the local registry. Returns false if it was already up to date.
+func pushTrustManagerBundle(ctx context.Context, caCertPath string) (bool, error) {
+	mozillaCA := mozillaCASource()
+
+	platforms, err := parseArtifactPlatforms(ctx)
+	if err != nil {
+		return false, err
 	}
 
-	// Also push trust-manager manifests bundle
-	tmCfg := kubernetes.TrustManagerBundleConfig{
+	cfg := kubernetes.TrustManagerBundleConfig{
 		RootCACertPath:    caCertPath,
-		RegistryURL:       "localhost:5000",
+		RegistryURL:       localRegistry(),
 		ImageName:         kubernetes.TrustManagerBundleImageName,
 		ImageTag:          kubernetes.TrustManagerBundleImageTag,
 		IncludeMozillaCAs: true,
+		MozillaCAPath:     mozillaCA.Path,
+		MozillaCACacheDir: mozillaCA.CacheDir,
+		MozillaCACacheTTL: mozillaCA.CacheTTL,
+		MozillaCASHA256:   config.GetString(config.KeyMozillaCASHA256),
+		Platforms:         platforms,
+		Force:             forcePush,
+	}
+
+	return kubernetes.BuildAndPushTrustManagerBundle(ctx, cfg)
+}
+
+// pushResult describes the outcome of a bundle push for progress output
+func pushResult(pushed bool) string {
+	if pushed {
+		return "Pushed"
 	}
+	return "Unchanged"
+}
 
-	return kubernetes.BuildAndPushTrustManagerBundle(ctx, tmCfg)
+// externalCAURL returns the URL of an adopted external Step CA, or "" when the
+// local Step CA container is used
+func externalCAURL() string {
+	return strings.TrimSuffix(config.GetString(config.KeyExternalCAURL), "/")
+}
+
+// externalACMEServerURL returns the ACME directory of the external Step CA,
+// assuming its ACME provisioner is named "acme", or "" when none is configured
+func externalACMEServerURL() string {
+	if caURL := externalCAURL(); caURL != "" {
+		return caURL + "/acme/acme/directory"
+	}
+	return ""
 }
 
-// pushCertManagerIssuer creates and pushes the cert-manager issuer OCI image
-func pushCertManagerIssuer(ctx context.Context, caCertPath string) error {
+// pushCertManagerIssuer creates and pushes the cert-manager issuer OCI image.
+// Returns false if the issuer image was already up to date.
+func pushCertManagerIssuer(ctx context.Context, caCertPath string) (bool, error) {
 	domain := config.GetString(config.KeyDomain)
 	port := config.GetString(config.KeyTraefikPort)
 
+	platforms, err := parseArtifactPlatforms(ctx)
+	if err != nil {
+		return false, err
+	}
+
 	cfg := kubernetes.CertManagerIssuerConfig{
 		RootCACertPath: caCertPath,
-		RegistryURL:    "localhost:5000",
+		RegistryURL:    localRegistry(),
 		Domain:         domain,
 		Port:           port,
+		ACMEServerURL:  externalACMEServerURL(),
+		Platforms:      platforms,
+		Force:          forcePush,
 	}
 
 	return kubernetes.BuildAndPushCertManagerIssuer(ctx, cfg)
 }
+
+// kubeTarget is the kubeconfig and context that status and diagnostics query
+type kubeTarget struct {
+	kubeconfig string // Path to the kubeconfig ("" for kubectl's default)
+	context    string
+}
+
+// clusterTarget returns the target set by --kubeconfig and --context. The
+// context defaults to Kind's context for the cluster.
+func clusterTarget(clusterName string) kubeTarget {
+	t := kubeTarget{kubeconfig: clusterKubeconfig, context: clusterContext}
+	if t.context == "" {
+		t.context = kubernetes.KindContext(clusterName)
+	}
+	return t
+}
+
+// kubectl returns a kubectl command run against the target
+func (t kubeTarget) kubectl(ctx context.Context, args ...string) *exec.Cmd {
+	var global []string
+	if t.kubeconfig != "" {
+		global = append(global, "--kubeconfig", t.kubeconfig)
+	}
+	global = append(global, "--context", t.context)
+	return exec.CommandContext(ctx, "kubectl", append(global, args...)...)
+}